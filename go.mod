@@ -3,13 +3,22 @@ module github.com/meateam/permission-service
 go 1.13
 
 require (
+	github.com/alicebob/miniredis/v2 v2.30.0
+	github.com/go-redis/redis v6.15.9+incompatible
 	github.com/golang/protobuf v1.3.2
 	github.com/grpc-ecosystem/go-grpc-middleware v1.1.0
+	github.com/lib/pq v1.10.9
 	github.com/meateam/elasticsearch-logger v1.1.3-0.20190901111807-4e8b84fb9fda
+	github.com/prometheus/client_golang v1.1.0
 	github.com/sirupsen/logrus v1.4.2
 	github.com/spf13/viper v1.4.0
+	go.elastic.co/apm/module/apmgrpc v1.5.0
 	go.elastic.co/apm/module/apmmongo v1.5.0
 	go.mongodb.org/mongo-driver v1.1.0
+	go.opentelemetry.io/otel v1.0.0
+	go.opentelemetry.io/otel/sdk v1.0.0
+	go.opentelemetry.io/otel/trace v1.0.0
+	google.golang.org/genproto v0.0.0-20190819201941-24fa4b261c55
 	google.golang.org/grpc v1.23.1
 )
 
@@ -30,18 +30,21 @@ const (
 	Role_NONE  Role = 0
 	Role_WRITE Role = 1
 	Role_READ  Role = 2
+	Role_OWNER Role = 3
 )
 
 var Role_name = map[int32]string{
 	0: "NONE",
 	1: "WRITE",
 	2: "READ",
+	3: "OWNER",
 }
 
 var Role_value = map[string]int32{
 	"NONE":  0,
 	"WRITE": 1,
 	"READ":  2,
+	"OWNER": 3,
 }
 
 func (x Role) String() string {
@@ -60,7 +63,22 @@ type CreatePermissionRequest struct {
 	// The role of the permission.
 	Role Role `protobuf:"varint,3,opt,name=role,proto3,enum=permission.Role" json:"role,omitempty"`
 	// The ID of the user that created the permission.
-	Creator              string   `protobuf:"bytes,4,opt,name=creator,proto3" json:"creator,omitempty"`
+	Creator string `protobuf:"bytes,4,opt,name=creator,proto3" json:"creator,omitempty"`
+	// The unix time in seconds at which the permission expires, 0 if it never expires.
+	ExpiresAt int64 `protobuf:"varint,5,opt,name=expiresAt,proto3" json:"expiresAt,omitempty"`
+	// Free-form attributes of the permission such as how it was shared, replacing the metadata
+	// of an existing permission. Its keys and values must be at most 4KB in total.
+	Metadata map[string]string `protobuf:"bytes,6,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Why the permission is given, recorded for auditing. It must be at most 512 characters.
+	Reason string `protobuf:"bytes,7,opt,name=reason,proto3" json:"reason,omitempty"`
+	// The type of the subject that userID identifies, USER or GROUP, USER if it's empty.
+	SubjectType string `protobuf:"bytes,8,opt,name=subjectType,proto3" json:"subjectType,omitempty"`
+	// Whether the permission denies userID any access to fileID, overriding every permission
+	// that would otherwise grant it, including inherited and group permissions.
+	Deny bool `protobuf:"varint,9,opt,name=deny,proto3" json:"deny,omitempty"`
+	// Whether to fail with ALREADY_EXISTS if userID already has a permission to fileID, instead of
+	// updating it. CreatePermissions ignores it.
+	FailIfExists         bool     `protobuf:"varint,10,opt,name=failIfExists,proto3" json:"failIfExists,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -119,6 +137,128 @@ func (m *CreatePermissionRequest) GetCreator() string {
 	return ""
 }
 
+func (m *CreatePermissionRequest) GetExpiresAt() int64 {
+	if m != nil {
+		return m.ExpiresAt
+	}
+	return 0
+}
+
+func (m *CreatePermissionRequest) GetMetadata() map[string]string {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+func (m *CreatePermissionRequest) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *CreatePermissionRequest) GetSubjectType() string {
+	if m != nil {
+		return m.SubjectType
+	}
+	return ""
+}
+
+func (m *CreatePermissionRequest) GetDeny() bool {
+	if m != nil {
+		return m.Deny
+	}
+	return false
+}
+
+func (m *CreatePermissionRequest) GetFailIfExists() bool {
+	if m != nil {
+		return m.FailIfExists
+	}
+	return false
+}
+
+type CreatePermissionsRequest struct {
+	// The permissions to create.
+	Permissions          []*CreatePermissionRequest `protobuf:"bytes,1,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                   `json:"-"`
+	XXX_unrecognized     []byte                     `json:"-"`
+	XXX_sizecache        int32                      `json:"-"`
+}
+
+func (m *CreatePermissionsRequest) Reset()         { *m = CreatePermissionsRequest{} }
+func (m *CreatePermissionsRequest) String() string { return proto.CompactTextString(m) }
+func (*CreatePermissionsRequest) ProtoMessage()    {}
+func (*CreatePermissionsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c837ef01cbda0ad8, []int{1}
+}
+
+func (m *CreatePermissionsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CreatePermissionsRequest.Unmarshal(m, b)
+}
+func (m *CreatePermissionsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CreatePermissionsRequest.Marshal(b, m, deterministic)
+}
+func (m *CreatePermissionsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CreatePermissionsRequest.Merge(m, src)
+}
+func (m *CreatePermissionsRequest) XXX_Size() int {
+	return xxx_messageInfo_CreatePermissionsRequest.Size(m)
+}
+func (m *CreatePermissionsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CreatePermissionsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CreatePermissionsRequest proto.InternalMessageInfo
+
+func (m *CreatePermissionsRequest) GetPermissions() []*CreatePermissionRequest {
+	if m != nil {
+		return m.Permissions
+	}
+	return nil
+}
+
+type CreatePermissionsResponse struct {
+	// The created permissions, in the order they were requested.
+	Permissions          []*PermissionObject `protobuf:"bytes,1,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *CreatePermissionsResponse) Reset()         { *m = CreatePermissionsResponse{} }
+func (m *CreatePermissionsResponse) String() string { return proto.CompactTextString(m) }
+func (*CreatePermissionsResponse) ProtoMessage()    {}
+func (*CreatePermissionsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c837ef01cbda0ad8, []int{2}
+}
+
+func (m *CreatePermissionsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CreatePermissionsResponse.Unmarshal(m, b)
+}
+func (m *CreatePermissionsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CreatePermissionsResponse.Marshal(b, m, deterministic)
+}
+func (m *CreatePermissionsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CreatePermissionsResponse.Merge(m, src)
+}
+func (m *CreatePermissionsResponse) XXX_Size() int {
+	return xxx_messageInfo_CreatePermissionsResponse.Size(m)
+}
+func (m *CreatePermissionsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_CreatePermissionsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CreatePermissionsResponse proto.InternalMessageInfo
+
+func (m *CreatePermissionsResponse) GetPermissions() []*PermissionObject {
+	if m != nil {
+		return m.Permissions
+	}
+	return nil
+}
+
 type DeletePermissionRequest struct {
 	// The ID of the file which is being permitted.
 	FileID string `protobuf:"bytes,1,opt,name=fileID,proto3" json:"fileID,omitempty"`
@@ -133,7 +273,7 @@ func (m *DeletePermissionRequest) Reset()         { *m = DeletePermissionRequest
 func (m *DeletePermissionRequest) String() string { return proto.CompactTextString(m) }
 func (*DeletePermissionRequest) ProtoMessage()    {}
 func (*DeletePermissionRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c837ef01cbda0ad8, []int{1}
+	return fileDescriptor_c837ef01cbda0ad8, []int{3}
 }
 
 func (m *DeletePermissionRequest) XXX_Unmarshal(b []byte) error {
@@ -168,6 +308,74 @@ func (m *DeletePermissionRequest) GetUserID() string {
 	return ""
 }
 
+type UpdatePermissionRequest struct {
+	// The ID of the file which is being permitted.
+	FileID string `protobuf:"bytes,1,opt,name=fileID,proto3" json:"fileID,omitempty"`
+	// The ID of the user that's given the permission.
+	UserID string `protobuf:"bytes,2,opt,name=userID,proto3" json:"userID,omitempty"`
+	// The new role of the permission.
+	Role Role `protobuf:"varint,3,opt,name=role,proto3,enum=permission.Role" json:"role,omitempty"`
+	// The version the permission is expected to have, if it's not 0 the update is rejected
+	// with ABORTED when the permission was modified since that version was read.
+	Version              int64    `protobuf:"varint,4,opt,name=version,proto3" json:"version,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *UpdatePermissionRequest) Reset()         { *m = UpdatePermissionRequest{} }
+func (m *UpdatePermissionRequest) String() string { return proto.CompactTextString(m) }
+func (*UpdatePermissionRequest) ProtoMessage()    {}
+func (*UpdatePermissionRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c837ef01cbda0ad8, []int{4}
+}
+
+func (m *UpdatePermissionRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_UpdatePermissionRequest.Unmarshal(m, b)
+}
+func (m *UpdatePermissionRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_UpdatePermissionRequest.Marshal(b, m, deterministic)
+}
+func (m *UpdatePermissionRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_UpdatePermissionRequest.Merge(m, src)
+}
+func (m *UpdatePermissionRequest) XXX_Size() int {
+	return xxx_messageInfo_UpdatePermissionRequest.Size(m)
+}
+func (m *UpdatePermissionRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_UpdatePermissionRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_UpdatePermissionRequest proto.InternalMessageInfo
+
+func (m *UpdatePermissionRequest) GetFileID() string {
+	if m != nil {
+		return m.FileID
+	}
+	return ""
+}
+
+func (m *UpdatePermissionRequest) GetUserID() string {
+	if m != nil {
+		return m.UserID
+	}
+	return ""
+}
+
+func (m *UpdatePermissionRequest) GetRole() Role {
+	if m != nil {
+		return m.Role
+	}
+	return Role_NONE
+}
+
+func (m *UpdatePermissionRequest) GetVersion() int64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
 type PermissionObject struct {
 	// The ID of the permission.
 	Id string `protobuf:"bytes,1,opt,name=id,proto3" json:"id,omitempty"`
@@ -178,7 +386,25 @@ type PermissionObject struct {
 	// The role of the permission.
 	Role Role `protobuf:"varint,4,opt,name=role,proto3,enum=permission.Role" json:"role,omitempty"`
 	// The ID of the user that created the permission.
-	Creator              string   `protobuf:"bytes,5,opt,name=creator,proto3" json:"creator,omitempty"`
+	Creator string `protobuf:"bytes,5,opt,name=creator,proto3" json:"creator,omitempty"`
+	// The unix time in seconds at which the permission expires, 0 if it never expires.
+	ExpiresAt int64 `protobuf:"varint,6,opt,name=expiresAt,proto3" json:"expiresAt,omitempty"`
+	// The version of the permission, incremented on every change to it.
+	Version int64 `protobuf:"varint,7,opt,name=version,proto3" json:"version,omitempty"`
+	// The unix time in seconds at which the permission was created, 0 if it's unknown.
+	CreatedAt int64 `protobuf:"varint,8,opt,name=createdAt,proto3" json:"createdAt,omitempty"`
+	// The unix time in seconds at which the permission was last changed, 0 if it's unknown.
+	UpdatedAt int64 `protobuf:"varint,9,opt,name=updatedAt,proto3" json:"updatedAt,omitempty"`
+	// Free-form attributes of the permission.
+	Metadata map[string]string `protobuf:"bytes,10,rep,name=metadata,proto3" json:"metadata,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	// Why the permission was given, empty if no reason was given.
+	Reason string `protobuf:"bytes,11,opt,name=reason,proto3" json:"reason,omitempty"`
+	// The ID of the actor that granted the permission when it was first created, empty if it's unknown.
+	GrantedBy string `protobuf:"bytes,12,opt,name=grantedBy,proto3" json:"grantedBy,omitempty"`
+	// The type of the subject that userID identifies, USER or GROUP.
+	SubjectType string `protobuf:"bytes,13,opt,name=subjectType,proto3" json:"subjectType,omitempty"`
+	// Whether the permission denies userID any access to fileID regardless of its role.
+	Deny                 bool     `protobuf:"varint,14,opt,name=deny,proto3" json:"deny,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -188,7 +414,7 @@ func (m *PermissionObject) Reset()         { *m = PermissionObject{} }
 func (m *PermissionObject) String() string { return proto.CompactTextString(m) }
 func (*PermissionObject) ProtoMessage()    {}
 func (*PermissionObject) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c837ef01cbda0ad8, []int{2}
+	return fileDescriptor_c837ef01cbda0ad8, []int{5}
 }
 
 func (m *PermissionObject) XXX_Unmarshal(b []byte) error {
@@ -244,9 +470,74 @@ func (m *PermissionObject) GetCreator() string {
 	return ""
 }
 
+func (m *PermissionObject) GetExpiresAt() int64 {
+	if m != nil {
+		return m.ExpiresAt
+	}
+	return 0
+}
+
+func (m *PermissionObject) GetVersion() int64 {
+	if m != nil {
+		return m.Version
+	}
+	return 0
+}
+
+func (m *PermissionObject) GetCreatedAt() int64 {
+	if m != nil {
+		return m.CreatedAt
+	}
+	return 0
+}
+
+func (m *PermissionObject) GetUpdatedAt() int64 {
+	if m != nil {
+		return m.UpdatedAt
+	}
+	return 0
+}
+
+func (m *PermissionObject) GetMetadata() map[string]string {
+	if m != nil {
+		return m.Metadata
+	}
+	return nil
+}
+
+func (m *PermissionObject) GetReason() string {
+	if m != nil {
+		return m.Reason
+	}
+	return ""
+}
+
+func (m *PermissionObject) GetGrantedBy() string {
+	if m != nil {
+		return m.GrantedBy
+	}
+	return ""
+}
+
+func (m *PermissionObject) GetSubjectType() string {
+	if m != nil {
+		return m.SubjectType
+	}
+	return ""
+}
+
+func (m *PermissionObject) GetDeny() bool {
+	if m != nil {
+		return m.Deny
+	}
+	return false
+}
+
 type GetPermissionRequest struct {
-	FileID               string   `protobuf:"bytes,1,opt,name=fileID,proto3" json:"fileID,omitempty"`
-	UserID               string   `protobuf:"bytes,2,opt,name=userID,proto3" json:"userID,omitempty"`
+	FileID string `protobuf:"bytes,1,opt,name=fileID,proto3" json:"fileID,omitempty"`
+	UserID string `protobuf:"bytes,2,opt,name=userID,proto3" json:"userID,omitempty"`
+	// The fields of the permission to return, such as role, if empty all of its fields are returned.
+	Fields               []string `protobuf:"bytes,3,rep,name=fields,proto3" json:"fields,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -256,7 +547,7 @@ func (m *GetPermissionRequest) Reset()         { *m = GetPermissionRequest{} }
 func (m *GetPermissionRequest) String() string { return proto.CompactTextString(m) }
 func (*GetPermissionRequest) ProtoMessage()    {}
 func (*GetPermissionRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c837ef01cbda0ad8, []int{3}
+	return fileDescriptor_c837ef01cbda0ad8, []int{6}
 }
 
 func (m *GetPermissionRequest) XXX_Unmarshal(b []byte) error {
@@ -291,9 +582,27 @@ func (m *GetPermissionRequest) GetUserID() string {
 	return ""
 }
 
+func (m *GetPermissionRequest) GetFields() []string {
+	if m != nil {
+		return m.Fields
+	}
+	return nil
+}
+
 type GetFilePermissionsRequest struct {
 	// The ID of the file which is being permitted.
-	FileID               string   `protobuf:"bytes,1,opt,name=fileID,proto3" json:"fileID,omitempty"`
+	FileID string `protobuf:"bytes,1,opt,name=fileID,proto3" json:"fileID,omitempty"`
+	// The maximum number of permissions to return, if 0 all permissions are returned.
+	PageSize int64 `protobuf:"varint,2,opt,name=pageSize,proto3" json:"pageSize,omitempty"`
+	// The nextPageToken of the previous page, empty for the first page.
+	PageToken string `protobuf:"bytes,3,opt,name=pageToken,proto3" json:"pageToken,omitempty"`
+	// The roles of the permissions to return, if empty permissions of all roles are returned.
+	Roles []Role `protobuf:"varint,4,rep,packed,name=roles,proto3,enum=permission.Role" json:"roles,omitempty"`
+	// The field to sort the permissions by, one of role, userID or createdAt, if empty the permissions are
+	// sorted by their creation order. Requires a pageSize of 0.
+	SortBy string `protobuf:"bytes,5,opt,name=sortBy,proto3" json:"sortBy,omitempty"`
+	// Whether the permissions are sorted by sortBy in descending order, such as the owner first.
+	SortDescending       bool     `protobuf:"varint,6,opt,name=sortDescending,proto3" json:"sortDescending,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -303,7 +612,7 @@ func (m *GetFilePermissionsRequest) Reset()         { *m = GetFilePermissionsReq
 func (m *GetFilePermissionsRequest) String() string { return proto.CompactTextString(m) }
 func (*GetFilePermissionsRequest) ProtoMessage()    {}
 func (*GetFilePermissionsRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c837ef01cbda0ad8, []int{4}
+	return fileDescriptor_c837ef01cbda0ad8, []int{7}
 }
 
 func (m *GetFilePermissionsRequest) XXX_Unmarshal(b []byte) error {
@@ -331,19 +640,56 @@ func (m *GetFilePermissionsRequest) GetFileID() string {
 	return ""
 }
 
+func (m *GetFilePermissionsRequest) GetPageSize() int64 {
+	if m != nil {
+		return m.PageSize
+	}
+	return 0
+}
+
+func (m *GetFilePermissionsRequest) GetPageToken() string {
+	if m != nil {
+		return m.PageToken
+	}
+	return ""
+}
+
+func (m *GetFilePermissionsRequest) GetRoles() []Role {
+	if m != nil {
+		return m.Roles
+	}
+	return nil
+}
+
+func (m *GetFilePermissionsRequest) GetSortBy() string {
+	if m != nil {
+		return m.SortBy
+	}
+	return ""
+}
+
+func (m *GetFilePermissionsRequest) GetSortDescending() bool {
+	if m != nil {
+		return m.SortDescending
+	}
+	return false
+}
+
 type GetFilePermissionsResponse struct {
 	// Array of user roles.
-	Permissions          []*GetFilePermissionsResponse_UserRole `protobuf:"bytes,1,rep,name=permissions,proto3" json:"permissions,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}                               `json:"-"`
-	XXX_unrecognized     []byte                                 `json:"-"`
-	XXX_sizecache        int32                                  `json:"-"`
+	Permissions []*GetFilePermissionsResponse_UserRole `protobuf:"bytes,1,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	// The token of the next page, empty if this is the last page.
+	NextPageToken        string   `protobuf:"bytes,2,opt,name=nextPageToken,proto3" json:"nextPageToken,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *GetFilePermissionsResponse) Reset()         { *m = GetFilePermissionsResponse{} }
 func (m *GetFilePermissionsResponse) String() string { return proto.CompactTextString(m) }
 func (*GetFilePermissionsResponse) ProtoMessage()    {}
 func (*GetFilePermissionsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c837ef01cbda0ad8, []int{5}
+	return fileDescriptor_c837ef01cbda0ad8, []int{8}
 }
 
 func (m *GetFilePermissionsResponse) XXX_Unmarshal(b []byte) error {
@@ -371,6 +717,13 @@ func (m *GetFilePermissionsResponse) GetPermissions() []*GetFilePermissionsRespo
 	return nil
 }
 
+func (m *GetFilePermissionsResponse) GetNextPageToken() string {
+	if m != nil {
+		return m.NextPageToken
+	}
+	return ""
+}
+
 // The role of a user.
 type GetFilePermissionsResponse_UserRole struct {
 	// The user ID.
@@ -378,7 +731,9 @@ type GetFilePermissionsResponse_UserRole struct {
 	// The role of the user.
 	Role Role `protobuf:"varint,2,opt,name=role,proto3,enum=permission.Role" json:"role,omitempty"`
 	// The creator of the permission.
-	Creator              string   `protobuf:"bytes,3,opt,name=creator,proto3" json:"creator,omitempty"`
+	Creator string `protobuf:"bytes,3,opt,name=creator,proto3" json:"creator,omitempty"`
+	// Whether the permission denies the user any access to the file regardless of its role.
+	Deny                 bool     `protobuf:"varint,4,opt,name=deny,proto3" json:"deny,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -388,7 +743,7 @@ func (m *GetFilePermissionsResponse_UserRole) Reset()         { *m = GetFilePerm
 func (m *GetFilePermissionsResponse_UserRole) String() string { return proto.CompactTextString(m) }
 func (*GetFilePermissionsResponse_UserRole) ProtoMessage()    {}
 func (*GetFilePermissionsResponse_UserRole) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c837ef01cbda0ad8, []int{5, 0}
+	return fileDescriptor_c837ef01cbda0ad8, []int{8, 0}
 }
 
 func (m *GetFilePermissionsResponse_UserRole) XXX_Unmarshal(b []byte) error {
@@ -430,6 +785,13 @@ func (m *GetFilePermissionsResponse_UserRole) GetCreator() string {
 	return ""
 }
 
+func (m *GetFilePermissionsResponse_UserRole) GetDeny() bool {
+	if m != nil {
+		return m.Deny
+	}
+	return false
+}
+
 type IsPermittedRequest struct {
 	// The ID of the file which is being permitted.
 	FileID string `protobuf:"bytes,1,opt,name=fileID,proto3" json:"fileID,omitempty"`
@@ -446,7 +808,7 @@ func (m *IsPermittedRequest) Reset()         { *m = IsPermittedRequest{} }
 func (m *IsPermittedRequest) String() string { return proto.CompactTextString(m) }
 func (*IsPermittedRequest) ProtoMessage()    {}
 func (*IsPermittedRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c837ef01cbda0ad8, []int{6}
+	return fileDescriptor_c837ef01cbda0ad8, []int{9}
 }
 
 func (m *IsPermittedRequest) XXX_Unmarshal(b []byte) error {
@@ -499,7 +861,7 @@ func (m *IsPermittedResponse) Reset()         { *m = IsPermittedResponse{} }
 func (m *IsPermittedResponse) String() string { return proto.CompactTextString(m) }
 func (*IsPermittedResponse) ProtoMessage()    {}
 func (*IsPermittedResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c837ef01cbda0ad8, []int{7}
+	return fileDescriptor_c837ef01cbda0ad8, []int{10}
 }
 
 func (m *IsPermittedResponse) XXX_Unmarshal(b []byte) error {
@@ -529,7 +891,11 @@ func (m *IsPermittedResponse) GetPermitted() bool {
 
 type GetUserPermissionsRequest struct {
 	// The ID of the user to get its permissions.
-	UserID               string   `protobuf:"bytes,1,opt,name=userID,proto3" json:"userID,omitempty"`
+	UserID string `protobuf:"bytes,1,opt,name=userID,proto3" json:"userID,omitempty"`
+	// The maximum number of permissions to return, if 0 all permissions are returned.
+	PageSize int64 `protobuf:"varint,2,opt,name=pageSize,proto3" json:"pageSize,omitempty"`
+	// The nextPageToken of the previous page, empty for the first page.
+	PageToken            string   `protobuf:"bytes,3,opt,name=pageToken,proto3" json:"pageToken,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -539,7 +905,7 @@ func (m *GetUserPermissionsRequest) Reset()         { *m = GetUserPermissionsReq
 func (m *GetUserPermissionsRequest) String() string { return proto.CompactTextString(m) }
 func (*GetUserPermissionsRequest) ProtoMessage()    {}
 func (*GetUserPermissionsRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c837ef01cbda0ad8, []int{8}
+	return fileDescriptor_c837ef01cbda0ad8, []int{11}
 }
 
 func (m *GetUserPermissionsRequest) XXX_Unmarshal(b []byte) error {
@@ -567,19 +933,35 @@ func (m *GetUserPermissionsRequest) GetUserID() string {
 	return ""
 }
 
+func (m *GetUserPermissionsRequest) GetPageSize() int64 {
+	if m != nil {
+		return m.PageSize
+	}
+	return 0
+}
+
+func (m *GetUserPermissionsRequest) GetPageToken() string {
+	if m != nil {
+		return m.PageToken
+	}
+	return ""
+}
+
 type GetUserPermissionsResponse struct {
 	// Array of files and their role.
-	Permissions          []*GetUserPermissionsResponse_FileRole `protobuf:"bytes,1,rep,name=permissions,proto3" json:"permissions,omitempty"`
-	XXX_NoUnkeyedLiteral struct{}                               `json:"-"`
-	XXX_unrecognized     []byte                                 `json:"-"`
-	XXX_sizecache        int32                                  `json:"-"`
+	Permissions []*GetUserPermissionsResponse_FileRole `protobuf:"bytes,1,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	// The token of the next page, empty if this is the last page.
+	NextPageToken        string   `protobuf:"bytes,2,opt,name=nextPageToken,proto3" json:"nextPageToken,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
 func (m *GetUserPermissionsResponse) Reset()         { *m = GetUserPermissionsResponse{} }
 func (m *GetUserPermissionsResponse) String() string { return proto.CompactTextString(m) }
 func (*GetUserPermissionsResponse) ProtoMessage()    {}
 func (*GetUserPermissionsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c837ef01cbda0ad8, []int{9}
+	return fileDescriptor_c837ef01cbda0ad8, []int{12}
 }
 
 func (m *GetUserPermissionsResponse) XXX_Unmarshal(b []byte) error {
@@ -607,6 +989,13 @@ func (m *GetUserPermissionsResponse) GetPermissions() []*GetUserPermissionsRespo
 	return nil
 }
 
+func (m *GetUserPermissionsResponse) GetNextPageToken() string {
+	if m != nil {
+		return m.NextPageToken
+	}
+	return ""
+}
+
 // The file of the permission and its role.
 type GetUserPermissionsResponse_FileRole struct {
 	// The file ID.
@@ -614,7 +1003,9 @@ type GetUserPermissionsResponse_FileRole struct {
 	// The role of the file permission.
 	Role Role `protobuf:"varint,2,opt,name=role,proto3,enum=permission.Role" json:"role,omitempty"`
 	// The creator of the permission.
-	Creator              string   `protobuf:"bytes,3,opt,name=creator,proto3" json:"creator,omitempty"`
+	Creator string `protobuf:"bytes,3,opt,name=creator,proto3" json:"creator,omitempty"`
+	// Whether the permission denies the user any access to the file regardless of its role.
+	Deny                 bool     `protobuf:"varint,4,opt,name=deny,proto3" json:"deny,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -624,7 +1015,7 @@ func (m *GetUserPermissionsResponse_FileRole) Reset()         { *m = GetUserPerm
 func (m *GetUserPermissionsResponse_FileRole) String() string { return proto.CompactTextString(m) }
 func (*GetUserPermissionsResponse_FileRole) ProtoMessage()    {}
 func (*GetUserPermissionsResponse_FileRole) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c837ef01cbda0ad8, []int{9, 0}
+	return fileDescriptor_c837ef01cbda0ad8, []int{12, 0}
 }
 
 func (m *GetUserPermissionsResponse_FileRole) XXX_Unmarshal(b []byte) error {
@@ -666,8 +1057,17 @@ func (m *GetUserPermissionsResponse_FileRole) GetCreator() string {
 	return ""
 }
 
+func (m *GetUserPermissionsResponse_FileRole) GetDeny() bool {
+	if m != nil {
+		return m.Deny
+	}
+	return false
+}
+
 type DeleteFilePermissionsRequest struct {
-	FileID               string   `protobuf:"bytes,1,opt,name=fileID,proto3" json:"fileID,omitempty"`
+	FileID string `protobuf:"bytes,1,opt,name=fileID,proto3" json:"fileID,omitempty"`
+	// If true the permissions that would be deleted are returned without deleting them.
+	DryRun               bool     `protobuf:"varint,2,opt,name=dryRun,proto3" json:"dryRun,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -677,7 +1077,7 @@ func (m *DeleteFilePermissionsRequest) Reset()         { *m = DeleteFilePermissi
 func (m *DeleteFilePermissionsRequest) String() string { return proto.CompactTextString(m) }
 func (*DeleteFilePermissionsRequest) ProtoMessage()    {}
 func (*DeleteFilePermissionsRequest) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c837ef01cbda0ad8, []int{10}
+	return fileDescriptor_c837ef01cbda0ad8, []int{13}
 }
 
 func (m *DeleteFilePermissionsRequest) XXX_Unmarshal(b []byte) error {
@@ -705,6 +1105,13 @@ func (m *DeleteFilePermissionsRequest) GetFileID() string {
 	return ""
 }
 
+func (m *DeleteFilePermissionsRequest) GetDryRun() bool {
+	if m != nil {
+		return m.DryRun
+	}
+	return false
+}
+
 type DeleteFilePermissionsResponse struct {
 	Permissions          []*PermissionObject `protobuf:"bytes,1,rep,name=permissions,proto3" json:"permissions,omitempty"`
 	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
@@ -716,7 +1123,7 @@ func (m *DeleteFilePermissionsResponse) Reset()         { *m = DeleteFilePermiss
 func (m *DeleteFilePermissionsResponse) String() string { return proto.CompactTextString(m) }
 func (*DeleteFilePermissionsResponse) ProtoMessage()    {}
 func (*DeleteFilePermissionsResponse) Descriptor() ([]byte, []int) {
-	return fileDescriptor_c837ef01cbda0ad8, []int{11}
+	return fileDescriptor_c837ef01cbda0ad8, []int{14}
 }
 
 func (m *DeleteFilePermissionsResponse) XXX_Unmarshal(b []byte) error {
@@ -744,13 +1151,460 @@ func (m *DeleteFilePermissionsResponse) GetPermissions() []*PermissionObject {
 	return nil
 }
 
-func init() {
-	proto.RegisterEnum("permission.Role", Role_name, Role_value)
-	proto.RegisterType((*CreatePermissionRequest)(nil), "permission.CreatePermissionRequest")
-	proto.RegisterType((*DeletePermissionRequest)(nil), "permission.DeletePermissionRequest")
-	proto.RegisterType((*PermissionObject)(nil), "permission.PermissionObject")
-	proto.RegisterType((*GetPermissionRequest)(nil), "permission.GetPermissionRequest")
-	proto.RegisterType((*GetFilePermissionsRequest)(nil), "permission.GetFilePermissionsRequest")
+type DeleteUserPermissionsRequest struct {
+	// The ID of the user to delete its permissions.
+	UserID string `protobuf:"bytes,1,opt,name=userID,proto3" json:"userID,omitempty"`
+	// If true the permissions that would be deleted are returned without deleting them.
+	DryRun               bool     `protobuf:"varint,2,opt,name=dryRun,proto3" json:"dryRun,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *DeleteUserPermissionsRequest) Reset()         { *m = DeleteUserPermissionsRequest{} }
+func (m *DeleteUserPermissionsRequest) String() string { return proto.CompactTextString(m) }
+func (*DeleteUserPermissionsRequest) ProtoMessage()    {}
+func (*DeleteUserPermissionsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c837ef01cbda0ad8, []int{15}
+}
+
+func (m *DeleteUserPermissionsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteUserPermissionsRequest.Unmarshal(m, b)
+}
+func (m *DeleteUserPermissionsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteUserPermissionsRequest.Marshal(b, m, deterministic)
+}
+func (m *DeleteUserPermissionsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteUserPermissionsRequest.Merge(m, src)
+}
+func (m *DeleteUserPermissionsRequest) XXX_Size() int {
+	return xxx_messageInfo_DeleteUserPermissionsRequest.Size(m)
+}
+func (m *DeleteUserPermissionsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteUserPermissionsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteUserPermissionsRequest proto.InternalMessageInfo
+
+func (m *DeleteUserPermissionsRequest) GetUserID() string {
+	if m != nil {
+		return m.UserID
+	}
+	return ""
+}
+
+func (m *DeleteUserPermissionsRequest) GetDryRun() bool {
+	if m != nil {
+		return m.DryRun
+	}
+	return false
+}
+
+type DeleteUserPermissionsResponse struct {
+	// The deleted permissions.
+	Permissions          []*PermissionObject `protobuf:"bytes,1,rep,name=permissions,proto3" json:"permissions,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}            `json:"-"`
+	XXX_unrecognized     []byte              `json:"-"`
+	XXX_sizecache        int32               `json:"-"`
+}
+
+func (m *DeleteUserPermissionsResponse) Reset()         { *m = DeleteUserPermissionsResponse{} }
+func (m *DeleteUserPermissionsResponse) String() string { return proto.CompactTextString(m) }
+func (*DeleteUserPermissionsResponse) ProtoMessage()    {}
+func (*DeleteUserPermissionsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c837ef01cbda0ad8, []int{16}
+}
+
+func (m *DeleteUserPermissionsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_DeleteUserPermissionsResponse.Unmarshal(m, b)
+}
+func (m *DeleteUserPermissionsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_DeleteUserPermissionsResponse.Marshal(b, m, deterministic)
+}
+func (m *DeleteUserPermissionsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_DeleteUserPermissionsResponse.Merge(m, src)
+}
+func (m *DeleteUserPermissionsResponse) XXX_Size() int {
+	return xxx_messageInfo_DeleteUserPermissionsResponse.Size(m)
+}
+func (m *DeleteUserPermissionsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_DeleteUserPermissionsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_DeleteUserPermissionsResponse proto.InternalMessageInfo
+
+func (m *DeleteUserPermissionsResponse) GetPermissions() []*PermissionObject {
+	if m != nil {
+		return m.Permissions
+	}
+	return nil
+}
+
+type CountPermissionsRequest struct {
+	// The ID of the file to count its permissions.
+	FileID               string   `protobuf:"bytes,1,opt,name=fileID,proto3" json:"fileID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CountPermissionsRequest) Reset()         { *m = CountPermissionsRequest{} }
+func (m *CountPermissionsRequest) String() string { return proto.CompactTextString(m) }
+func (*CountPermissionsRequest) ProtoMessage()    {}
+func (*CountPermissionsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c837ef01cbda0ad8, []int{17}
+}
+
+func (m *CountPermissionsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CountPermissionsRequest.Unmarshal(m, b)
+}
+func (m *CountPermissionsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CountPermissionsRequest.Marshal(b, m, deterministic)
+}
+func (m *CountPermissionsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CountPermissionsRequest.Merge(m, src)
+}
+func (m *CountPermissionsRequest) XXX_Size() int {
+	return xxx_messageInfo_CountPermissionsRequest.Size(m)
+}
+func (m *CountPermissionsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_CountPermissionsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CountPermissionsRequest proto.InternalMessageInfo
+
+func (m *CountPermissionsRequest) GetFileID() string {
+	if m != nil {
+		return m.FileID
+	}
+	return ""
+}
+
+type CountPermissionsResponse struct {
+	// The number of permissions that exist for the file.
+	Count                int64    `protobuf:"varint,1,opt,name=count,proto3" json:"count,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *CountPermissionsResponse) Reset()         { *m = CountPermissionsResponse{} }
+func (m *CountPermissionsResponse) String() string { return proto.CompactTextString(m) }
+func (*CountPermissionsResponse) ProtoMessage()    {}
+func (*CountPermissionsResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c837ef01cbda0ad8, []int{18}
+}
+
+func (m *CountPermissionsResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_CountPermissionsResponse.Unmarshal(m, b)
+}
+func (m *CountPermissionsResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_CountPermissionsResponse.Marshal(b, m, deterministic)
+}
+func (m *CountPermissionsResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CountPermissionsResponse.Merge(m, src)
+}
+func (m *CountPermissionsResponse) XXX_Size() int {
+	return xxx_messageInfo_CountPermissionsResponse.Size(m)
+}
+func (m *CountPermissionsResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_CountPermissionsResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_CountPermissionsResponse proto.InternalMessageInfo
+
+func (m *CountPermissionsResponse) GetCount() int64 {
+	if m != nil {
+		return m.Count
+	}
+	return 0
+}
+
+type TransferOwnershipRequest struct {
+	// The ID of the file whose ownership is transferred.
+	FileID string `protobuf:"bytes,1,opt,name=fileID,proto3" json:"fileID,omitempty"`
+	// The ID of the current owner of the file.
+	FromUserID string `protobuf:"bytes,2,opt,name=fromUserID,proto3" json:"fromUserID,omitempty"`
+	// The ID of the user that becomes the owner of the file.
+	ToUserID string `protobuf:"bytes,3,opt,name=toUserID,proto3" json:"toUserID,omitempty"`
+	// If true the transfer is validated without being applied.
+	DryRun               bool     `protobuf:"varint,4,opt,name=dryRun,proto3" json:"dryRun,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TransferOwnershipRequest) Reset()         { *m = TransferOwnershipRequest{} }
+func (m *TransferOwnershipRequest) String() string { return proto.CompactTextString(m) }
+func (*TransferOwnershipRequest) ProtoMessage()    {}
+func (*TransferOwnershipRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c837ef01cbda0ad8, []int{19}
+}
+
+func (m *TransferOwnershipRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TransferOwnershipRequest.Unmarshal(m, b)
+}
+func (m *TransferOwnershipRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TransferOwnershipRequest.Marshal(b, m, deterministic)
+}
+func (m *TransferOwnershipRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TransferOwnershipRequest.Merge(m, src)
+}
+func (m *TransferOwnershipRequest) XXX_Size() int {
+	return xxx_messageInfo_TransferOwnershipRequest.Size(m)
+}
+func (m *TransferOwnershipRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_TransferOwnershipRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TransferOwnershipRequest proto.InternalMessageInfo
+
+func (m *TransferOwnershipRequest) GetFileID() string {
+	if m != nil {
+		return m.FileID
+	}
+	return ""
+}
+
+func (m *TransferOwnershipRequest) GetFromUserID() string {
+	if m != nil {
+		return m.FromUserID
+	}
+	return ""
+}
+
+func (m *TransferOwnershipRequest) GetToUserID() string {
+	if m != nil {
+		return m.ToUserID
+	}
+	return ""
+}
+
+func (m *TransferOwnershipRequest) GetDryRun() bool {
+	if m != nil {
+		return m.DryRun
+	}
+	return false
+}
+
+type TransferOwnershipResponse struct {
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *TransferOwnershipResponse) Reset()         { *m = TransferOwnershipResponse{} }
+func (m *TransferOwnershipResponse) String() string { return proto.CompactTextString(m) }
+func (*TransferOwnershipResponse) ProtoMessage()    {}
+func (*TransferOwnershipResponse) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c837ef01cbda0ad8, []int{20}
+}
+
+func (m *TransferOwnershipResponse) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_TransferOwnershipResponse.Unmarshal(m, b)
+}
+func (m *TransferOwnershipResponse) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_TransferOwnershipResponse.Marshal(b, m, deterministic)
+}
+func (m *TransferOwnershipResponse) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_TransferOwnershipResponse.Merge(m, src)
+}
+func (m *TransferOwnershipResponse) XXX_Size() int {
+	return xxx_messageInfo_TransferOwnershipResponse.Size(m)
+}
+func (m *TransferOwnershipResponse) XXX_DiscardUnknown() {
+	xxx_messageInfo_TransferOwnershipResponse.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_TransferOwnershipResponse proto.InternalMessageInfo
+
+type StreamFilePermissionsRequest struct {
+	// The ID of the file whose permissions are streamed.
+	FileID               string   `protobuf:"bytes,1,opt,name=fileID,proto3" json:"fileID,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *StreamFilePermissionsRequest) Reset()         { *m = StreamFilePermissionsRequest{} }
+func (m *StreamFilePermissionsRequest) String() string { return proto.CompactTextString(m) }
+func (*StreamFilePermissionsRequest) ProtoMessage()    {}
+func (*StreamFilePermissionsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c837ef01cbda0ad8, []int{21}
+}
+
+func (m *StreamFilePermissionsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_StreamFilePermissionsRequest.Unmarshal(m, b)
+}
+func (m *StreamFilePermissionsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_StreamFilePermissionsRequest.Marshal(b, m, deterministic)
+}
+func (m *StreamFilePermissionsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_StreamFilePermissionsRequest.Merge(m, src)
+}
+func (m *StreamFilePermissionsRequest) XXX_Size() int {
+	return xxx_messageInfo_StreamFilePermissionsRequest.Size(m)
+}
+func (m *StreamFilePermissionsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_StreamFilePermissionsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_StreamFilePermissionsRequest proto.InternalMessageInfo
+
+func (m *StreamFilePermissionsRequest) GetFileID() string {
+	if m != nil {
+		return m.FileID
+	}
+	return ""
+}
+
+type WatchPermissionsRequest struct {
+	// The ID of the file whose permissions are watched.
+	FileID string `protobuf:"bytes,1,opt,name=fileID,proto3" json:"fileID,omitempty"`
+	// The resumeToken of the last event the client received, the watch resumes right after it.
+	// If it's empty the watch starts from now.
+	ResumeToken          []byte   `protobuf:"bytes,2,opt,name=resumeToken,proto3" json:"resumeToken,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *WatchPermissionsRequest) Reset()         { *m = WatchPermissionsRequest{} }
+func (m *WatchPermissionsRequest) String() string { return proto.CompactTextString(m) }
+func (*WatchPermissionsRequest) ProtoMessage()    {}
+func (*WatchPermissionsRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c837ef01cbda0ad8, []int{22}
+}
+
+func (m *WatchPermissionsRequest) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_WatchPermissionsRequest.Unmarshal(m, b)
+}
+func (m *WatchPermissionsRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_WatchPermissionsRequest.Marshal(b, m, deterministic)
+}
+func (m *WatchPermissionsRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_WatchPermissionsRequest.Merge(m, src)
+}
+func (m *WatchPermissionsRequest) XXX_Size() int {
+	return xxx_messageInfo_WatchPermissionsRequest.Size(m)
+}
+func (m *WatchPermissionsRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_WatchPermissionsRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_WatchPermissionsRequest proto.InternalMessageInfo
+
+func (m *WatchPermissionsRequest) GetFileID() string {
+	if m != nil {
+		return m.FileID
+	}
+	return ""
+}
+
+func (m *WatchPermissionsRequest) GetResumeToken() []byte {
+	if m != nil {
+		return m.ResumeToken
+	}
+	return nil
+}
+
+type PermissionEvent struct {
+	// The kind of the change, one of "created", "updated" and "deleted".
+	Type string `protobuf:"bytes,1,opt,name=type,proto3" json:"type,omitempty"`
+	// The ID of the changed permission.
+	Id string `protobuf:"bytes,2,opt,name=id,proto3" json:"id,omitempty"`
+	// The ID of the file of the changed permission.
+	FileID string `protobuf:"bytes,3,opt,name=fileID,proto3" json:"fileID,omitempty"`
+	// The ID of the user of the changed permission.
+	UserID string `protobuf:"bytes,4,opt,name=userID,proto3" json:"userID,omitempty"`
+	// The role of the permission after the change, NONE if it was deleted.
+	Role Role `protobuf:"varint,5,opt,name=role,proto3,enum=permission.Role" json:"role,omitempty"`
+	// Resumes the watch right after this event when it's sent as the resumeToken of a WatchPermissionsRequest.
+	ResumeToken          []byte   `protobuf:"bytes,6,opt,name=resumeToken,proto3" json:"resumeToken,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *PermissionEvent) Reset()         { *m = PermissionEvent{} }
+func (m *PermissionEvent) String() string { return proto.CompactTextString(m) }
+func (*PermissionEvent) ProtoMessage()    {}
+func (*PermissionEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_c837ef01cbda0ad8, []int{23}
+}
+
+func (m *PermissionEvent) XXX_Unmarshal(b []byte) error {
+	return xxx_messageInfo_PermissionEvent.Unmarshal(m, b)
+}
+func (m *PermissionEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	return xxx_messageInfo_PermissionEvent.Marshal(b, m, deterministic)
+}
+func (m *PermissionEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_PermissionEvent.Merge(m, src)
+}
+func (m *PermissionEvent) XXX_Size() int {
+	return xxx_messageInfo_PermissionEvent.Size(m)
+}
+func (m *PermissionEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_PermissionEvent.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_PermissionEvent proto.InternalMessageInfo
+
+func (m *PermissionEvent) GetType() string {
+	if m != nil {
+		return m.Type
+	}
+	return ""
+}
+
+func (m *PermissionEvent) GetId() string {
+	if m != nil {
+		return m.Id
+	}
+	return ""
+}
+
+func (m *PermissionEvent) GetFileID() string {
+	if m != nil {
+		return m.FileID
+	}
+	return ""
+}
+
+func (m *PermissionEvent) GetUserID() string {
+	if m != nil {
+		return m.UserID
+	}
+	return ""
+}
+
+func (m *PermissionEvent) GetRole() Role {
+	if m != nil {
+		return m.Role
+	}
+	return Role_NONE
+}
+
+func (m *PermissionEvent) GetResumeToken() []byte {
+	if m != nil {
+		return m.ResumeToken
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterEnum("permission.Role", Role_name, Role_value)
+	proto.RegisterType((*CreatePermissionRequest)(nil), "permission.CreatePermissionRequest")
+	proto.RegisterMapType((map[string]string)(nil), "permission.CreatePermissionRequest.MetadataEntry")
+	proto.RegisterType((*CreatePermissionsRequest)(nil), "permission.CreatePermissionsRequest")
+	proto.RegisterType((*CreatePermissionsResponse)(nil), "permission.CreatePermissionsResponse")
+	proto.RegisterType((*DeletePermissionRequest)(nil), "permission.DeletePermissionRequest")
+	proto.RegisterType((*UpdatePermissionRequest)(nil), "permission.UpdatePermissionRequest")
+	proto.RegisterType((*PermissionObject)(nil), "permission.PermissionObject")
+	proto.RegisterMapType((map[string]string)(nil), "permission.PermissionObject.MetadataEntry")
+	proto.RegisterType((*GetPermissionRequest)(nil), "permission.GetPermissionRequest")
+	proto.RegisterType((*GetFilePermissionsRequest)(nil), "permission.GetFilePermissionsRequest")
 	proto.RegisterType((*GetFilePermissionsResponse)(nil), "permission.GetFilePermissionsResponse")
 	proto.RegisterType((*GetFilePermissionsResponse_UserRole)(nil), "permission.GetFilePermissionsResponse.UserRole")
 	proto.RegisterType((*IsPermittedRequest)(nil), "permission.IsPermittedRequest")
@@ -760,44 +1614,94 @@ func init() {
 	proto.RegisterType((*GetUserPermissionsResponse_FileRole)(nil), "permission.GetUserPermissionsResponse.FileRole")
 	proto.RegisterType((*DeleteFilePermissionsRequest)(nil), "permission.DeleteFilePermissionsRequest")
 	proto.RegisterType((*DeleteFilePermissionsResponse)(nil), "permission.DeleteFilePermissionsResponse")
+	proto.RegisterType((*DeleteUserPermissionsRequest)(nil), "permission.DeleteUserPermissionsRequest")
+	proto.RegisterType((*DeleteUserPermissionsResponse)(nil), "permission.DeleteUserPermissionsResponse")
+	proto.RegisterType((*CountPermissionsRequest)(nil), "permission.CountPermissionsRequest")
+	proto.RegisterType((*CountPermissionsResponse)(nil), "permission.CountPermissionsResponse")
+	proto.RegisterType((*TransferOwnershipRequest)(nil), "permission.TransferOwnershipRequest")
+	proto.RegisterType((*TransferOwnershipResponse)(nil), "permission.TransferOwnershipResponse")
+	proto.RegisterType((*StreamFilePermissionsRequest)(nil), "permission.StreamFilePermissionsRequest")
+	proto.RegisterType((*WatchPermissionsRequest)(nil), "permission.WatchPermissionsRequest")
+	proto.RegisterType((*PermissionEvent)(nil), "permission.PermissionEvent")
 }
 
 func init() { proto.RegisterFile("permission.proto", fileDescriptor_c837ef01cbda0ad8) }
 
 var fileDescriptor_c837ef01cbda0ad8 = []byte{
-	// 514 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xb4, 0x55, 0xcb, 0x6e, 0xd3, 0x40,
-	0x14, 0xcd, 0x38, 0x4e, 0x49, 0x6e, 0x44, 0x65, 0x5d, 0x1e, 0x35, 0x51, 0x80, 0xc8, 0x50, 0x14,
-	0x58, 0x04, 0x29, 0x91, 0x58, 0x22, 0x21, 0x92, 0x56, 0xde, 0xf4, 0x61, 0x81, 0xba, 0x44, 0xa4,
-	0xb9, 0x48, 0xae, 0x4c, 0x1c, 0x3c, 0xd3, 0x8f, 0x60, 0xcb, 0x1f, 0xf0, 0x4d, 0xfc, 0x10, 0xb2,
-	0x63, 0xc7, 0x63, 0x7b, 0x86, 0x38, 0x0a, 0x5d, 0xce, 0x1d, 0xdf, 0x73, 0xe6, 0x9c, 0xfb, 0x30,
-	0x58, 0x2b, 0x8a, 0xbe, 0xfb, 0x9c, 0xfb, 0xe1, 0x72, 0xb4, 0x8a, 0x42, 0x11, 0x22, 0xe4, 0x11,
-	0xe7, 0x27, 0x83, 0xa3, 0x8f, 0x11, 0x7d, 0x15, 0x74, 0xb1, 0x09, 0x7a, 0xf4, 0xe3, 0x96, 0xb8,
-	0xc0, 0xc7, 0x70, 0xf0, 0xcd, 0x0f, 0xc8, 0x9d, 0xda, 0x6c, 0xc0, 0x86, 0x1d, 0x2f, 0x3d, 0xc5,
-	0xf1, 0x5b, 0x4e, 0x91, 0x3b, 0xb5, 0x8d, 0x75, 0x7c, 0x7d, 0xc2, 0x97, 0x60, 0x46, 0x61, 0x40,
-	0x76, 0x73, 0xc0, 0x86, 0x87, 0x63, 0x6b, 0x24, 0x11, 0x7b, 0x61, 0x40, 0x5e, 0x72, 0x8b, 0x36,
-	0xdc, 0xbb, 0x8e, 0x09, 0xc3, 0xc8, 0x36, 0x93, 0xf4, 0xec, 0xe8, 0xb8, 0x70, 0x34, 0xa5, 0x80,
-	0xfe, 0xc3, 0x53, 0x9c, 0x5f, 0x0c, 0xac, 0x1c, 0xe5, 0x7c, 0x7e, 0x43, 0xd7, 0x02, 0x0f, 0xc1,
-	0xf0, 0x17, 0x29, 0x80, 0xe1, 0x2f, 0x24, 0x50, 0x43, 0x03, 0xda, 0x54, 0xea, 0x33, 0xeb, 0xea,
-	0x6b, 0x15, 0xf5, 0x9d, 0xc0, 0xc3, 0x53, 0x12, 0xfb, 0x8b, 0x9b, 0xc0, 0x93, 0x53, 0x12, 0x27,
-	0x7e, 0x20, 0x19, 0xc5, 0xb7, 0x80, 0x39, 0x7f, 0x18, 0xf4, 0x54, 0x59, 0x7c, 0x15, 0x2e, 0x39,
-	0xe1, 0x25, 0x74, 0x73, 0x39, 0xdc, 0x66, 0x83, 0xe6, 0xb0, 0x3b, 0x7e, 0x2b, 0x4b, 0xd4, 0x27,
-	0x8f, 0x3e, 0x73, 0x8a, 0x12, 0x07, 0x64, 0x8c, 0xde, 0x1c, 0xda, 0xd9, 0x85, 0x24, 0x85, 0x29,
-	0x2d, 0x35, 0xea, 0x5a, 0xda, 0x2c, 0x5a, 0x7a, 0x03, 0xe8, 0xf2, 0xe4, 0x49, 0x42, 0xd0, 0xe2,
-	0x4e, 0x1b, 0xd7, 0x99, 0xc0, 0x83, 0x02, 0x57, 0xea, 0x5c, 0x1f, 0x3a, 0xab, 0x2c, 0x98, 0xf0,
-	0xb5, 0xbd, 0x3c, 0x90, 0xd6, 0x2a, 0xf6, 0x41, 0x5d, 0x2b, 0x95, 0x2b, 0x59, 0xad, 0x2a, 0x59,
-	0xbb, 0xd4, 0x4a, 0x93, 0x3c, 0x8a, 0x6b, 0xa8, 0xac, 0x55, 0x76, 0xa1, 0x75, 0x6f, 0xdf, 0x5a,
-	0xbd, 0x83, 0xfe, 0x7a, 0xbc, 0x77, 0xec, 0xdc, 0x2f, 0xf0, 0x54, 0x93, 0x97, 0xfa, 0xf1, 0x5e,
-	0xe5, 0x47, 0x5f, 0x7e, 0x5f, 0x79, 0x15, 0x14, 0xc4, 0xbf, 0x39, 0x06, 0x33, 0x11, 0xde, 0x06,
-	0xf3, 0xec, 0xfc, 0x6c, 0x66, 0x35, 0xb0, 0x03, 0xad, 0x2b, 0xcf, 0xfd, 0x34, 0xb3, 0x58, 0x1c,
-	0xf4, 0x66, 0x1f, 0xa6, 0x96, 0x31, 0xfe, 0xdd, 0x02, 0xc8, 0x81, 0xf0, 0x0a, 0xac, 0xf2, 0xe2,
-	0xc4, 0x17, 0x32, 0xa9, 0x66, 0xad, 0xf6, 0xfe, 0xf9, 0x32, 0xa7, 0x11, 0x03, 0x97, 0xd7, 0x60,
-	0x11, 0x58, 0xb3, 0x24, 0xb7, 0x02, 0x13, 0x60, 0x75, 0x88, 0xf1, 0x78, 0xdb, 0x90, 0xaf, 0xc1,
-	0x5f, 0xd5, 0xdb, 0x05, 0x1b, 0x9a, 0x52, 0xff, 0x55, 0x68, 0xd4, 0x23, 0x51, 0xa1, 0xd1, 0xb4,
-	0xb1, 0xd3, 0xc0, 0x0b, 0xe8, 0x4a, 0xe3, 0x88, 0xcf, 0xe4, 0xc4, 0xea, 0x4e, 0xe8, 0x3d, 0xd7,
-	0xde, 0x6f, 0x10, 0x97, 0xf0, 0x48, 0xd9, 0x68, 0x38, 0xac, 0xba, 0xaf, 0x71, 0xe9, 0x75, 0x8d,
-	0x2f, 0x37, 0x7c, 0x97, 0x70, 0xbf, 0xf0, 0x3f, 0xc0, 0x41, 0x49, 0xfc, 0xce, 0x25, 0x9e, 0x1f,
-	0x24, 0x7f, 0xf8, 0xc9, 0xdf, 0x00, 0x00, 0x00, 0xff, 0xff, 0x93, 0xe3, 0xbd, 0x4b, 0xf5, 0x07,
+	// 1170 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0xbd, 0x58, 0xcd, 0x6e, 0xdb, 0x46,
+	0x10, 0x36, 0x45, 0x49, 0xa6, 0x46, 0xb6, 0xcb, 0x6e, 0x92, 0x9a, 0x56, 0xdc, 0xd4, 0x60, 0x9c,
+	0xc0, 0xc9, 0x41, 0x71, 0x1c, 0x20, 0x28, 0x5a, 0xa0, 0x40, 0x5a, 0x2b, 0x85, 0x0e, 0xb1, 0x1d,
+	0xda, 0x86, 0x0b, 0x04, 0x68, 0x40, 0x4b, 0x2b, 0x87, 0x89, 0x44, 0xaa, 0xe4, 0xca, 0xb1, 0xfa,
+	0x00, 0x05, 0x7a, 0xe8, 0x63, 0xf4, 0xd8, 0x97, 0x29, 0xd0, 0x67, 0xe9, 0xb5, 0xbb, 0x4b, 0x8a,
+	0x5c, 0x92, 0x4b, 0xfd, 0xc4, 0x49, 0x4f, 0xde, 0x99, 0xe1, 0xfc, 0xee, 0x37, 0x33, 0x2b, 0x83,
+	0x3e, 0xc4, 0xfe, 0xc0, 0x09, 0x02, 0xc7, 0x73, 0x9b, 0x43, 0xdf, 0x23, 0x1e, 0x82, 0x84, 0x63,
+	0xfe, 0xa9, 0xc2, 0xfa, 0x0f, 0x3e, 0xb6, 0x09, 0x3e, 0x8a, 0x99, 0x16, 0xfe, 0x65, 0x84, 0x03,
+	0x82, 0xbe, 0x80, 0x6a, 0xcf, 0xe9, 0xe3, 0xf6, 0xbe, 0xa1, 0x6c, 0x29, 0x3b, 0x35, 0x2b, 0xa2,
+	0x18, 0x7f, 0x14, 0x60, 0x9f, 0xf2, 0x4b, 0x21, 0x3f, 0xa4, 0xd0, 0x36, 0x94, 0x7d, 0xaf, 0x8f,
+	0x0d, 0x95, 0x72, 0xd7, 0xf6, 0xf4, 0xa6, 0xe0, 0xd8, 0xa2, 0x7c, 0x8b, 0x4b, 0x91, 0x01, 0xcb,
+	0x1d, 0xe6, 0xd0, 0xf3, 0x8d, 0x32, 0x57, 0x9f, 0x90, 0x68, 0x13, 0x6a, 0xf8, 0x6a, 0xe8, 0xf8,
+	0x38, 0x78, 0x46, 0x8c, 0x0a, 0x95, 0xa9, 0x56, 0xc2, 0x40, 0x2f, 0x40, 0x1b, 0x60, 0x62, 0x77,
+	0x6d, 0x62, 0x1b, 0xd5, 0x2d, 0x75, 0xa7, 0xbe, 0xf7, 0x58, 0xf4, 0x50, 0x90, 0x44, 0xf3, 0x45,
+	0xa4, 0xd3, 0x72, 0x89, 0x3f, 0xb6, 0x62, 0x13, 0x2c, 0x09, 0xaa, 0x11, 0x78, 0xae, 0xb1, 0x1c,
+	0x26, 0x11, 0x52, 0x68, 0x0b, 0xea, 0xc1, 0xe8, 0xfc, 0x2d, 0xee, 0x90, 0x93, 0xf1, 0x10, 0x1b,
+	0x1a, 0x17, 0x8a, 0x2c, 0x84, 0xa0, 0xdc, 0xc5, 0xee, 0xd8, 0xa8, 0x51, 0x91, 0x66, 0xf1, 0x33,
+	0x32, 0x61, 0xa5, 0x67, 0x3b, 0xfd, 0x76, 0xaf, 0x75, 0xe5, 0x04, 0x24, 0x30, 0x80, 0xcb, 0x52,
+	0xbc, 0xc6, 0xb7, 0xb0, 0x9a, 0x0a, 0x06, 0xe9, 0xa0, 0xbe, 0xc3, 0xe3, 0xa8, 0xb8, 0xec, 0x88,
+	0x6e, 0x42, 0xe5, 0xd2, 0xee, 0x8f, 0x70, 0x54, 0xd8, 0x90, 0xf8, 0xa6, 0xf4, 0xb5, 0x62, 0xda,
+	0x60, 0x64, 0x33, 0x0c, 0x26, 0xf7, 0xd4, 0x82, 0x7a, 0x52, 0x88, 0x80, 0xda, 0x63, 0xc5, 0xb9,
+	0x3b, 0x47, 0x71, 0x2c, 0x51, 0xcf, 0x7c, 0x05, 0x1b, 0x12, 0x17, 0xc1, 0x90, 0xfe, 0xc1, 0xe8,
+	0x3b, 0x99, 0x8f, 0x4d, 0xd1, 0x47, 0xa2, 0x75, 0xc8, 0x6b, 0x95, 0x36, 0xde, 0x86, 0xf5, 0x7d,
+	0xdc, 0xc7, 0x1f, 0x01, 0x66, 0xe6, 0xef, 0x0a, 0xac, 0x9f, 0x0e, 0xbb, 0xff, 0x2f, 0x64, 0x2f,
+	0xb1, 0xcf, 0xb8, 0x1c, 0xb2, 0xaa, 0x35, 0x21, 0xcd, 0x7f, 0x55, 0xd0, 0xb3, 0x89, 0xa3, 0x35,
+	0x28, 0x39, 0xdd, 0x28, 0x00, 0x7a, 0x12, 0x82, 0x2a, 0x15, 0x04, 0xa5, 0x4a, 0x83, 0x2a, 0xcf,
+	0xdb, 0x47, 0x95, 0x29, 0x7d, 0x54, 0xcd, 0xf6, 0x91, 0x90, 0xcc, 0x72, 0x2a, 0x19, 0xa6, 0xc7,
+	0x4d, 0xe0, 0x2e, 0xd5, 0xd3, 0x42, 0xbd, 0x98, 0xc1, 0xa4, 0x23, 0x5e, 0x75, 0x26, 0xad, 0x85,
+	0xd2, 0x98, 0x81, 0x9e, 0x0b, 0xdd, 0x09, 0x1c, 0x1c, 0x0f, 0xa7, 0x81, 0x63, 0x8e, 0xb6, 0xac,
+	0xa7, 0xda, 0x92, 0x7a, 0xbf, 0xf0, 0x6d, 0x97, 0x3a, 0xfb, 0x7e, 0x6c, 0xac, 0x70, 0x51, 0xc2,
+	0xc8, 0x36, 0xed, 0x6a, 0x71, 0xd3, 0xae, 0x25, 0x4d, 0x7b, 0xbd, 0x86, 0xfc, 0x19, 0x6e, 0xfe,
+	0x88, 0xc9, 0xf5, 0x11, 0xc8, 0xbf, 0xc7, 0xfd, 0x6e, 0x40, 0x41, 0xa0, 0x86, 0xdf, 0x33, 0xca,
+	0xfc, 0x47, 0x81, 0x0d, 0xea, 0xe0, 0x39, 0xd5, 0x96, 0xb4, 0x7c, 0x91, 0x97, 0x06, 0x68, 0x43,
+	0xfb, 0x02, 0x1f, 0x3b, 0xbf, 0x86, 0x21, 0xab, 0x56, 0x4c, 0xb3, 0x12, 0xb2, 0xf3, 0x89, 0xf7,
+	0x0e, 0xbb, 0x11, 0xe2, 0x12, 0x06, 0xba, 0x0f, 0x15, 0x06, 0xab, 0x80, 0xa2, 0x4e, 0x95, 0xa2,
+	0x2e, 0x14, 0x33, 0xcf, 0x81, 0xe7, 0x13, 0x7a, 0x0b, 0x21, 0xea, 0x22, 0x8a, 0xea, 0xaf, 0xb1,
+	0xd3, 0x3e, 0x0e, 0x3a, 0xd8, 0xed, 0x3a, 0xee, 0x05, 0x47, 0x9e, 0x66, 0x65, 0xb8, 0xe6, 0x1f,
+	0x25, 0x68, 0xc8, 0xf2, 0x8a, 0xe6, 0xcc, 0x4b, 0xd9, 0x9c, 0x79, 0x24, 0x06, 0x53, 0xac, 0xdc,
+	0x3c, 0xa5, 0x25, 0xe5, 0xb1, 0x8a, 0x36, 0x68, 0x3b, 0xad, 0xba, 0xf8, 0x8a, 0x1c, 0xc5, 0xb9,
+	0x87, 0x17, 0x90, 0x66, 0x36, 0x2e, 0x41, 0x9b, 0xa8, 0x0b, 0x77, 0xa5, 0x48, 0x1b, 0xb3, 0x34,
+	0x6f, 0x63, 0xaa, 0xe9, 0xc6, 0x9c, 0x80, 0xb0, 0x9c, 0x80, 0xd0, 0x7c, 0x0b, 0xa8, 0x1d, 0xf0,
+	0x64, 0x08, 0xc5, 0xf2, 0x27, 0x9d, 0x63, 0xe6, 0x13, 0xb8, 0x91, 0xf2, 0x15, 0xd5, 0x9c, 0x01,
+	0x63, 0xc2, 0xe4, 0xfe, 0x34, 0x2b, 0x61, 0x98, 0x03, 0x8e, 0x43, 0x56, 0x1b, 0x39, 0x0e, 0xa5,
+	0x95, 0xfa, 0x60, 0x1c, 0x4e, 0xf0, 0x91, 0xf3, 0xb7, 0x08, 0x3e, 0x0a, 0x94, 0x9b, 0x0c, 0x37,
+	0xd7, 0xc0, 0xc7, 0x44, 0xbd, 0xf0, 0x76, 0x3e, 0x05, 0x3e, 0x0e, 0x60, 0x33, 0x5c, 0x9c, 0x0b,
+	0x4e, 0x02, 0xca, 0xef, 0xd2, 0xc9, 0x3a, 0x0a, 0xd3, 0xd1, 0xac, 0x88, 0x32, 0x5f, 0xc3, 0x97,
+	0x05, 0xf6, 0x3e, 0xd2, 0xa6, 0x8f, 0x03, 0x5e, 0x10, 0x32, 0x33, 0x03, 0x2e, 0x82, 0xc4, 0x75,
+	0x03, 0x7e, 0x4c, 0x5f, 0xc0, 0xde, 0xc8, 0x25, 0xf3, 0x17, 0xd7, 0xdc, 0xa5, 0xaf, 0xb1, 0x9c,
+	0x4a, 0x14, 0x0e, 0x5d, 0x19, 0x1d, 0x26, 0xe3, 0x2a, 0xaa, 0x15, 0x12, 0xe6, 0x6f, 0x0a, 0x18,
+	0x27, 0x74, 0x5f, 0x05, 0x3d, 0xec, 0x1f, 0xbe, 0x77, 0xe9, 0xc6, 0x7d, 0xe3, 0x0c, 0x67, 0xdd,
+	0xe1, 0x1d, 0x80, 0x9e, 0xef, 0x0d, 0x4e, 0xc5, 0x8e, 0x17, 0x38, 0xac, 0xcb, 0x88, 0x77, 0x2a,
+	0x3e, 0x21, 0x62, 0x5a, 0x28, 0x67, 0x39, 0x55, 0xce, 0xdb, 0xb0, 0x21, 0x89, 0x23, 0x8c, 0xdd,
+	0x7c, 0x0a, 0x9b, 0xc7, 0x84, 0x82, 0x71, 0xb0, 0x18, 0xd8, 0xcc, 0x63, 0x58, 0x3f, 0xb3, 0x49,
+	0xe7, 0xcd, 0x02, 0xf8, 0xa4, 0x2b, 0x9b, 0xbe, 0x47, 0x46, 0x03, 0xa1, 0xe7, 0x56, 0x2c, 0x91,
+	0x65, 0xfe, 0xa5, 0xc0, 0x67, 0x89, 0xc1, 0xd6, 0x25, 0x76, 0x09, 0xeb, 0x10, 0xc2, 0x36, 0x7c,
+	0x68, 0x8b, 0x9f, 0xa3, 0xe7, 0x56, 0x49, 0xf2, 0xdc, 0x52, 0x0b, 0x66, 0x67, 0x59, 0x3a, 0x3b,
+	0x2b, 0x53, 0xbb, 0x36, 0x13, 0x6f, 0x35, 0x17, 0xef, 0xc3, 0x5d, 0x28, 0xf3, 0xe9, 0xa0, 0x41,
+	0xf9, 0xe0, 0xf0, 0xa0, 0xa5, 0x2f, 0xa1, 0x1a, 0x54, 0xce, 0xac, 0xf6, 0x49, 0x4b, 0x57, 0x18,
+	0xd3, 0x6a, 0x3d, 0xdb, 0xd7, 0x4b, 0x8c, 0x79, 0x78, 0x76, 0xd0, 0xb2, 0x74, 0x75, 0xef, 0x6f,
+	0x00, 0x48, 0x32, 0x44, 0x67, 0xa0, 0x67, 0x1f, 0xe0, 0x68, 0x9e, 0x67, 0x7c, 0x63, 0x2a, 0xd8,
+	0xcd, 0x25, 0x66, 0x38, 0xfb, 0xf8, 0x4e, 0x1b, 0x2e, 0x78, 0x9a, 0xcf, 0x34, 0x8c, 0x01, 0xe5,
+	0xd7, 0x31, 0xba, 0x37, 0x6b, 0x5d, 0x87, 0xc6, 0xef, 0xcf, 0xb7, 0xd5, 0x63, 0x37, 0x99, 0xfe,
+	0xcf, 0xb9, 0x91, 0xcf, 0x9b, 0x9c, 0x9b, 0x82, 0x31, 0x42, 0xdd, 0x1c, 0x41, 0x5d, 0x58, 0x8f,
+	0xe8, 0x8e, 0xa8, 0x98, 0xdf, 0xd1, 0x8d, 0xaf, 0x0a, 0xe5, 0xb1, 0x45, 0x17, 0x6e, 0x49, 0x87,
+	0x2d, 0xda, 0xc9, 0x57, 0xbf, 0xa0, 0x4a, 0x0f, 0xe6, 0xf8, 0x32, 0xef, 0x2f, 0x5b, 0x2b, 0x89,
+	0xbf, 0x82, 0x72, 0x3d, 0x98, 0xe3, 0xcb, 0xd8, 0xdf, 0x4b, 0x58, 0x4d, 0x3d, 0x82, 0xd1, 0x56,
+	0xa6, 0xd8, 0x1f, 0x84, 0xd5, 0xec, 0x8f, 0xbb, 0x34, 0x56, 0x0b, 0x7e, 0xfa, 0xcd, 0x34, 0x7c,
+	0x0e, 0x9f, 0xe7, 0x7e, 0xde, 0xa2, 0xed, 0x69, 0xed, 0x15, 0xd7, 0xe4, 0xde, 0x8c, 0xaf, 0xe2,
+	0x7a, 0xbc, 0xa6, 0x1d, 0x9c, 0xd9, 0x0b, 0x99, 0x0e, 0x96, 0x2f, 0x9a, 0xc6, 0xf6, 0xf4, 0x8f,
+	0x62, 0x07, 0x34, 0x89, 0xdc, 0xf4, 0x4e, 0x27, 0x51, 0xb4, 0x64, 0xd2, 0x49, 0x14, 0xaf, 0x80,
+	0x25, 0xd4, 0x81, 0x5b, 0xd2, 0x25, 0x90, 0x06, 0xd1, 0xb4, 0x3d, 0x31, 0xeb, 0x2e, 0x76, 0x15,
+	0xf4, 0x13, 0xe8, 0xd9, 0x8d, 0x91, 0xae, 0x54, 0xc1, 0x3e, 0x69, 0xdc, 0x96, 0x9b, 0xe6, 0xeb,
+	0x81, 0x59, 0x3e, 0xaf, 0xf2, 0x7f, 0x72, 0x3d, 0xf9, 0x0f, 0x98, 0xb7, 0x71, 0x7d, 0xf8, 0x12,
 	0x00, 0x00,
 }
 
@@ -825,8 +1729,24 @@ type PermissionClient interface {
 	IsPermitted(ctx context.Context, in *IsPermittedRequest, opts ...grpc.CallOption) (*IsPermittedResponse, error)
 	// DeleteFilePermissions deletes all permissions of a file and returns them.
 	DeleteFilePermissions(ctx context.Context, in *DeleteFilePermissionsRequest, opts ...grpc.CallOption) (*DeleteFilePermissionsResponse, error)
+	// DeleteUserPermissions deletes all permissions of a user and returns them.
+	DeleteUserPermissions(ctx context.Context, in *DeleteUserPermissionsRequest, opts ...grpc.CallOption) (*DeleteUserPermissionsResponse, error)
 	// GetPermission returns a permission of the user to a file.
 	GetPermission(ctx context.Context, in *GetPermissionRequest, opts ...grpc.CallOption) (*PermissionObject, error)
+	// UpdatePermission updates the role of an existing permission and returns it.
+	UpdatePermission(ctx context.Context, in *UpdatePermissionRequest, opts ...grpc.CallOption) (*PermissionObject, error)
+	// CreatePermissions creates or updates many permissions at once and returns them.
+	CreatePermissions(ctx context.Context, in *CreatePermissionsRequest, opts ...grpc.CallOption) (*CreatePermissionsResponse, error)
+	// CountPermissions returns the number of permissions that exist for fileID.
+	CountPermissions(ctx context.Context, in *CountPermissionsRequest, opts ...grpc.CallOption) (*CountPermissionsResponse, error)
+	// TransferOwnership makes a user the owner of a file and demotes the file's current owner.
+	TransferOwnership(ctx context.Context, in *TransferOwnershipRequest, opts ...grpc.CallOption) (*TransferOwnershipResponse, error)
+	// StreamFilePermissions streams the permissions of fileID one by one, for files with too many
+	// permissions to return in a single response.
+	StreamFilePermissions(ctx context.Context, in *StreamFilePermissionsRequest, opts ...grpc.CallOption) (Permission_StreamFilePermissionsClient, error)
+	// WatchPermissions streams the changes of fileID's permissions as they happen,
+	// until the client disconnects.
+	WatchPermissions(ctx context.Context, in *WatchPermissionsRequest, opts ...grpc.CallOption) (Permission_WatchPermissionsClient, error)
 }
 
 type permissionClient struct {
@@ -891,6 +1811,15 @@ func (c *permissionClient) DeleteFilePermissions(ctx context.Context, in *Delete
 	return out, nil
 }
 
+func (c *permissionClient) DeleteUserPermissions(ctx context.Context, in *DeleteUserPermissionsRequest, opts ...grpc.CallOption) (*DeleteUserPermissionsResponse, error) {
+	out := new(DeleteUserPermissionsResponse)
+	err := c.cc.Invoke(ctx, "/permission.Permission/DeleteUserPermissions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *permissionClient) GetPermission(ctx context.Context, in *GetPermissionRequest, opts ...grpc.CallOption) (*PermissionObject, error) {
 	out := new(PermissionObject)
 	err := c.cc.Invoke(ctx, "/permission.Permission/GetPermission", in, out, opts...)
@@ -900,6 +1829,106 @@ func (c *permissionClient) GetPermission(ctx context.Context, in *GetPermissionR
 	return out, nil
 }
 
+func (c *permissionClient) UpdatePermission(ctx context.Context, in *UpdatePermissionRequest, opts ...grpc.CallOption) (*PermissionObject, error) {
+	out := new(PermissionObject)
+	err := c.cc.Invoke(ctx, "/permission.Permission/UpdatePermission", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *permissionClient) CreatePermissions(ctx context.Context, in *CreatePermissionsRequest, opts ...grpc.CallOption) (*CreatePermissionsResponse, error) {
+	out := new(CreatePermissionsResponse)
+	err := c.cc.Invoke(ctx, "/permission.Permission/CreatePermissions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *permissionClient) CountPermissions(ctx context.Context, in *CountPermissionsRequest, opts ...grpc.CallOption) (*CountPermissionsResponse, error) {
+	out := new(CountPermissionsResponse)
+	err := c.cc.Invoke(ctx, "/permission.Permission/CountPermissions", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *permissionClient) TransferOwnership(ctx context.Context, in *TransferOwnershipRequest, opts ...grpc.CallOption) (*TransferOwnershipResponse, error) {
+	out := new(TransferOwnershipResponse)
+	err := c.cc.Invoke(ctx, "/permission.Permission/TransferOwnership", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *permissionClient) StreamFilePermissions(ctx context.Context, in *StreamFilePermissionsRequest, opts ...grpc.CallOption) (Permission_StreamFilePermissionsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Permission_serviceDesc.Streams[0], "/permission.Permission/StreamFilePermissions", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &permissionStreamFilePermissionsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Permission_StreamFilePermissionsClient interface {
+	Recv() (*PermissionObject, error)
+	grpc.ClientStream
+}
+
+type permissionStreamFilePermissionsClient struct {
+	grpc.ClientStream
+}
+
+func (x *permissionStreamFilePermissionsClient) Recv() (*PermissionObject, error) {
+	m := new(PermissionObject)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *permissionClient) WatchPermissions(ctx context.Context, in *WatchPermissionsRequest, opts ...grpc.CallOption) (Permission_WatchPermissionsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_Permission_serviceDesc.Streams[1], "/permission.Permission/WatchPermissions", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &permissionWatchPermissionsClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type Permission_WatchPermissionsClient interface {
+	Recv() (*PermissionEvent, error)
+	grpc.ClientStream
+}
+
+type permissionWatchPermissionsClient struct {
+	grpc.ClientStream
+}
+
+func (x *permissionWatchPermissionsClient) Recv() (*PermissionEvent, error) {
+	m := new(PermissionEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
 // PermissionServer is the server API for Permission service.
 type PermissionServer interface {
 	// CreatePermission creates a new permission and returns it, if permission already exists, update it.
@@ -914,8 +1943,24 @@ type PermissionServer interface {
 	IsPermitted(context.Context, *IsPermittedRequest) (*IsPermittedResponse, error)
 	// DeleteFilePermissions deletes all permissions of a file and returns them.
 	DeleteFilePermissions(context.Context, *DeleteFilePermissionsRequest) (*DeleteFilePermissionsResponse, error)
+	// DeleteUserPermissions deletes all permissions of a user and returns them.
+	DeleteUserPermissions(context.Context, *DeleteUserPermissionsRequest) (*DeleteUserPermissionsResponse, error)
 	// GetPermission returns a permission of the user to a file.
 	GetPermission(context.Context, *GetPermissionRequest) (*PermissionObject, error)
+	// UpdatePermission updates the role of an existing permission and returns it.
+	UpdatePermission(context.Context, *UpdatePermissionRequest) (*PermissionObject, error)
+	// CreatePermissions creates or updates many permissions at once and returns them.
+	CreatePermissions(context.Context, *CreatePermissionsRequest) (*CreatePermissionsResponse, error)
+	// CountPermissions returns the number of permissions that exist for fileID.
+	CountPermissions(context.Context, *CountPermissionsRequest) (*CountPermissionsResponse, error)
+	// TransferOwnership makes a user the owner of a file and demotes the file's current owner.
+	TransferOwnership(context.Context, *TransferOwnershipRequest) (*TransferOwnershipResponse, error)
+	// StreamFilePermissions streams the permissions of fileID one by one, for files with too many
+	// permissions to return in a single response.
+	StreamFilePermissions(*StreamFilePermissionsRequest, Permission_StreamFilePermissionsServer) error
+	// WatchPermissions streams the changes of fileID's permissions as they happen,
+	// until the client disconnects.
+	WatchPermissions(*WatchPermissionsRequest, Permission_WatchPermissionsServer) error
 }
 
 // UnimplementedPermissionServer can be embedded to have forward compatible implementations.
@@ -940,9 +1985,30 @@ func (*UnimplementedPermissionServer) IsPermitted(ctx context.Context, req *IsPe
 func (*UnimplementedPermissionServer) DeleteFilePermissions(ctx context.Context, req *DeleteFilePermissionsRequest) (*DeleteFilePermissionsResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteFilePermissions not implemented")
 }
+func (*UnimplementedPermissionServer) DeleteUserPermissions(ctx context.Context, req *DeleteUserPermissionsRequest) (*DeleteUserPermissionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteUserPermissions not implemented")
+}
 func (*UnimplementedPermissionServer) GetPermission(ctx context.Context, req *GetPermissionRequest) (*PermissionObject, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetPermission not implemented")
 }
+func (*UnimplementedPermissionServer) UpdatePermission(ctx context.Context, req *UpdatePermissionRequest) (*PermissionObject, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdatePermission not implemented")
+}
+func (*UnimplementedPermissionServer) CreatePermissions(ctx context.Context, req *CreatePermissionsRequest) (*CreatePermissionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreatePermissions not implemented")
+}
+func (*UnimplementedPermissionServer) CountPermissions(ctx context.Context, req *CountPermissionsRequest) (*CountPermissionsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CountPermissions not implemented")
+}
+func (*UnimplementedPermissionServer) TransferOwnership(ctx context.Context, req *TransferOwnershipRequest) (*TransferOwnershipResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TransferOwnership not implemented")
+}
+func (*UnimplementedPermissionServer) StreamFilePermissions(req *StreamFilePermissionsRequest, srv Permission_StreamFilePermissionsServer) error {
+	return status.Errorf(codes.Unimplemented, "method StreamFilePermissions not implemented")
+}
+func (*UnimplementedPermissionServer) WatchPermissions(req *WatchPermissionsRequest, srv Permission_WatchPermissionsServer) error {
+	return status.Errorf(codes.Unimplemented, "method WatchPermissions not implemented")
+}
 
 func RegisterPermissionServer(s *grpc.Server, srv PermissionServer) {
 	s.RegisterService(&_Permission_serviceDesc, srv)
@@ -1056,6 +2122,24 @@ func _Permission_DeleteFilePermissions_Handler(srv interface{}, ctx context.Cont
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Permission_DeleteUserPermissions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteUserPermissionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PermissionServer).DeleteUserPermissions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/permission.Permission/DeleteUserPermissions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PermissionServer).DeleteUserPermissions(ctx, req.(*DeleteUserPermissionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _Permission_GetPermission_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(GetPermissionRequest)
 	if err := dec(in); err != nil {
@@ -1074,6 +2158,120 @@ func _Permission_GetPermission_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _Permission_UpdatePermission_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdatePermissionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PermissionServer).UpdatePermission(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/permission.Permission/UpdatePermission",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PermissionServer).UpdatePermission(ctx, req.(*UpdatePermissionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Permission_CreatePermissions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreatePermissionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PermissionServer).CreatePermissions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/permission.Permission/CreatePermissions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PermissionServer).CreatePermissions(ctx, req.(*CreatePermissionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Permission_CountPermissions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CountPermissionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PermissionServer).CountPermissions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/permission.Permission/CountPermissions",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PermissionServer).CountPermissions(ctx, req.(*CountPermissionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Permission_TransferOwnership_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TransferOwnershipRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(PermissionServer).TransferOwnership(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/permission.Permission/TransferOwnership",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(PermissionServer).TransferOwnership(ctx, req.(*TransferOwnershipRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _Permission_StreamFilePermissions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(StreamFilePermissionsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PermissionServer).StreamFilePermissions(m, &permissionStreamFilePermissionsServer{stream})
+}
+
+type Permission_StreamFilePermissionsServer interface {
+	Send(*PermissionObject) error
+	grpc.ServerStream
+}
+
+type permissionStreamFilePermissionsServer struct {
+	grpc.ServerStream
+}
+
+func (x *permissionStreamFilePermissionsServer) Send(m *PermissionObject) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _Permission_WatchPermissions_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(WatchPermissionsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(PermissionServer).WatchPermissions(m, &permissionWatchPermissionsServer{stream})
+}
+
+type Permission_WatchPermissionsServer interface {
+	Send(*PermissionEvent) error
+	grpc.ServerStream
+}
+
+type permissionWatchPermissionsServer struct {
+	grpc.ServerStream
+}
+
+func (x *permissionWatchPermissionsServer) Send(m *PermissionEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
 var _Permission_serviceDesc = grpc.ServiceDesc{
 	ServiceName: "permission.Permission",
 	HandlerType: (*PermissionServer)(nil),
@@ -1102,11 +2300,42 @@ var _Permission_serviceDesc = grpc.ServiceDesc{
 			MethodName: "DeleteFilePermissions",
 			Handler:    _Permission_DeleteFilePermissions_Handler,
 		},
+		{
+			MethodName: "DeleteUserPermissions",
+			Handler:    _Permission_DeleteUserPermissions_Handler,
+		},
 		{
 			MethodName: "GetPermission",
 			Handler:    _Permission_GetPermission_Handler,
 		},
+		{
+			MethodName: "UpdatePermission",
+			Handler:    _Permission_UpdatePermission_Handler,
+		},
+		{
+			MethodName: "CreatePermissions",
+			Handler:    _Permission_CreatePermissions_Handler,
+		},
+		{
+			MethodName: "CountPermissions",
+			Handler:    _Permission_CountPermissions_Handler,
+		},
+		{
+			MethodName: "TransferOwnership",
+			Handler:    _Permission_TransferOwnership_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "StreamFilePermissions",
+			Handler:       _Permission_StreamFilePermissions_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "WatchPermissions",
+			Handler:       _Permission_WatchPermissions_Handler,
+			ServerStreams: true,
+		},
 	},
-	Streams:  []grpc.StreamDesc{},
 	Metadata: "permission.proto",
 }
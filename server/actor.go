@@ -0,0 +1,10 @@
+package server
+
+import "github.com/meateam/permission-service/service"
+
+// actorIDMetadataKey is the incoming metadata key of the ID of the actor that made a request.
+const actorIDMetadataKey = "x-actor-id"
+
+// actorInterceptor sets the actor ID of each RPC's incoming metadata in the context the RPC is handled with,
+// so the permissions it creates are granted by the actor and the changes it makes are audited with it.
+var actorInterceptor = metadataInterceptor{key: actorIDMetadataKey, with: service.WithActorID}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/meateam/permission-service/service"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestActorInterceptorSetsActorID(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(actorIDMetadataKey, "actor"))
+	ctx = unaryContext(ctx, actorInterceptor, "/permission.Permission/CreatePermission")
+
+	if actorID := service.ActorIDFromContext(ctx); actorID != "actor" {
+		t.Errorf("actor ID = %q, want %q", actorID, "actor")
+	}
+}
+
+func TestActorInterceptorSetsStreamActorID(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(actorIDMetadataKey, "actor"))
+	ctx = streamContext(ctx, actorInterceptor, "/permission.Permission/WatchPermissions")
+
+	if actorID := service.ActorIDFromContext(ctx); actorID != "actor" {
+		t.Errorf("actor ID of the stream = %q, want %q", actorID, "actor")
+	}
+}
+
+func TestActorInterceptorWithoutMetadata(t *testing.T) {
+	ctx := unaryContext(context.Background(), actorInterceptor, "/permission.Permission/CreatePermission")
+
+	if actorID := service.ActorIDFromContext(ctx); actorID != "" {
+		t.Errorf("actor ID = %q, want none", actorID)
+	}
+}
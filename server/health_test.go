@@ -0,0 +1,142 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/meateam/permission-service/service"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// watchHealth serves healthServer over an in-memory connection and returns a Watch stream of name,
+// and a function that stops the server.
+func watchHealth(
+	t *testing.T,
+	healthServer *health.Server,
+	name string,
+) (grpc_health_v1.Health_WatchClient, func()) {
+	t.Helper()
+
+	listener := bufconn.Listen(1024 * 1024)
+	grpcServer := grpc.NewServer()
+	grpc_health_v1.RegisterHealthServer(grpcServer, healthServer)
+	go grpcServer.Serve(listener)
+
+	dialer := func(context.Context, string) (net.Conn, error) { return listener.Dial() }
+	conn, err := grpc.Dial("bufnet", grpc.WithContextDialer(dialer), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("grpc.Dial() = %v, want nil", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	request := &grpc_health_v1.HealthCheckRequest{Service: name}
+	stream, err := grpc_health_v1.NewHealthClient(conn).Watch(ctx, request)
+	if err != nil {
+		t.Fatalf("Watch() = %v, want nil", err)
+	}
+
+	return stream, func() {
+		cancel()
+		conn.Close()
+		grpcServer.Stop()
+	}
+}
+
+// expectStatus receives the next status of stream and fails the test if it's not want.
+func expectStatus(
+	t *testing.T,
+	stream grpc_health_v1.Health_WatchClient,
+	want grpc_health_v1.HealthCheckResponse_ServingStatus,
+) {
+	t.Helper()
+
+	response, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv() = %v, want nil", err)
+	}
+
+	if response.GetStatus() != want {
+		t.Errorf("Recv() status = %v, want %v", response.GetStatus(), want)
+	}
+}
+
+func TestWatchStreamsHealthTransitions(t *testing.T) {
+	healthy := service.HealthStatus{PrimaryReachable: true, SecondaryReachable: true}
+	healthServer := health.NewServer()
+	reportHealth(healthServer, healthy, nil)
+
+	stream, stop := watchHealth(t, healthServer, "")
+	defer stop()
+
+	expectStatus(t, stream, grpc_health_v1.HealthCheckResponse_SERVING)
+
+	// An unchanged status isn't sent again, so the next status received is the transition.
+	reportHealth(healthServer, healthy, nil)
+	reportHealth(healthServer, service.HealthStatus{}, nil)
+	expectStatus(t, stream, grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+
+	reportHealth(healthServer, healthy, nil)
+	expectStatus(t, stream, grpc_health_v1.HealthCheckResponse_SERVING)
+}
+
+// expectStatuses fails the test if the status of any of the services of want in healthServer
+// isn't its status in want.
+func expectStatuses(
+	t *testing.T,
+	healthServer *health.Server,
+	want map[string]grpc_health_v1.HealthCheckResponse_ServingStatus,
+) {
+	t.Helper()
+
+	for name, status := range want {
+		request := &grpc_health_v1.HealthCheckRequest{Service: name}
+		response, err := healthServer.Check(context.Background(), request)
+		if err != nil {
+			t.Fatalf("Check(%q) = %v, want nil", name, err)
+		}
+
+		if response.GetStatus() != status {
+			t.Errorf("Check(%q) status = %v, want %v", name, response.GetStatus(), status)
+		}
+	}
+}
+
+func TestDegradedHealthReportedPerMember(t *testing.T) {
+	healthServer := health.NewServer()
+	reportHealth(healthServer, service.HealthStatus{PrimaryReachable: true}, nil)
+
+	expectStatuses(t, healthServer, map[string]grpc_health_v1.HealthCheckResponse_ServingStatus{
+		"":                     grpc_health_v1.HealthCheckResponse_SERVING,
+		primaryHealthService:   grpc_health_v1.HealthCheckResponse_SERVING,
+		secondaryHealthService: grpc_health_v1.HealthCheckResponse_NOT_SERVING,
+	})
+}
+
+func TestHealthNotServingWithoutPrimary(t *testing.T) {
+	healthServer := health.NewServer()
+	reportHealth(healthServer, service.HealthStatus{SecondaryReachable: true}, errors.New("primary unreachable"))
+
+	expectStatuses(t, healthServer, map[string]grpc_health_v1.HealthCheckResponse_ServingStatus{
+		"":                     grpc_health_v1.HealthCheckResponse_NOT_SERVING,
+		primaryHealthService:   grpc_health_v1.HealthCheckResponse_NOT_SERVING,
+		secondaryHealthService: grpc_health_v1.HealthCheckResponse_SERVING,
+	})
+}
+
+func TestHealthNotServingWhenCollectionCheckFails(t *testing.T) {
+	healthServer := health.NewServer()
+	healthy := service.HealthStatus{PrimaryReachable: true, SecondaryReachable: true}
+	reportHealth(healthServer, healthy, errors.New("collection permissions does not exist"))
+
+	expectStatuses(t, healthServer, map[string]grpc_health_v1.HealthCheckResponse_ServingStatus{
+		"":                     grpc_health_v1.HealthCheckResponse_NOT_SERVING,
+		primaryHealthService:   grpc_health_v1.HealthCheckResponse_SERVING,
+		secondaryHealthService: grpc_health_v1.HealthCheckResponse_SERVING,
+	})
+}
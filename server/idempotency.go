@@ -0,0 +1,10 @@
+package server
+
+import "github.com/meateam/permission-service/service"
+
+// idempotencyKeyMetadataKey is the incoming metadata key of the idempotency key of a request.
+const idempotencyKeyMetadataKey = "x-idempotency-key"
+
+// idempotencyInterceptor sets the idempotency key of each RPC's incoming metadata in the context the RPC
+// is handled with, so a retried RPC returns the result of the first.
+var idempotencyInterceptor = metadataInterceptor{key: idempotencyKeyMetadataKey, with: service.WithIdempotencyKey}
@@ -0,0 +1,18 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/meateam/permission-service/service"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestIdempotencyInterceptorSetsKey(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(idempotencyKeyMetadataKey, "key"))
+	ctx = unaryContext(ctx, idempotencyInterceptor, "/permission.Permission/CreatePermission")
+
+	if key := service.IdempotencyKeyFromContext(ctx); key != "key" {
+		t.Errorf("idempotency key = %q, want %q", key, "key")
+	}
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
+	grpc_logrus "github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus"
+	ilogger "github.com/meateam/elasticsearch-logger"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+)
+
+// serverInterceptor intercepts both the unary and the stream RPCs of a server.
+type serverInterceptor interface {
+	// Unary intercepts a unary RPC, it's a grpc.UnaryServerInterceptor.
+	Unary(
+		ctx context.Context,
+		req interface{},
+		info *grpc.UnaryServerInfo,
+		handler grpc.UnaryHandler,
+	) (interface{}, error)
+
+	// Stream intercepts a stream RPC, it's a grpc.StreamServerInterceptor.
+	Stream(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error
+}
+
+// chainInterceptors returns the server options that intercept the server's RPCs with each of interceptors
+// in order, the first is the outermost. grpc allows only one unary and one stream interceptor per server,
+// so every interceptor of the server must be chained by a single call.
+func chainInterceptors(interceptors ...serverInterceptor) []grpc.ServerOption {
+	unary := make([]grpc.UnaryServerInterceptor, 0, len(interceptors))
+	stream := make([]grpc.StreamServerInterceptor, 0, len(interceptors))
+	for _, interceptor := range interceptors {
+		unary = append(unary, interceptor.Unary)
+		stream = append(stream, interceptor.Stream)
+	}
+
+	return []grpc.ServerOption{
+		grpc_middleware.WithUnaryServerChain(unary...),
+		grpc_middleware.WithStreamServerChain(stream...),
+	}
+}
+
+// interceptorPair is a serverInterceptor of a unary and a stream interceptor.
+type interceptorPair struct {
+	unary  grpc.UnaryServerInterceptor
+	stream grpc.StreamServerInterceptor
+}
+
+// Unary intercepts a unary RPC with p's unary interceptor.
+func (p interceptorPair) Unary(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	return p.unary(ctx, req, info, handler)
+}
+
+// Stream intercepts a stream RPC with p's stream interceptor.
+func (p interceptorPair) Stream(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	return p.stream(srv, stream, info, handler)
+}
+
+// traceIDLoggerInterceptor is a serverInterceptor that logs each RPC with entry and the ID of the RPC's
+// elastic APM trace, and sets the logger of the RPC in the context the RPC is handled with, so the
+// payload interceptors after it log with the trace ID as well.
+type traceIDLoggerInterceptor struct {
+	entry *logrus.Entry
+	opts  []grpc_logrus.Option
+}
+
+// Unary logs the unary RPC with the ID of its trace.
+func (i traceIDLoggerInterceptor) Unary(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	entry := i.entry.WithField("trace.id", ilogger.ExtractTraceParent(ctx))
+	return grpc_logrus.UnaryServerInterceptor(entry, i.opts...)(ctx, req, info, handler)
+}
+
+// Stream logs the stream RPC with the ID of its trace.
+func (i traceIDLoggerInterceptor) Stream(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	entry := i.entry.WithField("trace.id", ilogger.ExtractTraceParent(stream.Context()))
+	return grpc_logrus.StreamServerInterceptor(entry, i.opts...)(srv, stream, info, handler)
+}
+
+// streamWithContext returns stream with ctx as its context, so the stream RPC is handled with ctx.
+func streamWithContext(ctx context.Context, stream grpc.ServerStream) grpc.ServerStream {
+	wrapped := grpc_middleware.WrapServerStream(stream)
+	wrapped.WrappedContext = ctx
+
+	return wrapped
+}
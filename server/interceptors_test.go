@@ -0,0 +1,44 @@
+package server
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+)
+
+// unaryContext runs a unary RPC of fullMethodName with ctx through interceptor, and returns the context
+// the RPC was handled with.
+func unaryContext(ctx context.Context, interceptor serverInterceptor, fullMethodName string) context.Context {
+	var handledCtx context.Context
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		handledCtx = ctx
+		return nil, nil
+	}
+
+	interceptor.Unary(ctx, nil, &grpc.UnaryServerInfo{FullMethod: fullMethodName}, handler)
+	return handledCtx
+}
+
+// contextStream is a grpc.ServerStream of ctx.
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+// Context returns the context of s.
+func (s contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// streamContext runs a stream RPC of fullMethodName with ctx through interceptor, and returns the context
+// the RPC was handled with.
+func streamContext(ctx context.Context, interceptor serverInterceptor, fullMethodName string) context.Context {
+	var handledCtx context.Context
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		handledCtx = stream.Context()
+		return nil
+	}
+
+	interceptor.Stream(nil, contextStream{ctx: ctx}, &grpc.StreamServerInfo{FullMethod: fullMethodName}, handler)
+	return handledCtx
+}
@@ -0,0 +1,213 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// RedactionPolicy is how the userIDs of the RPCs' requests are redacted in the RPCs' logs.
+type RedactionPolicy int
+
+const (
+	// RedactHash logs a truncated SHA-256 hash of the userIDs, so the RPCs of a user can be correlated
+	// without logging the user's ID.
+	RedactHash RedactionPolicy = iota
+
+	// RedactTruncate logs only the first redactedPrefixLength characters of the userIDs.
+	RedactTruncate
+
+	// RedactNone logs the userIDs in plaintext.
+	RedactNone
+)
+
+const (
+	// redactedHashLength is the number of hex characters of the hash RedactHash logs.
+	redactedHashLength = 16
+
+	// redactedPrefixLength is the number of characters of the userIDs RedactTruncate logs.
+	redactedPrefixLength = 4
+)
+
+// ParseRedactionPolicy returns the RedactionPolicy named policy, one of "hash", "truncate" or "none",
+// otherwise returns an error.
+func ParseRedactionPolicy(policy string) (RedactionPolicy, error) {
+	switch strings.ToLower(policy) {
+	case "hash":
+		return RedactHash, nil
+	case "truncate":
+		return RedactTruncate, nil
+	case "none":
+		return RedactNone, nil
+	default:
+		return RedactHash, fmt.Errorf("unknown redaction policy %q", policy)
+	}
+}
+
+// Redact returns userID redacted by p, an empty userID is returned as is.
+func (p RedactionPolicy) Redact(userID string) string {
+	if userID == "" {
+		return ""
+	}
+
+	switch p {
+	case RedactNone:
+		return userID
+	case RedactTruncate:
+		if utf8.RuneCountInString(userID) <= redactedPrefixLength {
+			return "***"
+		}
+
+		return string([]rune(userID)[:redactedPrefixLength]) + "***"
+	default:
+		hash := sha256.Sum256([]byte(userID))
+		return "sha256:" + hex.EncodeToString(hash[:])[:redactedHashLength]
+	}
+}
+
+// rpcLog is what's logged of an RPC, collected while the RPC is handled.
+type rpcLog struct {
+	mu       sync.Mutex
+	method   string
+	deadline time.Time
+	begin    time.Time
+	fields   logrus.Fields
+
+	// hasRequest is true once the fields of the RPC's first request are collected.
+	hasRequest bool
+}
+
+// newRPCLog returns the rpcLog of the RPC of fullMethodName that is handled with ctx, which begins now.
+func newRPCLog(ctx context.Context, fullMethodName string) *rpcLog {
+	log := &rpcLog{method: fullMethodName, begin: time.Now(), fields: logrus.Fields{}}
+	log.deadline, _ = ctx.Deadline()
+
+	return log
+}
+
+// The getters of the request IDs that are logged.
+type (
+	fileIDGetter     interface{ GetFileID() string }
+	userIDGetter     interface{ GetUserID() string }
+	fromUserIDGetter interface{ GetFromUserID() string }
+	toUserIDGetter   interface{ GetToUserID() string }
+)
+
+// loggingInterceptor is a serverInterceptor that logs a line per RPC with its method, duration, code and
+// deadline, and the IDs of its request with the userIDs redacted by policy. RPCs that fail are logged at
+// error level and the rest at debug level.
+type loggingInterceptor struct {
+	logger *logrus.Logger
+	policy RedactionPolicy
+}
+
+// Unary logs the unary RPC with the IDs of its request once it's handled.
+func (i loggingInterceptor) Unary(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	log := newRPCLog(ctx, info.FullMethod)
+	i.addRequest(log, req)
+	resp, err := handler(ctx, req)
+	i.log(log, err)
+
+	return resp, err
+}
+
+// Stream logs the stream RPC with the IDs of its first request once it's handled.
+func (i loggingInterceptor) Stream(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	log := newRPCLog(stream.Context(), info.FullMethod)
+	err := handler(srv, loggedStream{ServerStream: stream, interceptor: i, log: log})
+	i.log(log, err)
+
+	return err
+}
+
+// addRequest adds the IDs of req to the fields of log, if it's the RPC's first request.
+func (i loggingInterceptor) addRequest(log *rpcLog, req interface{}) {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	if log.hasRequest {
+		return
+	}
+
+	log.hasRequest = true
+	if r, ok := req.(fileIDGetter); ok {
+		log.fields["fileID"] = r.GetFileID()
+	}
+
+	if r, ok := req.(userIDGetter); ok {
+		log.fields["userID"] = i.policy.Redact(r.GetUserID())
+	}
+
+	if r, ok := req.(fromUserIDGetter); ok {
+		log.fields["fromUserID"] = i.policy.Redact(r.GetFromUserID())
+	}
+
+	if r, ok := req.(toUserIDGetter); ok {
+		log.fields["toUserID"] = i.policy.Redact(r.GetToUserID())
+	}
+}
+
+// log logs the RPC of log that returned err.
+func (i loggingInterceptor) log(log *rpcLog, err error) {
+	log.mu.Lock()
+	defer log.mu.Unlock()
+
+	end := time.Now()
+	code := status.Code(err)
+	entry := i.logger.WithFields(log.fields).WithFields(logrus.Fields{
+		"method":   log.method,
+		"duration": end.Sub(log.begin).String(),
+		"code":     code.String(),
+	})
+
+	if !log.deadline.IsZero() {
+		entry = entry.WithFields(logrus.Fields{
+			"deadline":         log.deadline.Format(time.RFC3339Nano),
+			"deadlineExceeded": code == codes.DeadlineExceeded || end.After(log.deadline),
+		})
+	}
+
+	if code != codes.OK {
+		entry.WithError(err).Errorf("%s failed", log.method)
+		return
+	}
+
+	entry.Debugf("%s succeeded", log.method)
+}
+
+// loggedStream is a grpc.ServerStream that adds the IDs of the first request it receives to log.
+type loggedStream struct {
+	grpc.ServerStream
+	interceptor loggingInterceptor
+	log         *rpcLog
+}
+
+// RecvMsg receives a request into m, and adds its IDs to s.log if it's the first.
+func (s loggedStream) RecvMsg(m interface{}) error {
+	if err := s.ServerStream.RecvMsg(m); err != nil {
+		return err
+	}
+
+	s.interceptor.addRequest(s.log, m)
+	return nil
+}
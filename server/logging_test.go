@@ -0,0 +1,136 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// logRPC runs a unary RPC with req as its request through a loggingInterceptor with policy,
+// returning rpcErr, and returns the logged entries.
+func logRPC(ctx context.Context, policy RedactionPolicy, req interface{}, rpcErr error) []*logrus.Entry {
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+	i := loggingInterceptor{logger: logger, policy: policy}
+
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, rpcErr
+	}
+
+	i.Unary(ctx, req, &grpc.UnaryServerInfo{FullMethod: "/permission.Permission/GetPermission"}, handler)
+	return hook.AllEntries()
+}
+
+func TestLoggingInterceptorRedactsUserID(t *testing.T) {
+	req := &pb.GetPermissionRequest{FileID: "file", UserID: "secret-user"}
+	entries := logRPC(context.Background(), RedactHash, req, nil)
+
+	if len(entries) != 1 {
+		t.Fatalf("logged %d entries, want 1", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.Level != logrus.DebugLevel {
+		t.Errorf("level = %v, want %v", entry.Level, logrus.DebugLevel)
+	}
+
+	if entry.Data["fileID"] != "file" || entry.Data["code"] != codes.OK.String() {
+		t.Errorf("fileID = %v and code = %v, want file and %v", entry.Data["fileID"], entry.Data["code"], codes.OK)
+	}
+
+	if userID, _ := entry.Data["userID"].(string); userID == "" || strings.Contains(userID, "secret-user") {
+		t.Errorf("userID = %q, want it redacted", userID)
+	}
+}
+
+func TestLoggingInterceptorLogsFailuresAsErrors(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	rpcErr := status.Error(codes.DeadlineExceeded, "deadline exceeded")
+	entries := logRPC(ctx, RedactHash, &pb.GetPermissionRequest{FileID: "file"}, rpcErr)
+
+	if len(entries) != 1 {
+		t.Fatalf("logged %d entries, want 1", len(entries))
+	}
+
+	entry := entries[0]
+	if entry.Level != logrus.ErrorLevel {
+		t.Errorf("level = %v, want %v", entry.Level, logrus.ErrorLevel)
+	}
+
+	if entry.Data["deadline"] == nil || entry.Data["deadlineExceeded"] != true {
+		t.Errorf("deadline = %v, exceeded = %v, want it set and exceeded",
+			entry.Data["deadline"], entry.Data["deadlineExceeded"])
+	}
+}
+
+func TestRedactionPolicies(t *testing.T) {
+	if got := RedactNone.Redact("user-id"); got != "user-id" {
+		t.Errorf("RedactNone.Redact() = %q, want user-id", got)
+	}
+
+	if got := RedactTruncate.Redact("user-id"); got != "user***" {
+		t.Errorf("RedactTruncate.Redact() = %q, want user***", got)
+	}
+
+	got := RedactHash.Redact("user-id")
+	if !strings.HasPrefix(got, "sha256:") || got != RedactHash.Redact("user-id") {
+		t.Errorf("RedactHash.Redact() = %q, want a stable sha256 hash", got)
+	}
+}
+
+func TestParseRedactionPolicy(t *testing.T) {
+	if policy, err := ParseRedactionPolicy("truncate"); err != nil || policy != RedactTruncate {
+		t.Errorf("ParseRedactionPolicy(truncate) = %v, %v, want %v, nil", policy, err, RedactTruncate)
+	}
+
+	if _, err := ParseRedactionPolicy("unknown"); err == nil {
+		t.Errorf("ParseRedactionPolicy(unknown) = nil, want an error")
+	}
+}
+
+// requestStream is a grpc.ServerStream that receives StreamFilePermissionsRequests of fileID.
+type requestStream struct {
+	contextStream
+	fileID string
+}
+
+// RecvMsg receives a StreamFilePermissionsRequest of s.fileID into m.
+func (s requestStream) RecvMsg(m interface{}) error {
+	m.(*pb.StreamFilePermissionsRequest).FileID = s.fileID
+	return nil
+}
+
+func TestLoggingInterceptorLogsStreamRequest(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	logger.SetLevel(logrus.DebugLevel)
+	i := loggingInterceptor{logger: logger, policy: RedactHash}
+
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		return stream.RecvMsg(&pb.StreamFilePermissionsRequest{})
+	}
+
+	stream := requestStream{contextStream: contextStream{ctx: context.Background()}, fileID: "file"}
+	info := &grpc.StreamServerInfo{FullMethod: "/permission.Permission/StreamFilePermissions"}
+	if err := i.Stream(nil, stream, info, handler); err != nil {
+		t.Fatalf("Stream() = %v, want nil", err)
+	}
+
+	entries := hook.AllEntries()
+	if len(entries) != 1 {
+		t.Fatalf("logged %d entries, want 1", len(entries))
+	}
+
+	if fileID := entries[0].Data["fileID"]; fileID != "file" {
+		t.Errorf("fileID = %v, want file", fileID)
+	}
+}
@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// metadataInterceptor is a serverInterceptor that sets the value of key in each RPC's incoming metadata
+// in the context the RPC is handled with, using with.
+type metadataInterceptor struct {
+	key  string
+	with func(ctx context.Context, value string) context.Context
+}
+
+// tag returns ctx with the value of i.key in its incoming metadata, if it has one.
+func (i metadataInterceptor) tag(ctx context.Context) context.Context {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ctx
+	}
+
+	values := md.Get(i.key)
+	if len(values) == 0 || values[0] == "" {
+		return ctx
+	}
+
+	return i.with(ctx, values[0])
+}
+
+// Unary handles the unary RPC with the value of i.key in its incoming metadata.
+func (i metadataInterceptor) Unary(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	return handler(i.tag(ctx), req)
+}
+
+// Stream handles the stream RPC with the value of i.key in its incoming metadata.
+func (i metadataInterceptor) Stream(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	return handler(srv, streamWithContext(i.tag(stream.Context()), stream))
+}
@@ -0,0 +1,345 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis"
+	"github.com/meateam/permission-service/service"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// rateLimitKeyPrefix is the prefix of the keys of the token buckets in a RateLimitStore.
+const rateLimitKeyPrefix = "ratelimit:"
+
+// rateLimitedMethodPrefix is the prefix of the full method names of the rate limited RPCs,
+// which are the RPCs of the permission service, so health checks are never limited.
+const rateLimitedMethodPrefix = "/permission.Permission/"
+
+// memorySweepThreshold is the number of token buckets a MemoryRateLimitStore holds before it drops the
+// buckets that refilled, which are the same as new buckets.
+const memorySweepThreshold = 10000
+
+// writeRPCs are the RPCs that change permissions, which are limited by the write limit rather than the read limit.
+var writeRPCs = map[string]bool{
+	"CreatePermission":      true,
+	"CreatePermissions":     true,
+	"DeletePermission":      true,
+	"DeleteFilePermissions": true,
+	"DeleteUserPermissions": true,
+	"UpdatePermission":      true,
+	"TransferOwnership":     true,
+}
+
+// Limit is the token bucket of a rate limit, an actor makes up to Burst requests at once,
+// and the bucket refills at Rate requests per second.
+type Limit struct {
+	Rate  float64
+	Burst int
+}
+
+// Unlimited returns true if l doesn't limit requests, which is when its Rate isn't positive.
+func (l Limit) Unlimited() bool {
+	return l.Rate <= 0
+}
+
+// ParseLimit returns the Limit of limit, formatted as "rate:burst" such as "10:20", or as "rate" whose
+// burst is the rate rounded up. If limit is empty or its rate is 0 then the Limit is unlimited,
+// otherwise returns an error if limit is malformed.
+func ParseLimit(limit string) (Limit, error) {
+	limit = strings.TrimSpace(limit)
+	if limit == "" {
+		return Limit{}, nil
+	}
+
+	parts := strings.SplitN(limit, ":", 2)
+	rate, err := strconv.ParseFloat(parts[0], 64)
+	if err != nil || rate < 0 {
+		return Limit{}, fmt.Errorf("invalid rate limit %q: rate must be a non-negative number", limit)
+	}
+
+	burst := int(math.Ceil(rate))
+	if len(parts) == 2 {
+		if burst, err = strconv.Atoi(parts[1]); err != nil || burst < 1 {
+			return Limit{}, fmt.Errorf("invalid rate limit %q: burst must be a positive integer", limit)
+		}
+	}
+
+	return Limit{Rate: rate, Burst: burst}, nil
+}
+
+// ParseRPCLimits returns the limits of the RPCs in limits, formatted as a comma separated list of
+// "RPC=limit" such as "CreatePermission=1:5,IsPermitted=100", where each limit is parsed by ParseLimit.
+// Returns an error if limits is malformed.
+func ParseRPCLimits(limits string) (map[string]Limit, error) {
+	rpcLimits := map[string]Limit{}
+	for _, rpcLimit := range strings.Split(limits, ",") {
+		if strings.TrimSpace(rpcLimit) == "" {
+			continue
+		}
+
+		parts := strings.SplitN(rpcLimit, "=", 2)
+		if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+			return nil, fmt.Errorf("invalid rpc rate limit %q: must be RPC=limit", rpcLimit)
+		}
+
+		limit, err := ParseLimit(parts[1])
+		if err != nil {
+			return nil, err
+		}
+
+		rpcLimits[strings.TrimSpace(parts[0])] = limit
+	}
+
+	return rpcLimits, nil
+}
+
+// RateLimitStore keeps the token buckets of the rate limits, so replicas that share a store share their limits.
+type RateLimitStore interface {
+	// Take takes a token from the bucket of key whose limit is limit, creating a full bucket if it
+	// doesn't exist. Returns true if a token was taken, or false if the bucket is empty.
+	Take(key string, limit Limit) (bool, error)
+}
+
+// tokenBucket is the state of a token bucket of a MemoryRateLimitStore.
+type tokenBucket struct {
+	tokens    float64
+	updatedAt time.Time
+}
+
+// MemoryRateLimitStore is a RateLimitStore that keeps the token buckets in memory,
+// so each replica limits the requests it serves on its own.
+type MemoryRateLimitStore struct {
+	mu      sync.Mutex
+	buckets map[string]*tokenBucket
+	now     func() time.Time
+}
+
+// NewMemoryRateLimitStore returns an empty MemoryRateLimitStore.
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{buckets: map[string]*tokenBucket{}, now: time.Now}
+}
+
+// Take refills the bucket of key by the time that passed since it was last taken from,
+// and takes a token from it if it has one.
+func (s *MemoryRateLimitStore) Take(key string, limit Limit) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := s.now()
+	bucket, ok := s.buckets[key]
+	if !ok {
+		if len(s.buckets) >= memorySweepThreshold {
+			s.sweep(now, limit)
+		}
+
+		bucket = &tokenBucket{tokens: float64(limit.Burst), updatedAt: now}
+		s.buckets[key] = bucket
+	}
+
+	bucket.tokens = math.Min(float64(limit.Burst), bucket.tokens+now.Sub(bucket.updatedAt).Seconds()*limit.Rate)
+	bucket.updatedAt = now
+	if bucket.tokens < 1 {
+		return false, nil
+	}
+
+	bucket.tokens--
+	return true, nil
+}
+
+// sweep drops the buckets that would be full at now if they had limit, s.mu must be held.
+func (s *MemoryRateLimitStore) sweep(now time.Time, limit Limit) {
+	for key, bucket := range s.buckets {
+		if bucket.tokens+now.Sub(bucket.updatedAt).Seconds()*limit.Rate >= float64(limit.Burst) {
+			delete(s.buckets, key)
+		}
+	}
+}
+
+// redisTakeScript atomically refills the token bucket hash of KEYS[1] by the time that passed since
+// it was last taken from, and takes a token from it if it has one. ARGV holds the rate, the burst and
+// the current time in seconds. The bucket expires once it would be full, which is the same as a new bucket.
+var redisTakeScript = redis.NewScript(`
+local rate = tonumber(ARGV[1])
+local burst = tonumber(ARGV[2])
+local now = tonumber(ARGV[3])
+local bucket = redis.call("HMGET", KEYS[1], "tokens", "updatedAt")
+local tokens = tonumber(bucket[1]) or burst
+local updatedAt = tonumber(bucket[2]) or now
+tokens = math.min(burst, tokens + math.max(0, now - updatedAt) * rate)
+local taken = 0
+if tokens >= 1 then
+	tokens = tokens - 1
+	taken = 1
+end
+redis.call("HMSET", KEYS[1], "tokens", tostring(tokens), "updatedAt", tostring(now))
+redis.call("PEXPIRE", KEYS[1], math.ceil((burst - tokens) / rate * 1000) + 1000)
+return taken
+`)
+
+// RedisRateLimitStore is a RateLimitStore that keeps the token buckets in redis,
+// so the replicas that share the redis share their limits.
+type RedisRateLimitStore struct {
+	client redis.Cmdable
+}
+
+// NewRedisRateLimitStore returns a RedisRateLimitStore that keeps the token buckets using client.
+func NewRedisRateLimitStore(client redis.Cmdable) RedisRateLimitStore {
+	return RedisRateLimitStore{client: client}
+}
+
+// Take refills the bucket of key by the time that passed since it was last taken from,
+// and takes a token from it if it has one, in a single atomic script.
+func (s RedisRateLimitStore) Take(key string, limit Limit) (bool, error) {
+	now := float64(time.Now().UnixNano()) / float64(time.Second)
+	taken, err := redisTakeScript.Run(s.client, []string{key}, limit.Rate, limit.Burst, now).Int64()
+	if err != nil {
+		return false, err
+	}
+
+	return taken == 1, nil
+}
+
+// RateLimiter is a serverInterceptor that limits the rate of each actor's requests to each RPC of the
+// permission service, with a token bucket per actor and RPC. The actor of a request is its actor ID,
+// or the IP address of its peer if it has none.
+type RateLimiter struct {
+	store      RateLimitStore
+	readLimit  Limit
+	writeLimit Limit
+	rpcLimits  map[string]Limit
+	logger     *logrus.Logger
+}
+
+// NewRateLimiter returns a RateLimiter that keeps its token buckets in store, and limits the RPCs in
+// rpcLimits by their limit, the RPCs that change permissions by writeLimit, and the rest by readLimit.
+// Errors of store are logged and the requests are allowed, so an unavailable store doesn't fail them.
+func NewRateLimiter(
+	store RateLimitStore,
+	readLimit Limit,
+	writeLimit Limit,
+	rpcLimits map[string]Limit,
+	logger *logrus.Logger,
+) RateLimiter {
+	return RateLimiter{
+		store:      store,
+		readLimit:  readLimit,
+		writeLimit: writeLimit,
+		rpcLimits:  rpcLimits,
+		logger:     logger,
+	}
+}
+
+// Unlimited returns true if l doesn't limit any RPC.
+func (l RateLimiter) Unlimited() bool {
+	for _, limit := range l.rpcLimits {
+		if !limit.Unlimited() {
+			return false
+		}
+	}
+
+	return l.readLimit.Unlimited() && l.writeLimit.Unlimited()
+}
+
+// Allow returns nil if the actor of ctx may make a request to rpc, such as "CreatePermission",
+// otherwise returns a ResourceExhausted error.
+func (l RateLimiter) Allow(ctx context.Context, rpc string) error {
+	limit := l.limit(rpc)
+	if limit.Unlimited() {
+		return nil
+	}
+
+	actor := rateLimitActor(ctx)
+	allowed, err := l.store.Take(rateLimitKeyPrefix+rpc+":"+actor, limit)
+	if err != nil {
+		l.logger.Errorf("failed rate limiting %s: %v", rpc, err)
+		return nil
+	}
+
+	if !allowed {
+		return status.Errorf(codes.ResourceExhausted, "rate limit of %s exceeded, retry later", rpc)
+	}
+
+	return nil
+}
+
+// Unary handles the unary RPC if its actor didn't exceed the rate limit of the RPC,
+// otherwise returns a ResourceExhausted error.
+func (l RateLimiter) Unary(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	if err := l.allowMethod(ctx, info.FullMethod); err != nil {
+		return nil, err
+	}
+
+	return handler(ctx, req)
+}
+
+// Stream handles the stream RPC if its actor didn't exceed the rate limit of the RPC,
+// otherwise returns a ResourceExhausted error.
+func (l RateLimiter) Stream(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	if err := l.allowMethod(stream.Context(), info.FullMethod); err != nil {
+		return err
+	}
+
+	return handler(srv, stream)
+}
+
+// allowMethod returns Allow of the RPC of fullMethodName, or nil if it's not a rate limited RPC.
+func (l RateLimiter) allowMethod(ctx context.Context, fullMethodName string) error {
+	if !strings.HasPrefix(fullMethodName, rateLimitedMethodPrefix) {
+		return nil
+	}
+
+	return l.Allow(ctx, strings.TrimPrefix(fullMethodName, rateLimitedMethodPrefix))
+}
+
+// limit returns the limit of rpc.
+func (l RateLimiter) limit(rpc string) Limit {
+	if limit, ok := l.rpcLimits[rpc]; ok {
+		return limit
+	}
+
+	if writeRPCs[rpc] {
+		return l.writeLimit
+	}
+
+	return l.readLimit
+}
+
+// rateLimitActor returns the actor the request of ctx is limited as, which is "actor:" and its actor ID,
+// or "ip:" and the IP address of its peer if it has no actor ID, or "unknown" if it has neither.
+func rateLimitActor(ctx context.Context) string {
+	if actorID := service.ActorIDFromContext(ctx); actorID != "" {
+		return "actor:" + actorID
+	}
+
+	p, ok := peer.FromContext(ctx)
+	if !ok || p.Addr == nil {
+		return "unknown"
+	}
+
+	if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil {
+		return "ip:" + host
+	}
+
+	return "ip:" + p.Addr.String()
+}
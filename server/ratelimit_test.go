@@ -0,0 +1,258 @@
+package server
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/sirupsen/logrus/hooks/test"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// fakeClock is the current time of a MemoryRateLimitStore in tests, advanced by the tests.
+type fakeClock struct {
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	return c.now
+}
+
+// newTestRateLimitStore returns a MemoryRateLimitStore whose time is clock.
+func newTestRateLimitStore(clock *fakeClock) *MemoryRateLimitStore {
+	store := NewMemoryRateLimitStore()
+	store.now = clock.Now
+	return store
+}
+
+// takeAll takes tokens from the bucket of key until it's empty, and returns the number of taken tokens.
+func takeAll(t *testing.T, store RateLimitStore, key string, limit Limit) int {
+	t.Helper()
+
+	for taken := 0; taken <= limit.Burst; taken++ {
+		allowed, err := store.Take(key, limit)
+		if err != nil {
+			t.Fatalf("Take() = %v, want nil", err)
+		}
+
+		if !allowed {
+			return taken
+		}
+	}
+
+	t.Fatalf("Take() allowed more than the burst of %d", limit.Burst)
+	return 0
+}
+
+func TestParseLimit(t *testing.T) {
+	tests := []struct {
+		limit   string
+		want    Limit
+		wantErr bool
+	}{
+		{limit: "", want: Limit{}},
+		{limit: "10:20", want: Limit{Rate: 10, Burst: 20}},
+		{limit: " 0.5 ", want: Limit{Rate: 0.5, Burst: 1}},
+		{limit: "0", want: Limit{}},
+		{limit: "-1", wantErr: true},
+		{limit: "10:0", wantErr: true},
+		{limit: "fast", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := ParseLimit(tt.limit)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseLimit(%q) error = %v, wantErr %v", tt.limit, err, tt.wantErr)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("ParseLimit(%q) = %+v, want %+v", tt.limit, got, tt.want)
+		}
+	}
+}
+
+func TestParseRPCLimits(t *testing.T) {
+	got, err := ParseRPCLimits("CreatePermissions=1:5, IsPermitted=100,")
+	if err != nil {
+		t.Fatalf("ParseRPCLimits() = %v, want nil", err)
+	}
+
+	want := map[string]Limit{"CreatePermissions": {Rate: 1, Burst: 5}, "IsPermitted": {Rate: 100, Burst: 100}}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseRPCLimits() = %v, want %v", got, want)
+	}
+
+	if _, err := ParseRPCLimits("CreatePermissions"); err == nil {
+		t.Error("ParseRPCLimits() without a limit = nil, want an error")
+	}
+}
+
+func TestMemoryRateLimitStoreRefills(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	store := newTestRateLimitStore(clock)
+	limit := Limit{Rate: 2, Burst: 4}
+
+	if taken := takeAll(t, store, "key", limit); taken != 4 {
+		t.Fatalf("took %d tokens from a new bucket, want the burst of 4", taken)
+	}
+
+	clock.now = clock.now.Add(time.Second)
+	if taken := takeAll(t, store, "key", limit); taken != 2 {
+		t.Errorf("took %d tokens after 1s, want the rate of 2", taken)
+	}
+
+	clock.now = clock.now.Add(250 * time.Millisecond)
+	if taken := takeAll(t, store, "key", limit); taken != 0 {
+		t.Errorf("took %d tokens after 250ms, want 0 since half a token refilled", taken)
+	}
+
+	clock.now = clock.now.Add(250 * time.Millisecond)
+	if taken := takeAll(t, store, "key", limit); taken != 1 {
+		t.Errorf("took %d tokens after another 250ms, want 1", taken)
+	}
+
+	clock.now = clock.now.Add(time.Hour)
+	if taken := takeAll(t, store, "key", limit); taken != 4 {
+		t.Errorf("took %d tokens after an hour, want no more than the burst of 4", taken)
+	}
+
+	if taken := takeAll(t, store, "other-key", limit); taken != 4 {
+		t.Errorf("took %d tokens from another bucket, want the burst of 4", taken)
+	}
+}
+
+func TestRateLimiterLimitsEachActorAndRPC(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	limiter := NewRateLimiter(
+		newTestRateLimitStore(clock),
+		Limit{Rate: 1, Burst: 3},
+		Limit{Rate: 1, Burst: 1},
+		map[string]Limit{"CountPermissions": {}},
+		logger,
+	)
+
+	actor := service.WithActorID(context.Background(), "actor")
+	peerAddr := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 1234}
+	anonymous := peer.NewContext(context.Background(), &peer.Peer{Addr: peerAddr})
+	tests := []struct {
+		name    string
+		ctx     context.Context
+		rpc     string
+		allowed int
+	}{
+		{name: "write by actor", ctx: actor, rpc: "CreatePermission", allowed: 1},
+		{name: "other write by actor", ctx: actor, rpc: "DeletePermission", allowed: 1},
+		{name: "read by actor", ctx: actor, rpc: "IsPermitted", allowed: 3},
+		{name: "write by peer", ctx: anonymous, rpc: "CreatePermission", allowed: 1},
+	}
+
+	for _, tt := range tests {
+		allowed := 0
+		for ; allowed < 10; allowed++ {
+			if err := limiter.Allow(tt.ctx, tt.rpc); err != nil {
+				if status.Code(err) != codes.ResourceExhausted {
+					t.Errorf("%s: Allow() = %v, want code %v", tt.name, err, codes.ResourceExhausted)
+				}
+
+				break
+			}
+		}
+
+		if allowed != tt.allowed {
+			t.Errorf("%s: allowed %d requests, want %d", tt.name, allowed, tt.allowed)
+		}
+	}
+
+	for i := 0; i < 10; i++ {
+		if err := limiter.Allow(actor, "CountPermissions"); err != nil {
+			t.Fatalf("Allow() of an unlimited RPC = %v, want nil", err)
+		}
+	}
+
+	clock.now = clock.now.Add(time.Second)
+	if err := limiter.Allow(actor, "CreatePermission"); err != nil {
+		t.Errorf("Allow() after the bucket refilled = %v, want nil", err)
+	}
+}
+
+func TestRateLimiterUnlimited(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+	if limiter := NewRateLimiter(NewMemoryRateLimitStore(), Limit{}, Limit{}, nil, logger); !limiter.Unlimited() {
+		t.Error("Unlimited() without limits = false, want true")
+	}
+
+	rpcLimits := map[string]Limit{"CreatePermissions": {Rate: 1, Burst: 1}}
+	limiter := NewRateLimiter(NewMemoryRateLimitStore(), Limit{}, Limit{}, rpcLimits, logger)
+	if limiter.Unlimited() {
+		t.Error("Unlimited() with an RPC limit = true, want false")
+	}
+}
+
+func TestRateLimiterInterceptorRejectsExceedingRequests(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+	limiter := NewRateLimiter(NewMemoryRateLimitStore(), Limit{}, Limit{Rate: 1, Burst: 2}, nil, logger)
+	calls := 0
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		calls++
+		return &pb.PermissionObject{}, nil
+	}
+
+	ctx := service.WithActorID(context.Background(), "actor")
+	info := &grpc.UnaryServerInfo{FullMethod: "/permission.Permission/CreatePermission"}
+	for i := 0; i < 2; i++ {
+		if _, err := limiter.Unary(ctx, &pb.CreatePermissionRequest{}, info, handler); err != nil {
+			t.Fatalf("CreatePermission() = %v, want nil", err)
+		}
+	}
+
+	_, err := limiter.Unary(ctx, &pb.CreatePermissionRequest{}, info, handler)
+	if status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("CreatePermission() over the limit = %v, want code %v", err, codes.ResourceExhausted)
+	}
+
+	if calls != 2 {
+		t.Errorf("handler handled %d requests, want 2", calls)
+	}
+}
+
+func TestRateLimiterInterceptorLimitsStreams(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+	limiter := NewRateLimiter(NewMemoryRateLimitStore(), Limit{Rate: 1, Burst: 1}, Limit{}, nil, logger)
+	handler := func(srv interface{}, stream grpc.ServerStream) error {
+		return nil
+	}
+
+	stream := contextStream{ctx: service.WithActorID(context.Background(), "actor")}
+	info := &grpc.StreamServerInfo{FullMethod: "/permission.Permission/StreamFilePermissions"}
+	if err := limiter.Stream(nil, stream, info, handler); err != nil {
+		t.Fatalf("StreamFilePermissions() = %v, want nil", err)
+	}
+
+	if err := limiter.Stream(nil, stream, info, handler); status.Code(err) != codes.ResourceExhausted {
+		t.Errorf("StreamFilePermissions() over the limit = %v, want code %v", err, codes.ResourceExhausted)
+	}
+}
+
+func TestRateLimiterInterceptorIgnoresOtherServices(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+	limiter := NewRateLimiter(NewMemoryRateLimitStore(), Limit{Rate: 1, Burst: 1}, Limit{}, nil, logger)
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return nil, nil
+	}
+
+	info := &grpc.UnaryServerInfo{FullMethod: "/grpc.health.v1.Health/Check"}
+	for i := 0; i < 3; i++ {
+		if _, err := limiter.Unary(context.Background(), nil, info, handler); err != nil {
+			t.Fatalf("Check() = %v, want nil", err)
+		}
+	}
+}
@@ -4,21 +4,37 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"regexp"
 	"strings"
+	"syscall"
 	"time"
 
+	"github.com/go-redis/redis"
+	grpc_middleware "github.com/grpc-ecosystem/go-grpc-middleware"
 	grpc_logrus "github.com/grpc-ecosystem/go-grpc-middleware/logging/logrus"
+	grpc_ctxtags "github.com/grpc-ecosystem/go-grpc-middleware/tags"
 	ilogger "github.com/meateam/elasticsearch-logger"
 	pb "github.com/meateam/permission-service/proto"
 	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/audit"
+	"github.com/meateam/permission-service/service/cache"
+	"github.com/meateam/permission-service/service/metrics"
 	"github.com/meateam/permission-service/service/mongodb"
+	"github.com/meateam/permission-service/service/retry"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/viper"
+	"go.elastic.co/apm/module/apmgrpc"
 	"go.elastic.co/apm/module/apmmongo"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
 	"go.mongodb.org/mongo-driver/x/mongo/driver/connstring"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/health"
 	"google.golang.org/grpc/health/grpc_health_v1"
@@ -31,7 +47,39 @@ const (
 	configMongoConnectionString        = "mongo_host"
 	configMongoClientConnectionTimeout = "mongo_client_connection_timeout"
 	configMongoClientPingTimeout       = "mongo_client_ping_timeout"
+	configMongoConnectRetryTimeout     = "mongo_connect_retry_timeout"
+	configMongoCollection              = "mongo_collection"
+	configMongoReadPreference          = "mongo_read_preference"
+	configMongoWriteMajority           = "mongo_write_majority"
+	configMongoWriteTimeout            = "mongo_write_timeout"
 	configElasticAPMIgnoreURLS         = "elastic_apm_ignore_urls"
+	configStoreTimeout                 = "store_timeout"
+	configStoreMaxAttempts             = "store_max_attempts"
+	configRedisHost                    = "redis_host"
+	configCacheTTL                     = "cache_ttl"
+	configCacheSize                    = "cache_size"
+	configMetricsPort                  = "metrics_port"
+	configSoftDelete                   = "soft_delete"
+	configAuditLog                     = "audit_log"
+	configAuditFailOnError             = "audit_fail_on_error"
+	configRejectOwnerConflict          = "reject_owner_conflict"
+	configMultiTenant                  = "multi_tenant"
+	configLogRedaction                 = "log_redaction"
+	configShutdownTimeout              = "shutdown_timeout"
+	configExpirySweepInterval          = "expiry_sweep_interval"
+	configIdempotencyTTL               = "idempotency_ttl"
+	configIdempotencyCacheSize         = "idempotency_cache_size"
+	configRateLimitRead                = "rate_limit_read"
+	configRateLimitWrite               = "rate_limit_write"
+	configRateLimitRPCs                = "rate_limit_rpcs"
+)
+
+const (
+	// primaryHealthService is the name of the health service of the database's primary.
+	primaryHealthService = "permission.primary"
+
+	// secondaryHealthService is the name of the health service of the database's secondaries.
+	secondaryHealthService = "permission.secondary"
 )
 
 func init() {
@@ -41,10 +89,39 @@ func init() {
 	viper.SetDefault(configMongoConnectionString, "mongodb://localhost:27017/permission")
 	viper.SetDefault(configMongoClientConnectionTimeout, 10)
 	viper.SetDefault(configMongoClientPingTimeout, 10)
+	viper.SetDefault(configMongoConnectRetryTimeout, int(mongodb.DefaultConnectTimeout/time.Second))
+	viper.SetDefault(configMongoCollection, mongodb.PermissionCollectionName)
+	viper.SetDefault(configMongoReadPreference, "")
+	viper.SetDefault(configMongoWriteMajority, false)
+	viper.SetDefault(configMongoWriteTimeout, int(mongodb.DefaultWriteTimeout/time.Second))
+	viper.SetDefault(configStoreTimeout, int(service.DefaultStoreTimeout/time.Second))
+	viper.SetDefault(configStoreMaxAttempts, 1)
+	viper.SetDefault(configRedisHost, "")
+	viper.SetDefault(configCacheTTL, int(cache.DefaultTTL/time.Second))
+	viper.SetDefault(configCacheSize, 0)
+	viper.SetDefault(configMetricsPort, "")
+	viper.SetDefault(configSoftDelete, false)
+	viper.SetDefault(configAuditLog, false)
+	viper.SetDefault(configAuditFailOnError, false)
+	viper.SetDefault(configRejectOwnerConflict, false)
+	viper.SetDefault(configMultiTenant, false)
+	viper.SetDefault(configLogRedaction, "hash")
+	viper.SetDefault(configShutdownTimeout, 30)
+	viper.SetDefault(configExpirySweepInterval, 0)
+	viper.SetDefault(configIdempotencyTTL, int(cache.DefaultIdempotencyTTL/time.Second))
+	viper.SetDefault(configIdempotencyCacheSize, 10000)
+	viper.SetDefault(configRateLimitRead, "")
+	viper.SetDefault(configRateLimitWrite, "")
+	viper.SetDefault(configRateLimitRPCs, "")
 	viper.SetEnvPrefix(envPrefix)
 	viper.AutomaticEnv()
 }
 
+// storeCloser is a store that is closed when the server shuts down.
+type storeCloser interface {
+	Close(ctx context.Context) error
+}
+
 // PermissionServer is a structure that holds the permission grpc server
 // and its services and configuration.
 type PermissionServer struct {
@@ -53,6 +130,9 @@ type PermissionServer struct {
 	port                string
 	healthCheckInterval int
 	permissionService   service.Service
+	store               storeCloser
+	shutdownTimeout     time.Duration
+	stopSweeper         context.CancelFunc
 }
 
 // Serve accepts incoming connections on the listener `lis`, creating a new
@@ -63,6 +143,7 @@ type PermissionServer struct {
 // If `lis` is nil then Serve creates a `net.Listener` with "tcp" network listening
 // on the configured `TCP_PORT`, which defaults to "8080".
 // Serve will return a non-nil error unless Stop or GracefulStop is called.
+// On SIGTERM or SIGINT the server is shut down by Shutdown, and Serve returns once it's done.
 func (s PermissionServer) Serve(lis net.Listener) {
 	listener := lis
 	if lis == nil {
@@ -74,10 +155,70 @@ func (s PermissionServer) Serve(lis net.Listener) {
 		listener = l
 	}
 
+	shutdownDone := make(chan struct{})
+	go func() {
+		defer close(shutdownDone)
+
+		signals := make(chan os.Signal, 1)
+		signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT)
+		s.logger.Infof("received %v, shutting down", <-signals)
+		s.Shutdown()
+	}()
+
 	s.logger.Infof("listening and serving grpc server on port %s", s.port)
 	if err := s.Server.Serve(listener); err != nil {
 		s.logger.Fatalf(err.Error())
 	}
+
+	<-shutdownDone
+}
+
+// Shutdown stops the server from accepting new RPCs and waits for the in-flight RPCs to finish,
+// for at most the configured shutdown timeout after which the remaining RPCs are aborted.
+// The expiry sweeper is stopped and the store is closed after the RPCs are done so in-flight writes
+// aren't aborted.
+func (s PermissionServer) Shutdown() {
+	if !gracefulStop(s.Server, s.shutdownTimeout) {
+		s.logger.Warnf("in-flight RPCs didn't finish within %v, aborting them", s.shutdownTimeout)
+	}
+
+	if s.stopSweeper != nil {
+		s.stopSweeper()
+	}
+
+	if s.store == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), s.shutdownTimeout)
+	defer cancel()
+
+	if err := s.store.Close(ctx); err != nil {
+		s.logger.Errorf("failed closing the store: %v", err)
+	}
+}
+
+// gracefulStop gracefully stops grpcServer, waiting for its in-flight RPCs to finish for at most
+// timeout, after which grpcServer is stopped, aborting them.
+// Returns true if the RPCs finished within timeout, otherwise returns false.
+func gracefulStop(grpcServer *grpc.Server, timeout time.Duration) bool {
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-stopped:
+		return true
+	case <-timer.C:
+		grpcServer.Stop()
+		<-stopped
+		return false
+	}
 }
 
 // NewServer configures and creates a grpc.Server instance with the download service
@@ -85,24 +226,59 @@ func (s PermissionServer) Serve(lis net.Listener) {
 // Configure using environment variables.
 // `HEALTH_CHECK_INTERVAL`: Interval to update serving state of the health check server.
 // `PORT`: TCP port on which the grpc server would serve on.
+// `METRICS_PORT`: TCP port on which the store metrics are served on /metrics, not served if empty.
+// `MONGO_CONNECT_RETRY_TIMEOUT`: Seconds to keep retrying to connect to mongodb on startup before giving up.
+// `SHUTDOWN_TIMEOUT`: Seconds to wait for in-flight RPCs to finish on shutdown before aborting them.
+// `EXPIRY_SWEEP_INTERVAL`: Seconds between the passes that delete expired permissions, left to the
+// TTL index if 0.
+// `RATE_LIMIT_READ`, `RATE_LIMIT_WRITE`: The "rate:burst" limit of each actor's requests to each RPC that
+// reads or changes permissions, unlimited if empty.
+// `RATE_LIMIT_RPCS`: The limits of specific RPCs overriding the read and write limits, such as
+// "CreatePermissions=1:5,IsPermitted=100".
 func NewServer(logger *logrus.Logger) *PermissionServer {
 	// If no logger is given, create a new default logger for the server.
 	if logger == nil {
 		logger = ilogger.NewLogger()
 	}
 
-	// Set up grpc server opts with logger interceptor.
-	serverOpts := append(
+	redactionPolicy, err := ParseRedactionPolicy(viper.GetString(configLogRedaction))
+	if err != nil {
+		logger.Fatalf("%v", err)
+	}
+
+	rateLimiter, err := initRateLimiter(logger)
+	if err != nil {
+		logger.Fatalf("%v", err)
+	}
+
+	// Set up grpc server opts with a chain of interceptors that trace each RPC with the global
+	// tracer provider, continuing the trace context of the caller, pass the caller's actor ID to the
+	// store so its changes are audited, the caller's tenant ID so a multi-tenant store scopes them to
+	// the tenant, and the caller's idempotency key so a retried create returns the result of the first,
+	// log each RPC with the userIDs of its request redacted, and log it to elasticsearch.
+	// The RPCs are rate limited last, so the rejected RPCs are traced and logged, only if any rate limit
+	// is configured.
+	interceptors := []serverInterceptor{
+		newTracingInterceptor(otel.GetTracerProvider(), propagation.TraceContext{}),
+		actorInterceptor,
+		tenantInterceptor,
+		idempotencyInterceptor,
+		loggingInterceptor{logger: logger, policy: redactionPolicy},
 		serverLoggerInterceptor(logger),
-		grpc.MaxRecvMsgSize(16<<20),
-	)
+	}
+
+	if !rateLimiter.Unlimited() {
+		interceptors = append(interceptors, rateLimiter)
+	}
+
+	serverOpts := append(chainInterceptors(interceptors...), grpc.MaxRecvMsgSize(16<<20))
 
 	// Create a new grpc server.
 	grpcServer := grpc.NewServer(
 		serverOpts...,
 	)
 
-	controller, err := initMongoDBController(viper.GetString(configMongoConnectionString))
+	controller, mongoStore, err := initMongoDBController(viper.GetString(configMongoConnectionString), logger)
 	if err != nil {
 		logger.Fatalf("%v", err)
 	}
@@ -121,15 +297,27 @@ func NewServer(logger *logrus.Logger) *PermissionServer {
 		port:                viper.GetString(configPort),
 		healthCheckInterval: viper.GetInt(configHealthCheckInterval),
 		permissionService:   permissionService,
+		store:               mongoStore,
+		shutdownTimeout:     viper.GetDuration(configShutdownTimeout) * time.Second,
 	}
 
 	// Health check validation goroutine worker.
 	go permissionServer.healthCheckWorker(healthServer)
 
+	if sweepInterval := viper.GetDuration(configExpirySweepInterval) * time.Second; sweepInterval > 0 {
+		sweepCtx, stopSweeper := context.WithCancel(context.Background())
+		permissionServer.stopSweeper = stopSweeper
+		go mongodb.NewSweeper(mongoStore, sweepInterval, logger).Run(sweepCtx)
+	}
+
+	if metricsPort := viper.GetString(configMetricsPort); metricsPort != "" {
+		go serveMetrics(metricsPort, logger)
+	}
+
 	return permissionServer
 }
 
-func connectToMongoDB(connectionString string) (*mongo.Client, error) {
+func connectToMongoDB(ctx context.Context, connectionString string) (*mongo.Client, error) {
 	// Create mongodb client.
 	mongoOptions := options.Client().ApplyURI(connectionString).SetMonitor(apmmongo.CommandMonitor())
 	mongoClient, err := mongo.NewClient(mongoOptions)
@@ -139,7 +327,7 @@ func connectToMongoDB(connectionString string) (*mongo.Client, error) {
 
 	// Connect client to mongodb.
 	mongoClientConnectionTimout := viper.GetDuration(configMongoClientConnectionTimeout)
-	connectionTimeoutCtx, cancelConn := context.WithTimeout(context.TODO(), mongoClientConnectionTimout*time.Second)
+	connectionTimeoutCtx, cancelConn := context.WithTimeout(ctx, mongoClientConnectionTimout*time.Second)
 	defer cancelConn()
 	err = mongoClient.Connect(connectionTimeoutCtx)
 	if err != nil {
@@ -148,10 +336,11 @@ func connectToMongoDB(connectionString string) (*mongo.Client, error) {
 
 	// Check the connection.
 	mongoClientPingTimeout := viper.GetDuration(configMongoClientPingTimeout)
-	pingTimeoutCtx, cancelPing := context.WithTimeout(context.TODO(), mongoClientPingTimeout*time.Second)
+	pingTimeoutCtx, cancelPing := context.WithTimeout(ctx, mongoClientPingTimeout*time.Second)
 	defer cancelPing()
 	err = mongoClient.Ping(pingTimeoutCtx, readpref.Primary())
 	if err != nil {
+		mongoClient.Disconnect(context.Background())
 		return nil, fmt.Errorf("failed pinging to mongodb with connection string %s: %v", connectionString, err)
 	}
 
@@ -167,27 +356,156 @@ func getMongoDatabaseName(mongoClient *mongo.Client, connectionString string) (*
 	return mongoClient.Database(connString.Database), nil
 }
 
-func initMongoDBController(connectionString string) (service.Controller, error) {
-	mongoClient, err := connectToMongoDB(connectionString)
+// initMongoDBController connects to the mongodb of connectionString, retrying until it's reachable
+// for at most the configured connect retry timeout, and returns a controller of its store,
+// and the undecorated MongoStore, which is closed on shutdown.
+func initMongoDBController(
+	connectionString string,
+	logger *logrus.Logger,
+) (service.Controller, mongodb.MongoStore, error) {
+	dial := func(ctx context.Context) (*mongo.Database, error) {
+		mongoClient, err := connectToMongoDB(ctx, connectionString)
+		if err != nil {
+			return nil, err
+		}
+
+		db, err := getMongoDatabaseName(mongoClient, connectionString)
+		if err != nil {
+			mongoClient.Disconnect(context.Background())
+			return nil, err
+		}
+
+		return db, nil
+	}
+
+	connectTimeout := viper.GetDuration(configMongoConnectRetryTimeout) * time.Second
+	collectionName := viper.GetString(configMongoCollection)
+	mongoStore, err := mongodb.ConnectWithRetry(context.Background(), dial, collectionName, connectTimeout, logger)
+	if err != nil {
+		return nil, mongodb.MongoStore{}, fmt.Errorf("failed creating mongo store: %v", err)
+	}
+
+	store, mongoStore, err := initStore(mongoStore, logger)
+	if err != nil {
+		return nil, mongodb.MongoStore{}, err
+	}
+
+	return mongodb.NewController(store).WithWatcher(mongoStore), mongoStore, nil
+}
+
+// initStore configures mongoStore and wraps it with the configured decorators, transient errors are
+// retried only if more than one attempt is configured, the cache is used only if a redis host or a cache
+// size is configured, the changes are audited only if the audit log is enabled, the results of creates
+// with idempotency keys are kept in redis if a redis host is configured, or in-process if an idempotency
+// cache size is configured, and the operations of the composed store are recorded in the default prometheus
+// registry. The configured MongoStore is returned as well for watching the changes of permissions.
+func initStore(mongoStore mongodb.MongoStore, logger *logrus.Logger) (service.Store, mongodb.MongoStore, error) {
+	var err error
+	if readPreference := viper.GetString(configMongoReadPreference); readPreference != "" {
+		mode, err := readpref.ModeFromString(readPreference)
+		if err != nil {
+			return nil, mongodb.MongoStore{}, fmt.Errorf("invalid mongo read preference %s: %v", readPreference, err)
+		}
+
+		if mongoStore.ReadPreference, err = readpref.New(mode); err != nil {
+			return nil, mongodb.MongoStore{}, fmt.Errorf("invalid mongo read preference %s: %v", readPreference, err)
+		}
+	}
+
+	if viper.GetBool(configMongoWriteMajority) {
+		writeTimeout := viper.GetDuration(configMongoWriteTimeout) * time.Second
+		if mongoStore.WriteConcern, err = mongodb.MajorityWriteConcern(writeTimeout); err != nil {
+			return nil, mongodb.MongoStore{}, fmt.Errorf("invalid mongo write concern: %v", err)
+		}
+	}
+
+	mongoStore.SoftDelete = viper.GetBool(configSoftDelete)
+	mongoStore.MultiTenant = viper.GetBool(configMultiTenant)
+	if viper.GetBool(configRejectOwnerConflict) {
+		mongoStore.OwnerConflict = mongodb.OwnerConflictReject
+	}
+
+	storeTimeout := viper.GetDuration(configStoreTimeout)
+	var store service.Store = service.NewStoreWithTimeout(mongoStore, storeTimeout*time.Second)
+	if maxAttempts := viper.GetInt(configStoreMaxAttempts); maxAttempts > 1 {
+		store = retry.NewRetryingStore(store, maxAttempts, retry.DefaultBaseDelay)
+	}
+
+	var redisClient *redis.Client
+	if redisHost := viper.GetString(configRedisHost); redisHost != "" {
+		redisClient = redis.NewClient(&redis.Options{Addr: redisHost})
+	}
+
+	cacheTTL := viper.GetDuration(configCacheTTL)
+	if redisClient != nil {
+		store = cache.NewCachingStore(store, cache.NewRedisBackend(redisClient), cacheTTL*time.Second, logger).
+			WithNormalizer(mongoStore.Normalizer)
+	} else if cacheSize := viper.GetInt(configCacheSize); cacheSize > 0 {
+		store = cache.NewCachingStore(store, cache.NewLRUBackend(cacheSize), cacheTTL*time.Second, logger).
+			WithNormalizer(mongoStore.Normalizer)
+	}
+
+	if viper.GetBool(configAuditLog) {
+		auditLog := audit.NewMongoLog(mongoStore.DB)
+		store = audit.NewAuditingStore(store, auditLog, viper.GetBool(configAuditFailOnError), logger)
+	}
+
+	idempotencyTTL := viper.GetDuration(configIdempotencyTTL) * time.Second
+	if redisClient != nil {
+		store = cache.NewIdempotentStore(store, cache.NewRedisBackend(redisClient), idempotencyTTL, logger)
+	} else if cacheSize := viper.GetInt(configIdempotencyCacheSize); cacheSize > 0 {
+		store = cache.NewIdempotentStore(store, cache.NewLRUBackend(cacheSize), idempotencyTTL, logger)
+	}
+
+	instrumentedStore, err := metrics.NewInstrumentedStore(store, prometheus.DefaultRegisterer)
+	if err != nil {
+		return nil, mongodb.MongoStore{}, fmt.Errorf("failed registering store metrics: %v", err)
+	}
+
+	return instrumentedStore, mongoStore, nil
+}
+
+// initRateLimiter creates the RateLimiter of the configured limits, whose token buckets are kept in
+// redis if a redis host is configured so the replicas share them, or in-process otherwise.
+func initRateLimiter(logger *logrus.Logger) (RateLimiter, error) {
+	readLimit, err := ParseLimit(viper.GetString(configRateLimitRead))
 	if err != nil {
-		return nil, err
+		return RateLimiter{}, err
 	}
 
-	db, err := getMongoDatabaseName(mongoClient, connectionString)
+	writeLimit, err := ParseLimit(viper.GetString(configRateLimitWrite))
 	if err != nil {
-		return nil, err
+		return RateLimiter{}, err
 	}
 
-	controller, err := mongodb.NewMongoController(db)
+	rpcLimits, err := ParseRPCLimits(viper.GetString(configRateLimitRPCs))
 	if err != nil {
-		return nil, fmt.Errorf("failed creating mongo store: %v", err)
+		return RateLimiter{}, err
+	}
+
+	var store RateLimitStore = NewMemoryRateLimitStore()
+	if redisHost := viper.GetString(configRedisHost); redisHost != "" {
+		store = NewRedisRateLimitStore(redis.NewClient(&redis.Options{Addr: redisHost}))
 	}
 
-	return controller, nil
+	return NewRateLimiter(store, readLimit, writeLimit, rpcLimits, logger), nil
+}
+
+// serveMetrics serves the metrics of the default prometheus registry on /metrics of port.
+func serveMetrics(port string, logger *logrus.Logger) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", metrics.Handler(prometheus.DefaultGatherer))
+
+	logger.Infof("serving metrics on port %s", port)
+	if err := http.ListenAndServe(":"+port, mux); err != nil {
+		logger.Errorf("failed serving metrics: %v", err)
+	}
 }
 
-// serverLoggerInterceptor configures the logger interceptor for the permission server.
-func serverLoggerInterceptor(logger *logrus.Logger) []grpc.ServerOption {
+// serverLoggerInterceptor configures the elasticsearch logger interceptor for the permission server,
+// which reports unary RPCs to elastic APM, logs each RPC with the ID of its elastic APM trace, and logs
+// the payloads of the RPCs that are not ignored.
+func serverLoggerInterceptor(logger *logrus.Logger) serverInterceptor {
 	// Create new logrus entry for logger interceptor.
 	logrusEntry := logrus.NewEntry(logger)
 
@@ -199,33 +517,79 @@ func serverLoggerInterceptor(logger *logrus.Logger) []grpc.ServerOption {
 		strings.Split(viper.GetString(configElasticAPMIgnoreURLS), ",")...,
 	)
 
-	// Shared options for the logger, with a custom gRPC code to log level function.
-	loggerOpts := []grpc_logrus.Option{
-		grpc_logrus.WithDecider(func(fullMethodName string, err error) bool {
-			return ignorePayload(fullMethodName)
-		}),
-		grpc_logrus.WithLevels(grpc_logrus.DefaultCodeToLevel),
+	payloadDecider := func(ctx context.Context, fullMethodName string, servingObject interface{}) bool {
+		return ignorePayload(fullMethodName)
+	}
+
+	// Log each RPC with its trace ID, with a custom gRPC code to log level function.
+	traceIDLogger := traceIDLoggerInterceptor{
+		entry: logrusEntry,
+		opts: []grpc_logrus.Option{
+			grpc_logrus.WithDecider(func(fullMethodName string, err error) bool {
+				return ignorePayload(fullMethodName)
+			}),
+			grpc_logrus.WithLevels(grpc_logrus.DefaultCodeToLevel),
+		},
 	}
 
-	return ilogger.ElasticsearchLoggerServerInterceptor(
-		logrusEntry,
-		ignorePayload,
-		ignoreInitialRequest,
-		loggerOpts...,
+	// Report the unary RPCs to elastic APM, except the ignored ones.
+	apmInterceptor := apmgrpc.NewUnaryServerInterceptor(
+		apmgrpc.WithRecovery(),
+		apmgrpc.WithServerRequestIgnorer(
+			apmgrpc.NewRegexpRequestIgnorer(regexp.MustCompile(viper.GetString(configElasticAPMIgnoreURLS))),
+		),
 	)
+
+	return interceptorPair{
+		unary: grpc_middleware.ChainUnaryServer(
+			apmInterceptor,
+			traceIDLogger.Unary,
+			grpc_logrus.PayloadUnaryServerInterceptor(logrusEntry, payloadDecider),
+		),
+		stream: grpc_middleware.ChainStreamServer(
+			// Log incoming initial requests, the tags must be set before the logger.
+			grpc_ctxtags.StreamServerInterceptor(
+				grpc_ctxtags.WithFieldExtractorForInitialReq(ilogger.RequestExtractor(logrusEntry, ignoreInitialRequest)),
+			),
+			traceIDLogger.Stream,
+			grpc_logrus.PayloadStreamServerInterceptor(logrusEntry, payloadDecider),
+		),
+	}
 }
 
 // healthCheckWorker is running an infinite loop that sets the serving status once
-// in s.healthCheckInterval seconds.
+// in s.healthCheckInterval seconds. Since the gRPC health protocol has no degraded status,
+// the overall service is SERVING only if the primary is reachable and its permissions collection
+// can be queried, so writes can succeed, and the health of each member is reported separately
+// under primaryHealthService and secondaryHealthService, so a database whose secondaries are down
+// is reported as SERVING overall with secondaryHealthService NOT_SERVING.
 func (s PermissionServer) healthCheckWorker(healthServer *health.Server) {
 	mongoClientPingTimeout := viper.GetDuration(configMongoClientPingTimeout)
 	for {
-		if s.permissionService.HealthCheck(mongoClientPingTimeout * time.Second) {
-			healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_SERVING)
-		} else {
-			healthServer.SetServingStatus("", grpc_health_v1.HealthCheckResponse_NOT_SERVING)
+		storeHealth, err := s.permissionService.HealthStatus(mongoClientPingTimeout * time.Second)
+		if err != nil {
+			s.logger.Errorf("health check failed: %v", err)
 		}
 
+		reportHealth(healthServer, storeHealth, err)
 		time.Sleep(time.Second * time.Duration(s.healthCheckInterval))
 	}
 }
+
+// reportHealth sets the serving statuses of healthServer by storeHealth and the error of checking it,
+// the overall status is NOT_SERVING if checkErr is non-nil or the primary is unreachable. The watchers of
+// healthServer are only sent the statuses that changed.
+func reportHealth(healthServer *health.Server, storeHealth service.HealthStatus, checkErr error) {
+	healthServer.SetServingStatus("", servingStatus(checkErr == nil && storeHealth.PrimaryReachable))
+	healthServer.SetServingStatus(primaryHealthService, servingStatus(storeHealth.PrimaryReachable))
+	healthServer.SetServingStatus(secondaryHealthService, servingStatus(storeHealth.SecondaryReachable))
+}
+
+// servingStatus returns SERVING if serving is true, otherwise returns NOT_SERVING.
+func servingStatus(serving bool) grpc_health_v1.HealthCheckResponse_ServingStatus {
+	if serving {
+		return grpc_health_v1.HealthCheckResponse_SERVING
+	}
+
+	return grpc_health_v1.HealthCheckResponse_NOT_SERVING
+}
@@ -0,0 +1,158 @@
+package server
+
+import (
+	"context"
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/memory"
+	"github.com/sirupsen/logrus/hooks/test"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+// slowController is a Controller whose StreamFilePermissions closes started, and then waits for
+// release to be closed before streaming a single permission, or until its context is done.
+type slowController struct {
+	service.Controller
+	started chan struct{}
+	release chan struct{}
+}
+
+// StreamFilePermissions sends the permission of user to fileID once c.release is closed.
+func (c slowController) StreamFilePermissions(
+	ctx context.Context,
+	fileID string,
+	send func(service.Permission) error,
+) error {
+	close(c.started)
+	select {
+	case <-c.release:
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+
+	return send(&memory.Permission{FileID: fileID, UserID: "user", Role: pb.Role_READ})
+}
+
+// startSlowServer serves a PermissionServer with a slowController and shutdownTimeout over an
+// in-memory connection, and returns it, its controller, an open StreamFilePermissions stream,
+// and a function that closes the connection.
+func startSlowServer(
+	t *testing.T,
+	shutdownTimeout time.Duration,
+) (PermissionServer, slowController, pb.Permission_StreamFilePermissionsClient, func()) {
+	t.Helper()
+
+	logger, _ := test.NewNullLogger()
+	controller := slowController{started: make(chan struct{}), release: make(chan struct{})}
+	grpcServer := grpc.NewServer()
+	pb.RegisterPermissionServer(grpcServer, service.NewService(controller, logger))
+
+	listener := bufconn.Listen(1024 * 1024)
+	go grpcServer.Serve(listener)
+
+	dialer := func(context.Context, string) (net.Conn, error) { return listener.Dial() }
+	conn, err := grpc.Dial("bufnet", grpc.WithContextDialer(dialer), grpc.WithInsecure())
+	if err != nil {
+		t.Fatalf("grpc.Dial() = %v, want nil", err)
+	}
+
+	request := &pb.StreamFilePermissionsRequest{FileID: "file"}
+	stream, err := pb.NewPermissionClient(conn).StreamFilePermissions(context.Background(), request)
+	if err != nil {
+		conn.Close()
+		t.Fatalf("StreamFilePermissions() = %v, want nil", err)
+	}
+
+	<-controller.started
+	server := PermissionServer{Server: grpcServer, logger: logger, shutdownTimeout: shutdownTimeout}
+	return server, controller, stream, func() { conn.Close() }
+}
+
+func TestShutdownDrainsInFlightRPCs(t *testing.T) {
+	server, controller, stream, closeConn := startSlowServer(t, time.Minute)
+	defer closeConn()
+
+	done := make(chan struct{})
+	go func() {
+		server.Shutdown()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		t.Fatal("Shutdown() returned before the in-flight RPC finished")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(controller.release)
+	permission, err := stream.Recv()
+	if err != nil {
+		t.Fatalf("Recv() = %v, want nil", err)
+	}
+
+	if permission.GetUserID() != "user" {
+		t.Errorf("Recv() userID = %q, want user", permission.GetUserID())
+	}
+
+	if _, err := stream.Recv(); err != io.EOF {
+		t.Errorf("Recv() at the end of the stream = %v, want %v", err, io.EOF)
+	}
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Shutdown() didn't return after the in-flight RPC finished")
+	}
+}
+
+func TestShutdownAbortsRPCsAfterTimeout(t *testing.T) {
+	server, _, stream, closeConn := startSlowServer(t, 50*time.Millisecond)
+	defer closeConn()
+
+	done := make(chan struct{})
+	go func() {
+		server.Shutdown()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Shutdown() didn't return after its timeout")
+	}
+
+	if _, err := stream.Recv(); err == nil {
+		t.Error("Recv() of an aborted RPC = nil, want an error")
+	}
+}
+
+// closeCounter is a storeCloser that counts the times it was closed.
+type closeCounter struct {
+	closed *int
+}
+
+// Close increments c.closed.
+func (c closeCounter) Close(ctx context.Context) error {
+	*c.closed++
+	return nil
+}
+
+func TestShutdownClosesStore(t *testing.T) {
+	server, controller, _, closeConn := startSlowServer(t, time.Minute)
+	defer closeConn()
+
+	closed := 0
+	server.store = closeCounter{closed: &closed}
+	close(controller.release)
+	server.Shutdown()
+
+	if closed != 1 {
+		t.Errorf("Shutdown() closed the store %d times, want once", closed)
+	}
+}
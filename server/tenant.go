@@ -0,0 +1,10 @@
+package server
+
+import "github.com/meateam/permission-service/service"
+
+// tenantIDMetadataKey is the incoming metadata key of the ID of the tenant that made a request.
+const tenantIDMetadataKey = "x-tenant-id"
+
+// tenantInterceptor sets the tenant ID of each RPC's incoming metadata in the context the RPC is handled with,
+// so a multi-tenant store scopes the RPC to the tenant.
+var tenantInterceptor = metadataInterceptor{key: tenantIDMetadataKey, with: service.WithTenantID}
@@ -0,0 +1,26 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/meateam/permission-service/service"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestTenantInterceptorSetsTenantID(t *testing.T) {
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(tenantIDMetadataKey, "tenant"))
+	ctx = unaryContext(ctx, tenantInterceptor, "/permission.Permission/GetPermission")
+
+	if tenantID := service.TenantIDFromContext(ctx); tenantID != "tenant" {
+		t.Errorf("tenant ID = %q, want %q", tenantID, "tenant")
+	}
+}
+
+func TestTenantInterceptorWithoutMetadata(t *testing.T) {
+	ctx := unaryContext(context.Background(), tenantInterceptor, "/permission.Permission/GetPermission")
+
+	if tenantID := service.TenantIDFromContext(ctx); tenantID != "" {
+		t.Errorf("tenant ID = %q, want none", tenantID)
+	}
+}
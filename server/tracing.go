@@ -0,0 +1,120 @@
+package server
+
+import (
+	"context"
+	"strings"
+
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// tracerName is the name of the tracer that starts the RPCs' spans.
+const tracerName = "github.com/meateam/permission-service/server"
+
+// tracingInterceptor is a serverInterceptor that starts a span for each RPC, continuing the trace
+// propagated in the RPC's incoming metadata, and handles the RPC with the span's context.
+type tracingInterceptor struct {
+	tracer     trace.Tracer
+	propagator propagation.TextMapPropagator
+}
+
+// newTracingInterceptor returns a tracingInterceptor that starts spans with tracerProvider's tracer and
+// extracts the incoming trace context from metadata with propagator.
+func newTracingInterceptor(
+	tracerProvider trace.TracerProvider,
+	propagator propagation.TextMapPropagator,
+) tracingInterceptor {
+	return tracingInterceptor{tracer: tracerProvider.Tracer(tracerName), propagator: propagator}
+}
+
+// Unary handles the unary RPC with the context of its span.
+func (i tracingInterceptor) Unary(
+	ctx context.Context,
+	req interface{},
+	info *grpc.UnaryServerInfo,
+	handler grpc.UnaryHandler,
+) (interface{}, error) {
+	ctx, span := i.start(ctx, info.FullMethod)
+	resp, err := handler(ctx, req)
+	endRPCSpan(span, err)
+
+	return resp, err
+}
+
+// Stream handles the stream RPC with the context of its span.
+func (i tracingInterceptor) Stream(
+	srv interface{},
+	stream grpc.ServerStream,
+	info *grpc.StreamServerInfo,
+	handler grpc.StreamHandler,
+) error {
+	ctx, span := i.start(stream.Context(), info.FullMethod)
+	err := handler(srv, streamWithContext(ctx, stream))
+	endRPCSpan(span, err)
+
+	return err
+}
+
+// start starts the span of the RPC of fullMethodName, and returns ctx with the span, which is
+// the context the RPC is handled with.
+func (i tracingInterceptor) start(ctx context.Context, fullMethodName string) (context.Context, trace.Span) {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if ok {
+		ctx = i.propagator.Extract(ctx, metadataCarrier(md))
+	}
+
+	method := strings.TrimPrefix(fullMethodName, "/")
+	return i.tracer.Start(
+		ctx,
+		method,
+		trace.WithSpanKind(trace.SpanKindServer),
+		trace.WithAttributes(
+			attribute.String("rpc.system", "grpc"),
+			attribute.String("rpc.method", method),
+		),
+	)
+}
+
+// endRPCSpan ends the span of an RPC that returned err, recording the RPC's status code and error.
+func endRPCSpan(span trace.Span, err error) {
+	span.SetAttributes(attribute.String("rpc.grpc.status_code", status.Code(err).String()))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// metadataCarrier is a propagation.TextMapCarrier of grpc metadata.
+type metadataCarrier metadata.MD
+
+// Get returns the first value of key, or an empty string if key is not set.
+func (c metadataCarrier) Get(key string) string {
+	values := metadata.MD(c).Get(key)
+	if len(values) == 0 {
+		return ""
+	}
+
+	return values[0]
+}
+
+// Set sets the value of key to value.
+func (c metadataCarrier) Set(key string, value string) {
+	metadata.MD(c).Set(key, value)
+}
+
+// Keys returns the keys that are set.
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for key := range c {
+		keys = append(keys, key)
+	}
+
+	return keys
+}
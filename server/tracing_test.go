@@ -0,0 +1,99 @@
+package server
+
+import (
+	"context"
+	"testing"
+
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// incomingTraceID is the trace ID of the incoming traceparent.
+	incomingTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+
+	// incomingSpanID is the span ID of the incoming traceparent.
+	incomingSpanID = "00f067aa0ba902b7"
+)
+
+// handleRPC runs a unary RPC of fullMethodName with md as its incoming metadata through i,
+// returning rpcErr, and returns the context the RPC was handled with.
+func handleRPC(i tracingInterceptor, fullMethodName string, md metadata.MD, rpcErr error) context.Context {
+	var handledCtx context.Context
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		handledCtx = ctx
+		return nil, rpcErr
+	}
+
+	ctx := metadata.NewIncomingContext(context.Background(), md)
+	i.Unary(ctx, nil, &grpc.UnaryServerInfo{FullMethod: fullMethodName}, handler)
+
+	return handledCtx
+}
+
+func TestTracingInterceptorContinuesIncomingTrace(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	i := newTracingInterceptor(
+		sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)),
+		propagation.TraceContext{},
+	)
+
+	md := metadata.Pairs("traceparent", "00-"+incomingTraceID+"-"+incomingSpanID+"-01")
+	ctx := handleRPC(i, "/permission.Permission/GetPermission", md, nil)
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+
+	span := spans[0]
+	if span.Name() != "permission.Permission/GetPermission" {
+		t.Errorf("span name = %s, want permission.Permission/GetPermission", span.Name())
+	}
+
+	if span.SpanKind() != trace.SpanKindServer {
+		t.Errorf("span kind = %v, want %v", span.SpanKind(), trace.SpanKindServer)
+	}
+
+	if traceID := span.SpanContext().TraceID().String(); traceID != incomingTraceID {
+		t.Errorf("span trace ID = %s, want %s", traceID, incomingTraceID)
+	}
+
+	if parentID := span.Parent().SpanID().String(); parentID != incomingSpanID {
+		t.Errorf("span parent ID = %s, want %s", parentID, incomingSpanID)
+	}
+
+	if handledSpan := trace.SpanContextFromContext(ctx); handledSpan.SpanID() != span.SpanContext().SpanID() {
+		t.Errorf("RPC was not handled with the context of its span")
+	}
+}
+
+func TestTracingInterceptorRecordsError(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	i := newTracingInterceptor(
+		sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)),
+		propagation.TraceContext{},
+	)
+
+	handleRPC(i, "/permission.Permission/GetPermission", metadata.MD{}, status.Error(codes.NotFound, "not found"))
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+
+	if code := spans[0].Status().Code; code != otelcodes.Error {
+		t.Errorf("span status = %v, want %v", code, otelcodes.Error)
+	}
+
+	if spans[0].Parent().IsValid() {
+		t.Errorf("span without an incoming trace has a parent")
+	}
+}
@@ -0,0 +1,18 @@
+package service
+
+import "context"
+
+// actorIDKey is the context key of the ID of the actor that made a request.
+type actorIDKey struct{}
+
+// WithActorID returns a copy of ctx that carries actorID as the ID of the actor that made the request,
+// the permissions it creates are granted by the actor and the changes it makes are audited with it.
+func WithActorID(ctx context.Context, actorID string) context.Context {
+	return context.WithValue(ctx, actorIDKey{}, actorID)
+}
+
+// ActorIDFromContext returns the actor ID that ctx carries, or an empty string if it carries none.
+func ActorIDFromContext(ctx context.Context) string {
+	actorID, _ := ctx.Value(actorIDKey{}).(string)
+	return actorID
+}
@@ -0,0 +1,32 @@
+package audit
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// CollectionName is the name of the collection the audit entries are stored in.
+const CollectionName = "permission_audit"
+
+// MongoLog is a Log that inserts the entries into the audit collection of a mongodb database.
+// Entries are only ever inserted, never updated or removed.
+type MongoLog struct {
+	collection *mongo.Collection
+}
+
+// NewMongoLog returns a MongoLog of the audit collection of db.
+func NewMongoLog(db *mongo.Database) MongoLog {
+	return MongoLog{collection: db.Collection(CollectionName)}
+}
+
+// Write inserts entries into the audit collection.
+func (l MongoLog) Write(ctx context.Context, entries ...Entry) error {
+	documents := make([]interface{}, 0, len(entries))
+	for _, entry := range entries {
+		documents = append(documents, entry)
+	}
+
+	_, err := l.collection.InsertMany(ctx, documents)
+	return err
+}
@@ -0,0 +1,454 @@
+package audit
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/mongodb"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// OperationCreate is the operation of an entry of a created or upserted permission.
+	OperationCreate = "create"
+
+	// OperationUpdate is the operation of an entry of a permission whose role was updated.
+	OperationUpdate = "update"
+
+	// OperationDelete is the operation of an entry of a deleted permission.
+	OperationDelete = "delete"
+)
+
+// Entry is a record of a single change of a permission.
+type Entry struct {
+	Timestamp time.Time `bson:"timestamp"`
+	Operation string    `bson:"operation"`
+	FileID    string    `bson:"fileID"`
+	UserID    string    `bson:"userID"`
+	OldRole   pb.Role   `bson:"oldRole"`
+	NewRole   pb.Role   `bson:"newRole"`
+	ActorID   string    `bson:"actorID,omitempty"`
+	Reason    string    `bson:"reason,omitempty"`
+}
+
+// Log is an interface for the append only storage the audit entries are written to.
+type Log interface {
+	// Write appends entries to the log.
+	Write(ctx context.Context, entries ...Entry) error
+}
+
+// AuditingStore is a Store that writes an audit Entry of every permission that was successfully
+// changed through it, with the actor ID of the request's context. The permissions' previous roles
+// are read from the wrapped Store before they're changed.
+// If failOnError is false then errors of writing the entries are logged and the operation
+// succeeds, otherwise they're returned along with the operation's result.
+type AuditingStore struct {
+	inner       service.Store
+	log         Log
+	failOnError bool
+	logger      *logrus.Logger
+}
+
+// NewAuditingStore returns an AuditingStore that writes the changes of inner's permissions to log.
+func NewAuditingStore(inner service.Store, log Log, failOnError bool, logger *logrus.Logger) AuditingStore {
+	if logger == nil {
+		logger = logrus.New()
+	}
+
+	return AuditingStore{inner: inner, log: log, failOnError: failOnError, logger: logger}
+}
+
+// Create creates permission in the wrapped store and audits it.
+func (s AuditingStore) Create(ctx context.Context, permission service.Permission) (service.Permission, error) {
+	oldRole, err := s.currentRole(ctx, permission.GetFileID(), permission.GetUserID())
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.inner.Create(ctx, permission)
+	if err != nil {
+		return nil, err
+	}
+
+	return created, s.write(ctx, newEntry(ctx, OperationCreate, created, oldRole, created.GetRole()))
+}
+
+// CreateIfAbsent creates permission in the wrapped store if it's absent and audits it, a permission that
+// already exists isn't audited since it's left as is.
+func (s AuditingStore) CreateIfAbsent(
+	ctx context.Context,
+	permission service.Permission,
+) (service.Permission, error) {
+	created, err := s.inner.CreateIfAbsent(ctx, permission)
+	if err != nil {
+		return nil, err
+	}
+
+	return created, s.write(ctx, newEntry(ctx, OperationCreate, created, pb.Role_NONE, created.GetRole()))
+}
+
+// CreateMany creates permissions in the wrapped store and audits the ones that were created,
+// even if they were written but the wrapped store failed reading them back.
+func (s AuditingStore) CreateMany(
+	ctx context.Context,
+	permissions []service.Permission,
+) ([]service.Permission, error) {
+	oldRoles, err := s.currentRoles(ctx, permissions)
+	if err != nil {
+		return nil, err
+	}
+
+	created, err := s.inner.CreateMany(ctx, permissions)
+	createErr, partial := err.(*service.CreateManyError)
+	if err != nil && !partial {
+		return nil, err
+	}
+
+	// The permissions that were written but couldn't be read back are audited as they were given.
+	written := created
+	if partial && createErr.ReadErr != nil {
+		written = createErr.Written(permissions)
+	}
+
+	entries := make([]Entry, 0, len(written))
+	for _, permission := range written {
+		oldRole := oldRoles[[2]string{permission.GetFileID(), permission.GetUserID()}]
+		entries = append(entries, newEntry(ctx, OperationCreate, permission, oldRole, permission.GetRole()))
+	}
+
+	if writeErr := s.write(ctx, entries...); writeErr != nil {
+		return created, writeErr
+	}
+
+	return created, err
+}
+
+// Get returns the permission that matches filter from the wrapped store.
+func (s AuditingStore) Get(ctx context.Context, filter interface{}) (service.Permission, error) {
+	return s.inner.Get(ctx, filter)
+}
+
+// GetAll returns the permissions that match filter from the wrapped store.
+func (s AuditingStore) GetAll(ctx context.Context, filter interface{}) ([]service.Permission, error) {
+	return s.inner.GetAll(ctx, filter)
+}
+
+// GetAllSorted returns the permissions that match filter sorted by sortBy from the wrapped store.
+func (s AuditingStore) GetAllSorted(
+	ctx context.Context,
+	filter interface{},
+	sortBy service.SortBy,
+) ([]service.Permission, error) {
+	return s.inner.GetAllSorted(ctx, filter, sortBy)
+}
+
+// GetAllCursor returns an iterator of the permissions that match filter from the wrapped store.
+func (s AuditingStore) GetAllCursor(ctx context.Context, filter interface{}) (service.PermissionIterator, error) {
+	return s.inner.GetAllCursor(ctx, filter)
+}
+
+// GetAllPaged returns a page of the permissions that match filter from the wrapped store.
+func (s AuditingStore) GetAllPaged(
+	ctx context.Context,
+	filter interface{},
+	pageSize int64,
+	pageToken string,
+) ([]service.Permission, string, error) {
+	return s.inner.GetAllPaged(ctx, filter, pageSize, pageToken)
+}
+
+// Count returns the number of permissions that match filter from the wrapped store.
+func (s AuditingStore) Count(ctx context.Context, filter interface{}) (int64, error) {
+	return s.inner.Count(ctx, filter)
+}
+
+// Exists returns whether userID has a permission to fileID from the wrapped store.
+func (s AuditingStore) Exists(ctx context.Context, fileID string, userID string) (bool, error) {
+	return s.inner.Exists(ctx, fileID, userID)
+}
+
+// ExistsMatching returns whether any permission matches filter from the wrapped store.
+func (s AuditingStore) ExistsMatching(ctx context.Context, filter interface{}) (bool, error) {
+	return s.inner.ExistsMatching(ctx, filter)
+}
+
+// UpdateRole updates the role of the permission in the wrapped store and audits it.
+func (s AuditingStore) UpdateRole(
+	ctx context.Context,
+	fileID string,
+	userID string,
+	role pb.Role,
+) (service.Permission, error) {
+	oldRole, err := s.currentRole(ctx, fileID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.inner.UpdateRole(ctx, fileID, userID, role)
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, s.write(ctx, newEntry(ctx, OperationUpdate, updated, oldRole, updated.GetRole()))
+}
+
+// UpdateRoleIfVersion updates the role of the permission in the wrapped store if its version is
+// still expectedVersion, and audits it.
+func (s AuditingStore) UpdateRoleIfVersion(
+	ctx context.Context,
+	fileID string,
+	userID string,
+	role pb.Role,
+	expectedVersion int64,
+) (service.Permission, error) {
+	oldRole, err := s.currentRole(ctx, fileID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	updated, err := s.inner.UpdateRoleIfVersion(ctx, fileID, userID, role, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	return updated, s.write(ctx, newEntry(ctx, OperationUpdate, updated, oldRole, updated.GetRole()))
+}
+
+// Delete deletes the permission that matches filter from the wrapped store and audits it.
+func (s AuditingStore) Delete(ctx context.Context, filter interface{}) (service.Permission, error) {
+	deleted, err := s.inner.Delete(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return deleted, s.write(ctx, newEntry(ctx, OperationDelete, deleted, deleted.GetRole(), pb.Role_NONE))
+}
+
+// DeleteMany deletes the permissions that match filter from the wrapped store and audits them,
+// dry runs aren't audited since nothing is deleted.
+func (s AuditingStore) DeleteMany(ctx context.Context, filter interface{}) (int64, error) {
+	if service.IsDryRun(ctx) {
+		return s.inner.DeleteMany(ctx, filter)
+	}
+
+	if err := service.RequireFilter(filter); err != nil {
+		return 0, err
+	}
+
+	permissions, err := s.inner.GetAll(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := s.inner.DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, s.write(ctx, deleteEntries(ctx, permissions)...)
+}
+
+// DeleteAllByFileID deletes the permissions of fileID from the wrapped store and audits them,
+// dry runs aren't audited.
+func (s AuditingStore) DeleteAllByFileID(ctx context.Context, fileID string) (int64, error) {
+	if service.IsDryRun(ctx) {
+		return s.inner.DeleteAllByFileID(ctx, fileID)
+	}
+
+	permissions, err := s.inner.GetAll(ctx, service.ByFile(fileID))
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := s.inner.DeleteAllByFileID(ctx, fileID)
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, s.write(ctx, deleteEntries(ctx, permissions)...)
+}
+
+// DeleteAllByUserID deletes the permissions of userID from the wrapped store and audits them,
+// dry runs aren't audited.
+func (s AuditingStore) DeleteAllByUserID(ctx context.Context, userID string) (int64, error) {
+	if service.IsDryRun(ctx) {
+		return s.inner.DeleteAllByUserID(ctx, userID)
+	}
+
+	permissions, err := s.inner.GetAll(ctx, service.ByUser(userID))
+	if err != nil {
+		return 0, err
+	}
+
+	deleted, err := s.inner.DeleteAllByUserID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+
+	return deleted, s.write(ctx, deleteEntries(ctx, permissions)...)
+}
+
+// TransferOwnership transfers the ownership of fileID in the wrapped store and audits the
+// changes of both users' permissions, their new roles are read after the transfer.
+// Dry runs aren't audited.
+func (s AuditingStore) TransferOwnership(
+	ctx context.Context,
+	fileID string,
+	fromUserID string,
+	toUserID string,
+) error {
+	if service.IsDryRun(ctx) {
+		return s.inner.TransferOwnership(ctx, fileID, fromUserID, toUserID)
+	}
+
+	oldRoles := make(map[string]pb.Role, 2)
+	for _, userID := range []string{fromUserID, toUserID} {
+		role, err := s.currentRole(ctx, fileID, userID)
+		if err != nil {
+			return err
+		}
+
+		oldRoles[userID] = role
+	}
+
+	if err := s.inner.TransferOwnership(ctx, fileID, fromUserID, toUserID); err != nil {
+		return err
+	}
+
+	entries := make([]Entry, 0, 2)
+	for _, userID := range []string{fromUserID, toUserID} {
+		permission, err := s.inner.Get(ctx, service.And(service.ByFile(fileID), service.ByUser(userID)))
+		if err != nil {
+			return s.failed(2, err)
+		}
+
+		operation := OperationUpdate
+		if oldRoles[userID] == pb.Role_NONE {
+			operation = OperationCreate
+		}
+
+		entries = append(entries, newEntry(ctx, operation, permission, oldRoles[userID], permission.GetRole()))
+	}
+
+	return s.write(ctx, entries...)
+}
+
+// HealthCheck runs the wrapped store's HealthCheck.
+func (s AuditingStore) HealthCheck(ctx context.Context) (service.HealthResult, error) {
+	return s.inner.HealthCheck(ctx)
+}
+
+// HealthStatus runs the wrapped store's HealthStatus.
+func (s AuditingStore) HealthStatus(ctx context.Context) (service.HealthStatus, error) {
+	return s.inner.HealthStatus(ctx)
+}
+
+// currentRole returns the role of the permission of userID to fileID before it's changed,
+// or NONE if it doesn't exist.
+func (s AuditingStore) currentRole(ctx context.Context, fileID string, userID string) (pb.Role, error) {
+	permission, err := s.inner.Get(ctx, service.And(service.ByFile(fileID), service.ByUser(userID)))
+	if err == service.ErrPermissionNotFound {
+		return pb.Role_NONE, nil
+	}
+
+	if err != nil {
+		return pb.Role_NONE, err
+	}
+
+	return permission.GetRole(), nil
+}
+
+// currentRoles returns the roles of the existing permissions of permissions' files and users,
+// by their fileID and userID.
+func (s AuditingStore) currentRoles(
+	ctx context.Context,
+	permissions []service.Permission,
+) (map[[2]string]pb.Role, error) {
+	roles := make(map[[2]string]pb.Role, len(permissions))
+	if len(permissions) == 0 {
+		return roles, nil
+	}
+
+	filters := make(bson.A, 0, len(permissions))
+	for _, permission := range permissions {
+		filters = append(filters, mongodb.FilterByFileAndUser(permission.GetFileID(), permission.GetUserID()))
+	}
+
+	existing, err := s.inner.GetAll(ctx, bson.D{bson.E{Key: "$or", Value: filters}})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, permission := range existing {
+		roles[[2]string{permission.GetFileID(), permission.GetUserID()}] = permission.GetRole()
+	}
+
+	return roles, nil
+}
+
+// write writes entries to the audit log, if it fails the error is logged, and it's
+// returned only if s.failOnError is set.
+func (s AuditingStore) write(ctx context.Context, entries ...Entry) error {
+	if len(entries) == 0 {
+		return nil
+	}
+
+	return s.failed(len(entries), s.log.Write(ctx, entries...))
+}
+
+// failed logs err of failing to write count audit entries if it's non-nil,
+// and returns it only if s.failOnError is set.
+func (s AuditingStore) failed(count int, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	s.logger.Errorf("failed writing %d audit entries: %v", count, err)
+	if !s.failOnError {
+		return nil
+	}
+
+	return status.Errorf(codes.Internal, "failed writing audit entries: %v", err)
+}
+
+// newEntry returns the entry of operation on permission that changed its role from oldRole to newRole,
+// made by the actor of ctx.
+func newEntry(
+	ctx context.Context,
+	operation string,
+	permission service.Permission,
+	oldRole pb.Role,
+	newRole pb.Role,
+) Entry {
+	entry := Entry{
+		Timestamp: time.Now(),
+		Operation: operation,
+		FileID:    permission.GetFileID(),
+		UserID:    permission.GetUserID(),
+		OldRole:   oldRole,
+		NewRole:   newRole,
+		ActorID:   service.ActorIDFromContext(ctx),
+	}
+
+	// The stored reason is the reason the permission was granted, so it only describes creations.
+	if operation == OperationCreate {
+		entry.Reason = permission.GetReason()
+	}
+
+	return entry
+}
+
+// deleteEntries returns the entries of deleting permissions, made by the actor of ctx.
+func deleteEntries(ctx context.Context, permissions []service.Permission) []Entry {
+	entries := make([]Entry, 0, len(permissions))
+	for _, permission := range permissions {
+		entries = append(entries, newEntry(ctx, OperationDelete, permission, permission.GetRole(), pb.Role_NONE))
+	}
+
+	return entries
+}
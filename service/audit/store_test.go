@@ -0,0 +1,277 @@
+package audit
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/memory"
+	"github.com/meateam/permission-service/service/mongodb"
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// recordingLog is a Log that keeps the entries written to it, or fails with err if it's set.
+type recordingLog struct {
+	entries []Entry
+	err     error
+}
+
+// Write appends entries to l.entries, or returns l.err if it's set.
+func (l *recordingLog) Write(ctx context.Context, entries ...Entry) error {
+	if l.err != nil {
+		return l.err
+	}
+
+	l.entries = append(l.entries, entries...)
+	return nil
+}
+
+// newPermission returns a permission of userID to fileID with role.
+func newPermission(fileID string, userID string, role pb.Role) *memory.Permission {
+	return &memory.Permission{FileID: fileID, UserID: userID, Role: role, Creator: "creator"}
+}
+
+func TestChangesAreAuditedWithActor(t *testing.T) {
+	log := &recordingLog{}
+	store := NewAuditingStore(memory.NewMemoryStore(), log, false, nil)
+	ctx := service.WithActorID(context.Background(), "actor")
+
+	if _, err := store.Create(ctx, newPermission("file", "user", pb.Role_READ)); err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	if _, err := store.UpdateRole(ctx, "file", "user", pb.Role_WRITE); err != nil {
+		t.Fatalf("UpdateRole() = %v, want nil", err)
+	}
+
+	if _, err := store.Delete(ctx, mongodb.FilterByFileAndUser("file", "user")); err != nil {
+		t.Fatalf("Delete() = %v, want nil", err)
+	}
+
+	want := []Entry{
+		{Operation: OperationCreate, OldRole: pb.Role_NONE, NewRole: pb.Role_READ},
+		{Operation: OperationUpdate, OldRole: pb.Role_READ, NewRole: pb.Role_WRITE},
+		{Operation: OperationDelete, OldRole: pb.Role_WRITE, NewRole: pb.Role_NONE},
+	}
+
+	if len(log.entries) != len(want) {
+		t.Fatalf("wrote %d entries, want %d", len(log.entries), len(want))
+	}
+
+	for i, entry := range log.entries {
+		if entry.Operation != want[i].Operation || entry.OldRole != want[i].OldRole || entry.NewRole != want[i].NewRole {
+			t.Errorf("entry %d = %s %v -> %v, want %s %v -> %v", i,
+				entry.Operation, entry.OldRole, entry.NewRole,
+				want[i].Operation, want[i].OldRole, want[i].NewRole)
+		}
+
+		if entry.FileID != "file" || entry.UserID != "user" || entry.ActorID != "actor" {
+			t.Errorf("entry %d is of file %s, user %s and actor %s, want file, user and actor",
+				i, entry.FileID, entry.UserID, entry.ActorID)
+		}
+
+		if entry.Timestamp.IsZero() {
+			t.Errorf("entry %d has no timestamp", i)
+		}
+	}
+}
+
+func TestCreateAuditsReason(t *testing.T) {
+	log := &recordingLog{}
+	store := NewAuditingStore(memory.NewMemoryStore(), log, false, nil)
+
+	permission := newPermission("file", "user", pb.Role_READ)
+	permission.Reason = "shared for review"
+	if _, err := store.Create(context.Background(), permission); err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	if _, err := store.Delete(context.Background(), mongodb.FilterByFileAndUser("file", "user")); err != nil {
+		t.Fatalf("Delete() = %v, want nil", err)
+	}
+
+	if len(log.entries) != 2 {
+		t.Fatalf("wrote %d entries, want 2", len(log.entries))
+	}
+
+	if reason := log.entries[0].Reason; reason != permission.Reason {
+		t.Errorf("create entry reason = %q, want %q", reason, permission.Reason)
+	}
+
+	if reason := log.entries[1].Reason; reason != "" {
+		t.Errorf("delete entry reason = %q, want none", reason)
+	}
+}
+
+func TestFailedOperationNotAudited(t *testing.T) {
+	log := &recordingLog{}
+	store := NewAuditingStore(memory.NewMemoryStore(), log, false, nil)
+
+	if _, err := store.UpdateRole(context.Background(), "file", "user", pb.Role_WRITE); err == nil {
+		t.Fatalf("UpdateRole() of a missing permission returned a nil error")
+	}
+
+	if len(log.entries) != 0 {
+		t.Errorf("wrote %d entries, want 0", len(log.entries))
+	}
+}
+
+// unreadableStore is a service.Store whose CreateMany writes only the first permission and then fails
+// reading back the permissions it wrote, as though the others failed to be written.
+type unreadableStore struct {
+	service.Store
+}
+
+// CreateMany writes the first of permissions to the embedded store and fails the others and the read-back.
+func (s unreadableStore) CreateMany(
+	ctx context.Context,
+	permissions []service.Permission,
+) ([]service.Permission, error) {
+	if _, err := s.Store.CreateMany(ctx, permissions[:1]); err != nil {
+		return nil, err
+	}
+
+	failed := make(map[int]error)
+	for i := 1; i < len(permissions); i++ {
+		failed[i] = errors.New("write failed")
+	}
+
+	return nil, &service.CreateManyError{Failed: failed, ReadErr: errors.New("read failed")}
+}
+
+func TestCreateManyAuditsWrittenPermissionsWhenReadBackFails(t *testing.T) {
+	log := &recordingLog{}
+	store := NewAuditingStore(unreadableStore{memory.NewMemoryStore()}, log, false, nil)
+	permissions := []service.Permission{
+		newPermission("file", "written", pb.Role_READ),
+		newPermission("file", "failed", pb.Role_READ),
+	}
+
+	if _, err := store.CreateMany(context.Background(), permissions); err == nil {
+		t.Fatalf("CreateMany() returned a nil error")
+	}
+
+	if len(log.entries) != 1 {
+		t.Fatalf("wrote %d entries, want 1", len(log.entries))
+	}
+
+	if entry := log.entries[0]; entry.UserID != "written" || entry.NewRole != pb.Role_READ {
+		t.Errorf("entry = %s of %v, want written of %v", entry.UserID, entry.NewRole, pb.Role_READ)
+	}
+}
+
+func TestDeleteAllByFileIDAuditsEachPermission(t *testing.T) {
+	log := &recordingLog{}
+	store := NewAuditingStore(memory.NewMemoryStore(), log, false, nil)
+
+	for _, userID := range []string{"a", "b"} {
+		if _, err := store.Create(context.Background(), newPermission("file", userID, pb.Role_READ)); err != nil {
+			t.Fatalf("Create() = %v, want nil", err)
+		}
+	}
+
+	log.entries = nil
+	if _, err := store.DeleteAllByFileID(context.Background(), "file"); err != nil {
+		t.Fatalf("DeleteAllByFileID() = %v, want nil", err)
+	}
+
+	if len(log.entries) != 2 {
+		t.Fatalf("wrote %d entries, want 2", len(log.entries))
+	}
+
+	for _, entry := range log.entries {
+		if entry.Operation != OperationDelete || entry.OldRole != pb.Role_READ {
+			t.Errorf("entry = %s of %v, want %s of %v", entry.Operation, entry.OldRole, OperationDelete, pb.Role_READ)
+		}
+	}
+}
+
+func TestDryRunNotAudited(t *testing.T) {
+	log := &recordingLog{}
+	store := NewAuditingStore(memory.NewMemoryStore(), log, false, nil)
+
+	if _, err := store.Create(context.Background(), newPermission("file", "owner", pb.Role_OWNER)); err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	log.entries = nil
+	ctx := service.WithDryRun(context.Background())
+	if err := store.TransferOwnership(ctx, "file", "owner", "user"); err != nil {
+		t.Fatalf("TransferOwnership() = %v, want nil", err)
+	}
+
+	if _, err := store.DeleteAllByFileID(ctx, "file"); err != nil {
+		t.Fatalf("DeleteAllByFileID() = %v, want nil", err)
+	}
+
+	if len(log.entries) != 0 {
+		t.Errorf("wrote %d entries, want 0", len(log.entries))
+	}
+}
+
+func TestLogFailureIsLoggedNotReturned(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	log := &recordingLog{err: errors.New("audit collection unavailable")}
+	store := NewAuditingStore(memory.NewMemoryStore(), log, false, logger)
+
+	created, err := store.Create(context.Background(), newPermission("file", "user", pb.Role_READ))
+	if err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	if created == nil {
+		t.Errorf("Create() returned a nil permission")
+	}
+
+	if entry := hook.LastEntry(); entry == nil || entry.Level != logrus.ErrorLevel {
+		t.Errorf("audit failure was not logged as an error")
+	}
+}
+
+func TestLogFailureReturnedWhenFailOnError(t *testing.T) {
+	log := &recordingLog{err: errors.New("audit collection unavailable")}
+	logger, _ := test.NewNullLogger()
+	store := NewAuditingStore(memory.NewMemoryStore(), log, true, logger)
+
+	_, err := store.Create(context.Background(), newPermission("file", "user", pb.Role_READ))
+	if status.Code(err) != codes.Internal {
+		t.Errorf("Create() = %v, want a %v error", err, codes.Internal)
+	}
+}
+
+func TestTransferOwnershipAuditsBothUsers(t *testing.T) {
+	log := &recordingLog{}
+	store := NewAuditingStore(memory.NewMemoryStore(), log, false, nil)
+
+	if _, err := store.Create(context.Background(), newPermission("file", "owner", pb.Role_OWNER)); err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	log.entries = nil
+	if err := store.TransferOwnership(context.Background(), "file", "owner", "user"); err != nil {
+		t.Fatalf("TransferOwnership() = %v, want nil", err)
+	}
+
+	want := []Entry{
+		{Operation: OperationUpdate, UserID: "owner", OldRole: pb.Role_OWNER, NewRole: pb.Role_WRITE},
+		{Operation: OperationCreate, UserID: "user", OldRole: pb.Role_NONE, NewRole: pb.Role_OWNER},
+	}
+
+	if len(log.entries) != len(want) {
+		t.Fatalf("wrote %d entries, want %d", len(log.entries), len(want))
+	}
+
+	for i, entry := range log.entries {
+		if entry.Operation != want[i].Operation || entry.UserID != want[i].UserID ||
+			entry.OldRole != want[i].OldRole || entry.NewRole != want[i].NewRole {
+			t.Errorf("entry %d = %s of %s %v -> %v, want %s of %s %v -> %v", i,
+				entry.Operation, entry.UserID, entry.OldRole, entry.NewRole,
+				want[i].Operation, want[i].UserID, want[i].OldRole, want[i].NewRole)
+		}
+	}
+}
@@ -0,0 +1,245 @@
+package cache
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultIdempotencyTTL is the default time the result of a request with an idempotency key is kept.
+const DefaultIdempotencyTTL = 5 * time.Minute
+
+// idempotencyKeyPrefix is the prefix of all idempotency result keys.
+const idempotencyKeyPrefix = "idempotency"
+
+// IdempotentStore is a Store that keeps the result of each Create made with an idempotency key,
+// see service.WithIdempotencyKey, and returns it for a retried Create with the same key instead of
+// creating the permission again, so a retry isn't reported or audited as another change.
+// Only successful results are kept, and concurrent requests with the same key aren't deduplicated
+// since neither of them has a result yet. The results are kept in a Backend, an LRUBackend keeps
+// them in-process and a RedisBackend shares them between replicas. Errors of the Backend are logged
+// and fall through to the wrapped Store.
+type IdempotentStore struct {
+	inner   service.Store
+	backend Backend
+	ttl     time.Duration
+	logger  *logrus.Logger
+}
+
+// NewIdempotentStore returns an IdempotentStore that keeps the results of inner's Create in backend
+// for ttl, if ttl is not positive then DefaultIdempotencyTTL is used.
+func NewIdempotentStore(
+	inner service.Store,
+	backend Backend,
+	ttl time.Duration,
+	logger *logrus.Logger,
+) IdempotentStore {
+	if ttl <= 0 {
+		ttl = DefaultIdempotencyTTL
+	}
+
+	if logger == nil {
+		logger = logrus.New()
+	}
+
+	return IdempotentStore{inner: inner, backend: backend, ttl: ttl, logger: logger}
+}
+
+// Create returns the kept result of the Create of permission with the idempotency key of ctx,
+// otherwise creates permission in the wrapped store and keeps the result if ctx has a key.
+func (s IdempotentStore) Create(ctx context.Context, permission service.Permission) (service.Permission, error) {
+	return s.create(ctx, permission, s.inner.Create)
+}
+
+// CreateIfAbsent returns the kept result of the create of permission with the idempotency key of ctx,
+// otherwise creates permission in the wrapped store if it's absent and keeps the result if ctx has a key,
+// so a retried create succeeds instead of failing because the first one already created the permission.
+func (s IdempotentStore) CreateIfAbsent(
+	ctx context.Context,
+	permission service.Permission,
+) (service.Permission, error) {
+	return s.create(ctx, permission, s.inner.CreateIfAbsent)
+}
+
+// create returns the kept result of the create of permission with the idempotency key of ctx,
+// otherwise creates permission by createFunc and keeps the result if ctx has a key.
+func (s IdempotentStore) create(
+	ctx context.Context,
+	permission service.Permission,
+	createFunc func(ctx context.Context, permission service.Permission) (service.Permission, error),
+) (service.Permission, error) {
+	idempotencyKey := service.IdempotencyKeyFromContext(ctx)
+	if idempotencyKey == "" {
+		return createFunc(ctx, permission)
+	}
+
+	key := resultKey(ctx, idempotencyKey, permission.GetFileID(), permission.GetUserID())
+	kept, err := s.backend.Get(key)
+	if err == nil {
+		var previous service.Permission
+		previous, err = decodePermission(kept)
+		if err == nil {
+			return previous, nil
+		}
+	}
+
+	if err != nil && err != ErrCacheMiss {
+		s.logger.Warnf("failed reading result %s: %v", key, err)
+	}
+
+	created, err := createFunc(ctx, permission)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := encodePermission(created)
+	if err != nil {
+		s.logger.Warnf("failed keeping result %s: %v", key, err)
+		return created, nil
+	}
+
+	if err := s.backend.Set(key, value, s.ttl); err != nil {
+		s.logger.Warnf("failed keeping result %s: %v", key, err)
+	}
+
+	return created, nil
+}
+
+// CreateMany creates permissions in the wrapped store.
+func (s IdempotentStore) CreateMany(
+	ctx context.Context,
+	permissions []service.Permission,
+) ([]service.Permission, error) {
+	return s.inner.CreateMany(ctx, permissions)
+}
+
+// Get returns the permission that matches filter from the wrapped store.
+func (s IdempotentStore) Get(ctx context.Context, filter interface{}) (service.Permission, error) {
+	return s.inner.Get(ctx, filter)
+}
+
+// GetAll returns the permissions that match filter from the wrapped store.
+func (s IdempotentStore) GetAll(ctx context.Context, filter interface{}) ([]service.Permission, error) {
+	return s.inner.GetAll(ctx, filter)
+}
+
+// GetAllSorted returns the permissions that match filter sorted by sortBy from the wrapped store.
+func (s IdempotentStore) GetAllSorted(
+	ctx context.Context,
+	filter interface{},
+	sortBy service.SortBy,
+) ([]service.Permission, error) {
+	return s.inner.GetAllSorted(ctx, filter, sortBy)
+}
+
+// GetAllCursor returns an iterator of the permissions that match filter from the wrapped store.
+func (s IdempotentStore) GetAllCursor(
+	ctx context.Context,
+	filter interface{},
+) (service.PermissionIterator, error) {
+	return s.inner.GetAllCursor(ctx, filter)
+}
+
+// GetAllPaged returns a page of the permissions that match filter from the wrapped store.
+func (s IdempotentStore) GetAllPaged(
+	ctx context.Context,
+	filter interface{},
+	pageSize int64,
+	pageToken string,
+) ([]service.Permission, string, error) {
+	return s.inner.GetAllPaged(ctx, filter, pageSize, pageToken)
+}
+
+// Count returns the number of permissions that match filter from the wrapped store.
+func (s IdempotentStore) Count(ctx context.Context, filter interface{}) (int64, error) {
+	return s.inner.Count(ctx, filter)
+}
+
+// Exists returns whether userID has a permission to fileID from the wrapped store.
+func (s IdempotentStore) Exists(ctx context.Context, fileID string, userID string) (bool, error) {
+	return s.inner.Exists(ctx, fileID, userID)
+}
+
+// ExistsMatching returns whether any permission matches filter from the wrapped store.
+func (s IdempotentStore) ExistsMatching(ctx context.Context, filter interface{}) (bool, error) {
+	return s.inner.ExistsMatching(ctx, filter)
+}
+
+// UpdateRole updates the role of the permission in the wrapped store.
+func (s IdempotentStore) UpdateRole(
+	ctx context.Context,
+	fileID string,
+	userID string,
+	role pb.Role,
+) (service.Permission, error) {
+	return s.inner.UpdateRole(ctx, fileID, userID, role)
+}
+
+// UpdateRoleIfVersion updates the role of the permission in the wrapped store if its version matches.
+func (s IdempotentStore) UpdateRoleIfVersion(
+	ctx context.Context,
+	fileID string,
+	userID string,
+	role pb.Role,
+	expectedVersion int64,
+) (service.Permission, error) {
+	return s.inner.UpdateRoleIfVersion(ctx, fileID, userID, role, expectedVersion)
+}
+
+// Delete deletes the permission that matches filter in the wrapped store.
+func (s IdempotentStore) Delete(ctx context.Context, filter interface{}) (service.Permission, error) {
+	return s.inner.Delete(ctx, filter)
+}
+
+// DeleteMany deletes the permissions that match filter in the wrapped store.
+func (s IdempotentStore) DeleteMany(ctx context.Context, filter interface{}) (int64, error) {
+	return s.inner.DeleteMany(ctx, filter)
+}
+
+// DeleteAllByFileID deletes the permissions of fileID in the wrapped store.
+func (s IdempotentStore) DeleteAllByFileID(ctx context.Context, fileID string) (int64, error) {
+	return s.inner.DeleteAllByFileID(ctx, fileID)
+}
+
+// DeleteAllByUserID deletes the permissions of userID in the wrapped store.
+func (s IdempotentStore) DeleteAllByUserID(ctx context.Context, userID string) (int64, error) {
+	return s.inner.DeleteAllByUserID(ctx, userID)
+}
+
+// TransferOwnership transfers the ownership of fileID in the wrapped store.
+func (s IdempotentStore) TransferOwnership(
+	ctx context.Context,
+	fileID string,
+	fromUserID string,
+	toUserID string,
+) error {
+	return s.inner.TransferOwnership(ctx, fileID, fromUserID, toUserID)
+}
+
+// HealthCheck runs the wrapped store's HealthCheck, the backend's health doesn't affect
+// the result since the store is usable without it.
+func (s IdempotentStore) HealthCheck(ctx context.Context) (service.HealthResult, error) {
+	return s.inner.HealthCheck(ctx)
+}
+
+// HealthStatus runs the wrapped store's HealthStatus, the backend's health doesn't affect
+// the result for the same reason as HealthCheck.
+func (s IdempotentStore) HealthStatus(ctx context.Context) (service.HealthStatus, error) {
+	return s.inner.HealthStatus(ctx)
+}
+
+// resultKey returns the key of the result of the Create of the permission of userID to fileID with
+// idempotencyKey by the tenant of ctx, so a key that's reused for another permission or by another
+// tenant doesn't return the result of a different request.
+func resultKey(ctx context.Context, idempotencyKey string, fileID string, userID string) string {
+	prefix := idempotencyKeyPrefix
+	if tenantID := service.TenantIDFromContext(ctx); tenantID != "" {
+		prefix = fmt.Sprintf("%s@%s", prefix, escapeKey(tenantID))
+	}
+
+	return fmt.Sprintf("%s:%s:%s:%s", prefix, escapeKey(idempotencyKey), escapeKey(fileID), escapeKey(userID))
+}
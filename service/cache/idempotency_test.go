@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/audit"
+	"github.com/meateam/permission-service/service/memory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// countingLog is an audit.Log that counts the entries written to it.
+type countingLog struct {
+	entries int
+}
+
+// Write counts entries.
+func (l *countingLog) Write(ctx context.Context, entries ...audit.Entry) error {
+	l.entries += len(entries)
+	return nil
+}
+
+// newAuditedIdempotentStore returns an IdempotentStore over an LRUBackend of an AuditingStore
+// that writes to the returned countingLog.
+func newAuditedIdempotentStore() (IdempotentStore, *countingLog) {
+	log := &countingLog{}
+	inner := audit.NewAuditingStore(memory.NewMemoryStore(), log, false, nil)
+
+	return NewIdempotentStore(inner, NewLRUBackend(0), 0, nil), log
+}
+
+func TestRepeatedIdempotencyKeyIsNotAuditedTwice(t *testing.T) {
+	store, log := newAuditedIdempotentStore()
+	ctx := service.WithIdempotencyKey(context.Background(), "key")
+	permission := &memory.Permission{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"}
+
+	first, err := store.Create(ctx, permission)
+	if err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	retried, err := store.Create(ctx, permission)
+	if err != nil {
+		t.Fatalf("retried Create() = %v, want nil", err)
+	}
+
+	if retried.GetID() != first.GetID() || retried.GetVersion() != first.GetVersion() {
+		t.Errorf("retried Create() = %s version %d, want %s version %d",
+			retried.GetID(), retried.GetVersion(), first.GetID(), first.GetVersion())
+	}
+
+	if log.entries != 1 {
+		t.Errorf("wrote %d audit entries, want 1", log.entries)
+	}
+}
+
+func TestCreateWithoutIdempotencyKeyRunsEveryTime(t *testing.T) {
+	store, log := newAuditedIdempotentStore()
+	permission := &memory.Permission{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"}
+
+	for i := 0; i < 2; i++ {
+		if _, err := store.Create(context.Background(), permission); err != nil {
+			t.Fatalf("Create() = %v, want nil", err)
+		}
+	}
+
+	if log.entries != 2 {
+		t.Errorf("wrote %d audit entries, want 2", log.entries)
+	}
+}
+
+func TestIdempotencyKeyReusedForAnotherPermission(t *testing.T) {
+	store, log := newAuditedIdempotentStore()
+	ctx := service.WithIdempotencyKey(context.Background(), "key")
+
+	for _, userID := range []string{"a", "b"} {
+		permission := &memory.Permission{FileID: "file", UserID: userID, Role: pb.Role_READ, Creator: "creator"}
+		created, err := store.Create(ctx, permission)
+		if err != nil {
+			t.Fatalf("Create() = %v, want nil", err)
+		}
+
+		if created.GetUserID() != userID {
+			t.Errorf("Create() of user %s = permission of user %s", userID, created.GetUserID())
+		}
+	}
+
+	if log.entries != 2 {
+		t.Errorf("wrote %d audit entries, want 2", log.entries)
+	}
+}
+
+func TestRetriedCreateIfAbsentReturnsFirstResult(t *testing.T) {
+	store, _ := newAuditedIdempotentStore()
+	ctx := service.WithIdempotencyKey(context.Background(), "key")
+	permission := &memory.Permission{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"}
+
+	first, err := store.CreateIfAbsent(ctx, permission)
+	if err != nil {
+		t.Fatalf("CreateIfAbsent() = %v, want nil", err)
+	}
+
+	retried, err := store.CreateIfAbsent(ctx, permission)
+	if err != nil {
+		t.Fatalf("retried CreateIfAbsent() = %v, want nil", err)
+	}
+
+	if retried.GetID() != first.GetID() {
+		t.Errorf("retried CreateIfAbsent() = %s, want %s", retried.GetID(), first.GetID())
+	}
+
+	_, err = store.CreateIfAbsent(service.WithIdempotencyKey(context.Background(), "other"), permission)
+	if status.Code(err) != codes.AlreadyExists {
+		t.Errorf("CreateIfAbsent() with another key = %v, want code %v", err, codes.AlreadyExists)
+	}
+}
@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"container/list"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+)
+
+// DefaultMaxSize is the default number of keys an LRUBackend holds.
+const DefaultMaxSize = 10000
+
+// LRUBackend is a Backend that caches in process memory, evicting the least recently used key
+// when it holds more than its max size. It's safe for concurrent use.
+type LRUBackend struct {
+	mu      sync.Mutex
+	maxSize int
+	order   *list.List
+	entries map[string]*list.Element
+}
+
+// lruEntry is a cached value of an LRUBackend.
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+// NewLRUBackend returns an LRUBackend that holds at most maxSize keys,
+// if maxSize is not positive then DefaultMaxSize is used.
+func NewLRUBackend(maxSize int) *LRUBackend {
+	if maxSize <= 0 {
+		maxSize = DefaultMaxSize
+	}
+
+	return &LRUBackend{maxSize: maxSize, order: list.New(), entries: make(map[string]*list.Element)}
+}
+
+// Get returns the value of key, or ErrCacheMiss if key is not in the cache or expired.
+func (b *LRUBackend) Get(key string) ([]byte, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	element, ok := b.entries[key]
+	if !ok {
+		return nil, ErrCacheMiss
+	}
+
+	entry := element.Value.(*lruEntry)
+	if !time.Now().Before(entry.expiresAt) {
+		b.remove(element)
+		return nil, ErrCacheMiss
+	}
+
+	b.order.MoveToFront(element)
+	return entry.value, nil
+}
+
+// Set sets the value of key to value, to be evicted after ttl.
+func (b *LRUBackend) Set(key string, value []byte, ttl time.Duration) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	entry := &lruEntry{key: key, value: value, expiresAt: time.Now().Add(ttl)}
+	if element, ok := b.entries[key]; ok {
+		element.Value = entry
+		b.order.MoveToFront(element)
+		return nil
+	}
+
+	b.entries[key] = b.order.PushFront(entry)
+	if b.order.Len() > b.maxSize {
+		b.remove(b.order.Back())
+	}
+
+	return nil
+}
+
+// Delete removes keys from the cache.
+func (b *LRUBackend) Delete(keys ...string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for _, key := range keys {
+		if element, ok := b.entries[key]; ok {
+			b.remove(element)
+		}
+	}
+
+	return nil
+}
+
+// DeleteMatching removes all keys that match the glob pattern from the cache.
+func (b *LRUBackend) DeleteMatching(pattern string) error {
+	matcher, err := globRegexp(pattern)
+	if err != nil {
+		return err
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for key, element := range b.entries {
+		if matcher.MatchString(key) {
+			b.remove(element)
+		}
+	}
+
+	return nil
+}
+
+// Len returns the number of keys in the cache, including expired keys that weren't evicted yet.
+func (b *LRUBackend) Len() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.order.Len()
+}
+
+// remove removes element from the cache, b.mu must be held.
+func (b *LRUBackend) remove(element *list.Element) {
+	b.order.Remove(element)
+	delete(b.entries, element.Value.(*lruEntry).key)
+}
+
+// globRegexp returns a regexp that matches the same strings as the glob pattern, which may
+// contain * and ? wildcards and backslash escapes, the patterns of CachingStore's invalidations.
+func globRegexp(pattern string) (*regexp.Regexp, error) {
+	var expr strings.Builder
+	expr.WriteString(`(?s)^`)
+	for i := 0; i < len(pattern); i++ {
+		switch c := pattern[i]; c {
+		case '*':
+			expr.WriteString(`.*`)
+		case '?':
+			expr.WriteString(`.`)
+		case '\\':
+			if i+1 < len(pattern) {
+				i++
+			}
+
+			expr.WriteString(regexp.QuoteMeta(pattern[i : i+1]))
+		default:
+			expr.WriteString(regexp.QuoteMeta(string(c)))
+		}
+	}
+
+	expr.WriteString(`$`)
+	return regexp.Compile(expr.String())
+}
@@ -0,0 +1,64 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestLRUBackendEvictsLeastRecentlyUsed(t *testing.T) {
+	backend := NewLRUBackend(2)
+	backend.Set("a", []byte("a"), time.Minute)
+	backend.Set("b", []byte("b"), time.Minute)
+
+	// Reading a makes b the least recently used key.
+	if _, err := backend.Get("a"); err != nil {
+		t.Fatalf("Get(a) = %v, want nil", err)
+	}
+
+	backend.Set("c", []byte("c"), time.Minute)
+
+	if _, err := backend.Get("b"); err != ErrCacheMiss {
+		t.Errorf("Get(b) = %v, want %v", err, ErrCacheMiss)
+	}
+
+	for _, key := range []string{"a", "c"} {
+		if _, err := backend.Get(key); err != nil {
+			t.Errorf("Get(%s) = %v, want nil", key, err)
+		}
+	}
+}
+
+func TestLRUBackendExpires(t *testing.T) {
+	backend := NewLRUBackend(0)
+	backend.Set("key", []byte("value"), time.Millisecond)
+	time.Sleep(5 * time.Millisecond)
+
+	if _, err := backend.Get("key"); err != ErrCacheMiss {
+		t.Errorf("Get() of an expired key = %v, want %v", err, ErrCacheMiss)
+	}
+
+	if backend.Len() != 0 {
+		t.Errorf("Len() = %d, want 0", backend.Len())
+	}
+}
+
+func TestLRUBackendDeleteMatching(t *testing.T) {
+	backend := NewLRUBackend(0)
+	fileKey := permissionKey(context.Background(), "f*le", "user")
+	otherKey := permissionKey(context.Background(), "file", "user")
+	backend.Set(fileKey, []byte("value"), time.Minute)
+	backend.Set(otherKey, []byte("value"), time.Minute)
+
+	if err := backend.DeleteMatching(keyPrefix + ":" + escapePattern(escapeKey("f*le")) + ":*"); err != nil {
+		t.Fatalf("DeleteMatching() = %v, want nil", err)
+	}
+
+	if _, err := backend.Get(fileKey); err != ErrCacheMiss {
+		t.Errorf("Get(%s) = %v, want %v", fileKey, err, ErrCacheMiss)
+	}
+
+	if _, err := backend.Get(otherKey); err != nil {
+		t.Errorf("Get(%s) = %v, want nil", otherKey, err)
+	}
+}
@@ -0,0 +1,228 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/proto"
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+)
+
+// cachedPermission is a permission read from the cache. It holds the permission as the wrapped
+// store marshaled it with MarshalProto, so the cache doesn't depend on the store's permission type.
+type cachedPermission struct {
+	object pb.PermissionObject
+}
+
+// encodePermission returns permission encoded as the protobuf of its pb.PermissionObject, to be
+// decoded by decodePermission, or nil and the error that occurred.
+func encodePermission(permission service.Permission) ([]byte, error) {
+	var object pb.PermissionObject
+	if err := permission.MarshalProto(&object); err != nil {
+		return nil, err
+	}
+
+	return proto.Marshal(&object)
+}
+
+// decodePermission returns the permission that value, encoded by encodePermission, holds,
+// or nil and the error that occurred.
+func decodePermission(value []byte) (service.Permission, error) {
+	permission := &cachedPermission{}
+	if err := proto.Unmarshal(value, &permission.object); err != nil {
+		return nil, err
+	}
+
+	return permission, nil
+}
+
+// GetID returns the ID of p.
+func (p cachedPermission) GetID() string {
+	return p.object.GetId()
+}
+
+// SetID sets the ID of p to id.
+func (p *cachedPermission) SetID(id string) error {
+	p.object.Id = id
+	return nil
+}
+
+// GetFileID returns the fileID of p.
+func (p cachedPermission) GetFileID() string {
+	return p.object.GetFileID()
+}
+
+// SetFileID sets the fileID of p to fileID.
+func (p *cachedPermission) SetFileID(fileID string) error {
+	if fileID == "" {
+		return fmt.Errorf("FileID is required")
+	}
+
+	p.object.FileID = fileID
+	return nil
+}
+
+// GetUserID returns the userID of p.
+func (p cachedPermission) GetUserID() string {
+	return p.object.GetUserID()
+}
+
+// SetUserID sets the userID of p to userID.
+func (p *cachedPermission) SetUserID(userID string) error {
+	if userID == "" {
+		return fmt.Errorf("UserID is required")
+	}
+
+	p.object.UserID = userID
+	return nil
+}
+
+// GetRole returns the role of p.
+func (p cachedPermission) GetRole() pb.Role {
+	return p.object.GetRole()
+}
+
+// SetRole sets the role of p to role.
+func (p *cachedPermission) SetRole(role pb.Role) error {
+	if err := service.ValidateRole(role); err != nil {
+		return err
+	}
+
+	p.object.Role = role
+	return nil
+}
+
+// GetCreator returns the creator of p.
+func (p cachedPermission) GetCreator() string {
+	return p.object.GetCreator()
+}
+
+// SetCreator sets the creator of p to creator.
+func (p *cachedPermission) SetCreator(creator string) error {
+	if creator == "" {
+		return fmt.Errorf("Creator is required")
+	}
+
+	p.object.Creator = creator
+	return nil
+}
+
+// GetExpiresAt returns the time p expires at to the second, or the zero time if p never expires.
+func (p cachedPermission) GetExpiresAt() time.Time {
+	if p.object.GetExpiresAt() == 0 {
+		return time.Time{}
+	}
+
+	return time.Unix(p.object.GetExpiresAt(), 0)
+}
+
+// SetExpiresAt sets the time p expires at to expiresAt, the zero time means p never expires.
+func (p *cachedPermission) SetExpiresAt(expiresAt time.Time) error {
+	p.object.ExpiresAt = 0
+	if !expiresAt.IsZero() {
+		p.object.ExpiresAt = expiresAt.Unix()
+	}
+
+	return nil
+}
+
+// GetVersion returns the version of p.
+func (p cachedPermission) GetVersion() int64 {
+	return p.object.GetVersion()
+}
+
+// SetVersion sets the version of p to version.
+func (p *cachedPermission) SetVersion(version int64) error {
+	if version < 0 {
+		return fmt.Errorf("Version must not be negative")
+	}
+
+	p.object.Version = version
+	return nil
+}
+
+// GetMetadata returns the metadata of p.
+func (p cachedPermission) GetMetadata() map[string]string {
+	return p.object.GetMetadata()
+}
+
+// SetMetadata sets the metadata of p to metadata, which must be at most service.MaxMetadataSize bytes.
+func (p *cachedPermission) SetMetadata(metadata map[string]string) error {
+	if err := service.ValidateMetadata(metadata); err != nil {
+		return err
+	}
+
+	p.object.Metadata = metadata
+	return nil
+}
+
+// GetReason returns the reason of p.
+func (p cachedPermission) GetReason() string {
+	return p.object.GetReason()
+}
+
+// SetReason sets the reason of p to reason, which must be at most service.MaxReasonLength characters.
+func (p *cachedPermission) SetReason(reason string) error {
+	if err := service.ValidateReason(reason); err != nil {
+		return err
+	}
+
+	p.object.Reason = reason
+	return nil
+}
+
+// GetGrantedBy returns the actor that granted p, or an empty string if it's unknown.
+func (p cachedPermission) GetGrantedBy() string {
+	return p.object.GetGrantedBy()
+}
+
+// GetSubjectType returns the subject type of p, or service.SubjectTypeUser if it has none.
+func (p cachedPermission) GetSubjectType() string {
+	if p.object.GetSubjectType() == "" {
+		return service.SubjectTypeUser
+	}
+
+	return p.object.GetSubjectType()
+}
+
+// SetSubjectType sets the subject type of p to subjectType, which must be empty,
+// service.SubjectTypeUser or service.SubjectTypeGroup.
+func (p *cachedPermission) SetSubjectType(subjectType string) error {
+	if err := service.ValidateSubjectType(subjectType); err != nil {
+		return err
+	}
+
+	p.object.SubjectType = subjectType
+	return nil
+}
+
+// GetDeny returns whether p denies access.
+func (p cachedPermission) GetDeny() bool {
+	return p.object.GetDeny()
+}
+
+// SetDeny sets whether p denies access to deny.
+func (p *cachedPermission) SetDeny(deny bool) error {
+	p.object.Deny = deny
+	return nil
+}
+
+// MarshalProto marshals p into a permission, the same way the store it was cached from did.
+func (p cachedPermission) MarshalProto(permission *pb.PermissionObject) error {
+	permission.Id = p.object.GetId()
+	permission.FileID = p.object.GetFileID()
+	permission.UserID = p.object.GetUserID()
+	permission.Role = p.object.GetRole()
+	permission.Creator = p.object.GetCreator()
+	permission.ExpiresAt = p.object.GetExpiresAt()
+	permission.Version = p.object.GetVersion()
+	permission.CreatedAt = p.object.GetCreatedAt()
+	permission.UpdatedAt = p.object.GetUpdatedAt()
+	permission.Metadata = p.object.GetMetadata()
+	permission.Reason = p.object.GetReason()
+	permission.GrantedBy = p.object.GetGrantedBy()
+	permission.SubjectType = p.GetSubjectType()
+	permission.Deny = p.object.GetDeny()
+	return nil
+}
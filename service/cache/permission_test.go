@@ -0,0 +1,56 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/memory"
+)
+
+func TestDecodeEncodedPermission(t *testing.T) {
+	permission := &memory.Permission{
+		ID:          "id",
+		FileID:      "file",
+		UserID:      "team",
+		Role:        pb.Role_WRITE,
+		Creator:     "creator",
+		ExpiresAt:   time.Unix(2000, 0),
+		Version:     3,
+		Metadata:    map[string]string{"source": "sync"},
+		Reason:      "shared",
+		GrantedBy:   "admin",
+		SubjectType: service.SubjectTypeGroup,
+		Deny:        true,
+	}
+
+	value, err := encodePermission(permission)
+	if err != nil {
+		t.Fatalf("encodePermission() = %v, want nil", err)
+	}
+
+	decoded, err := decodePermission(value)
+	if err != nil {
+		t.Fatalf("decodePermission() = %v, want nil", err)
+	}
+
+	var want, got pb.PermissionObject
+	if err := permission.MarshalProto(&want); err != nil {
+		t.Fatalf("MarshalProto() = %v, want nil", err)
+	}
+
+	if err := decoded.MarshalProto(&got); err != nil {
+		t.Fatalf("MarshalProto() of the decoded permission = %v, want nil", err)
+	}
+
+	if got.String() != want.String() {
+		t.Errorf("decodePermission() = %v, want %v", got.String(), want.String())
+	}
+
+	expiresAt := decoded.GetExpiresAt()
+	if !expiresAt.Equal(permission.GetExpiresAt()) || decoded.GetSubjectType() != service.SubjectTypeGroup {
+		t.Errorf("decodePermission() expires at %v of subject type %s, want %v of %s",
+			expiresAt, decoded.GetSubjectType(), permission.GetExpiresAt(), service.SubjectTypeGroup)
+	}
+}
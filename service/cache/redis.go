@@ -0,0 +1,67 @@
+package cache
+
+import (
+	"time"
+
+	"github.com/go-redis/redis"
+)
+
+// scanCount is the number of keys requested from redis in each SCAN iteration.
+const scanCount = 100
+
+// RedisBackend is a Backend that caches in redis.
+type RedisBackend struct {
+	client redis.Cmdable
+}
+
+// NewRedisBackend returns a RedisBackend that caches using client.
+func NewRedisBackend(client redis.Cmdable) RedisBackend {
+	return RedisBackend{client: client}
+}
+
+// Get returns the value of key, or ErrCacheMiss if key is not in the cache.
+func (b RedisBackend) Get(key string) ([]byte, error) {
+	value, err := b.client.Get(key).Bytes()
+	if err == redis.Nil {
+		return nil, ErrCacheMiss
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return value, nil
+}
+
+// Set sets the value of key to value, to be evicted after ttl.
+func (b RedisBackend) Set(key string, value []byte, ttl time.Duration) error {
+	return b.client.Set(key, value, ttl).Err()
+}
+
+// Delete removes keys from the cache.
+func (b RedisBackend) Delete(keys ...string) error {
+	return b.client.Del(keys...).Err()
+}
+
+// DeleteMatching removes all keys that match the glob pattern from the cache.
+func (b RedisBackend) DeleteMatching(pattern string) error {
+	var cursor uint64
+	for {
+		keys, nextCursor, err := b.client.Scan(cursor, pattern, scanCount).Result()
+		if err != nil {
+			return err
+		}
+
+		if len(keys) > 0 {
+			if err := b.client.Del(keys...).Err(); err != nil {
+				return err
+			}
+		}
+
+		if nextCursor == 0 {
+			return nil
+		}
+
+		cursor = nextCursor
+	}
+}
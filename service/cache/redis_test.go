@@ -0,0 +1,113 @@
+package cache
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis"
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/memory"
+)
+
+// newRedisCachingStore returns a CachingStore over a RedisBackend of a miniredis server and a countingStore
+// with the permission of user to file, the countingStore, and the miniredis server, which the test must close.
+func newRedisCachingStore(t *testing.T) (CachingStore, *countingStore, *miniredis.Miniredis) {
+	t.Helper()
+
+	server, err := miniredis.Run()
+	if err != nil {
+		t.Fatalf("miniredis.Run() = %v, want nil", err)
+	}
+
+	client := redis.NewClient(&redis.Options{Addr: server.Addr()})
+
+	inner := &countingStore{Store: memory.NewMemoryStore()}
+	store := NewCachingStore(inner, NewRedisBackend(client), time.Minute, nil)
+
+	permission := &memory.Permission{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"}
+	if _, err := store.Create(context.Background(), permission); err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	return store, inner, server
+}
+
+func TestRedisSecondGetServedFromCache(t *testing.T) {
+	store, inner, server := newRedisCachingStore(t)
+	defer server.Close()
+
+	filter := service.And(service.ByFile("file"), service.ByUser("user"))
+
+	for i := 0; i < 2; i++ {
+		permission, err := store.Get(context.Background(), filter)
+		if err != nil {
+			t.Fatalf("Get() = %v, want nil", err)
+		}
+
+		if permission.GetRole() != pb.Role_READ {
+			t.Errorf("Get() role = %v, want %v", permission.GetRole(), pb.Role_READ)
+		}
+	}
+
+	if inner.gets != 1 {
+		t.Errorf("inner store Get calls = %d, want 1", inner.gets)
+	}
+
+	key := permissionKey(context.Background(), "file", "user")
+	if !server.Exists(key) {
+		t.Errorf("key %s is not in redis after Get()", key)
+	}
+
+	if ttl := server.TTL(key); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("TTL of %s = %v, want at most %v", key, ttl, time.Minute)
+	}
+}
+
+func TestRedisUpdateInvalidatesCachedPermission(t *testing.T) {
+	store, _, server := newRedisCachingStore(t)
+	defer server.Close()
+
+	filter := service.And(service.ByFile("file"), service.ByUser("user"))
+
+	if _, err := store.Get(context.Background(), filter); err != nil {
+		t.Fatalf("Get() = %v, want nil", err)
+	}
+
+	if _, err := store.UpdateRole(context.Background(), "file", "user", pb.Role_WRITE); err != nil {
+		t.Fatalf("UpdateRole() = %v, want nil", err)
+	}
+
+	if key := permissionKey(context.Background(), "file", "user"); server.Exists(key) {
+		t.Errorf("key %s is still in redis after UpdateRole()", key)
+	}
+
+	permission, err := store.Get(context.Background(), filter)
+	if err != nil {
+		t.Fatalf("Get() = %v, want nil", err)
+	}
+
+	if permission.GetRole() != pb.Role_WRITE {
+		t.Errorf("Get() role after UpdateRole() = %v, want %v", permission.GetRole(), pb.Role_WRITE)
+	}
+}
+
+func TestRedisOutageFallsThroughToStore(t *testing.T) {
+	store, inner, server := newRedisCachingStore(t)
+	server.Close()
+
+	permission, err := store.Get(context.Background(), service.And(service.ByFile("file"), service.ByUser("user")))
+	if err != nil {
+		t.Fatalf("Get() while redis is down = %v, want nil", err)
+	}
+
+	if permission.GetRole() != pb.Role_READ {
+		t.Errorf("Get() role = %v, want %v", permission.GetRole(), pb.Role_READ)
+	}
+
+	if inner.gets != 1 {
+		t.Errorf("inner store Get calls = %d, want 1", inner.gets)
+	}
+}
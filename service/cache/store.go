@@ -0,0 +1,376 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultTTL is the default time a permission is kept in the cache.
+const DefaultTTL = time.Minute
+
+// keyPrefix is the prefix of all permission cache keys.
+const keyPrefix = "permission"
+
+// ErrCacheMiss is returned by a Backend when a key is not in the cache.
+var ErrCacheMiss = errors.New("cache miss")
+
+// Backend is an interface for the key-value storage the permissions are cached in.
+type Backend interface {
+	// Get returns the value of key, or ErrCacheMiss if key is not in the cache.
+	Get(key string) ([]byte, error)
+
+	// Set sets the value of key to value, to be evicted after ttl.
+	Set(key string, value []byte, ttl time.Duration) error
+
+	// Delete removes keys from the cache.
+	Delete(keys ...string) error
+
+	// DeleteMatching removes all keys that match the glob pattern from the cache.
+	DeleteMatching(pattern string) error
+}
+
+// IDNormalizer normalizes the fileIDs and userIDs permissions are cached by. It must normalize them
+// the same way the wrapped store does, so IDs that identify the same permission share its cache key.
+// A mongodb.IDNormalizer is an IDNormalizer, since the service.FilterField of an ID is named as its BSON field.
+type IDNormalizer interface {
+	// NormalizeID returns the normalized form of id of field, which is service.FilterFieldFileID
+	// or service.FilterFieldUserID.
+	NormalizeID(field string, id string) string
+}
+
+// CachingStore is a Store that caches the permissions read by Get from the Store it wraps,
+// Get calls whose filter is a service.Filter that looks up a single permission by its fileID
+// and userID are cached, any other filter is passed to the wrapped Store as is.
+// Cached permissions are invalidated when they're changed through the CachingStore,
+// errors of the cache Backend are logged and fall through to the wrapped Store.
+type CachingStore struct {
+	inner   service.Store
+	backend Backend
+	ttl     time.Duration
+	logger  *logrus.Logger
+
+	// normalizer normalizes the IDs of the cache keys, the IDs are used as is if it's nil.
+	normalizer IDNormalizer
+}
+
+// NewCachingStore returns a CachingStore that caches inner's permissions in backend for ttl,
+// if ttl is not positive then DefaultTTL is used.
+func NewCachingStore(inner service.Store, backend Backend, ttl time.Duration, logger *logrus.Logger) CachingStore {
+	if ttl <= 0 {
+		ttl = DefaultTTL
+	}
+
+	if logger == nil {
+		logger = logrus.New()
+	}
+
+	return CachingStore{inner: inner, backend: backend, ttl: ttl, logger: logger}
+}
+
+// WithNormalizer returns a copy of s that normalizes the IDs of its cache keys with normalizer,
+// which should be the normalizer of the wrapped store. A nil normalizer uses the IDs as they are.
+func (s CachingStore) WithNormalizer(normalizer IDNormalizer) CachingStore {
+	s.normalizer = normalizer
+	return s
+}
+
+// Get returns the cached permission that matches filter, if it's not cached then it's
+// read from the wrapped store and cached.
+func (s CachingStore) Get(ctx context.Context, filter interface{}) (service.Permission, error) {
+	f, isFilter := filter.(service.Filter)
+	if !isFilter {
+		return s.inner.Get(ctx, filter)
+	}
+
+	fileID, userID, ok := f.FileAndUser()
+	if !ok {
+		return s.inner.Get(ctx, filter)
+	}
+
+	key := s.cacheKey(ctx, fileID, userID)
+	cached, err := s.backend.Get(key)
+	if err == nil {
+		var permission service.Permission
+		permission, err = decodePermission(cached)
+		if err == nil {
+			return permission, nil
+		}
+	}
+
+	if err != nil && err != ErrCacheMiss {
+		s.logger.Warnf("failed reading permission %s from cache: %v", key, err)
+	}
+
+	permission, err := s.inner.Get(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	s.cache(key, permission)
+	return permission, nil
+}
+
+// GetAll returns the permissions that match filter from the wrapped store.
+func (s CachingStore) GetAll(ctx context.Context, filter interface{}) ([]service.Permission, error) {
+	return s.inner.GetAll(ctx, filter)
+}
+
+// GetAllSorted returns the permissions that match filter sorted by sortBy from the wrapped store.
+func (s CachingStore) GetAllSorted(
+	ctx context.Context,
+	filter interface{},
+	sortBy service.SortBy,
+) ([]service.Permission, error) {
+	return s.inner.GetAllSorted(ctx, filter, sortBy)
+}
+
+// GetAllCursor returns an iterator of the permissions that match filter from the wrapped store.
+func (s CachingStore) GetAllCursor(ctx context.Context, filter interface{}) (service.PermissionIterator, error) {
+	return s.inner.GetAllCursor(ctx, filter)
+}
+
+// GetAllPaged returns a page of the permissions that match filter from the wrapped store.
+func (s CachingStore) GetAllPaged(
+	ctx context.Context,
+	filter interface{},
+	pageSize int64,
+	pageToken string,
+) ([]service.Permission, string, error) {
+	return s.inner.GetAllPaged(ctx, filter, pageSize, pageToken)
+}
+
+// Count returns the number of permissions that match filter from the wrapped store.
+func (s CachingStore) Count(ctx context.Context, filter interface{}) (int64, error) {
+	return s.inner.Count(ctx, filter)
+}
+
+// Exists returns whether userID has a permission to fileID from the wrapped store, it's not
+// answered from the cache since it's already cheaper than reading the permission.
+func (s CachingStore) Exists(ctx context.Context, fileID string, userID string) (bool, error) {
+	return s.inner.Exists(ctx, fileID, userID)
+}
+
+// ExistsMatching returns whether any permission matches filter from the wrapped store,
+// it's not answered from the cache for the same reason as Exists.
+func (s CachingStore) ExistsMatching(ctx context.Context, filter interface{}) (bool, error) {
+	return s.inner.ExistsMatching(ctx, filter)
+}
+
+// Create creates permission in the wrapped store and invalidates its cached value.
+func (s CachingStore) Create(ctx context.Context, permission service.Permission) (service.Permission, error) {
+	created, err := s.inner.Create(ctx, permission)
+	s.invalidate(s.cacheKey(ctx, permission.GetFileID(), permission.GetUserID()))
+
+	return created, err
+}
+
+// CreateIfAbsent creates permission in the wrapped store if it's absent and invalidates its cached value.
+func (s CachingStore) CreateIfAbsent(
+	ctx context.Context,
+	permission service.Permission,
+) (service.Permission, error) {
+	created, err := s.inner.CreateIfAbsent(ctx, permission)
+	s.invalidate(s.cacheKey(ctx, permission.GetFileID(), permission.GetUserID()))
+
+	return created, err
+}
+
+// CreateMany creates permissions in the wrapped store and invalidates their cached values.
+func (s CachingStore) CreateMany(
+	ctx context.Context,
+	permissions []service.Permission,
+) ([]service.Permission, error) {
+	created, err := s.inner.CreateMany(ctx, permissions)
+	for _, permission := range permissions {
+		s.invalidate(s.cacheKey(ctx, permission.GetFileID(), permission.GetUserID()))
+	}
+
+	return created, err
+}
+
+// UpdateRole updates the role of the permission in the wrapped store and invalidates its cached value.
+func (s CachingStore) UpdateRole(
+	ctx context.Context,
+	fileID string,
+	userID string,
+	role pb.Role,
+) (service.Permission, error) {
+	updated, err := s.inner.UpdateRole(ctx, fileID, userID, role)
+	s.invalidate(s.cacheKey(ctx, fileID, userID))
+
+	return updated, err
+}
+
+// UpdateRoleIfVersion updates the role of the permission in the wrapped store if its version is
+// still expectedVersion, and invalidates its cached value.
+func (s CachingStore) UpdateRoleIfVersion(
+	ctx context.Context,
+	fileID string,
+	userID string,
+	role pb.Role,
+	expectedVersion int64,
+) (service.Permission, error) {
+	updated, err := s.inner.UpdateRoleIfVersion(ctx, fileID, userID, role, expectedVersion)
+	s.invalidate(s.cacheKey(ctx, fileID, userID))
+
+	return updated, err
+}
+
+// Delete deletes the permission that matches filter from the wrapped store and invalidates its cached value.
+func (s CachingStore) Delete(ctx context.Context, filter interface{}) (service.Permission, error) {
+	deleted, err := s.inner.Delete(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidate(s.cacheKey(ctx, deleted.GetFileID(), deleted.GetUserID()))
+	return deleted, nil
+}
+
+// DeleteMany deletes the permissions that match filter from the wrapped store and invalidates all the cached
+// permissions of the tenant of ctx, since which of them were deleted isn't known.
+func (s CachingStore) DeleteMany(ctx context.Context, filter interface{}) (int64, error) {
+	deleted, err := s.inner.DeleteMany(ctx, filter)
+	pattern := fmt.Sprintf("%s:*", escapePattern(tenantKeyPrefix(ctx)))
+	if err := s.backend.DeleteMatching(pattern); err != nil {
+		s.logger.Warnf("failed invalidating cached permissions: %v", err)
+	}
+
+	return deleted, err
+}
+
+// DeleteAllByFileID deletes the permissions of fileID from the wrapped store and invalidates their cached values.
+func (s CachingStore) DeleteAllByFileID(ctx context.Context, fileID string) (int64, error) {
+	deleted, err := s.inner.DeleteAllByFileID(ctx, fileID)
+	filePattern := escapePattern(escapeKey(s.normalizeID(service.FilterFieldFileID, fileID)))
+	pattern := fmt.Sprintf("%s:%s:*", escapePattern(tenantKeyPrefix(ctx)), filePattern)
+	if err := s.backend.DeleteMatching(pattern); err != nil {
+		s.logger.Warnf("failed invalidating cached permissions of file %s: %v", fileID, err)
+	}
+
+	return deleted, err
+}
+
+// DeleteAllByUserID deletes the permissions of userID from the wrapped store and invalidates their cached values.
+func (s CachingStore) DeleteAllByUserID(ctx context.Context, userID string) (int64, error) {
+	deleted, err := s.inner.DeleteAllByUserID(ctx, userID)
+	userPattern := escapePattern(escapeKey(s.normalizeID(service.FilterFieldUserID, userID)))
+	pattern := fmt.Sprintf("%s:*:%s", escapePattern(tenantKeyPrefix(ctx)), userPattern)
+	if err := s.backend.DeleteMatching(pattern); err != nil {
+		s.logger.Warnf("failed invalidating cached permissions of user %s: %v", userID, err)
+	}
+
+	return deleted, err
+}
+
+// TransferOwnership transfers the ownership of fileID in the wrapped store and invalidates
+// the cached values of both users' permissions.
+func (s CachingStore) TransferOwnership(
+	ctx context.Context,
+	fileID string,
+	fromUserID string,
+	toUserID string,
+) error {
+	err := s.inner.TransferOwnership(ctx, fileID, fromUserID, toUserID)
+	s.invalidate(s.cacheKey(ctx, fileID, fromUserID))
+	s.invalidate(s.cacheKey(ctx, fileID, toUserID))
+
+	return err
+}
+
+// HealthCheck runs the wrapped store's HealthCheck, the cache's health doesn't affect
+// the result since the store is usable without it.
+func (s CachingStore) HealthCheck(ctx context.Context) (service.HealthResult, error) {
+	return s.inner.HealthCheck(ctx)
+}
+
+// HealthStatus runs the wrapped store's HealthStatus, the cache's health doesn't affect
+// the result for the same reason as HealthCheck.
+func (s CachingStore) HealthStatus(ctx context.Context) (service.HealthStatus, error) {
+	return s.inner.HealthStatus(ctx)
+}
+
+// cache stores permission in the cache under key, logging any error.
+func (s CachingStore) cache(key string, permission service.Permission) {
+	// Don't keep a permission cached after it expires.
+	ttl := s.ttl
+	if expiresAt := permission.GetExpiresAt(); !expiresAt.IsZero() && time.Until(expiresAt) < ttl {
+		ttl = time.Until(expiresAt)
+	}
+
+	if ttl <= 0 {
+		return
+	}
+
+	value, err := encodePermission(permission)
+	if err != nil {
+		s.logger.Warnf("failed caching permission %s: %v", key, err)
+		return
+	}
+
+	if err := s.backend.Set(key, value, ttl); err != nil {
+		s.logger.Warnf("failed caching permission %s: %v", key, err)
+	}
+}
+
+// invalidate removes key from the cache, logging any error.
+func (s CachingStore) invalidate(key string) {
+	if err := s.backend.Delete(key); err != nil {
+		s.logger.Warnf("failed invalidating cached permission %s: %v", key, err)
+	}
+}
+
+// cacheKey returns the permissionKey of the permission of userID to fileID of the tenant of ctx,
+// after normalizing fileID and userID with s.normalizer.
+func (s CachingStore) cacheKey(ctx context.Context, fileID string, userID string) string {
+	return permissionKey(ctx, s.normalizeID(service.FilterFieldFileID, fileID),
+		s.normalizeID(service.FilterFieldUserID, userID))
+}
+
+// normalizeID returns id of field normalized by s.normalizer, or id as is if s has no normalizer.
+func (s CachingStore) normalizeID(field service.FilterField, id string) string {
+	if s.normalizer == nil {
+		return id
+	}
+
+	return s.normalizer.NormalizeID(string(field), id)
+}
+
+// permissionKey returns the cache key of the permission of userID to fileID of the tenant of ctx.
+func permissionKey(ctx context.Context, fileID string, userID string) string {
+	return fmt.Sprintf("%s:%s:%s", tenantKeyPrefix(ctx), escapeKey(fileID), escapeKey(userID))
+}
+
+// tenantKeyPrefix returns the prefix of the cache keys of the permissions of the tenant of ctx,
+// or keyPrefix if ctx carries no tenant, so tenants never share cached permissions.
+func tenantKeyPrefix(ctx context.Context) string {
+	tenantID := service.TenantIDFromContext(ctx)
+	if tenantID == "" {
+		return keyPrefix
+	}
+
+	return fmt.Sprintf("%s@%s", keyPrefix, escapeKey(tenantID))
+}
+
+// keyEscaper escapes the separator of the key's parts so different IDs can't map to the same key.
+var keyEscaper = strings.NewReplacer(`\`, `\\`, `:`, `\:`)
+
+// escapeKey escapes id to be used as a part of a cache key.
+func escapeKey(id string) string {
+	return keyEscaper.Replace(id)
+}
+
+// patternEscaper escapes the special characters of glob patterns.
+var patternEscaper = strings.NewReplacer(`\`, `\\`, `*`, `\*`, `?`, `\?`, `[`, `\[`, `]`, `\]`)
+
+// escapePattern escapes keyPart to be matched literally in a glob pattern.
+func escapePattern(keyPart string) string {
+	return patternEscaper.Replace(keyPart)
+}
@@ -0,0 +1,157 @@
+package cache
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/memory"
+)
+
+// countingStore is a Store that counts the Get calls that reach it.
+type countingStore struct {
+	service.Store
+	gets int
+}
+
+// Get counts the call and returns the inner store's Get.
+func (s *countingStore) Get(ctx context.Context, filter interface{}) (service.Permission, error) {
+	s.gets++
+	return s.Store.Get(ctx, filter)
+}
+
+// newCountingCachingStore returns a CachingStore over an LRUBackend of a countingStore with
+// the permission of user to file, and the countingStore.
+func newCountingCachingStore(t *testing.T) (CachingStore, *countingStore) {
+	t.Helper()
+
+	inner := &countingStore{Store: memory.NewMemoryStore()}
+	store := NewCachingStore(inner, NewLRUBackend(0), time.Minute, nil)
+
+	permission := &memory.Permission{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"}
+	if _, err := store.Create(context.Background(), permission); err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	return store, inner
+}
+
+func TestSecondGetServedFromCache(t *testing.T) {
+	store, inner := newCountingCachingStore(t)
+
+	for i := 0; i < 2; i++ {
+		permission, err := store.Get(context.Background(), service.And(service.ByFile("file"), service.ByUser("user")))
+		if err != nil {
+			t.Fatalf("Get() = %v, want nil", err)
+		}
+
+		if permission.GetRole() != pb.Role_READ {
+			t.Errorf("Get() role = %v, want %v", permission.GetRole(), pb.Role_READ)
+		}
+	}
+
+	if inner.gets != 1 {
+		t.Errorf("inner store Get calls = %d, want 1", inner.gets)
+	}
+}
+
+func TestExists(t *testing.T) {
+	store, _ := newCountingCachingStore(t)
+
+	for _, test := range []struct {
+		userID string
+		want   bool
+	}{
+		{userID: "user", want: true},
+		{userID: "other", want: false},
+	} {
+		exists, err := store.Exists(context.Background(), "file", test.userID)
+		if err != nil {
+			t.Fatalf("Exists(%s) = %v, want nil", test.userID, err)
+		}
+
+		if exists != test.want {
+			t.Errorf("Exists(%s) = %t, want %t", test.userID, exists, test.want)
+		}
+	}
+}
+
+func TestDeleteInvalidatesCachedPermission(t *testing.T) {
+	store, inner := newCountingCachingStore(t)
+	filter := service.And(service.ByFile("file"), service.ByUser("user"))
+
+	if _, err := store.Get(context.Background(), filter); err != nil {
+		t.Fatalf("Get() = %v, want nil", err)
+	}
+
+	if _, err := store.Delete(context.Background(), filter); err != nil {
+		t.Fatalf("Delete() = %v, want nil", err)
+	}
+
+	if _, err := store.Get(context.Background(), filter); err == nil {
+		t.Errorf("Get() of a deleted permission returned a nil error")
+	}
+
+	if inner.gets != 2 {
+		t.Errorf("inner store Get calls = %d, want 2", inner.gets)
+	}
+}
+
+func TestTenantsDontShareCachedPermissions(t *testing.T) {
+	store, inner := newCountingCachingStore(t)
+	filter := service.And(service.ByFile("file"), service.ByUser("user"))
+
+	for _, tenantID := range []string{"tenant", "other-tenant"} {
+		ctx := service.WithTenantID(context.Background(), tenantID)
+		if _, err := store.Get(ctx, filter); err != nil {
+			t.Fatalf("Get() of tenant %s = %v, want nil", tenantID, err)
+		}
+	}
+
+	if inner.gets != 2 {
+		t.Errorf("inner store Get calls = %d, want 2", inner.gets)
+	}
+}
+
+// lowercaseNormalizer is an IDNormalizer that lowercases IDs.
+type lowercaseNormalizer struct{}
+
+// NormalizeID returns id in lowercase.
+func (lowercaseNormalizer) NormalizeID(field string, id string) string {
+	return strings.ToLower(id)
+}
+
+func TestNormalizedIDsShareCachedPermission(t *testing.T) {
+	store, inner := newCountingCachingStore(t)
+	store = store.WithNormalizer(lowercaseNormalizer{})
+	filter := service.And(service.ByFile("file"), service.ByUser("user"))
+
+	if _, err := store.Get(context.Background(), filter); err != nil {
+		t.Fatalf("Get() = %v, want nil", err)
+	}
+
+	cased := service.And(service.ByFile("File"), service.ByUser("USER"))
+	permission, err := store.Get(context.Background(), cased)
+	if err != nil {
+		t.Fatalf("Get() of differently cased IDs = %v, want nil", err)
+	}
+
+	if permission.GetFileID() != "file" || permission.GetUserID() != "user" {
+		t.Errorf("Get() of differently cased IDs = %v, want the permission of user to file", permission)
+	}
+
+	if inner.gets != 1 {
+		t.Errorf("inner store Get calls = %d, want 1", inner.gets)
+	}
+
+	if _, err := store.Delete(context.Background(), filter); err != nil {
+		t.Fatalf("Delete() = %v, want nil", err)
+	}
+
+	if _, err := store.Get(context.Background(), cased); err == nil {
+		t.Errorf("Get() of differently cased IDs after Delete() returned a nil error")
+	}
+}
@@ -2,6 +2,7 @@ package service
 
 import (
 	"context"
+	"time"
 
 	pb "github.com/meateam/permission-service/proto"
 )
@@ -13,11 +14,51 @@ type Controller interface {
 		fileID string,
 		userID string,
 		role pb.Role,
-		creator string) (Permission, error)
+		creator string,
+		expiresAt time.Time,
+		metadata map[string]string,
+		reason string,
+		subjectType string,
+		deny bool) (Permission, error)
+	CreatePermissionIfAbsent(
+		ctx context.Context,
+		fileID string,
+		userID string,
+		role pb.Role,
+		creator string,
+		expiresAt time.Time,
+		metadata map[string]string,
+		reason string,
+		subjectType string,
+		deny bool) (Permission, error)
+	CreatePermissions(ctx context.Context, permissions []*pb.CreatePermissionRequest) ([]Permission, error)
 	DeletePermission(ctx context.Context, fileID string, userID string) (Permission, error)
-	GetFilePermissions(ctx context.Context, fileID string) ([]*pb.GetFilePermissionsResponse_UserRole, error)
+	UpdatePermission(
+		ctx context.Context,
+		fileID string,
+		userID string,
+		role pb.Role,
+		expectedVersion int64) (Permission, error)
+	GetFilePermissions(
+		ctx context.Context,
+		fileID string,
+		roles []pb.Role,
+		sortBy SortBy,
+		pageSize int64,
+		pageToken string) ([]*pb.GetFilePermissionsResponse_UserRole, string, error)
+	StreamFilePermissions(ctx context.Context, fileID string, send func(Permission) error) error
+	WatchFilePermissions(ctx context.Context, fileID string, send func(PermissionEvent) error) error
 	GetByFileAndUser(ctx context.Context, fileID string, userID string) (Permission, error)
-	GetUserPermissions(ctx context.Context, userID string) ([]*pb.GetUserPermissionsResponse_FileRole, error)
+	IsPermitted(ctx context.Context, fileID string, userID string, role pb.Role) (bool, error)
+	GetUserPermissions(
+		ctx context.Context,
+		userID string,
+		pageSize int64,
+		pageToken string) ([]*pb.GetUserPermissionsResponse_FileRole, string, error)
 	DeleteFilePermissions(ctx context.Context, fileID string) ([]*pb.PermissionObject, error)
-	HealthCheck(ctx context.Context) (bool, error)
+	DeleteUserPermissions(ctx context.Context, userID string) ([]*pb.PermissionObject, error)
+	CountFilePermissions(ctx context.Context, fileID string) (int64, error)
+	TransferOwnership(ctx context.Context, fileID string, fromUserID string, toUserID string) error
+	HealthCheck(ctx context.Context) (HealthResult, error)
+	HealthStatus(ctx context.Context) (HealthStatus, error)
 }
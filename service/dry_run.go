@@ -0,0 +1,19 @@
+package service
+
+import "context"
+
+// dryRunKey is the context key of the dry run flag of a request.
+type dryRunKey struct{}
+
+// WithDryRun returns a copy of ctx that marks the request as a dry run, the stores validate the
+// operations made with it and compute their effects without mutating anything.
+// Only DeleteAllByFileID, DeleteAllByUserID, DeleteMany, ReassignFile and TransferOwnership support dry runs.
+func WithDryRun(ctx context.Context) context.Context {
+	return context.WithValue(ctx, dryRunKey{}, true)
+}
+
+// IsDryRun returns true if ctx marks the request as a dry run.
+func IsDryRun(ctx context.Context) bool {
+	dryRun, _ := ctx.Value(dryRunKey{}).(bool)
+	return dryRun
+}
@@ -0,0 +1,96 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	pb "github.com/meateam/permission-service/proto"
+)
+
+// MaskableFields are the fields of a pb.PermissionObject a field mask can select, by their proto names,
+// which are also the names they're stored by.
+var MaskableFields = []string{
+	"id",
+	"fileID",
+	"userID",
+	"role",
+	"creator",
+	"expiresAt",
+	"version",
+	"createdAt",
+	"updatedAt",
+	"metadata",
+	"reason",
+	"grantedBy",
+	"subjectType",
+	"deny",
+}
+
+// ValidateFieldMask returns an InvalidArgumentError of the first of fields that isn't one of
+// MaskableFields, naming it by its index such as fields[1], or nil if all of fields are valid.
+func ValidateFieldMask(fields []string) error {
+	for i, field := range fields {
+		if !isMaskableField(field) {
+			return InvalidArgumentError(fmt.Sprintf("fields[%d]", i),
+				fmt.Sprintf("%s does not exist, must be one of %s", field, strings.Join(MaskableFields, ", ")))
+		}
+	}
+
+	return nil
+}
+
+// isMaskableField returns true if field is one of MaskableFields.
+func isMaskableField(field string) bool {
+	for _, maskable := range MaskableFields {
+		if field == maskable {
+			return true
+		}
+	}
+
+	return false
+}
+
+// MaskPermissionObject returns a copy of permission with only the fields in fields set, so the fields
+// the caller doesn't need aren't serialized. If fields is empty then permission is returned as is.
+// fields must be valid by ValidateFieldMask, unknown fields are ignored.
+func MaskPermissionObject(permission *pb.PermissionObject, fields []string) *pb.PermissionObject {
+	if len(fields) == 0 {
+		return permission
+	}
+
+	masked := &pb.PermissionObject{}
+	for _, field := range fields {
+		switch field {
+		case "id":
+			masked.Id = permission.GetId()
+		case "fileID":
+			masked.FileID = permission.GetFileID()
+		case "userID":
+			masked.UserID = permission.GetUserID()
+		case "role":
+			masked.Role = permission.GetRole()
+		case "creator":
+			masked.Creator = permission.GetCreator()
+		case "expiresAt":
+			masked.ExpiresAt = permission.GetExpiresAt()
+		case "version":
+			masked.Version = permission.GetVersion()
+		case "createdAt":
+			masked.CreatedAt = permission.GetCreatedAt()
+		case "updatedAt":
+			masked.UpdatedAt = permission.GetUpdatedAt()
+		case "metadata":
+			masked.Metadata = permission.GetMetadata()
+		case "reason":
+			masked.Reason = permission.GetReason()
+		case "grantedBy":
+			masked.GrantedBy = permission.GetGrantedBy()
+		case "subjectType":
+			masked.SubjectType = permission.GetSubjectType()
+		case "deny":
+			masked.Deny = permission.GetDeny()
+		}
+	}
+
+	return masked
+}
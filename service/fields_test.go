@@ -0,0 +1,41 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+)
+
+func TestValidateFieldMask(t *testing.T) {
+	if err := ValidateFieldMask(MaskableFields); err != nil {
+		t.Errorf("ValidateFieldMask(MaskableFields) = %v, want nil", err)
+	}
+
+	violations := fieldViolations(t, ValidateFieldMask([]string{"role", "tenantID"}))
+	if len(violations) != 1 || violations[0].GetField() != "fields[1]" {
+		t.Errorf("ValidateFieldMask() field violations = %v, want a single violation of fields[1]", violations)
+	}
+}
+
+func TestMaskPermissionObject(t *testing.T) {
+	permission := &pb.PermissionObject{
+		Id:       "id",
+		FileID:   "file",
+		UserID:   "user",
+		Role:     pb.Role_WRITE,
+		Creator:  "creator",
+		Version:  3,
+		Metadata: map[string]string{"sharedBy": "link"},
+	}
+
+	if masked := MaskPermissionObject(permission, nil); masked != permission {
+		t.Errorf("MaskPermissionObject() without fields = %v, want %v", masked, permission)
+	}
+
+	masked := MaskPermissionObject(permission, []string{"userID", "role"})
+	want := &pb.PermissionObject{UserID: "user", Role: pb.Role_WRITE}
+	if !reflect.DeepEqual(masked, want) {
+		t.Errorf("MaskPermissionObject() = %v, want %v", masked, want)
+	}
+}
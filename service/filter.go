@@ -0,0 +1,164 @@
+package service
+
+import (
+	pb "github.com/meateam/permission-service/proto"
+)
+
+// FilterField is a field of a permission that a Filter can match.
+type FilterField string
+
+const (
+	// FilterFieldFileID matches the fileID of a permission.
+	FilterFieldFileID FilterField = "fileID"
+
+	// FilterFieldUserID matches the userID of a permission.
+	FilterFieldUserID FilterField = "userID"
+
+	// FilterFieldRole matches the role of a permission.
+	FilterFieldRole FilterField = "role"
+
+	// FilterFieldDeny matches whether a permission denies access.
+	FilterFieldDeny FilterField = "deny"
+)
+
+// FilterCondition matches the permissions whose Field equals any of Values,
+// which are strings for the ID fields, pb.Role values for FilterFieldRole and bools for FilterFieldDeny.
+type FilterCondition struct {
+	Field  FilterField
+	Values []interface{}
+}
+
+// Filter is a backend-agnostic filter of permissions that every Store translates to its native query.
+// It matches the permissions that match all of its conditions, the zero Filter matches every permission.
+// Filters are built with ByFile, ByUser, ByRole, ByRoles and ByDeny and combined with And.
+type Filter struct {
+	conditions []FilterCondition
+}
+
+// ByFile returns a Filter that matches the permissions of fileID.
+func ByFile(fileID string) Filter {
+	return Filter{conditions: []FilterCondition{{Field: FilterFieldFileID, Values: []interface{}{fileID}}}}
+}
+
+// ByUser returns a Filter that matches the permissions of userID.
+func ByUser(userID string) Filter {
+	return Filter{conditions: []FilterCondition{{Field: FilterFieldUserID, Values: []interface{}{userID}}}}
+}
+
+// ByRole returns a Filter that matches the permissions with exactly role.
+func ByRole(role pb.Role) Filter {
+	return ByRoles(role)
+}
+
+// ByRoles returns a Filter that matches the permissions with any of roles,
+// if roles is empty it matches every permission.
+func ByRoles(roles ...pb.Role) Filter {
+	if len(roles) == 0 {
+		return Filter{}
+	}
+
+	values := make([]interface{}, 0, len(roles))
+	for _, role := range roles {
+		values = append(values, role)
+	}
+
+	return Filter{conditions: []FilterCondition{{Field: FilterFieldRole, Values: values}}}
+}
+
+// ByDeny returns a Filter that matches the permissions that deny access if deny is true,
+// otherwise the permissions that grant it.
+func ByDeny(deny bool) Filter {
+	return Filter{conditions: []FilterCondition{{Field: FilterFieldDeny, Values: []interface{}{deny}}}}
+}
+
+// And returns a Filter that matches the permissions that match all of filters.
+func And(filters ...Filter) Filter {
+	var conditions []FilterCondition
+	for _, filter := range filters {
+		conditions = append(conditions, filter.conditions...)
+	}
+
+	return Filter{conditions: conditions}
+}
+
+// Conditions returns a copy of the conditions of f, all of which a permission must match.
+func (f Filter) Conditions() []FilterCondition {
+	conditions := make([]FilterCondition, 0, len(f.conditions))
+	for _, condition := range f.conditions {
+		conditions = append(conditions, FilterCondition{
+			Field:  condition.Field,
+			Values: append([]interface{}(nil), condition.Values...),
+		})
+	}
+
+	return conditions
+}
+
+// IsEmpty returns true if f has no conditions and matches every permission, otherwise returns false.
+func (f Filter) IsEmpty() bool {
+	return len(f.conditions) == 0
+}
+
+// Matches returns true if permission matches all of the conditions of f, otherwise returns false.
+func (f Filter) Matches(permission Permission) bool {
+	for _, condition := range f.conditions {
+		if !condition.matches(permission) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// FileAndUser returns the fileID and userID of f if it matches exactly the permission of a single
+// user to a single file, such as And(ByFile(fileID), ByUser(userID)), otherwise returns ok as false.
+func (f Filter) FileAndUser() (fileID string, userID string, ok bool) {
+	if len(f.conditions) != 2 {
+		return "", "", false
+	}
+
+	var fileOK, userOK bool
+	for _, condition := range f.conditions {
+		if len(condition.Values) != 1 {
+			return "", "", false
+		}
+
+		switch condition.Field {
+		case FilterFieldFileID:
+			fileID, fileOK = condition.Values[0].(string)
+		case FilterFieldUserID:
+			userID, userOK = condition.Values[0].(string)
+		}
+	}
+
+	if !fileOK || !userOK {
+		return "", "", false
+	}
+
+	return fileID, userID, true
+}
+
+// matches returns true if the value of c's field in permission equals any of c's values.
+func (c FilterCondition) matches(permission Permission) bool {
+	var value interface{}
+	switch c.Field {
+	case FilterFieldFileID:
+		value = permission.GetFileID()
+	case FilterFieldUserID:
+		value = permission.GetUserID()
+	case FilterFieldRole:
+		value = permission.GetRole()
+	case FilterFieldDeny:
+		value = permission.GetDeny()
+	default:
+		return false
+	}
+
+	for _, conditionValue := range c.Values {
+		if value == conditionValue {
+			return true
+		}
+	}
+
+	return false
+}
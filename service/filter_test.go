@@ -0,0 +1,46 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+)
+
+func TestFilterConditions(t *testing.T) {
+	filter := And(ByFile("file"), ByUser("user"), ByRoles(pb.Role_READ, pb.Role_WRITE), ByRoles(), ByDeny(false))
+	want := []FilterCondition{
+		{Field: FilterFieldFileID, Values: []interface{}{"file"}},
+		{Field: FilterFieldUserID, Values: []interface{}{"user"}},
+		{Field: FilterFieldRole, Values: []interface{}{pb.Role_READ, pb.Role_WRITE}},
+		{Field: FilterFieldDeny, Values: []interface{}{false}},
+	}
+
+	if got := filter.Conditions(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Conditions() = %+v, want %+v", got, want)
+	}
+
+	if !And(ByRoles(), Filter{}).IsEmpty() {
+		t.Errorf("IsEmpty() of a Filter without conditions = false, want true")
+	}
+}
+
+func TestFilterFileAndUser(t *testing.T) {
+	fileID, userID, ok := And(ByUser("user"), ByFile("file")).FileAndUser()
+	if !ok || fileID != "file" || userID != "user" {
+		t.Errorf("FileAndUser() = %q, %q, %v, want file, user, true", fileID, userID, ok)
+	}
+
+	filters := []Filter{
+		ByFile("file"),
+		And(ByFile("file"), ByRole(pb.Role_READ)),
+		And(ByFile("file"), ByUser("user"), ByRole(pb.Role_READ)),
+		And(ByFile("file"), ByFile("other")),
+	}
+
+	for _, filter := range filters {
+		if _, _, ok := filter.FileAndUser(); ok {
+			t.Errorf("FileAndUser() of %+v = true, want false", filter.Conditions())
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import "time"
+
+// HealthReasonPingTimeout is the reason of a HealthResult of a check whose deadline passed before
+// the database responded, which is reported as unhealthy rather than as an error.
+const HealthReasonPingTimeout = "ping timeout"
+
+// HealthResult is the result of a store's health check.
+type HealthResult struct {
+	// Healthy is true if the store can serve requests.
+	Healthy bool
+
+	// Reason describes why the store is unhealthy, such as HealthReasonPingTimeout,
+	// it's empty if it's healthy.
+	Reason string
+}
+
+// HealthState is the overall health of a store.
+type HealthState int
+
+const (
+	// HealthServing means both the primary and a secondary are reachable.
+	HealthServing HealthState = iota
+
+	// HealthDegraded means only one of the primary and the secondaries is reachable,
+	// so either writes or reads from secondaries fail.
+	HealthDegraded
+
+	// HealthNotServing means neither the primary nor a secondary is reachable.
+	HealthNotServing
+)
+
+// String returns the name of s.
+func (s HealthState) String() string {
+	switch s {
+	case HealthServing:
+		return "SERVING"
+	case HealthDegraded:
+		return "DEGRADED"
+	default:
+		return "NOT_SERVING"
+	}
+}
+
+// HealthStatus is the health of the database members a store depends on.
+type HealthStatus struct {
+	// PrimaryReachable is true if the primary responded, so writes can succeed.
+	PrimaryReachable bool
+
+	// SecondaryReachable is true if a secondary responded, so reads from secondaries can succeed.
+	SecondaryReachable bool
+
+	// Latency is the round trip time of the primary, or of the secondary if the primary is unreachable.
+	Latency time.Duration
+}
+
+// State returns the overall health of s.
+func (s HealthStatus) State() HealthState {
+	switch {
+	case s.PrimaryReachable && s.SecondaryReachable:
+		return HealthServing
+	case s.PrimaryReachable || s.SecondaryReachable:
+		return HealthDegraded
+	default:
+		return HealthNotServing
+	}
+}
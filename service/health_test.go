@@ -0,0 +1,21 @@
+package service
+
+import "testing"
+
+func TestHealthStatusState(t *testing.T) {
+	tests := []struct {
+		status HealthStatus
+		want   HealthState
+	}{
+		{status: HealthStatus{PrimaryReachable: true, SecondaryReachable: true}, want: HealthServing},
+		{status: HealthStatus{PrimaryReachable: true}, want: HealthDegraded},
+		{status: HealthStatus{SecondaryReachable: true}, want: HealthDegraded},
+		{status: HealthStatus{}, want: HealthNotServing},
+	}
+
+	for _, tt := range tests {
+		if got := tt.status.State(); got != tt.want {
+			t.Errorf("%+v.State() = %v, want %v", tt.status, got, tt.want)
+		}
+	}
+}
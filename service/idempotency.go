@@ -0,0 +1,20 @@
+package service
+
+import "context"
+
+// idempotencyKeyKey is the context key of the idempotency key of a request.
+type idempotencyKeyKey struct{}
+
+// WithIdempotencyKey returns a copy of ctx that carries key as the idempotency key of the request,
+// a request that's retried with the same key returns the result of the first one instead of
+// running again. Only Create supports idempotency keys.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyKey{}, key)
+}
+
+// IdempotencyKeyFromContext returns the idempotency key that ctx carries, or an empty string
+// if it carries none.
+func IdempotencyKeyFromContext(ctx context.Context) string {
+	key, _ := ctx.Value(idempotencyKeyKey{}).(string)
+	return key
+}
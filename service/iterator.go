@@ -0,0 +1,73 @@
+package service
+
+import "context"
+
+// PermissionIterator iterates over permissions one at a time, so they don't have to be held
+// in memory all at once. It must be closed once it's no longer used.
+type PermissionIterator interface {
+	// Next advances the iterator to the next permission, returns false when there are no more
+	// permissions or an error occurred, which is then returned by Err.
+	Next(ctx context.Context) bool
+
+	// Permission returns the permission the iterator was advanced to by Next.
+	Permission() Permission
+
+	// Err returns the error that stopped the iteration, or nil if there was none.
+	Err() error
+
+	// Close releases the resources of the iterator.
+	Close(ctx context.Context) error
+}
+
+// ForEach calls fn with each permission of iterator in turn, until all of them were iterated, fn returns
+// an error or ctx is done, so only the current permission is held in memory. The iterator is closed
+// in any case. Returns the error of fn or of the iteration if any occurred.
+func ForEach(ctx context.Context, iterator PermissionIterator, fn func(Permission) error) error {
+	defer iterator.Close(context.Background())
+
+	for iterator.Next(ctx) {
+		if err := fn(iterator.Permission()); err != nil {
+			return err
+		}
+	}
+
+	return iterator.Err()
+}
+
+// sliceIterator is a PermissionIterator of permissions that are already in memory.
+type sliceIterator struct {
+	permissions []Permission
+	current     Permission
+}
+
+// NewSliceIterator returns a PermissionIterator of permissions, for stores that can't iterate lazily.
+func NewSliceIterator(permissions []Permission) PermissionIterator {
+	return &sliceIterator{permissions: permissions}
+}
+
+// Next advances the iterator to the next permission, returns false when there are no more permissions
+// or ctx is done.
+func (i *sliceIterator) Next(ctx context.Context) bool {
+	if len(i.permissions) == 0 || ctx.Err() != nil {
+		return false
+	}
+
+	i.current, i.permissions = i.permissions[0], i.permissions[1:]
+	return true
+}
+
+// Permission returns the permission the iterator was advanced to by Next.
+func (i *sliceIterator) Permission() Permission {
+	return i.current
+}
+
+// Err always returns nil since iterating over a slice can't fail.
+func (i *sliceIterator) Err() error {
+	return nil
+}
+
+// Close drops the remaining permissions.
+func (i *sliceIterator) Close(ctx context.Context) error {
+	i.permissions = nil
+	return nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+// countingIterator is a PermissionIterator that counts the permissions it was advanced to
+// and records whether it was closed.
+type countingIterator struct {
+	PermissionIterator
+	advanced int
+	closed   bool
+}
+
+// Next advances the inner iterator and counts it.
+func (i *countingIterator) Next(ctx context.Context) bool {
+	if !i.PermissionIterator.Next(ctx) {
+		return false
+	}
+
+	i.advanced++
+	return true
+}
+
+// Close records the closing and closes the inner iterator.
+func (i *countingIterator) Close(ctx context.Context) error {
+	i.closed = true
+	return i.PermissionIterator.Close(ctx)
+}
+
+// newCountingIterator returns a countingIterator of count permissions, which are all nil
+// since only their number matters.
+func newCountingIterator(count int) *countingIterator {
+	return &countingIterator{PermissionIterator: NewSliceIterator(make([]Permission, count))}
+}
+
+func TestForEach(t *testing.T) {
+	iterator := newCountingIterator(3)
+
+	calls := 0
+	err := ForEach(context.Background(), iterator, func(permission Permission) error {
+		calls++
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEach() = %v, want nil", err)
+	}
+
+	if calls != 3 {
+		t.Errorf("ForEach() called fn %d times, want 3", calls)
+	}
+
+	if !iterator.closed {
+		t.Errorf("ForEach() didn't close the iterator")
+	}
+}
+
+func TestForEachStopsOnError(t *testing.T) {
+	iterator := newCountingIterator(5)
+	errStop := errors.New("stop")
+
+	calls := 0
+	err := ForEach(context.Background(), iterator, func(permission Permission) error {
+		calls++
+		if calls == 2 {
+			return errStop
+		}
+
+		return nil
+	})
+	if err != errStop {
+		t.Errorf("ForEach() = %v, want %v", err, errStop)
+	}
+
+	if calls != 2 || iterator.advanced != 2 {
+		t.Errorf("ForEach() called fn %d times and advanced %d times, want 2", calls, iterator.advanced)
+	}
+
+	if !iterator.closed {
+		t.Errorf("ForEach() didn't close the iterator after fn failed")
+	}
+}
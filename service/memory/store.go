@@ -0,0 +1,706 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"sync"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// The names of the fields that filters can match, they're the same as the stored field names
+// of mongodb.MongoStore so both stores accept the same filters.
+const (
+	idField      = "_id"
+	fileIDField  = "fileID"
+	userIDField  = "userID"
+	roleField    = "role"
+	creatorField = "creator"
+)
+
+// permissionKey is the unique key of a permission, a user has at most one permission to a file.
+type permissionKey struct {
+	fileID string
+	userID string
+}
+
+// MemoryStore is an in-memory implementation of the Store interface, meant for tests and local development.
+// It accepts service.Filter filters, and the same bson.D and bson.M filters as mongodb.MongoStore,
+// limited to field equality, $in, $and and $or.
+type MemoryStore struct {
+	mu          sync.RWMutex
+	permissions map[permissionKey]*Permission
+}
+
+var _ service.Store = (*MemoryStore)(nil)
+
+// NewMemoryStore returns a new empty store.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{permissions: make(map[permissionKey]*Permission)}
+}
+
+// HealthCheck always reports the store as healthy since it has no external dependencies.
+func (s *MemoryStore) HealthCheck(ctx context.Context) (service.HealthResult, error) {
+	return service.HealthResult{Healthy: true}, nil
+}
+
+// HealthStatus always reports the primary and secondary as reachable since the store
+// has no external dependencies.
+func (s *MemoryStore) HealthStatus(ctx context.Context) (service.HealthStatus, error) {
+	return service.HealthStatus{PrimaryReachable: true, SecondaryReachable: true}, nil
+}
+
+// Create creates a permission of a file to a user,
+// If permission already exists then it's updated to have permission values,
+// If successful returns the permission and a nil error,
+// otherwise returns nil and non-nil error if any occurred.
+func (s *MemoryStore) Create(ctx context.Context, permission service.Permission) (service.Permission, error) {
+	if err := service.ValidatePermission(nil, permission); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return copyPermission(s.upsert(ctx, permission)), nil
+}
+
+// CreateIfAbsent creates a permission of a file to a user the same way Create does, unless the user
+// already has a permission to the file, in which case it's left as is and an AlreadyExists error is
+// returned. An expired permission is absent, and is replaced as if it was inserted.
+// If successful returns the permission and a nil error,
+// otherwise returns nil and non-nil error if any occurred.
+func (s *MemoryStore) CreateIfAbsent(
+	ctx context.Context,
+	permission service.Permission,
+) (service.Permission, error) {
+	if err := service.ValidatePermission(nil, permission); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := permissionKey{fileID: permission.GetFileID(), userID: permission.GetUserID()}
+	if stored, ok := s.permissions[key]; ok && !isExpired(stored) {
+		return nil, status.Errorf(codes.AlreadyExists, "permission of user %s to file %s already exists",
+			key.userID, key.fileID)
+	}
+
+	return copyPermission(s.upsert(ctx, permission)), nil
+}
+
+// CreateMany creates permissions the same way Create does, all of them are validated
+// before any of them is created.
+// If successful returns the created permissions in the order they were given and a nil error,
+// otherwise returns nil and non-nil error if any occurred.
+func (s *MemoryStore) CreateMany(
+	ctx context.Context,
+	permissions []service.Permission,
+) ([]service.Permission, error) {
+	if err := service.ValidatePermissions(nil, permissions); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	created := make([]service.Permission, 0, len(permissions))
+	for _, permission := range permissions {
+		created = append(created, copyPermission(s.upsert(ctx, permission)))
+	}
+
+	return created, nil
+}
+
+// upsert creates permission or updates the existing permission of its user to its file,
+// and returns the stored permission. A created permission is granted by the actor of ctx.
+// s.mu must be held by the caller.
+func (s *MemoryStore) upsert(ctx context.Context, permission service.Permission) *Permission {
+	key := permissionKey{fileID: permission.GetFileID(), userID: permission.GetUserID()}
+	stored, ok := s.permissions[key]
+	if !ok {
+		stored = &Permission{
+			ID:        primitive.NewObjectID().Hex(),
+			FileID:    key.fileID,
+			UserID:    key.userID,
+			GrantedBy: service.ActorIDFromContext(ctx),
+		}
+		s.permissions[key] = stored
+	}
+
+	stored.Role = permission.GetRole()
+	stored.Creator = permission.GetCreator()
+	stored.ExpiresAt = permission.GetExpiresAt()
+	stored.Metadata = copyMetadata(permission.GetMetadata())
+	stored.Reason = permission.GetReason()
+	stored.SubjectType = permission.GetSubjectType()
+	stored.Deny = permission.GetDeny()
+	stored.Version++
+
+	return stored
+}
+
+// Get finds one permission that matches filter,
+// if successful returns the permission, and a nil error,
+// if the permission is not found it would return nil and service.ErrPermissionNotFound,
+// otherwise returns nil and non-nil error if any occurred.
+func (s *MemoryStore) Get(ctx context.Context, filter interface{}) (service.Permission, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched, err := s.find(filter, false)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(matched) == 0 {
+		return nil, service.ErrPermissionNotFound
+	}
+
+	return copyPermission(matched[0]), nil
+}
+
+// GetAll finds all permissions that matches filter, sorted by their ID,
+// if successful returns the permissions, and a nil error,
+// otherwise returns nil and non-nil error if any occurred.
+func (s *MemoryStore) GetAll(ctx context.Context, filter interface{}) ([]service.Permission, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched, err := s.find(filter, false)
+	if err != nil {
+		return nil, err
+	}
+
+	return copyPermissions(matched), nil
+}
+
+// GetAllSorted finds all permissions that match filter the same way GetAll does, sorted by sortBy,
+// if successful returns the permissions, and a nil error,
+// otherwise returns nil and non-nil error if any occurred.
+func (s *MemoryStore) GetAllSorted(
+	ctx context.Context,
+	filter interface{},
+	sortBy service.SortBy,
+) ([]service.Permission, error) {
+	permissions, err := s.GetAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := service.SortPermissions(permissions, sortBy); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+// GetAllCursor returns an iterator of the permissions that match filter, the same ones GetAll finds,
+// which are read up front since the store is in memory anyway.
+func (s *MemoryStore) GetAllCursor(ctx context.Context, filter interface{}) (service.PermissionIterator, error) {
+	permissions, err := s.GetAll(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return service.NewSliceIterator(permissions), nil
+}
+
+// GetAllPaged finds a page of at most pageSize permissions that match filter, sorted by their ID,
+// starting right after the page that pageToken was returned with, an empty pageToken
+// starts from the first page.
+// If successful returns the permissions, the token of the next page and a nil error,
+// an empty next page token is returned on the last page,
+// otherwise returns nil, an empty token and non-nil error if any occurred.
+func (s *MemoryStore) GetAllPaged(
+	ctx context.Context,
+	filter interface{},
+	pageSize int64,
+	pageToken string,
+) ([]service.Permission, string, error) {
+	if pageSize <= 0 {
+		return nil, "", status.Error(codes.InvalidArgument, "pageSize must be positive")
+	}
+
+	if pageToken != "" {
+		if _, err := primitive.ObjectIDFromHex(pageToken); err != nil {
+			return nil, "", status.Errorf(codes.InvalidArgument, "invalid page token %s", pageToken)
+		}
+	}
+
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched, err := s.find(filter, false)
+	if err != nil {
+		return nil, "", err
+	}
+
+	start := sort.Search(len(matched), func(i int) bool {
+		return matched[i].ID > pageToken
+	})
+	matched = matched[start:]
+
+	if int64(len(matched)) <= pageSize {
+		return copyPermissions(matched), "", nil
+	}
+
+	matched = matched[:pageSize]
+	return copyPermissions(matched), matched[pageSize-1].ID, nil
+}
+
+// Count returns the number of permissions that match filter,
+// otherwise returns 0 and non-nil error if any occurred.
+func (s *MemoryStore) Count(ctx context.Context, filter interface{}) (int64, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched, err := s.find(filter, false)
+	if err != nil {
+		return 0, err
+	}
+
+	return int64(len(matched)), nil
+}
+
+// Exists returns true if userID has a permission to fileID, the same permission Get would find,
+// or false and a nil error if it has none.
+func (s *MemoryStore) Exists(ctx context.Context, fileID string, userID string) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	stored, ok := s.permissions[permissionKey{fileID: fileID, userID: userID}]
+	return ok && !isExpired(stored), nil
+}
+
+// ExistsMatching returns true if any permission matches filter, the same permissions Count counts,
+// otherwise returns false and non-nil error if any occurred.
+func (s *MemoryStore) ExistsMatching(ctx context.Context, filter interface{}) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	matched, err := s.find(filter, false)
+	if err != nil {
+		return false, err
+	}
+
+	return len(matched) > 0, nil
+}
+
+// Delete finds the first permission that matches filter and deletes it, expired permissions
+// that were not removed yet are matched as well, the same as they are by mongodb.MongoStore.
+// If successful returns the deleted permission, if the permission is not found
+// it would return nil and service.ErrPermissionNotFound, otherwise returns nil,
+// and non-nil error if any occurred.
+func (s *MemoryStore) Delete(ctx context.Context, filter interface{}) (service.Permission, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	matched, err := s.find(filter, true)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(matched) == 0 {
+		return nil, service.ErrPermissionNotFound
+	}
+
+	deleted := matched[0]
+	delete(s.permissions, permissionKey{fileID: deleted.FileID, userID: deleted.UserID})
+
+	return deleted, nil
+}
+
+// DeleteMany deletes all permissions that match filter, expired permissions that were not removed yet
+// are matched as well, the same as they are by Delete.
+// If successful returns the number of deleted permissions and a nil error,
+// if filter is empty it would return 0 and an InvalidArgument error,
+// otherwise returns 0 and non-nil error if any occurred.
+func (s *MemoryStore) DeleteMany(ctx context.Context, filter interface{}) (int64, error) {
+	if err := service.RequireFilter(filter); err != nil {
+		return 0, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	matched, err := s.find(filter, true)
+	if err != nil {
+		return 0, err
+	}
+
+	if service.IsDryRun(ctx) {
+		return int64(len(matched)), nil
+	}
+
+	for _, permission := range matched {
+		delete(s.permissions, permissionKey{fileID: permission.FileID, userID: permission.UserID})
+	}
+
+	return int64(len(matched)), nil
+}
+
+// DeleteAllByFileID deletes all permissions of fileID,
+// if successful returns the number of deleted permissions and a nil error,
+// otherwise returns 0 and non-nil error if any occurred.
+func (s *MemoryStore) DeleteAllByFileID(ctx context.Context, fileID string) (int64, error) {
+	if fileID == "" {
+		return 0, status.Error(codes.InvalidArgument, "fileID is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for key := range s.permissions {
+		if key.fileID == fileID {
+			if !service.IsDryRun(ctx) {
+				delete(s.permissions, key)
+			}
+
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// DeleteAllByUserID deletes all permissions of userID,
+// if successful returns the number of deleted permissions and a nil error,
+// otherwise returns 0 and non-nil error if any occurred.
+func (s *MemoryStore) DeleteAllByUserID(ctx context.Context, userID string) (int64, error) {
+	if userID == "" {
+		return 0, status.Error(codes.InvalidArgument, "userID is required")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var deleted int64
+	for key := range s.permissions {
+		if key.userID == userID {
+			if !service.IsDryRun(ctx) {
+				delete(s.permissions, key)
+			}
+
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+// UpdateRole updates the role of the permission of userID to fileID,
+// if successful returns the updated permission and a nil error,
+// if the permission does not exist it would return nil and service.ErrPermissionNotFound,
+// otherwise returns nil and non-nil error if any occurred.
+func (s *MemoryStore) UpdateRole(
+	ctx context.Context,
+	fileID string,
+	userID string,
+	role pb.Role,
+) (service.Permission, error) {
+	if fileID == "" {
+		return nil, fmt.Errorf("fileID is required")
+	}
+
+	if userID == "" {
+		return nil, fmt.Errorf("userID is required")
+	}
+
+	if err := service.ValidateRole(role); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.permissions[permissionKey{fileID: fileID, userID: userID}]
+	if !ok || isExpired(stored) {
+		return nil, service.ErrPermissionNotFound
+	}
+
+	stored.Role = role
+	stored.Version++
+	return copyPermission(stored), nil
+}
+
+// UpdateRoleIfVersion updates the role of the permission of userID to fileID only if its
+// version is still expectedVersion.
+// If successful returns the updated permission and a nil error,
+// if the permission does not exist it would return nil and service.ErrPermissionNotFound,
+// if the permission's version changed it would return nil and an Aborted error,
+// otherwise returns nil and non-nil error if any occurred.
+func (s *MemoryStore) UpdateRoleIfVersion(
+	ctx context.Context,
+	fileID string,
+	userID string,
+	role pb.Role,
+	expectedVersion int64,
+) (service.Permission, error) {
+	if fileID == "" {
+		return nil, fmt.Errorf("fileID is required")
+	}
+
+	if userID == "" {
+		return nil, fmt.Errorf("userID is required")
+	}
+
+	if err := service.ValidateRole(role); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	stored, ok := s.permissions[permissionKey{fileID: fileID, userID: userID}]
+	if !ok || isExpired(stored) {
+		return nil, service.ErrPermissionNotFound
+	}
+
+	if stored.Version != expectedVersion {
+		return nil, status.Errorf(
+			codes.Aborted,
+			"permission of user %s to file %s was modified, expected version %d but found %d",
+			userID,
+			fileID,
+			expectedVersion,
+			stored.Version,
+		)
+	}
+
+	stored.Role = role
+	stored.Version++
+	return copyPermission(stored), nil
+}
+
+// TransferOwnership makes toUserID the owner of fileID and demotes fromUserID, the current owner,
+// to WRITE, the same way mongodb.MongoStore does by default. Both permissions are updated while
+// the store is locked so the file never has two owners or none. A permission of toUserID is
+// created if it doesn't exist, with fromUserID as its creator.
+// If successful returns a nil error, if fromUserID is not the owner of fileID it would return a
+// FailedPrecondition error, otherwise returns non-nil error if any occurred.
+func (s *MemoryStore) TransferOwnership(
+	ctx context.Context,
+	fileID string,
+	fromUserID string,
+	toUserID string,
+) error {
+	if fileID == "" {
+		return status.Error(codes.InvalidArgument, "fileID is required")
+	}
+
+	if fromUserID == "" {
+		return status.Error(codes.InvalidArgument, "fromUserID is required")
+	}
+
+	if toUserID == "" {
+		return status.Error(codes.InvalidArgument, "toUserID is required")
+	}
+
+	if fromUserID == toUserID {
+		return status.Error(codes.InvalidArgument, "fromUserID and toUserID must be different users")
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	owner, ok := s.permissions[permissionKey{fileID: fileID, userID: fromUserID}]
+	if !ok || isExpired(owner) || owner.Role != pb.Role_OWNER {
+		return status.Errorf(codes.FailedPrecondition, "user %s is not the owner of file %s", fromUserID, fileID)
+	}
+
+	if service.IsDryRun(ctx) {
+		return nil
+	}
+
+	owner.Role = pb.Role_WRITE
+	owner.Version++
+
+	key := permissionKey{fileID: fileID, userID: toUserID}
+	newOwner, ok := s.permissions[key]
+	if !ok {
+		newOwner = &Permission{
+			ID:      primitive.NewObjectID().Hex(),
+			FileID:  fileID,
+			UserID:  toUserID,
+			Creator: fromUserID,
+		}
+		s.permissions[key] = newOwner
+	}
+
+	newOwner.Role = pb.Role_OWNER
+	newOwner.ExpiresAt = time.Time{}
+	newOwner.Version++
+
+	return nil
+}
+
+// find returns the permissions that match filter sorted by their ID, expired permissions
+// are matched only if includeExpired is true. s.mu must be held for reading by the caller.
+func (s *MemoryStore) find(filter interface{}, includeExpired bool) ([]*Permission, error) {
+	matched := []*Permission{}
+	for _, permission := range s.permissions {
+		if !includeExpired && isExpired(permission) {
+			continue
+		}
+
+		ok, err := matches(permission, filter)
+		if err != nil {
+			return nil, err
+		}
+
+		if ok {
+			matched = append(matched, permission)
+		}
+	}
+
+	sort.Slice(matched, func(i, j int) bool {
+		return matched[i].ID < matched[j].ID
+	})
+
+	return matched, nil
+}
+
+// matches returns true if permission matches filter, a nil filter matches every permission.
+func matches(permission *Permission, filter interface{}) (bool, error) {
+	var elements bson.D
+	switch f := filter.(type) {
+	case nil:
+		return true, nil
+	case service.Filter:
+		return f.Matches(permission), nil
+	case bson.D:
+		elements = f
+	case bson.M:
+		for key, value := range f {
+			elements = append(elements, bson.E{Key: key, Value: value})
+		}
+	default:
+		return false, fmt.Errorf("unsupported filter type %T", filter)
+	}
+
+	for _, element := range elements {
+		ok, err := matchesElement(permission, element)
+		if err != nil || !ok {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// matchesElement returns true if permission matches the single filter element.
+func matchesElement(permission *Permission, element bson.E) (bool, error) {
+	switch element.Key {
+	case "$and", "$or":
+		filters, ok := element.Value.(bson.A)
+		if !ok {
+			return false, fmt.Errorf("%s requires an array of filters", element.Key)
+		}
+
+		for _, filter := range filters {
+			ok, err := matches(permission, filter)
+			if err != nil {
+				return false, err
+			}
+
+			if element.Key == "$or" && ok {
+				return true, nil
+			}
+
+			if element.Key == "$and" && !ok {
+				return false, nil
+			}
+		}
+
+		return element.Key == "$and", nil
+	case idField:
+		if id, ok := element.Value.(primitive.ObjectID); ok {
+			return permission.ID == id.Hex(), nil
+		}
+
+		return reflect.DeepEqual(permission.ID, element.Value), nil
+	case fileIDField:
+		return matchesValue(permission.FileID, element.Value)
+	case userIDField:
+		return matchesValue(permission.UserID, element.Value)
+	case roleField:
+		return matchesValue(permission.Role, element.Value)
+	case creatorField:
+		return matchesValue(permission.Creator, element.Value)
+	default:
+		return false, fmt.Errorf("unsupported filter key %s", element.Key)
+	}
+}
+
+// matchesValue returns true if value equals filterValue, or if filterValue is an $in
+// condition and value equals one of its values.
+func matchesValue(value interface{}, filterValue interface{}) (bool, error) {
+	condition, ok := filterValue.(bson.D)
+	if !ok {
+		return reflect.DeepEqual(value, filterValue), nil
+	}
+
+	if len(condition) != 1 || condition[0].Key != "$in" {
+		return false, fmt.Errorf("unsupported condition %v", condition)
+	}
+
+	values, ok := condition[0].Value.(bson.A)
+	if !ok {
+		return false, fmt.Errorf("$in requires an array of values")
+	}
+
+	for _, in := range values {
+		if reflect.DeepEqual(value, in) {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// isExpired returns true if permission has an expiration time that already passed.
+func isExpired(permission *Permission) bool {
+	return !permission.ExpiresAt.IsZero() && !permission.ExpiresAt.After(time.Now())
+}
+
+// copyPermission returns a copy of permission so callers can't modify the stored permission.
+func copyPermission(permission *Permission) *Permission {
+	copied := *permission
+	copied.Metadata = copyMetadata(permission.Metadata)
+	return &copied
+}
+
+// copyMetadata returns a copy of metadata, or nil if it's empty.
+func copyMetadata(metadata map[string]string) map[string]string {
+	if len(metadata) == 0 {
+		return nil
+	}
+
+	copied := make(map[string]string, len(metadata))
+	for key, value := range metadata {
+		copied[key] = value
+	}
+
+	return copied
+}
+
+// copyPermissions returns a copy of each of permissions.
+func copyPermissions(permissions []*Permission) []service.Permission {
+	copied := make([]service.Permission, 0, len(permissions))
+	for _, permission := range permissions {
+		copied = append(copied, copyPermission(permission))
+	}
+
+	return copied
+}
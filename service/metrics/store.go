@@ -0,0 +1,236 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"google.golang.org/grpc/status"
+)
+
+// InstrumentedStore is a Store that records the duration and the errors of each operation
+// of the Store it wraps.
+type InstrumentedStore struct {
+	inner    service.Store
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+}
+
+// NewInstrumentedStore returns an InstrumentedStore that records the operations of inner,
+// its collectors are registered with registerer.
+// Returns an error if the collectors failed to be registered.
+func NewInstrumentedStore(inner service.Store, registerer prometheus.Registerer) (InstrumentedStore, error) {
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "permission_store_operation_duration_seconds",
+		Help:    "Duration of permission store operations in seconds, by gRPC code.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"operation", "code"})
+
+	errors := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "permission_store_operation_errors_total",
+		Help: "Number of permission store operations that failed, by gRPC code.",
+	}, []string{"operation", "code"})
+
+	for _, collector := range []prometheus.Collector{duration, errors} {
+		if err := registerer.Register(collector); err != nil {
+			return InstrumentedStore{}, err
+		}
+	}
+
+	return InstrumentedStore{inner: inner, duration: duration, errors: errors}, nil
+}
+
+// Handler returns an http.Handler that serves the metrics of gatherer, to be served on /metrics.
+func Handler(gatherer prometheus.Gatherer) http.Handler {
+	return promhttp.HandlerFor(gatherer, promhttp.HandlerOpts{})
+}
+
+// Create records inner's Create.
+func (s InstrumentedStore) Create(ctx context.Context, permission service.Permission) (service.Permission, error) {
+	start := time.Now()
+	created, err := s.inner.Create(ctx, permission)
+	return created, s.record("Create", start, err)
+}
+
+// CreateIfAbsent records inner's CreateIfAbsent.
+func (s InstrumentedStore) CreateIfAbsent(
+	ctx context.Context,
+	permission service.Permission,
+) (service.Permission, error) {
+	start := time.Now()
+	created, err := s.inner.CreateIfAbsent(ctx, permission)
+	return created, s.record("CreateIfAbsent", start, err)
+}
+
+// CreateMany records inner's CreateMany.
+func (s InstrumentedStore) CreateMany(
+	ctx context.Context,
+	permissions []service.Permission,
+) ([]service.Permission, error) {
+	start := time.Now()
+	created, err := s.inner.CreateMany(ctx, permissions)
+	return created, s.record("CreateMany", start, err)
+}
+
+// Get records inner's Get.
+func (s InstrumentedStore) Get(ctx context.Context, filter interface{}) (service.Permission, error) {
+	start := time.Now()
+	permission, err := s.inner.Get(ctx, filter)
+	return permission, s.record("Get", start, err)
+}
+
+// GetAll records inner's GetAll.
+func (s InstrumentedStore) GetAll(ctx context.Context, filter interface{}) ([]service.Permission, error) {
+	start := time.Now()
+	permissions, err := s.inner.GetAll(ctx, filter)
+	return permissions, s.record("GetAll", start, err)
+}
+
+// GetAllSorted records inner's GetAllSorted.
+func (s InstrumentedStore) GetAllSorted(
+	ctx context.Context,
+	filter interface{},
+	sortBy service.SortBy,
+) ([]service.Permission, error) {
+	start := time.Now()
+	permissions, err := s.inner.GetAllSorted(ctx, filter, sortBy)
+	return permissions, s.record("GetAllSorted", start, err)
+}
+
+// GetAllCursor records opening inner's GetAllCursor, the iteration itself isn't recorded.
+func (s InstrumentedStore) GetAllCursor(
+	ctx context.Context,
+	filter interface{},
+) (service.PermissionIterator, error) {
+	start := time.Now()
+	permissions, err := s.inner.GetAllCursor(ctx, filter)
+	return permissions, s.record("GetAllCursor", start, err)
+}
+
+// GetAllPaged records inner's GetAllPaged.
+func (s InstrumentedStore) GetAllPaged(
+	ctx context.Context,
+	filter interface{},
+	pageSize int64,
+	pageToken string,
+) ([]service.Permission, string, error) {
+	start := time.Now()
+	permissions, nextPageToken, err := s.inner.GetAllPaged(ctx, filter, pageSize, pageToken)
+	return permissions, nextPageToken, s.record("GetAllPaged", start, err)
+}
+
+// Delete records inner's Delete.
+func (s InstrumentedStore) Delete(ctx context.Context, filter interface{}) (service.Permission, error) {
+	start := time.Now()
+	permission, err := s.inner.Delete(ctx, filter)
+	return permission, s.record("Delete", start, err)
+}
+
+// UpdateRole records inner's UpdateRole.
+func (s InstrumentedStore) UpdateRole(
+	ctx context.Context,
+	fileID string,
+	userID string,
+	role pb.Role,
+) (service.Permission, error) {
+	start := time.Now()
+	permission, err := s.inner.UpdateRole(ctx, fileID, userID, role)
+	return permission, s.record("UpdateRole", start, err)
+}
+
+// UpdateRoleIfVersion records inner's UpdateRoleIfVersion.
+func (s InstrumentedStore) UpdateRoleIfVersion(
+	ctx context.Context,
+	fileID string,
+	userID string,
+	role pb.Role,
+	expectedVersion int64,
+) (service.Permission, error) {
+	start := time.Now()
+	permission, err := s.inner.UpdateRoleIfVersion(ctx, fileID, userID, role, expectedVersion)
+	return permission, s.record("UpdateRoleIfVersion", start, err)
+}
+
+// Count records inner's Count.
+func (s InstrumentedStore) Count(ctx context.Context, filter interface{}) (int64, error) {
+	start := time.Now()
+	count, err := s.inner.Count(ctx, filter)
+	return count, s.record("Count", start, err)
+}
+
+// Exists records inner's Exists.
+func (s InstrumentedStore) Exists(ctx context.Context, fileID string, userID string) (bool, error) {
+	start := time.Now()
+	exists, err := s.inner.Exists(ctx, fileID, userID)
+	return exists, s.record("Exists", start, err)
+}
+
+// ExistsMatching records inner's ExistsMatching.
+func (s InstrumentedStore) ExistsMatching(ctx context.Context, filter interface{}) (bool, error) {
+	start := time.Now()
+	exists, err := s.inner.ExistsMatching(ctx, filter)
+	return exists, s.record("ExistsMatching", start, err)
+}
+
+// DeleteMany records inner's DeleteMany.
+func (s InstrumentedStore) DeleteMany(ctx context.Context, filter interface{}) (int64, error) {
+	start := time.Now()
+	deleted, err := s.inner.DeleteMany(ctx, filter)
+	return deleted, s.record("DeleteMany", start, err)
+}
+
+// DeleteAllByFileID records inner's DeleteAllByFileID.
+func (s InstrumentedStore) DeleteAllByFileID(ctx context.Context, fileID string) (int64, error) {
+	start := time.Now()
+	deleted, err := s.inner.DeleteAllByFileID(ctx, fileID)
+	return deleted, s.record("DeleteAllByFileID", start, err)
+}
+
+// DeleteAllByUserID records inner's DeleteAllByUserID.
+func (s InstrumentedStore) DeleteAllByUserID(ctx context.Context, userID string) (int64, error) {
+	start := time.Now()
+	deleted, err := s.inner.DeleteAllByUserID(ctx, userID)
+	return deleted, s.record("DeleteAllByUserID", start, err)
+}
+
+// TransferOwnership records inner's TransferOwnership.
+func (s InstrumentedStore) TransferOwnership(
+	ctx context.Context,
+	fileID string,
+	fromUserID string,
+	toUserID string,
+) error {
+	start := time.Now()
+	err := s.inner.TransferOwnership(ctx, fileID, fromUserID, toUserID)
+	return s.record("TransferOwnership", start, err)
+}
+
+// HealthCheck records inner's HealthCheck.
+func (s InstrumentedStore) HealthCheck(ctx context.Context) (service.HealthResult, error) {
+	start := time.Now()
+	result, err := s.inner.HealthCheck(ctx)
+	return result, s.record("HealthCheck", start, err)
+}
+
+// HealthStatus records inner's HealthStatus.
+func (s InstrumentedStore) HealthStatus(ctx context.Context) (service.HealthStatus, error) {
+	start := time.Now()
+	health, err := s.inner.HealthStatus(ctx)
+	return health, s.record("HealthStatus", start, err)
+}
+
+// record records the duration of operation which started at start labeled by the gRPC code of err,
+// and counts err as an error of operation if it's non-nil. Returns err as is.
+func (s InstrumentedStore) record(operation string, start time.Time, err error) error {
+	code := status.Code(err).String()
+	s.duration.WithLabelValues(operation, code).Observe(time.Since(start).Seconds())
+	if err != nil {
+		s.errors.WithLabelValues(operation, code).Inc()
+	}
+
+	return err
+}
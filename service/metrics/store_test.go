@@ -0,0 +1,94 @@
+package metrics
+
+import (
+	"context"
+	"testing"
+
+	"github.com/meateam/permission-service/service/memory"
+	"github.com/meateam/permission-service/service/mongodb"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestErrorsCountedByOperationAndCode(t *testing.T) {
+	store, err := NewInstrumentedStore(memory.NewMemoryStore(), prometheus.NewRegistry())
+	if err != nil {
+		t.Fatalf("NewInstrumentedStore() = %v, want nil", err)
+	}
+
+	for i := 1; i <= 2; i++ {
+		if _, err := store.Get(context.Background(), mongodb.FilterByFileAndUser("file", "user")); err == nil {
+			t.Fatalf("Get() of a missing permission returned a nil error")
+		}
+
+		if count := testutil.ToFloat64(store.errors.WithLabelValues("Get", "NotFound")); count != float64(i) {
+			t.Errorf("Get NotFound errors = %v, want %d", count, i)
+		}
+	}
+}
+
+func TestSuccessfulOperationNotCounted(t *testing.T) {
+	store, err := NewInstrumentedStore(memory.NewMemoryStore(), prometheus.NewRegistry())
+	if err != nil {
+		t.Fatalf("NewInstrumentedStore() = %v, want nil", err)
+	}
+
+	if _, err := store.Count(context.Background(), mongodb.FilterByFile("file")); err != nil {
+		t.Fatalf("Count() = %v, want nil", err)
+	}
+
+	if count := testutil.ToFloat64(store.errors.WithLabelValues("Count", "OK")); count != 0 {
+		t.Errorf("Count errors = %v, want 0", count)
+	}
+}
+
+func TestRegisterTwiceFails(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	if _, err := NewInstrumentedStore(memory.NewMemoryStore(), registry); err != nil {
+		t.Fatalf("NewInstrumentedStore() = %v, want nil", err)
+	}
+
+	if _, err := NewInstrumentedStore(memory.NewMemoryStore(), registry); err == nil {
+		t.Errorf("second NewInstrumentedStore() with the same registerer returned a nil error")
+	}
+}
+
+func TestDurationObservedByOperationAndCode(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	store, err := NewInstrumentedStore(memory.NewMemoryStore(), registry)
+	if err != nil {
+		t.Fatalf("NewInstrumentedStore() = %v, want nil", err)
+	}
+
+	store.Get(context.Background(), mongodb.FilterByFileAndUser("file", "user"))
+	store.Count(context.Background(), mongodb.FilterByFile("file"))
+	store.Count(context.Background(), mongodb.FilterByFile("file"))
+
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather() = %v, want nil", err)
+	}
+
+	observed := map[string]uint64{}
+	for _, family := range families {
+		if family.GetName() != "permission_store_operation_duration_seconds" {
+			continue
+		}
+
+		for _, metric := range family.GetMetric() {
+			labels := map[string]string{}
+			for _, label := range metric.GetLabel() {
+				labels[label.GetName()] = label.GetValue()
+			}
+
+			observed[labels["operation"]+"/"+labels["code"]] = metric.GetHistogram().GetSampleCount()
+		}
+	}
+
+	want := map[string]uint64{"Get/NotFound": 1, "Count/OK": 2}
+	for labels, count := range want {
+		if observed[labels] != count {
+			t.Errorf("%s observations = %d, want %d", labels, observed[labels], count)
+		}
+	}
+}
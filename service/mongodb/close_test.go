@@ -0,0 +1,40 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestCloseDisconnectsOnce(t *testing.T) {
+	client, err := mongo.NewClient(options.Client().ApplyURI("mongodb://127.0.0.1:1"))
+	if err != nil {
+		t.Fatalf("mongo.NewClient() = %v, want nil", err)
+	}
+
+	if err := client.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect() = %v, want nil", err)
+	}
+
+	store := MongoStore{DB: client.Database("permission")}
+	if err := store.Close(context.Background()); err != nil {
+		t.Fatalf("Close() = %v, want nil", err)
+	}
+
+	if err := store.Close(context.Background()); err != nil {
+		t.Errorf("Close() of a closed store = %v, want nil", err)
+	}
+
+	_, err = store.Get(context.Background(), FilterByFileAndUser("file", "user"))
+	if err != mongo.ErrClientDisconnected {
+		t.Errorf("Get() after Close() = %v, want %v", err, mongo.ErrClientDisconnected)
+	}
+
+	permission := &BSON{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"}
+	if _, err := store.Create(context.Background(), permission); err != mongo.ErrClientDisconnected {
+		t.Errorf("Create() after Close() = %v, want %v", err, mongo.ErrClientDisconnected)
+	}
+}
@@ -0,0 +1,56 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestCollectionNameDefaultsToPermissionCollectionName(t *testing.T) {
+	if got := (MongoStore{}).CollectionName(); got != PermissionCollectionName {
+		t.Errorf("CollectionName() = %q, want %q", got, PermissionCollectionName)
+	}
+}
+
+func TestNewMongoStoreWithCollection(t *testing.T) {
+	defaultStore, drop := integrationStore(t)
+	defer drop()
+
+	const collectionName = "custom_permissions"
+	store, err := NewMongoStoreWithCollection(defaultStore.DB, collectionName)
+	if err != nil {
+		t.Fatalf("NewMongoStoreWithCollection() = %v, want nil", err)
+	}
+
+	if got := store.CollectionName(); got != collectionName {
+		t.Errorf("CollectionName() = %q, want %q", got, collectionName)
+	}
+
+	createPermission(t, store, "file", "user", pb.Role_READ)
+
+	ctx := context.Background()
+	count, err := store.DB.Collection(collectionName).CountDocuments(ctx, bson.D{})
+	if err != nil || count != 1 {
+		t.Errorf("%s has %d permissions with error %v, want 1", collectionName, count, err)
+	}
+
+	count, err = store.DB.Collection(PermissionCollectionName).CountDocuments(ctx, bson.D{})
+	if err != nil || count != 0 {
+		t.Errorf("%s has %d permissions with error %v, want 0", PermissionCollectionName, count, err)
+	}
+
+	// The unique index of the configured collection rejects a second permission of the user to the file.
+	_, err = store.DB.Collection(collectionName).InsertOne(
+		ctx,
+		&BSON{FileID: "file", UserID: "user", Role: pb.Role_WRITE},
+	)
+	if err == nil {
+		t.Errorf("inserting a duplicate permission into %s succeeded, want a duplicate key error", collectionName)
+	}
+
+	if _, err := store.HealthCheck(ctx); err != nil {
+		t.Errorf("HealthCheck() = %v, want nil", err)
+	}
+}
@@ -0,0 +1,14 @@
+package mongodb
+
+import (
+	"testing"
+
+	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/storetest"
+)
+
+func TestStoreConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) (service.Store, func()) {
+		return integrationStore(t)
+	})
+}
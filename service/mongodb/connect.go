@@ -0,0 +1,109 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// DefaultConnectTimeout is the default time ConnectWithRetry keeps retrying to connect to mongodb.
+	DefaultConnectTimeout = time.Minute
+
+	// DefaultConnectRetryBaseDelay is the delay before the first retry of ConnectWithRetry,
+	// the delay is doubled on every following retry up to MaxConnectRetryDelay.
+	DefaultConnectRetryBaseDelay = 500 * time.Millisecond
+
+	// MaxConnectRetryDelay is the longest delay between the attempts of ConnectWithRetry.
+	MaxConnectRetryDelay = 10 * time.Second
+)
+
+// Dialer connects to mongodb and returns the database of the store, or the error that occurred.
+// It's called again on every attempt of ConnectWithRetry, so it should create a new client each time.
+type Dialer func(ctx context.Context) (*mongo.Database, error)
+
+// ConnectWithRetry connects to mongodb by dial and returns a new store of the collectionName collection
+// of its database, creating the store's indexes the same way NewMongoStoreWithCollection does.
+// If connecting or creating the indexes fails, such as when mongodb isn't up yet on startup, then they're
+// retried with exponential backoff, logging each failed attempt to logger, until timeout passes or ctx
+// is done. If timeout isn't positive then DefaultConnectTimeout is used, and if logger is nil then a new
+// logrus.Logger is used. The client of a database whose indexes couldn't be created is disconnected.
+// Returns the store as soon as an attempt succeeds, otherwise returns the error of the last attempt.
+func ConnectWithRetry(
+	ctx context.Context,
+	dial Dialer,
+	collectionName string,
+	timeout time.Duration,
+	logger *logrus.Logger,
+) (MongoStore, error) {
+	var store MongoStore
+	err := retryConnect(ctx, timeout, DefaultConnectRetryBaseDelay, logger, func(ctx context.Context) error {
+		db, err := dial(ctx)
+		if err != nil {
+			return err
+		}
+
+		if store, err = newMongoStore(ctx, db, collectionName); err != nil {
+			db.Client().Disconnect(context.Background())
+			return err
+		}
+
+		return nil
+	})
+
+	return store, err
+}
+
+// retryConnect runs attempt, and while it fails it retries it with exponential backoff starting at
+// baseDelay, up to MaxConnectRetryDelay between the attempts, until timeout passes or ctx is done.
+// Retrying stops early if the timeout would pass before the next attempt. Each attempt gets a context
+// that is done once the timeout passes. Returns nil as soon as an attempt succeeds, otherwise returns
+// the error of the last attempt.
+func retryConnect(
+	ctx context.Context,
+	timeout time.Duration,
+	baseDelay time.Duration,
+	logger *logrus.Logger,
+	attempt func(ctx context.Context) error,
+) error {
+	if timeout <= 0 {
+		timeout = DefaultConnectTimeout
+	}
+
+	if logger == nil {
+		logger = logrus.New()
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	delay := baseDelay
+	for attempts := 1; ; attempts++ {
+		err := attempt(ctx)
+		if err == nil {
+			logger.Infof("connected to mongodb on attempt %d", attempts)
+			return nil
+		}
+
+		if deadline, _ := ctx.Deadline(); time.Until(deadline) < delay {
+			return fmt.Errorf("failed connecting to mongodb after %d attempts: %v", attempts, err)
+		}
+
+		logger.Warnf("attempt %d to connect to mongodb failed, retrying in %v: %v", attempts, delay, err)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return fmt.Errorf("failed connecting to mongodb after %d attempts: %v", attempts, err)
+		case <-timer.C:
+		}
+
+		if delay *= 2; delay > MaxConnectRetryDelay {
+			delay = MaxConnectRetryDelay
+		}
+	}
+}
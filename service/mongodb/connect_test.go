@@ -0,0 +1,140 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/sirupsen/logrus/hooks/test"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var errNotReachable = errors.New("mongodb is not reachable")
+
+func TestRetryConnectWaitsUntilReachable(t *testing.T) {
+	logger, hook := test.NewNullLogger()
+	reachableAt := time.Now().Add(100 * time.Millisecond)
+
+	attempts := 0
+	err := retryConnect(context.Background(), time.Second, 10*time.Millisecond, logger, func(context.Context) error {
+		attempts++
+		if time.Now().Before(reachableAt) {
+			return errNotReachable
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("retryConnect() = %v, want nil", err)
+	}
+
+	if time.Now().Before(reachableAt) {
+		t.Errorf("retryConnect() returned before mongodb was reachable")
+	}
+
+	if attempts < 2 {
+		t.Errorf("retryConnect() made %d attempts, want at least 2", attempts)
+	}
+
+	warnings := 0
+	for _, entry := range hook.AllEntries() {
+		if entry.Level == logrus.WarnLevel {
+			warnings++
+		}
+	}
+
+	if warnings != attempts-1 {
+		t.Errorf("retryConnect() logged %d failed attempts, want %d", warnings, attempts-1)
+	}
+}
+
+func TestRetryConnectGivesUpAfterTimeout(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+
+	attempts := 0
+	attempt := func(context.Context) error {
+		attempts++
+		return errNotReachable
+	}
+
+	start := time.Now()
+	err := retryConnect(context.Background(), 100*time.Millisecond, 10*time.Millisecond, logger, attempt)
+	if err == nil {
+		t.Fatalf("retryConnect() = nil, want an error")
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("retryConnect() returned after %v, want about 100ms", elapsed)
+	}
+
+	if attempts < 2 {
+		t.Errorf("retryConnect() made %d attempts, want at least 2", attempts)
+	}
+}
+
+func TestRetryConnectStopsOnCancel(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	attempts := 0
+	err := retryConnect(ctx, time.Minute, 10*time.Millisecond, logger, func(context.Context) error {
+		if attempts++; attempts == 2 {
+			cancel()
+		}
+
+		return errNotReachable
+	})
+
+	if err == nil {
+		t.Fatalf("retryConnect() = nil, want an error")
+	}
+
+	if attempts != 2 {
+		t.Errorf("retryConnect() made %d attempts, want 2", attempts)
+	}
+}
+
+func TestConnectWithRetry(t *testing.T) {
+	connectionString := os.Getenv(mongoTestHostEnv)
+	if connectionString == "" {
+		t.Skipf("%s is not set", mongoTestHostEnv)
+	}
+
+	logger, _ := test.NewNullLogger()
+	dbName := fmt.Sprintf("permission_test_%d", time.Now().UnixNano())
+
+	dials := 0
+	dial := func(ctx context.Context) (*mongo.Database, error) {
+		if dials++; dials < 3 {
+			return nil, errNotReachable
+		}
+
+		client, err := mongo.Connect(ctx, options.Client().ApplyURI(connectionString))
+		if err != nil {
+			return nil, err
+		}
+
+		return client.Database(dbName), nil
+	}
+
+	store, err := ConnectWithRetry(context.Background(), dial, "", 10*time.Second, logger)
+	if err != nil {
+		t.Fatalf("ConnectWithRetry() = %v, want nil", err)
+	}
+	defer store.Close(context.Background())
+	defer store.DB.Drop(context.Background())
+
+	if dials != 3 {
+		t.Errorf("ConnectWithRetry() dialed %d times, want 3", dials)
+	}
+
+	if _, err := store.Count(context.Background(), FilterByFileAndUser("file", "user")); err != nil {
+		t.Errorf("Count() = %v, want nil", err)
+	}
+}
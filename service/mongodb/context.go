@@ -0,0 +1,15 @@
+package mongodb
+
+import "context"
+
+// contextError returns the error of ctx if err occurred after ctx was done, otherwise returns err as is.
+// The driver reports an operation that was aborted by its context in different ways depending on when it
+// was aborted, such as a server selection error or a network error of the connection it closed, so the
+// store's methods return the context's error instead to let callers tell cancellations and timeouts apart.
+func contextError(ctx context.Context, err error) error {
+	if err != nil && ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	return err
+}
@@ -0,0 +1,169 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestContextError(t *testing.T) {
+	errOperation := errors.New("operation failed")
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name string
+		ctx  context.Context
+		err  error
+		want error
+	}{
+		{name: "no error", ctx: canceled, err: nil, want: nil},
+		{name: "context not done", ctx: context.Background(), err: errOperation, want: errOperation},
+		{name: "context done", ctx: canceled, err: errOperation, want: context.Canceled},
+	}
+
+	for _, tt := range tests {
+		if err := contextError(tt.ctx, tt.err); err != tt.want {
+			t.Errorf("%s: contextError() = %v, want %v", tt.name, err, tt.want)
+		}
+	}
+}
+
+// unreachableStore returns a MongoStore of a client of a mongodb that is never reachable,
+// so its operations block on server selection until their contexts are done.
+func unreachableStore(t *testing.T) MongoStore {
+	t.Helper()
+
+	client, err := mongo.NewClient(options.Client().ApplyURI("mongodb://127.0.0.1:1"))
+	if err != nil {
+		t.Fatalf("mongo.NewClient() = %v, want nil", err)
+	}
+
+	if err := client.Connect(context.Background()); err != nil {
+		t.Fatalf("Connect() = %v, want nil", err)
+	}
+
+	return MongoStore{DB: client.Database("permission")}
+}
+
+// storeOperations are the operations of a MongoStore that must abort once their context is done.
+var storeOperations = []struct {
+	name string
+	run  func(ctx context.Context, store MongoStore) error
+}{
+	{
+		name: "Create",
+		run: func(ctx context.Context, store MongoStore) error {
+			permission := &BSON{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"}
+			_, err := store.Create(ctx, permission)
+			return err
+		},
+	},
+	{
+		name: "Get",
+		run: func(ctx context.Context, store MongoStore) error {
+			_, err := store.Get(ctx, FilterByFileAndUser("file", "user"))
+			return err
+		},
+	},
+	{
+		name: "GetAll",
+		run: func(ctx context.Context, store MongoStore) error {
+			_, err := store.GetAll(ctx, FilterByFile("file"))
+			return err
+		},
+	},
+	{
+		name: "GetAllPaged",
+		run: func(ctx context.Context, store MongoStore) error {
+			_, _, err := store.GetAllPaged(ctx, FilterByFile("file"), 10, "")
+			return err
+		},
+	},
+	{
+		name: "Count",
+		run: func(ctx context.Context, store MongoStore) error {
+			_, err := store.Count(ctx, FilterByFile("file"))
+			return err
+		},
+	},
+	{
+		name: "Exists",
+		run: func(ctx context.Context, store MongoStore) error {
+			_, err := store.Exists(ctx, "file", "user")
+			return err
+		},
+	},
+	{
+		name: "Delete",
+		run: func(ctx context.Context, store MongoStore) error {
+			_, err := store.Delete(ctx, FilterByFileAndUser("file", "user"))
+			return err
+		},
+	},
+	{
+		name: "DeleteMany",
+		run: func(ctx context.Context, store MongoStore) error {
+			_, err := store.DeleteMany(ctx, FilterByFile("file"))
+			return err
+		},
+	},
+	{
+		name: "UpdateRole",
+		run: func(ctx context.Context, store MongoStore) error {
+			_, err := store.UpdateRole(ctx, "file", "user", pb.Role_WRITE)
+			return err
+		},
+	},
+}
+
+func TestStoreOperationsAbortOnCancel(t *testing.T) {
+	store := unreachableStore(t)
+	defer store.Close(context.Background())
+
+	for _, op := range storeOperations {
+		ctx, cancel := context.WithCancel(context.Background())
+		timer := time.AfterFunc(50*time.Millisecond, cancel)
+
+		start := time.Now()
+		err := op.run(ctx, store)
+		elapsed := time.Since(start)
+		timer.Stop()
+		cancel()
+
+		if err != context.Canceled {
+			t.Errorf("%s() after cancel = %v, want %v", op.name, err, context.Canceled)
+		}
+
+		if elapsed > time.Second {
+			t.Errorf("%s() returned %v after its context was canceled, want it to return promptly", op.name, elapsed)
+		}
+	}
+}
+
+func TestStoreOperationsAbortOnDeadline(t *testing.T) {
+	store := unreachableStore(t)
+	defer store.Close(context.Background())
+
+	for _, op := range storeOperations {
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+
+		start := time.Now()
+		err := op.run(ctx, store)
+		elapsed := time.Since(start)
+		cancel()
+
+		if err != context.DeadlineExceeded {
+			t.Errorf("%s() past its deadline = %v, want %v", op.name, err, context.DeadlineExceeded)
+		}
+
+		if elapsed > time.Second {
+			t.Errorf("%s() returned %v after its deadline passed, want it to return promptly", op.name, elapsed)
+		}
+	}
+}
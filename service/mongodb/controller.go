@@ -2,46 +2,151 @@ package mongodb
 
 import (
 	"context"
-	"fmt"
+	"time"
 
 	pb "github.com/meateam/permission-service/proto"
 	"github.com/meateam/permission-service/service"
-	"go.mongodb.org/mongo-driver/bson"
-	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
 
-// Controller is the permissions service business logic implementation using MongoStore.
+// Controller is the permissions service business logic implementation using a Store,
+// which is a MongoStore possibly wrapped by decorators such as a cache.
 type Controller struct {
-	store MongoStore
+	store   service.Store
+	watcher service.PermissionWatcher
 }
 
-// NewMongoController returns a new controller.
+// NewController returns a new controller that uses store.
+func NewController(store service.Store) Controller {
+	return Controller{store: store}
+}
+
+// WithWatcher returns a copy of c that watches the changes of permissions with watcher, which is usually
+// the MongoStore that c's store wraps, since the store's decorators don't report changes.
+func (c Controller) WithWatcher(watcher service.PermissionWatcher) Controller {
+	c.watcher = watcher
+	return c
+}
+
+// NewMongoController returns a new controller that uses a MongoStore of db.
 func NewMongoController(db *mongo.Database) (Controller, error) {
-	store, err := newMongoStore(db)
+	store, err := NewMongoStore(db)
 	if err != nil {
 		return Controller{}, err
 	}
 
-	return Controller{store: store}, nil
+	return NewController(store).WithWatcher(store), nil
 }
 
-// CreatePermission creates a Permission in store and returns its unique ID.
+// CreatePermission creates a Permission in store and returns its unique ID,
+// if deny is true the permission denies userID any access to fileID regardless of role.
 func (c Controller) CreatePermission(
 	ctx context.Context,
 	fileID string,
 	userID string,
 	role pb.Role,
-	creator string) (service.Permission, error) {
-	permission := &BSON{FileID: fileID, UserID: userID, Role: role, Creator: creator}
-	createdPermission, err := c.store.Create(ctx, permission)
+	creator string,
+	expiresAt time.Time,
+	metadata map[string]string,
+	reason string,
+	subjectType string,
+	deny bool) (service.Permission, error) {
+	permission, err := buildPermission(fileID, userID, role, creator, expiresAt, metadata, reason, subjectType, deny)
 	if err != nil {
-		return nil, fmt.Errorf("failed creating permission: %v", err)
+		return nil, err
+	}
+
+	return c.store.Create(ctx, permission)
+}
+
+// CreatePermissionIfAbsent creates a Permission in store the same way CreatePermission does, unless
+// userID already has a permission to fileID, in which case it's left as is and an AlreadyExists error
+// is returned.
+func (c Controller) CreatePermissionIfAbsent(
+	ctx context.Context,
+	fileID string,
+	userID string,
+	role pb.Role,
+	creator string,
+	expiresAt time.Time,
+	metadata map[string]string,
+	reason string,
+	subjectType string,
+	deny bool) (service.Permission, error) {
+	permission, err := buildPermission(fileID, userID, role, creator, expiresAt, metadata, reason, subjectType, deny)
+	if err != nil {
+		return nil, err
+	}
+
+	return c.store.CreateIfAbsent(ctx, permission)
+}
+
+// buildPermission returns the permission of userID to fileID with the given values,
+// or the error of any value that is invalid.
+func buildPermission(
+	fileID string,
+	userID string,
+	role pb.Role,
+	creator string,
+	expiresAt time.Time,
+	metadata map[string]string,
+	reason string,
+	subjectType string,
+	deny bool) (*BSON, error) {
+	permission := &BSON{FileID: fileID, UserID: userID, Role: role, Creator: creator, Deny: deny}
+	if err := permission.SetExpiresAt(expiresAt); err != nil {
+		return nil, err
+	}
+
+	if err := permission.SetMetadata(metadata); err != nil {
+		return nil, err
+	}
+
+	if err := permission.SetReason(reason); err != nil {
+		return nil, err
 	}
 
-	return createdPermission, nil
+	if err := permission.SetSubjectType(subjectType); err != nil {
+		return nil, err
+	}
+
+	return permission, nil
+}
+
+// CreatePermissions creates all permissions in a single bulk write in store and returns them,
+// if some of the permissions failed it returns the ones that were created and a *service.CreateManyError.
+func (c Controller) CreatePermissions(
+	ctx context.Context,
+	permissions []*pb.CreatePermissionRequest,
+) ([]service.Permission, error) {
+	newPermissions := make([]service.Permission, 0, len(permissions))
+	for _, permission := range permissions {
+		newPermission := &BSON{
+			FileID:   permission.GetFileID(),
+			UserID:   permission.GetUserID(),
+			Role:     permission.GetRole(),
+			Creator:  permission.GetCreator(),
+			Metadata: permission.GetMetadata(),
+			Reason:   permission.GetReason(),
+			Deny:     permission.GetDeny(),
+		}
+
+		if err := newPermission.SetSubjectType(permission.GetSubjectType()); err != nil {
+			return nil, err
+		}
+
+		if expiresAt := permission.GetExpiresAt(); expiresAt != 0 {
+			if err := newPermission.SetExpiresAt(time.Unix(expiresAt, 0)); err != nil {
+				return nil, err
+			}
+		}
+
+		newPermissions = append(newPermissions, newPermission)
+	}
+
+	return c.store.CreateMany(ctx, newPermissions)
 }
 
 // GetByFileAndUser retrieves the permissoin that matches fileID and userID, and any error if occurred.
@@ -49,29 +154,41 @@ func (c Controller) GetByFileAndUser(
 	ctx context.Context,
 	fileID string,
 	userID string) (service.Permission, error) {
-	filter := bson.D{
-		bson.E{
-			Key:   PermissionBSONFileIDField,
-			Value: fileID,
-		},
-		bson.E{
-			Key:   PermissionBSONUserIDField,
-			Value: userID,
-		},
-	}
+	filter := service.And(service.ByFile(fileID), service.ByUser(userID))
 
 	permission, err := c.store.Get(ctx, filter)
-	if err != nil && err != mongo.ErrNoDocuments {
-		return nil, err
+	if err == service.ErrPermissionNotFound {
+		return nil, status.Errorf(codes.NotFound, "permission of user %s to file %s not found", userID, fileID)
 	}
 
-	if err == mongo.ErrNoDocuments {
-		return nil, status.Error(codes.NotFound, "permission not found")
+	if err != nil {
+		return nil, err
 	}
 
 	return permission, nil
 }
 
+// IsPermitted returns true if userID has a permission to fileID that grants at least role,
+// returns false if userID has no permission to fileID or its permission denies access,
+// and any error if occurred. It's checked with the store's ExistsMatching without reading the permission.
+func (c Controller) IsPermitted(
+	ctx context.Context,
+	fileID string,
+	userID string,
+	role pb.Role) (bool, error) {
+	roles := service.RolesIncluding(service.Role(role))
+	if len(roles) == 0 {
+		return false, nil
+	}
+
+	return c.store.ExistsMatching(ctx, service.And(
+		service.ByFile(fileID),
+		service.ByUser(userID),
+		service.ByRoles(roles...),
+		service.ByDeny(false),
+	))
+}
+
 // DeletePermission deletes the permission in store that matches fileID and userID
 // and returns the deleted permission.
 func (c Controller) DeletePermission(
@@ -79,49 +196,84 @@ func (c Controller) DeletePermission(
 	fileID string,
 	userID string,
 ) (service.Permission, error) {
-	filter := bson.D{
-		bson.E{
-			Key:   PermissionBSONFileIDField,
-			Value: fileID,
-		},
-		bson.E{
-			Key:   PermissionBSONUserIDField,
-			Value: userID,
-		},
-	}
+	filter := service.And(service.ByFile(fileID), service.ByUser(userID))
 
 	permission, err := c.store.Delete(ctx, filter)
-	if err != nil && err != mongo.ErrNoDocuments {
+	if err == service.ErrPermissionNotFound {
+		return nil, status.Errorf(codes.NotFound, "permission of user %s to file %s not found", userID, fileID)
+	}
+
+	if err != nil {
 		return nil, err
 	}
 
-	if err == mongo.ErrNoDocuments {
-		return nil, status.Error(codes.NotFound, "permission not found")
+	return permission, nil
+}
+
+// UpdatePermission updates the role of the permission that matches fileID and userID
+// and returns the updated permission. If expectedVersion is not 0 the permission is updated
+// only if its version is still expectedVersion, otherwise an Aborted error is returned.
+func (c Controller) UpdatePermission(
+	ctx context.Context,
+	fileID string,
+	userID string,
+	role pb.Role,
+	expectedVersion int64,
+) (service.Permission, error) {
+	var permission service.Permission
+	var err error
+	if expectedVersion == 0 {
+		permission, err = c.store.UpdateRole(ctx, fileID, userID, role)
+	} else {
+		permission, err = c.store.UpdateRoleIfVersion(ctx, fileID, userID, role, expectedVersion)
+	}
+
+	if err == service.ErrPermissionNotFound {
+		return nil, status.Errorf(codes.NotFound, "permission of user %s to file %s not found", userID, fileID)
+	}
+
+	if err != nil {
+		return nil, err
 	}
 
 	return permission, nil
 }
 
-// HealthCheck runs store's healthcheck and returns true if healthy, otherwise returns false
-// and any error if occurred.
-func (c Controller) HealthCheck(ctx context.Context) (bool, error) {
+// HealthCheck runs store's healthcheck and returns its result, and any error if occurred.
+func (c Controller) HealthCheck(ctx context.Context) (service.HealthResult, error) {
 	return c.store.HealthCheck(ctx)
 }
 
-// GetFilePermissions returns a slice of UserRole,
+// HealthStatus runs store's HealthStatus and returns the health of the database,
+// and any error if occurred.
+func (c Controller) HealthStatus(ctx context.Context) (service.HealthStatus, error) {
+	return c.store.HealthStatus(ctx)
+}
+
+// GetFilePermissions returns a slice of UserRole and the token of the next page,
+// if pageSize is 0 all of the file's permissions are returned in a single page sorted by sortBy,
+// otherwise the pages are sorted by SortByID,
+// if roles is not empty only the permissions with one of roles are returned,
 // otherwise returns nil and any error if occurred.
 func (c Controller) GetFilePermissions(ctx context.Context,
-	fileID string) ([]*pb.GetFilePermissionsResponse_UserRole, error) {
-	filter := bson.D{
-		bson.E{
-			Key:   PermissionBSONFileIDField,
-			Value: fileID,
-		},
+	fileID string,
+	roles []pb.Role,
+	sortBy service.SortBy,
+	pageSize int64,
+	pageToken string) ([]*pb.GetFilePermissionsResponse_UserRole, string, error) {
+	filter := service.And(service.ByFile(fileID), service.ByRoles(roles...))
+
+	var filePermissions []service.Permission
+	var nextPageToken string
+	var err error
+	if pageSize == 0 {
+		filePermissions, err = c.store.GetAllSorted(ctx, filter, sortBy)
+	} else {
+		filePermissions, nextPageToken, err = c.store.GetAllPaged(ctx, filter, pageSize, pageToken)
 	}
 
-	filePermissions, err := c.store.GetAll(ctx, filter)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	returnedPermissions := make([]*pb.GetFilePermissionsResponse_UserRole, 0, len(filePermissions))
@@ -130,26 +282,117 @@ func (c Controller) GetFilePermissions(ctx context.Context,
 			UserID:  permission.GetUserID(),
 			Role:    permission.GetRole(),
 			Creator: permission.GetCreator(),
+			Deny:    permission.GetDeny(),
 		})
 	}
-	return returnedPermissions, nil
+
+	return returnedPermissions, nextPageToken, nil
+}
+
+// StreamFilePermissions calls send with each of fileID's permissions as it's read from the store,
+// until all of them were sent, send fails or ctx is done. The store's iterator is closed in any case.
+// Returns the error of send or of the iteration if any occurred.
+func (c Controller) StreamFilePermissions(
+	ctx context.Context,
+	fileID string,
+	send func(service.Permission) error,
+) error {
+	permissions, err := c.store.GetAllCursor(ctx, service.ByFile(fileID))
+	if err != nil {
+		return err
+	}
+
+	return service.ForEach(ctx, permissions, send)
+}
+
+// WatchFilePermissions calls send with each change of fileID's permissions as it happens,
+// until send fails, ctx is done or watching fails. A deleted permission whose file isn't reported
+// by the watcher is recognized by its ID if it existed when the watching started or was reported
+// since. If c has no watcher it returns an Unimplemented error, otherwise returns the error of send
+// or of watching.
+func (c Controller) WatchFilePermissions(
+	ctx context.Context,
+	fileID string,
+	send func(service.PermissionEvent) error,
+) error {
+	if c.watcher == nil {
+		return status.Error(codes.Unimplemented, "watching permissions is not supported")
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	events, err := c.watcher.Watch(ctx)
+	if err != nil {
+		return err
+	}
+
+	// The existing permissions are read after the watching started so a permission that is created
+	// in between is reported by the watcher rather than missed.
+	permissions, err := c.store.GetAll(ctx, service.ByFile(fileID))
+	if err != nil {
+		return err
+	}
+
+	userIDs := make(map[string]string, len(permissions))
+	for _, permission := range permissions {
+		userIDs[permission.GetID()] = permission.GetUserID()
+	}
+
+	for event := range events {
+		if event.Err != nil {
+			return event.Err
+		}
+
+		if event.FileID == "" {
+			userID, ok := userIDs[event.ID]
+			if !ok {
+				continue
+			}
+
+			event.FileID = fileID
+			event.UserID = userID
+		}
+
+		if event.FileID != fileID {
+			continue
+		}
+
+		if event.Type == service.PermissionEventDeleted {
+			delete(userIDs, event.ID)
+		} else {
+			userIDs[event.ID] = event.UserID
+		}
+
+		if err := send(event); err != nil {
+			return err
+		}
+	}
+
+	return ctx.Err()
 }
 
-// GetUserPermissions returns a slice of FileRole,
+// GetUserPermissions returns a slice of FileRole and the token of the next page,
+// if pageSize is 0 all of the user's permissions are returned in a single page,
 // otherwise returns nil and any error if occurred.
 func (c Controller) GetUserPermissions(
 	ctx context.Context,
-	userID string) ([]*pb.GetUserPermissionsResponse_FileRole, error) {
-	filter := bson.D{
-		bson.E{
-			Key:   PermissionBSONUserIDField,
-			Value: userID,
-		},
+	userID string,
+	pageSize int64,
+	pageToken string) ([]*pb.GetUserPermissionsResponse_FileRole, string, error) {
+	filter := service.ByUser(userID)
+
+	var permissions []service.Permission
+	var nextPageToken string
+	var err error
+	if pageSize == 0 {
+		permissions, err = c.store.GetAll(ctx, filter)
+	} else {
+		permissions, nextPageToken, err = c.store.GetAllPaged(ctx, filter, pageSize, pageToken)
 	}
 
-	permissions, err := c.store.GetAll(ctx, filter)
 	if err != nil {
-		return nil, err
+		return nil, "", err
 	}
 
 	filePermissions := make([]*pb.GetUserPermissionsResponse_FileRole, 0, len(permissions))
@@ -158,55 +401,82 @@ func (c Controller) GetUserPermissions(
 			FileID:  permission.GetFileID(),
 			Role:    permission.GetRole(),
 			Creator: permission.GetCreator(),
+			Deny:    permission.GetDeny(),
 		})
 	}
 
-	return filePermissions, nil
+	return filePermissions, nextPageToken, nil
 }
 
 // DeleteFilePermissions deletes all permissions that exist for fileID and
 // returns a slice of Permissions that were deleted.
 func (c Controller) DeleteFilePermissions(ctx context.Context,
 	fileID string) ([]*pb.PermissionObject, error) {
-	filePermissionsFilter := bson.D{
-		bson.E{
-			Key:   PermissionBSONFileIDField,
-			Value: fileID,
-		},
-	}
+	filePermissionsFilter := service.ByFile(fileID)
 	permissions, err := c.store.GetAll(ctx, filePermissionsFilter)
 	if err != nil {
 		return nil, err
 	}
 
+	if _, err := c.store.DeleteAllByFileID(ctx, fileID); err != nil {
+		return nil, err
+	}
+
 	deletedPermissions := make([]*pb.PermissionObject, 0, len(permissions))
-	for _, permission := range permissions {
-		permissionID, err := primitive.ObjectIDFromHex(permission.GetID())
-		if err != nil {
+	for _, deletedPermission := range permissions {
+		protoDeletedPermission := &pb.PermissionObject{}
+		if err := deletedPermission.MarshalProto(protoDeletedPermission); err != nil {
 			return nil, err
 		}
 
-		permissionFilter := bson.D{
-			bson.E{
-				Key:   MongoObjectIDField,
-				Value: permissionID,
-			},
-		}
+		deletedPermissions = append(deletedPermissions, protoDeletedPermission)
+	}
 
-		deletedPermission, err := c.store.Delete(ctx, permissionFilter)
-		if err != nil {
+	return deletedPermissions, nil
+}
+
+// DeleteUserPermissions deletes all permissions that exist for userID and
+// returns a slice of Permissions that were deleted.
+func (c Controller) DeleteUserPermissions(ctx context.Context,
+	userID string) ([]*pb.PermissionObject, error) {
+	userPermissionsFilter := service.ByUser(userID)
+	permissions, err := c.store.GetAll(ctx, userPermissionsFilter)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := c.store.DeleteAllByUserID(ctx, userID); err != nil {
+		return nil, err
+	}
+
+	deletedPermissions := make([]*pb.PermissionObject, 0, len(permissions))
+	for _, deletedPermission := range permissions {
+		protoDeletedPermission := &pb.PermissionObject{}
+		if err := deletedPermission.MarshalProto(protoDeletedPermission); err != nil {
 			return nil, err
 		}
 
-		protoDeletedPermission := &pb.PermissionObject{
-			Id:      deletedPermission.GetID(),
-			FileID:  deletedPermission.GetFileID(),
-			UserID:  deletedPermission.GetUserID(),
-			Role:    deletedPermission.GetRole(),
-			Creator: deletedPermission.GetCreator(),
-		}
 		deletedPermissions = append(deletedPermissions, protoDeletedPermission)
 	}
 
 	return deletedPermissions, nil
 }
+
+// CountFilePermissions returns the number of permissions that exist for fileID,
+// otherwise returns 0 and any error if occurred.
+func (c Controller) CountFilePermissions(ctx context.Context, fileID string) (int64, error) {
+	filter := service.ByFile(fileID)
+
+	return c.store.Count(ctx, filter)
+}
+
+// TransferOwnership makes toUserID the owner of fileID and demotes fromUserID, its current owner,
+// in a single transaction, otherwise returns any error if occurred.
+func (c Controller) TransferOwnership(
+	ctx context.Context,
+	fileID string,
+	fromUserID string,
+	toUserID string,
+) error {
+	return c.store.TransferOwnership(ctx, fileID, fromUserID, toUserID)
+}
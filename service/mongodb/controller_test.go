@@ -0,0 +1,290 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/memory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetFilePermissionsByRoles(t *testing.T) {
+	store := memory.NewMemoryStore()
+	roles := map[string]pb.Role{
+		"reader":       pb.Role_READ,
+		"writer":       pb.Role_WRITE,
+		"owner":        pb.Role_OWNER,
+		"other-writer": pb.Role_WRITE,
+	}
+
+	for userID, role := range roles {
+		permission := &memory.Permission{FileID: "file", UserID: userID, Role: role, Creator: "creator"}
+		if _, err := store.Create(context.Background(), permission); err != nil {
+			t.Fatalf("Create() = %v, want nil", err)
+		}
+	}
+
+	controller := NewController(store)
+
+	tests := []struct {
+		name  string
+		roles []pb.Role
+		want  int
+	}{
+		{name: "all roles", roles: nil, want: 4},
+		{name: "owners", roles: []pb.Role{pb.Role_OWNER}, want: 1},
+		{name: "writers and owners", roles: []pb.Role{pb.Role_WRITE, pb.Role_OWNER}, want: 3},
+	}
+
+	for _, tt := range tests {
+		permissions, _, err := controller.GetFilePermissions(
+			context.Background(), "file", tt.roles, service.SortByID, 0, "")
+		if err != nil {
+			t.Fatalf("%s: GetFilePermissions() = %v, want nil", tt.name, err)
+		}
+
+		if len(permissions) != tt.want {
+			t.Errorf("%s: GetFilePermissions() returned %d permissions, want %d", tt.name, len(permissions), tt.want)
+		}
+
+		for _, permission := range permissions {
+			if permission.GetRole() != roles[permission.GetUserID()] {
+				t.Errorf("%s: GetFilePermissions() returned role %v of %s, want %v",
+					tt.name, permission.GetRole(), permission.GetUserID(), roles[permission.GetUserID()])
+			}
+
+			if len(tt.roles) > 0 && !containsRole(tt.roles, permission.GetRole()) {
+				t.Errorf("%s: GetFilePermissions() returned %s with role %v",
+					tt.name, permission.GetUserID(), permission.GetRole())
+			}
+		}
+	}
+}
+
+// containsRole returns true if role is one of roles.
+func containsRole(roles []pb.Role, role pb.Role) bool {
+	for _, r := range roles {
+		if r == role {
+			return true
+		}
+	}
+
+	return false
+}
+
+// closeRecordingStore is a Store whose iterators record whether they were closed.
+type closeRecordingStore struct {
+	service.Store
+	closed bool
+}
+
+// closeRecordingIterator is a PermissionIterator that records its closing in its store.
+type closeRecordingIterator struct {
+	service.PermissionIterator
+	store *closeRecordingStore
+}
+
+// GetAllCursor returns an iterator of the inner store that records its closing.
+func (s *closeRecordingStore) GetAllCursor(
+	ctx context.Context,
+	filter interface{},
+) (service.PermissionIterator, error) {
+	iterator, err := s.Store.GetAllCursor(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	return closeRecordingIterator{PermissionIterator: iterator, store: s}, nil
+}
+
+// Close records the closing and closes the inner iterator.
+func (i closeRecordingIterator) Close(ctx context.Context) error {
+	i.store.closed = true
+	return i.PermissionIterator.Close(ctx)
+}
+
+// newStreamingController returns a Controller of a closeRecordingStore with count permissions to file.
+func newStreamingController(t *testing.T, count int) (Controller, *closeRecordingStore) {
+	t.Helper()
+
+	store := &closeRecordingStore{Store: memory.NewMemoryStore()}
+	for i := 0; i < count; i++ {
+		userID := string(rune('a' + i))
+		permission := &memory.Permission{FileID: "file", UserID: userID, Role: pb.Role_READ, Creator: "creator"}
+		if _, err := store.Create(context.Background(), permission); err != nil {
+			t.Fatalf("Create() = %v, want nil", err)
+		}
+	}
+
+	return NewController(store), store
+}
+
+func TestStreamFilePermissions(t *testing.T) {
+	controller, store := newStreamingController(t, 3)
+
+	var streamed []string
+	err := controller.StreamFilePermissions(context.Background(), "file", func(permission service.Permission) error {
+		streamed = append(streamed, permission.GetUserID())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("StreamFilePermissions() = %v, want nil", err)
+	}
+
+	if len(streamed) != 3 {
+		t.Errorf("StreamFilePermissions() sent %v, want 3 permissions", streamed)
+	}
+
+	if !store.closed {
+		t.Errorf("StreamFilePermissions() didn't close the iterator")
+	}
+}
+
+func TestStreamFilePermissionsStopsWhenSendFails(t *testing.T) {
+	controller, store := newStreamingController(t, 3)
+	errDisconnected := errors.New("client disconnected")
+
+	sent := 0
+	err := controller.StreamFilePermissions(context.Background(), "file", func(service.Permission) error {
+		sent++
+		return errDisconnected
+	})
+	if err != errDisconnected {
+		t.Errorf("StreamFilePermissions() = %v, want %v", err, errDisconnected)
+	}
+
+	if sent != 1 {
+		t.Errorf("StreamFilePermissions() sent %d permissions after send failed, want 1", sent)
+	}
+
+	if !store.closed {
+		t.Errorf("StreamFilePermissions() didn't close the iterator")
+	}
+}
+
+// unreadableStore is a Store whose Get always fails, so reading a permission fails the test.
+type unreadableStore struct {
+	service.Store
+}
+
+// Get returns an error since permissions must not be read.
+func (s unreadableStore) Get(ctx context.Context, filter interface{}) (service.Permission, error) {
+	return nil, errors.New("permission was read")
+}
+
+// unreadableCreateStore is a Store whose CreateMany writes the permissions but fails reading them back.
+type unreadableCreateStore struct {
+	service.Store
+}
+
+// CreateMany writes permissions to the embedded store and returns the error of a failed read-back.
+func (s unreadableCreateStore) CreateMany(
+	ctx context.Context,
+	permissions []service.Permission,
+) ([]service.Permission, error) {
+	if _, err := s.Store.CreateMany(ctx, permissions); err != nil {
+		return nil, err
+	}
+
+	return nil, &service.CreateManyError{Failed: map[int]error{}, ReadErr: errors.New("read failed")}
+}
+
+func TestCreatePermissionsReadBackFailureIsUnavailable(t *testing.T) {
+	store := unreadableCreateStore{Store: memory.NewMemoryStore()}
+	req := &pb.CreatePermissionsRequest{Permissions: []*pb.CreatePermissionRequest{
+		{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"},
+	}}
+
+	_, err := service.NewService(NewController(store), nil).CreatePermissions(context.Background(), req)
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("CreatePermissions() with a failed read-back = %v, want code %v", err, codes.Unavailable)
+	}
+
+	for _, detail := range status.Convert(err).Details() {
+		if _, ok := detail.(*pb.CreatePermissionsResponse); ok {
+			t.Errorf("CreatePermissions() with a failed read-back reported created permissions")
+		}
+	}
+
+	if _, err := store.Get(context.Background(), FilterByFileAndUser("file", "user")); err != nil {
+		t.Errorf("Get() of the written permission = %v, want nil", err)
+	}
+}
+
+func TestIsPermittedDoesNotReadPermission(t *testing.T) {
+	store := unreadableStore{Store: memory.NewMemoryStore()}
+	permission := &memory.Permission{FileID: "file", UserID: "writer", Role: pb.Role_WRITE, Creator: "creator"}
+	if _, err := store.Create(context.Background(), permission); err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	controller := NewController(store)
+	tests := []struct {
+		userID string
+		role   pb.Role
+		want   bool
+	}{
+		{userID: "writer", role: pb.Role_READ, want: true},
+		{userID: "writer", role: pb.Role_WRITE, want: true},
+		{userID: "writer", role: pb.Role_OWNER, want: false},
+		{userID: "writer", role: pb.Role_NONE, want: false},
+		{userID: "other", role: pb.Role_READ, want: false},
+	}
+
+	for _, tt := range tests {
+		permitted, err := controller.IsPermitted(context.Background(), "file", tt.userID, tt.role)
+		if err != nil || permitted != tt.want {
+			t.Errorf("IsPermitted(%s, %v) = %v, %v, want %v, nil", tt.userID, tt.role, permitted, err, tt.want)
+		}
+	}
+}
+
+func TestUpdatePermissionNotFound(t *testing.T) {
+	svc := service.NewService(NewController(memory.NewMemoryStore()), nil)
+	req := &pb.UpdatePermissionRequest{FileID: "file", UserID: "user", Role: pb.Role_WRITE}
+
+	_, err := svc.UpdatePermission(context.Background(), req)
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("UpdatePermission() of a missing permission = %v, want code %v", err, codes.NotFound)
+	}
+
+	create := &pb.CreatePermissionRequest{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"}
+	if _, err := svc.CreatePermission(context.Background(), create); err != nil {
+		t.Fatalf("CreatePermission() = %v, want nil", err)
+	}
+
+	updated, err := svc.UpdatePermission(context.Background(), req)
+	if err != nil || updated.GetRole() != pb.Role_WRITE {
+		t.Errorf("UpdatePermission() = %v, %v, want a WRITE permission", updated, err)
+	}
+}
+
+func TestIsPermittedRequestWriteSatisfiesRead(t *testing.T) {
+	svc := service.NewService(NewController(memory.NewMemoryStore()), nil)
+	create := &pb.CreatePermissionRequest{FileID: "file", UserID: "writer", Role: pb.Role_WRITE, Creator: "creator"}
+	if _, err := svc.CreatePermission(context.Background(), create); err != nil {
+		t.Fatalf("CreatePermission() = %v, want nil", err)
+	}
+
+	tests := []struct {
+		userID string
+		role   pb.Role
+		want   bool
+	}{
+		{userID: "writer", role: pb.Role_READ, want: true},
+		{userID: "writer", role: pb.Role_OWNER, want: false},
+		{userID: "missing", role: pb.Role_READ, want: false},
+	}
+
+	for _, tt := range tests {
+		req := &pb.IsPermittedRequest{FileID: "file", UserID: tt.userID, Role: tt.role}
+		res, err := svc.IsPermitted(context.Background(), req)
+		if err != nil || res.GetPermitted() != tt.want {
+			t.Errorf("IsPermitted(%s, %v) = %v, %v, want %v, nil", tt.userID, tt.role, res.GetPermitted(), err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/memory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreatePermissionFailIfExists(t *testing.T) {
+	permissionService := service.NewService(NewController(memory.NewMemoryStore()), nil)
+	req := &pb.CreatePermissionRequest{
+		FileID:       "file",
+		UserID:       "user",
+		Role:         pb.Role_READ,
+		Creator:      "creator",
+		FailIfExists: true,
+	}
+
+	if _, err := permissionService.CreatePermission(context.Background(), req); err != nil {
+		t.Fatalf("CreatePermission() = %v, want nil", err)
+	}
+
+	_, err := permissionService.CreatePermission(context.Background(), req)
+	if status.Code(err) != codes.AlreadyExists {
+		t.Errorf("CreatePermission() of an existing permission = %v, want code %v", err, codes.AlreadyExists)
+	}
+
+	req.FailIfExists = false
+	req.Role = pb.Role_WRITE
+	updated, err := permissionService.CreatePermission(context.Background(), req)
+	if err != nil {
+		t.Fatalf("CreatePermission() without failIfExists = %v, want nil", err)
+	}
+
+	if updated.GetRole() != pb.Role_WRITE {
+		t.Errorf("CreatePermission() without failIfExists = role %v, want %v", updated.GetRole(), pb.Role_WRITE)
+	}
+}
+
+func TestCreateIfAbsent(t *testing.T) {
+	store, cleanup := integrationStore(t)
+	defer cleanup()
+
+	for _, role := range []pb.Role{pb.Role_READ, pb.Role_OWNER} {
+		permission := &BSON{FileID: "file-" + role.String(), UserID: "user", Role: role, Creator: "creator"}
+		if _, err := store.CreateIfAbsent(context.Background(), permission); err != nil {
+			t.Fatalf("CreateIfAbsent(%v) = %v, want nil", role, err)
+		}
+
+		_, err := store.CreateIfAbsent(context.Background(), permission)
+		if status.Code(err) != codes.AlreadyExists {
+			t.Errorf("CreateIfAbsent(%v) of an existing permission = %v, want code %v", role, err, codes.AlreadyExists)
+		}
+	}
+
+	deleted := &BSON{FileID: "file", UserID: "deleted", Role: pb.Role_READ, Creator: "creator"}
+	if _, err := store.Create(context.Background(), deleted); err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	store.SoftDelete = true
+	if _, err := store.Delete(context.Background(), FilterByFileAndUser("file", "deleted")); err != nil {
+		t.Fatalf("Delete() = %v, want nil", err)
+	}
+
+	if _, err := store.CreateIfAbsent(context.Background(), deleted); err != nil {
+		t.Errorf("CreateIfAbsent() over a soft deleted permission = %v, want nil", err)
+	}
+}
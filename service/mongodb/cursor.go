@@ -0,0 +1,115 @@
+package mongodb
+
+import (
+	"context"
+
+	"github.com/meateam/permission-service/service"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// cursorIterator is a PermissionIterator that decodes the permissions of a mongo cursor one at a time.
+type cursorIterator struct {
+	cursor  *mongo.Cursor
+	current *BSON
+	err     error
+}
+
+// GetAllCursor finds all permissions that match filter the same way GetAll does, but returns an iterator
+// that reads them from the database as it's advanced instead of loading all of them at once.
+// The iterator must be closed, which also happens if the context passed to its Next is done.
+// If successful returns the iterator, and a nil error,
+// otherwise returns nil and non-nil error if any occurred.
+func (s MongoStore) GetAllCursor(
+	ctx context.Context,
+	filter interface{},
+) (iterator service.PermissionIterator, err error) {
+	ctx, span := s.startSpan(ctx, "GetAllCursor", filterAttributes(filter)...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	collection := s.readCollection(ctx)
+	sort, err := sortDocument(SortByID)
+	if err != nil {
+		return nil, err
+	}
+
+	filter, err = s.scope(ctx, active(filter))
+	if err != nil {
+		return nil, err
+	}
+
+	var cursor *mongo.Cursor
+	err = s.retry(ctx, func() error {
+		var err error
+		cursor, err = collection.Find(ctx, filter, options.Find().SetSort(sort))
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &cursorIterator{cursor: cursor}, nil
+}
+
+// ForEach finds all permissions that match filter the same way GetAll does, and calls fn with each of
+// them as it's read from the cursor instead of loading all of them at once, until fn returns an error
+// or ctx is done. The cursor is closed in any case.
+// Returns the error of fn or of the iteration if any occurred.
+func (s MongoStore) ForEach(
+	ctx context.Context,
+	filter interface{},
+	fn func(service.Permission) error,
+) (err error) {
+	ctx, span := s.startSpan(ctx, "ForEach", filterAttributes(filter)...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	iterator, err := s.GetAllCursor(ctx, filter)
+	if err != nil {
+		return err
+	}
+
+	return service.ForEach(ctx, iterator, fn)
+}
+
+// Next advances the iterator to the next permission and decodes it, returns false when there are
+// no more permissions or an error occurred, in which case the cursor is closed.
+func (i *cursorIterator) Next(ctx context.Context) bool {
+	if i.err != nil {
+		return false
+	}
+
+	if !i.cursor.Next(ctx) {
+		i.err = contextError(ctx, i.cursor.Err())
+		if i.err == nil {
+			i.err = ctx.Err()
+		}
+
+		i.cursor.Close(context.Background())
+		return false
+	}
+
+	permission := &BSON{}
+	if err := i.cursor.Decode(permission); err != nil {
+		i.err = err
+		i.cursor.Close(context.Background())
+		return false
+	}
+
+	i.current = permission
+	return true
+}
+
+// Permission returns the permission the iterator was advanced to by Next.
+func (i *cursorIterator) Permission() service.Permission {
+	return i.current
+}
+
+// Err returns the error that stopped the iteration, or nil if there was none.
+func (i *cursorIterator) Err() error {
+	return i.err
+}
+
+// Close closes the cursor, it's safe to call more than once.
+func (i *cursorIterator) Close(ctx context.Context) error {
+	return i.cursor.Close(ctx)
+}
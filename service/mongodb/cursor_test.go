@@ -0,0 +1,104 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+)
+
+func TestGetAllCursorIteratesAllPermissions(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	for _, userID := range []string{"a", "b", "c"} {
+		createPermission(t, store, "file", userID, pb.Role_READ)
+	}
+	createPermission(t, store, "other", "a", pb.Role_READ)
+
+	iterator, err := store.GetAllCursor(context.Background(), FilterByFile("file"))
+	if err != nil {
+		t.Fatalf("GetAllCursor() = %v, want nil", err)
+	}
+	defer iterator.Close(context.Background())
+
+	var userIDs []string
+	for iterator.Next(context.Background()) {
+		userIDs = append(userIDs, iterator.Permission().GetUserID())
+	}
+
+	if err := iterator.Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil", err)
+	}
+
+	if len(userIDs) != 3 || userIDs[0] != "a" || userIDs[2] != "c" {
+		t.Errorf("iterated %v, want [a b c]", userIDs)
+	}
+}
+
+func TestGetAllCursorStopsWhenContextIsDone(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "a", pb.Role_READ)
+	createPermission(t, store, "file", "b", pb.Role_READ)
+
+	iterator, err := store.GetAllCursor(context.Background(), FilterByFile("file"))
+	if err != nil {
+		t.Fatalf("GetAllCursor() = %v, want nil", err)
+	}
+	defer iterator.Close(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if iterator.Next(ctx) {
+		t.Errorf("Next() with a done context = true, want false")
+	}
+
+	if iterator.Err() == nil {
+		t.Errorf("Err() after the context is done = nil, want an error")
+	}
+}
+
+func TestForEachStopsOnError(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	for _, userID := range []string{"a", "b", "c"} {
+		createPermission(t, store, "file", userID, pb.Role_READ)
+	}
+
+	errStop := errors.New("stop")
+	var userIDs []string
+	err := store.ForEach(context.Background(), FilterByFile("file"), func(permission service.Permission) error {
+		userIDs = append(userIDs, permission.GetUserID())
+		if len(userIDs) == 2 {
+			return errStop
+		}
+
+		return nil
+	})
+	if err != errStop {
+		t.Errorf("ForEach() = %v, want %v", err, errStop)
+	}
+
+	if len(userIDs) != 2 || userIDs[0] != "a" || userIDs[1] != "b" {
+		t.Errorf("ForEach() iterated %v, want [a b]", userIDs)
+	}
+
+	var all []string
+	err = store.ForEach(context.Background(), FilterByFile("file"), func(permission service.Permission) error {
+		all = append(all, permission.GetUserID())
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("ForEach() = %v, want nil", err)
+	}
+
+	if len(all) != 3 {
+		t.Errorf("ForEach() iterated %v, want [a b c]", all)
+	}
+}
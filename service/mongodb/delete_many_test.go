@@ -0,0 +1,113 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDeleteManyRejectsEmptyFilter(t *testing.T) {
+	store, _ := tracedStore(t)
+
+	for _, filter := range []interface{}{nil, bson.D{}, bson.M{}} {
+		if _, err := store.DeleteMany(context.Background(), filter); status.Code(err) != codes.InvalidArgument {
+			t.Errorf("DeleteMany(%#v) = %v, want code %v", filter, err, codes.InvalidArgument)
+		}
+	}
+}
+
+func TestDeleteManyDeletesAllMatches(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "reader", pb.Role_READ)
+	createPermission(t, store, "file", "other-reader", pb.Role_READ)
+	createPermission(t, store, "file", "writer", pb.Role_WRITE)
+	createPermission(t, store, "other-file", "reader", pb.Role_READ)
+
+	deleted, err := store.DeleteMany(context.Background(), FilterByFileAndRole("file", pb.Role_READ))
+	if err != nil {
+		t.Fatalf("DeleteMany() = %v, want nil", err)
+	}
+
+	if deleted != 2 {
+		t.Errorf("DeleteMany() = %d, want 2", deleted)
+	}
+
+	remaining, err := store.GetAll(context.Background(), bson.D{})
+	if err != nil {
+		t.Fatalf("GetAll() = %v, want nil", err)
+	}
+
+	if len(remaining) != 2 {
+		t.Errorf("GetAll() = %d permissions, want the writer's and the other file's", len(remaining))
+	}
+}
+
+func TestDeleteManyByRole(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "reader", pb.Role_READ)
+	createPermission(t, store, "file", "writer", pb.Role_WRITE)
+	createPermission(t, store, "other-file", "reader", pb.Role_READ)
+
+	deleted, err := store.DeleteMany(context.Background(), service.ByRole(pb.Role_READ))
+	if err != nil {
+		t.Fatalf("DeleteMany() = %v, want nil", err)
+	}
+
+	if deleted != 2 {
+		t.Errorf("DeleteMany() = %d, want 2", deleted)
+	}
+
+	if role := roleOf(t, store, "file", "writer"); role != pb.Role_WRITE {
+		t.Errorf("writer's role = %v, want it kept as %v", role, pb.Role_WRITE)
+	}
+}
+
+func TestDeleteManyCreatedBefore(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "old-reader", pb.Role_READ)
+	createPermission(t, store, "file", "old-writer", pb.Role_WRITE)
+	createPermission(t, store, "file", "new-reader", pb.Role_READ)
+
+	cutoff := time.Now().Add(-time.Hour)
+	backdate := bson.D{bson.E{
+		Key:   "$set",
+		Value: bson.D{bson.E{Key: PermissionBSONCreatedAtField, Value: cutoff.Add(-24 * time.Hour)}},
+	}}
+	for _, userID := range []string{"old-reader", "old-writer"} {
+		_, err := store.collection().UpdateOne(context.Background(), FilterByFileAndUser("file", userID), backdate)
+		if err != nil {
+			t.Fatalf("UpdateOne() = %v, want nil", err)
+		}
+	}
+
+	filter := append(FilterByFileAndRole("file", pb.Role_READ), FilterCreatedBefore(cutoff)...)
+	if deleted, err := store.DeleteMany(context.Background(), filter); err != nil || deleted != 1 {
+		t.Errorf("DeleteMany() of old readers = %d, %v, want 1, nil", deleted, err)
+	}
+
+	deleted, err := store.DeleteMany(context.Background(), FilterCreatedBefore(cutoff))
+	if err != nil || deleted != 1 {
+		t.Errorf("DeleteMany() of old permissions = %d, %v, want 1, nil", deleted, err)
+	}
+
+	remaining, err := store.GetAll(context.Background(), bson.D{})
+	if err != nil {
+		t.Fatalf("GetAll() = %v, want nil", err)
+	}
+
+	if len(remaining) != 1 || remaining[0].GetUserID() != "new-reader" {
+		t.Errorf("GetAll() = %v, want only new-reader's permission", remaining)
+	}
+}
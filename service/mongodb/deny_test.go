@@ -0,0 +1,156 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/memory"
+)
+
+func TestDenyOverridesGrant(t *testing.T) {
+	controller := NewController(memory.NewMemoryStore())
+	ctx := context.Background()
+
+	create := func(deny bool) {
+		t.Helper()
+
+		_, err := controller.CreatePermission(
+			ctx, "file", "user", pb.Role_WRITE, "creator", time.Time{}, nil, "", "", deny)
+		if err != nil {
+			t.Fatalf("CreatePermission(deny %v) = %v, want nil", deny, err)
+		}
+	}
+
+	create(false)
+	create(true)
+
+	permitted, err := controller.IsPermitted(ctx, "file", "user", pb.Role_READ)
+	if err != nil || permitted {
+		t.Errorf("IsPermitted() of a denied WRITE permission = %v, %v, want false, nil", permitted, err)
+	}
+
+	roles, _, err := controller.GetFilePermissions(ctx, "file", nil, service.SortByID, 0, "")
+	if err != nil {
+		t.Fatalf("GetFilePermissions() = %v, want nil", err)
+	}
+
+	if len(roles) != 1 || !roles[0].GetDeny() || roles[0].GetRole() != pb.Role_WRITE {
+		t.Errorf("GetFilePermissions() = %v, want a denying WRITE permission", roles)
+	}
+
+	create(false)
+	if permitted, err := controller.IsPermitted(ctx, "file", "user", pb.Role_WRITE); err != nil || !permitted {
+		t.Errorf("IsPermitted() after granting again = %v, %v, want true, nil", permitted, err)
+	}
+}
+
+func TestIsPermittedDenied(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	ctx := context.Background()
+	createPermission(t, store, "file", "user", pb.Role_WRITE)
+
+	denied := &BSON{FileID: "file", UserID: "user", Role: pb.Role_WRITE, Creator: "creator", Deny: true}
+	if _, err := store.Create(ctx, denied); err != nil {
+		t.Fatalf("Create() of a denying permission = %v, want nil", err)
+	}
+
+	if permitted, err := store.IsPermitted(ctx, "file", "user", pb.Role_READ); err != nil || permitted {
+		t.Errorf("IsPermitted() = %v, %v, want false, nil", permitted, err)
+	}
+
+	permissions, err := store.GetAll(ctx, FilterByFile("file"))
+	if err != nil {
+		t.Fatalf("GetAll() = %v, want nil", err)
+	}
+
+	if len(permissions) != 1 || !permissions[0].GetDeny() {
+		t.Errorf("GetAll() = %v, want a single denying permission", permissions)
+	}
+}
+
+func TestIsPermittedWithInheritanceDenied(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	ctx := context.Background()
+	createPermission(t, store, "file", "user", pb.Role_WRITE)
+	denied := &BSON{FileID: "root", UserID: "user", Role: pb.Role_READ, Creator: "creator", Deny: true}
+	if _, err := store.Create(ctx, denied); err != nil {
+		t.Fatalf("Create() of a denying permission = %v, want nil", err)
+	}
+
+	permitted, effective, err := store.IsPermittedWithInheritance(
+		ctx, "file", "user", []string{"parent", "root"}, service.RoleRead)
+	if err != nil {
+		t.Fatalf("IsPermittedWithInheritance() error = %v, want nil", err)
+	}
+
+	want := EffectiveRole{Role: service.RoleRead, InheritedFrom: "root", Denied: true}
+	if permitted || effective != want {
+		t.Errorf("IsPermittedWithInheritance() = %v, %+v, want false, %+v", permitted, effective, want)
+	}
+}
+
+func TestGetFilesForUserExcludesDenied(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "shared", "user", pb.Role_READ)
+	denied := &BSON{FileID: "denied", UserID: "user", Role: pb.Role_WRITE, Creator: "creator", Deny: true}
+	if _, err := store.Create(context.Background(), denied); err != nil {
+		t.Fatalf("Create() of a denying permission = %v, want nil", err)
+	}
+
+	found, err := store.GetFilesForUser(context.Background(), "user", service.RoleNone)
+	if err != nil {
+		t.Fatalf("GetFilesForUser() = %v, want nil", err)
+	}
+
+	if len(found) != 1 || found[0].GetFileID() != "shared" {
+		t.Errorf("GetFilesForUser() = %v, want only the shared file", found)
+	}
+}
+
+func TestTransferOwnershipLiftsDenial(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	ctx := context.Background()
+	createPermission(t, store, "file", "owner", pb.Role_OWNER)
+	denied := &BSON{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator", Deny: true}
+	if _, err := store.Create(ctx, denied); err != nil {
+		t.Fatalf("Create() of a denying permission = %v, want nil", err)
+	}
+
+	if err := store.TransferOwnership(ctx, "file", "owner", "user"); err != nil {
+		t.Fatalf("TransferOwnership() = %v, want nil", err)
+	}
+
+	permission, err := store.Get(ctx, FilterByFileAndUser("file", "user"))
+	if err != nil {
+		t.Fatalf("Get() = %v, want nil", err)
+	}
+
+	if permission.GetRole() != pb.Role_OWNER || permission.GetDeny() {
+		t.Errorf("Get() of the new owner = %v, want an OWNER permission that doesn't deny", permission)
+	}
+}
+
+func TestPrecedes(t *testing.T) {
+	depths := map[string]int{"file": 0, "parent": 1}
+	grant := &BSON{FileID: "file", Role: pb.Role_OWNER}
+	deny := &BSON{FileID: "parent", Role: pb.Role_READ, Deny: true}
+
+	if !precedes(deny, grant, depths) {
+		t.Errorf("precedes() of a farther deny over a grant = false, want true")
+	}
+
+	if precedes(grant, deny, depths) {
+		t.Errorf("precedes() of a closer grant over a deny = true, want false")
+	}
+}
@@ -0,0 +1,166 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/memory"
+	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestDryRunDeleteFilePermissionsPreviewsWithoutDeleting(t *testing.T) {
+	store := memory.NewMemoryStore()
+	for _, userID := range []string{"a", "b"} {
+		permission := &memory.Permission{FileID: "file", UserID: userID, Role: pb.Role_READ, Creator: "creator"}
+		if _, err := store.Create(context.Background(), permission); err != nil {
+			t.Fatalf("Create() = %v, want nil", err)
+		}
+	}
+
+	ctx := service.WithDryRun(context.Background())
+	previewed, err := NewController(store).DeleteFilePermissions(ctx, "file")
+	if err != nil {
+		t.Fatalf("DeleteFilePermissions() = %v, want nil", err)
+	}
+
+	if len(previewed) != 2 {
+		t.Errorf("DeleteFilePermissions() = %d permissions, want 2", len(previewed))
+	}
+
+	if count, err := store.Count(context.Background(), FilterByFile("file")); err != nil || count != 2 {
+		t.Errorf("Count() = %d, %v, want 2, nil", count, err)
+	}
+}
+
+func TestDryRunStillValidates(t *testing.T) {
+	store, _ := tracedStore(t)
+	ctx := service.WithDryRun(context.Background())
+
+	if _, err := store.DeleteAllByFileID(ctx, ""); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("DeleteAllByFileID() = %v, want code %v", err, codes.InvalidArgument)
+	}
+
+	if err := store.TransferOwnership(ctx, "file", "user", "user"); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("TransferOwnership() = %v, want code %v", err, codes.InvalidArgument)
+	}
+
+	if _, err := store.DeleteMany(ctx, service.Filter{}); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("DeleteMany() = %v, want code %v", err, codes.InvalidArgument)
+	}
+
+	if _, err := store.ReassignFile(ctx, "", "file"); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("ReassignFile() = %v, want code %v", err, codes.InvalidArgument)
+	}
+}
+
+func TestDryRunDeleteAllByUserIDCountsWithoutDeleting(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "user", pb.Role_READ)
+	createPermission(t, store, "other-file", "user", pb.Role_WRITE)
+
+	deleted, err := store.DeleteAllByUserID(service.WithDryRun(context.Background()), "user")
+	if err != nil {
+		t.Fatalf("DeleteAllByUserID() = %v, want nil", err)
+	}
+
+	if deleted != 2 {
+		t.Errorf("DeleteAllByUserID() = %d, want 2", deleted)
+	}
+
+	if count, err := store.Count(context.Background(), bson.D{}); err != nil || count != 2 {
+		t.Errorf("Count() = %d, %v, want 2, nil", count, err)
+	}
+}
+
+func TestDryRunTransferOwnership(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "owner", pb.Role_OWNER)
+	ctx := service.WithDryRun(context.Background())
+
+	if err := store.TransferOwnership(ctx, "file", "owner", "user"); err != nil {
+		t.Fatalf("TransferOwnership() = %v, want nil", err)
+	}
+
+	if role := roleOf(t, store, "file", "owner"); role != pb.Role_OWNER {
+		t.Errorf("owner's role = %v, want it unchanged as %v", role, pb.Role_OWNER)
+	}
+
+	err := store.TransferOwnership(ctx, "file", "user", "owner")
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("TransferOwnership() from a non-owner = %v, want a %v error", err, codes.FailedPrecondition)
+	}
+}
+
+func TestDryRunDeleteManyLeavesCollectionUntouched(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	for i := 0; i < 3; i++ {
+		createPermission(t, store, "file", fmt.Sprintf("user-%d", i), pb.Role_READ)
+	}
+	createPermission(t, store, "other-file", "user-0", pb.Role_READ)
+
+	deleted, err := store.DeleteMany(service.WithDryRun(context.Background()), service.ByFile("file"))
+	if err != nil {
+		t.Fatalf("DeleteMany() = %v, want nil", err)
+	}
+
+	if deleted != 3 {
+		t.Errorf("DeleteMany() = %d, want 3", deleted)
+	}
+
+	if count, err := store.Count(context.Background(), bson.D{}); err != nil || count != 4 {
+		t.Errorf("Count() = %d, %v, want 4, nil", count, err)
+	}
+}
+
+func TestDryRunReassignFileMatchesReassignFile(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	store.SoftDelete = true
+	createPermission(t, store, "old-file", "a", pb.Role_READ)
+	createPermission(t, store, "old-file", "b", pb.Role_WRITE)
+	createPermission(t, store, "old-file", "deleted", pb.Role_READ)
+	createPermission(t, store, "new-file", "b", pb.Role_READ)
+	if _, err := store.Delete(context.Background(), FilterByFileAndUser("old-file", "deleted")); err != nil {
+		t.Fatalf("Delete() = %v, want nil", err)
+	}
+
+	wouldMove, err := store.ReassignFile(service.WithDryRun(context.Background()), "old-file", "new-file")
+	if err != nil {
+		t.Fatalf("ReassignFile() of a dry run = %v, want nil", err)
+	}
+
+	if count, err := store.Count(context.Background(), FilterByFile("old-file")); err != nil || count != 2 {
+		t.Errorf("Count() of old-file after a dry run = %d, %v, want 2, nil", count, err)
+	}
+
+	if role := roleOf(t, store, "new-file", "b"); role != pb.Role_READ {
+		t.Errorf("new-file's role of b = %v, want it unchanged as %v", role, pb.Role_READ)
+	}
+
+	moved, err := store.ReassignFile(context.Background(), "old-file", "new-file")
+	if err != nil {
+		t.Fatalf("ReassignFile() = %v, want nil", err)
+	}
+
+	if wouldMove != 1 || moved != wouldMove {
+		t.Errorf("ReassignFile() of a dry run = %d and ReassignFile() = %d, want both 1", wouldMove, moved)
+	}
+
+	deleted := &BSON{}
+	filter := FilterByFileAndUser("new-file", "deleted")
+	if err := store.collection().FindOne(context.Background(), filter).Decode(deleted); err != nil {
+		t.Errorf("FindOne() of the soft deleted permission on new-file = %v, want it moved", err)
+	}
+}
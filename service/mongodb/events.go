@@ -0,0 +1,50 @@
+package mongodb
+
+import (
+	"github.com/meateam/permission-service/service"
+)
+
+// PermissionEventSink is notified of the permissions that were changed through a MongoStore,
+// for example to keep a search index or an activity feed in sync with the permissions.
+// Its methods are called in a goroutine of their own after the change was written, so they
+// can't block the write, must be safe for concurrent use, and may be called out of order.
+type PermissionEventSink interface {
+	// OnCreated is called with a permission that was created, upserted or restored.
+	OnCreated(permission service.Permission)
+
+	// OnUpdated is called with a permission whose role was updated.
+	OnUpdated(permission service.Permission)
+
+	// OnDeleted is called with a permission that was deleted.
+	OnDeleted(permission service.Permission)
+}
+
+// emitCreated notifies s.EventSink that permissions were created.
+func (s MongoStore) emitCreated(permissions ...service.Permission) {
+	s.emit(PermissionEventSink.OnCreated, permissions)
+}
+
+// emitUpdated notifies s.EventSink that permissions were updated.
+func (s MongoStore) emitUpdated(permissions ...service.Permission) {
+	s.emit(PermissionEventSink.OnUpdated, permissions)
+}
+
+// emitDeleted notifies s.EventSink that permissions were deleted.
+func (s MongoStore) emitDeleted(permissions ...service.Permission) {
+	s.emit(PermissionEventSink.OnDeleted, permissions)
+}
+
+// emit calls event with each of permissions on s.EventSink in a new goroutine,
+// it does nothing if s.EventSink is nil.
+func (s MongoStore) emit(event func(PermissionEventSink, service.Permission), permissions []service.Permission) {
+	sink := s.EventSink
+	if sink == nil || len(permissions) == 0 {
+		return
+	}
+
+	go func() {
+		for _, permission := range permissions {
+			event(sink, permission)
+		}
+	}()
+}
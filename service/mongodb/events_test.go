@@ -0,0 +1,126 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+)
+
+// event is a call to a method of a PermissionEventSink.
+type event struct {
+	name       string
+	permission service.Permission
+}
+
+// recordingSink is a PermissionEventSink that sends the events it's notified of to events.
+type recordingSink struct {
+	events chan event
+}
+
+func newRecordingSink() recordingSink {
+	return recordingSink{events: make(chan event, 16)}
+}
+
+func (s recordingSink) OnCreated(permission service.Permission) {
+	s.events <- event{name: "created", permission: permission}
+}
+
+func (s recordingSink) OnUpdated(permission service.Permission) {
+	s.events <- event{name: "updated", permission: permission}
+}
+
+func (s recordingSink) OnDeleted(permission service.Permission) {
+	s.events <- event{name: "deleted", permission: permission}
+}
+
+// next returns the next event of s, failing the test if none is emitted in time.
+func (s recordingSink) next(t *testing.T) event {
+	t.Helper()
+
+	select {
+	case e := <-s.events:
+		return e
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no event was emitted")
+		return event{}
+	}
+}
+
+func TestEventsEmitted(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	sink := newRecordingSink()
+	store.EventSink = sink
+
+	createPermission(t, store, "file", "user", pb.Role_READ)
+	if e := sink.next(t); e.name != "created" || e.permission.GetRole() != pb.Role_READ {
+		t.Errorf("Create() emitted %s of %v, want created of %v", e.name, e.permission.GetRole(), pb.Role_READ)
+	}
+
+	if _, err := store.UpdateRole(context.Background(), "file", "user", pb.Role_WRITE); err != nil {
+		t.Fatalf("UpdateRole() = %v, want nil", err)
+	}
+
+	if e := sink.next(t); e.name != "updated" || e.permission.GetRole() != pb.Role_WRITE {
+		t.Errorf("UpdateRole() emitted %s of %v, want updated of %v", e.name, e.permission.GetRole(), pb.Role_WRITE)
+	}
+
+	if _, err := store.Delete(context.Background(), FilterByFileAndUser("file", "user")); err != nil {
+		t.Fatalf("Delete() = %v, want nil", err)
+	}
+
+	if e := sink.next(t); e.name != "deleted" || e.permission.GetUserID() != "user" {
+		t.Errorf("Delete() emitted %s of %s, want deleted of user", e.name, e.permission.GetUserID())
+	}
+}
+
+func TestFailedMutationNotEmitted(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	sink := newRecordingSink()
+	store.EventSink = sink
+
+	_, err := store.UpdateRole(context.Background(), "file", "user", pb.Role_WRITE)
+	if err != service.ErrPermissionNotFound {
+		t.Fatalf("UpdateRole() of a missing permission = %v, want %v", err, service.ErrPermissionNotFound)
+	}
+
+	select {
+	case e := <-sink.events:
+		t.Errorf("failed UpdateRole() emitted %s", e.name)
+	case <-time.After(100 * time.Millisecond):
+	}
+}
+
+func TestEmitDoesNotBlock(t *testing.T) {
+	sink := recordingSink{events: make(chan event)}
+	store := MongoStore{EventSink: sink}
+
+	done := make(chan struct{})
+	go func() {
+		store.emitCreated(&BSON{FileID: "file", UserID: "user"})
+		close(done)
+	}()
+
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatalf("emitCreated() blocked on the sink")
+	}
+
+	if e := sink.next(t); e.name != "created" {
+		t.Errorf("emitCreated() emitted %s, want created", e.name)
+	}
+}
+
+func TestNilSinkIsNoop(t *testing.T) {
+	store := MongoStore{}
+	store.emitCreated(&BSON{FileID: "file", UserID: "user"})
+	store.emitUpdated(&BSON{FileID: "file", UserID: "user"})
+	store.emitDeleted(&BSON{FileID: "file", UserID: "user"})
+}
@@ -0,0 +1,118 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+)
+
+func TestExists(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	exists, err := store.Exists(context.Background(), "file", "user")
+	if err != nil {
+		t.Fatalf("Exists() of an absent permission = %v, want nil", err)
+	}
+
+	if exists {
+		t.Errorf("Exists() of an absent permission = true, want false")
+	}
+
+	createPermission(t, store, "file", "user", pb.Role_READ)
+
+	exists, err = store.Exists(context.Background(), "file", "user")
+	if err != nil {
+		t.Fatalf("Exists() = %v, want nil", err)
+	}
+
+	if !exists {
+		t.Errorf("Exists() of a present permission = false, want true")
+	}
+}
+
+func TestExistsMatching(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	exists, err := store.ExistsMatching(context.Background(), FilterByFile("file"))
+	if err != nil {
+		t.Fatalf("ExistsMatching() = %v, want nil", err)
+	}
+
+	if exists {
+		t.Errorf("ExistsMatching() of an empty collection = true, want false")
+	}
+
+	createPermission(t, store, "file", "a", pb.Role_READ)
+	createPermission(t, store, "file", "b", pb.Role_READ)
+
+	exists, err = store.ExistsMatching(context.Background(), FilterByFile("file"))
+	if err != nil {
+		t.Fatalf("ExistsMatching() = %v, want nil", err)
+	}
+
+	if !exists {
+		t.Errorf("ExistsMatching() of a shared file = false, want true")
+	}
+}
+
+func TestIsPermittedChecksIncludingRoles(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "user", pb.Role_WRITE)
+
+	for role, want := range map[pb.Role]bool{pb.Role_READ: true, pb.Role_WRITE: true, pb.Role_OWNER: false} {
+		permitted, err := store.IsPermitted(context.Background(), "file", "user", role)
+		if err != nil {
+			t.Fatalf("IsPermitted(%v) = %v, want nil", role, err)
+		}
+
+		if permitted != want {
+			t.Errorf("IsPermitted(%v) of a writer = %t, want %t", role, permitted, want)
+		}
+	}
+}
+
+func TestIsPermittedNoneSkipsQuery(t *testing.T) {
+	store, recorder := tracedStore(t)
+
+	permitted, err := store.IsPermitted(context.Background(), "file", "user", pb.Role_NONE)
+	if err != nil || permitted {
+		t.Fatalf("IsPermitted(NONE) = %t, %v, want false, nil", permitted, err)
+	}
+
+	if spans := recorder.Ended(); len(spans) != 1 {
+		t.Errorf("recorded %d spans, want only the mongo.IsPermitted span", len(spans))
+	}
+}
+
+func TestIsPermittedEachTier(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "reader", pb.Role_READ)
+	createPermission(t, store, "file", "writer", pb.Role_WRITE)
+	createPermission(t, store, "file", "owner", pb.Role_OWNER)
+
+	tests := []struct {
+		userID string
+		want   map[pb.Role]bool
+	}{
+		{"reader", map[pb.Role]bool{pb.Role_READ: true, pb.Role_WRITE: false, pb.Role_OWNER: false}},
+		{"writer", map[pb.Role]bool{pb.Role_READ: true, pb.Role_WRITE: true, pb.Role_OWNER: false}},
+		{"owner", map[pb.Role]bool{pb.Role_READ: true, pb.Role_WRITE: true, pb.Role_OWNER: true}},
+		{"missing", map[pb.Role]bool{pb.Role_READ: false, pb.Role_WRITE: false, pb.Role_OWNER: false}},
+	}
+
+	for _, tt := range tests {
+		for role, want := range tt.want {
+			permitted, err := store.IsPermitted(context.Background(), "file", tt.userID, role)
+			if err != nil || permitted != want {
+				t.Errorf("IsPermitted(%s, %v) = %t, %v, want %t, nil", tt.userID, role, permitted, err, want)
+			}
+		}
+	}
+}
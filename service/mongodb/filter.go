@@ -0,0 +1,213 @@
+package mongodb
+
+import (
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// filterFields maps the fields of a service.Filter to the fields of BSON they match.
+var filterFields = map[service.FilterField]string{
+	service.FilterFieldFileID: PermissionBSONFileIDField,
+	service.FilterFieldUserID: PermissionBSONUserIDField,
+	service.FilterFieldRole:   PermissionBSONRoleField,
+	service.FilterFieldDeny:   PermissionBSONDenyField,
+}
+
+// FilterByFile returns a filter that matches the permissions of fileID.
+func FilterByFile(fileID string) bson.D {
+	return bson.D{
+		bson.E{
+			Key:   PermissionBSONFileIDField,
+			Value: fileID,
+		},
+	}
+}
+
+// FilterByUser returns a filter that matches the permissions of userID.
+func FilterByUser(userID string) bson.D {
+	return bson.D{
+		bson.E{
+			Key:   PermissionBSONUserIDField,
+			Value: userID,
+		},
+	}
+}
+
+// FilterByFileAndUser returns a filter that matches the permission of userID to fileID.
+func FilterByFileAndUser(fileID string, userID string) bson.D {
+	return bson.D{
+		bson.E{
+			Key:   PermissionBSONFileIDField,
+			Value: fileID,
+		},
+		bson.E{
+			Key:   PermissionBSONUserIDField,
+			Value: userID,
+		},
+	}
+}
+
+// FilterByFileAndRole returns a filter that matches the permissions of fileID with exactly role.
+func FilterByFileAndRole(fileID string, role pb.Role) bson.D {
+	return bson.D{
+		bson.E{
+			Key:   PermissionBSONFileIDField,
+			Value: fileID,
+		},
+		bson.E{
+			Key:   PermissionBSONRoleField,
+			Value: role,
+		},
+	}
+}
+
+// FilterByFileAndRoles returns a filter that matches the permissions of fileID with any of roles,
+// if roles is empty it matches all of the permissions of fileID.
+func FilterByFileAndRoles(fileID string, roles []pb.Role) bson.D {
+	filter := FilterByFile(fileID)
+	if len(roles) == 0 {
+		return filter
+	}
+
+	values := make(bson.A, 0, len(roles))
+	for _, role := range roles {
+		values = append(values, role)
+	}
+
+	return append(filter, bson.E{
+		Key:   PermissionBSONRoleField,
+		Value: bson.D{bson.E{Key: "$in", Value: values}},
+	})
+}
+
+// FilterByFileUserAndRoles returns a filter that matches the permission of userID to fileID
+// if it has any of roles, if roles is empty it matches the permission with any role.
+func FilterByFileUserAndRoles(fileID string, userID string, roles []pb.Role) bson.D {
+	filter := FilterByFileAndUser(fileID, userID)
+	if len(roles) == 0 {
+		return filter
+	}
+
+	values := make(bson.A, 0, len(roles))
+	for _, role := range roles {
+		values = append(values, role)
+	}
+
+	return append(filter, bson.E{
+		Key:   PermissionBSONRoleField,
+		Value: bson.D{bson.E{Key: "$in", Value: values}},
+	})
+}
+
+// FilterByUserAndRole returns a filter that matches the permissions of userID with exactly role.
+func FilterByUserAndRole(userID string, role pb.Role) bson.D {
+	return bson.D{
+		bson.E{
+			Key:   PermissionBSONUserIDField,
+			Value: userID,
+		},
+		bson.E{
+			Key:   PermissionBSONRoleField,
+			Value: role,
+		},
+	}
+}
+
+// FilterCreatedBefore returns a filter that matches the permissions created before createdBefore,
+// for example to delete the permissions older than a date with DeleteMany. It can be combined with
+// the other filters by appending its elements to theirs. Permissions created before createdAt was
+// recorded have none and are never matched.
+func FilterCreatedBefore(createdBefore time.Time) bson.D {
+	return bson.D{
+		bson.E{
+			Key:   PermissionBSONCreatedAtField,
+			Value: bson.D{bson.E{Key: "$lt", Value: createdBefore}},
+		},
+	}
+}
+
+// FilterToBSON translates filter to the bson.D query that matches the same permissions.
+// Each condition is an equality of its field, or an $in of its values if it has several,
+// and if a field has more than one condition they're all matched with $and.
+// Granting permissions are stored without the deny field, so a deny condition of false also matches null.
+func FilterToBSON(filter service.Filter) bson.D {
+	conditions := filter.Conditions()
+	query := make(bson.D, 0, len(conditions))
+	fields := make(map[string]bool, len(conditions))
+	repeated := false
+	for _, condition := range conditions {
+		field := filterFields[condition.Field]
+		repeated = repeated || fields[field]
+		fields[field] = true
+
+		if condition.Field == service.FilterFieldDeny && containsValue(condition.Values, false) {
+			condition.Values = append(condition.Values, nil)
+		}
+
+		if len(condition.Values) == 1 {
+			query = append(query, bson.E{Key: field, Value: condition.Values[0]})
+			continue
+		}
+
+		query = append(query, bson.E{
+			Key:   field,
+			Value: bson.D{bson.E{Key: "$in", Value: bson.A(condition.Values)}},
+		})
+	}
+
+	if !repeated {
+		return query
+	}
+
+	elements := make(bson.A, 0, len(query))
+	for _, element := range query {
+		elements = append(elements, bson.D{element})
+	}
+
+	return bson.D{bson.E{Key: "$and", Value: elements}}
+}
+
+// containsValue returns true if value is one of values.
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+
+	return false
+}
+
+// bsonFilter returns the bson.D query of filter if it's a service.Filter,
+// otherwise returns filter as is since it's already a native query.
+func bsonFilter(filter interface{}) interface{} {
+	if f, ok := filter.(service.Filter); ok {
+		return FilterToBSON(f)
+	}
+
+	return filter
+}
+
+// FileAndUserFromFilter returns the fileID and userID of filter if it was built by FilterByFileAndUser,
+// or it's a service.Filter that matches a single user's permission to a single file,
+// otherwise returns ok as false.
+func FileAndUserFromFilter(filter interface{}) (fileID string, userID string, ok bool) {
+	if f, isFilter := filter.(service.Filter); isFilter {
+		return f.FileAndUser()
+	}
+
+	elements, isD := filter.(bson.D)
+	if !isD || len(elements) != 2 ||
+		elements[0].Key != PermissionBSONFileIDField ||
+		elements[1].Key != PermissionBSONUserIDField {
+		return "", "", false
+	}
+
+	fileID, fileOK := elements[0].Value.(string)
+	userID, userOK := elements[1].Value.(string)
+
+	return fileID, userID, fileOK && userOK
+}
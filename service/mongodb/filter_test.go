@@ -0,0 +1,151 @@
+package mongodb
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/memory"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestFilterToBSON(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter service.Filter
+		want   bson.D
+	}{
+		{"empty", service.Filter{}, bson.D{}},
+		{
+			"file and user",
+			service.And(service.ByFile("file"), service.ByUser("user")),
+			FilterByFileAndUser("file", "user"),
+		},
+		{
+			"file and roles",
+			service.And(service.ByFile("file"), service.ByRoles(pb.Role_READ, pb.Role_WRITE)),
+			FilterByFileAndRoles("file", []pb.Role{pb.Role_READ, pb.Role_WRITE}),
+		},
+		{
+			"granting",
+			service.ByDeny(false),
+			bson.D{bson.E{Key: "deny", Value: bson.D{bson.E{Key: "$in", Value: bson.A{false, nil}}}}},
+		},
+		{"denying", service.ByDeny(true), bson.D{bson.E{Key: "deny", Value: true}}},
+		{
+			"repeated field",
+			service.And(service.ByFile("file"), service.ByFile("other")),
+			bson.D{bson.E{Key: "$and", Value: bson.A{FilterByFile("file"), FilterByFile("other")}}},
+		},
+	}
+
+	for _, tt := range tests {
+		if got := FilterToBSON(tt.filter); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: FilterToBSON() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestFilterCreatedBefore(t *testing.T) {
+	createdBefore := time.Unix(1600000000, 0)
+	want := bson.D{bson.E{Key: "createdAt", Value: bson.D{bson.E{Key: "$lt", Value: createdBefore}}}}
+	if got := FilterCreatedBefore(createdBefore); !reflect.DeepEqual(got, want) {
+		t.Errorf("FilterCreatedBefore() = %v, want %v", got, want)
+	}
+
+	if err := service.RequireFilter(FilterCreatedBefore(createdBefore)); err != nil {
+		t.Errorf("RequireFilter(FilterCreatedBefore()) = %v, want nil", err)
+	}
+}
+
+func TestFilterAcrossStores(t *testing.T) {
+	mongoStore, drop := integrationStore(t)
+	defer drop()
+
+	stores := map[string]service.Store{"mongodb": mongoStore, "memory": memory.NewMemoryStore()}
+	for _, store := range stores {
+		for _, permission := range []*BSON{
+			{FileID: "file", UserID: "reader", Role: pb.Role_READ, Creator: "creator"},
+			{FileID: "file", UserID: "writer", Role: pb.Role_WRITE, Creator: "creator"},
+			{FileID: "other", UserID: "reader", Role: pb.Role_OWNER, Creator: "creator"},
+		} {
+			if _, err := store.Create(context.Background(), permission); err != nil {
+				t.Fatalf("Create() = %v, want nil", err)
+			}
+		}
+	}
+
+	tests := []struct {
+		name   string
+		filter service.Filter
+		want   []string
+	}{
+		{"file", service.ByFile("file"), []string{"file/reader", "file/writer"}},
+		{"user", service.ByUser("reader"), []string{"file/reader", "other/reader"}},
+		{"role", service.ByRole(pb.Role_OWNER), []string{"other/reader"}},
+		{
+			"file and roles",
+			service.And(service.ByFile("file"), service.ByRoles(pb.Role_WRITE, pb.Role_OWNER)),
+			[]string{"file/writer"},
+		},
+		{"file and user", service.And(service.ByFile("other"), service.ByUser("writer")), []string{}},
+	}
+
+	for _, tt := range tests {
+		for name, store := range stores {
+			permissions, err := store.GetAll(context.Background(), tt.filter)
+			if err != nil {
+				t.Fatalf("%s: %s GetAll() = %v, want nil", tt.name, name, err)
+			}
+
+			got := make([]string, 0, len(permissions))
+			for _, permission := range permissions {
+				got = append(got, permission.GetFileID()+"/"+permission.GetUserID())
+			}
+
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("%s: %s GetAll() = %v, want %v", tt.name, name, got, tt.want)
+			}
+		}
+	}
+}
+
+func TestFilterByRoleHelpersMatchOnlyRole(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "owner", pb.Role_OWNER)
+	createPermission(t, store, "file", "writer", pb.Role_WRITE)
+	createPermission(t, store, "other", "writer", pb.Role_READ)
+	createPermission(t, store, "third", "writer", pb.Role_WRITE)
+
+	tests := []struct {
+		name   string
+		filter bson.D
+		want   []string
+	}{
+		{"owners of file", FilterByFileAndRole("file", pb.Role_OWNER), []string{"file/owner"}},
+		{"readers of file", FilterByFileAndRole("file", pb.Role_READ), []string{}},
+		{"files writer writes", FilterByUserAndRole("writer", pb.Role_WRITE), []string{"file/writer", "third/writer"}},
+		{"files writer reads", FilterByUserAndRole("writer", pb.Role_READ), []string{"other/writer"}},
+	}
+
+	for _, tt := range tests {
+		permissions, err := store.GetAll(context.Background(), tt.filter)
+		if err != nil {
+			t.Fatalf("%s: GetAll() = %v, want nil", tt.name, err)
+		}
+
+		got := make([]string, 0, len(permissions))
+		for _, permission := range permissions {
+			got = append(got, permission.GetFileID()+"/"+permission.GetUserID())
+		}
+
+		if !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: GetAll() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,54 @@
+package mongodb
+
+import (
+	"context"
+
+	"github.com/meateam/permission-service/service"
+	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// MaxGetManyKeys is the maximum number of keys GetMany accepts in a single call.
+const MaxGetManyKeys = 1000
+
+// GetMany finds the permissions of keys in a single query, soft deleted and expired permissions
+// are excluded the same way Get does. Keys that have no permission are omitted from the result.
+// If successful returns the permissions by their keys, and a nil error,
+// if more than MaxGetManyKeys keys are given it would return nil and an InvalidArgument error,
+// otherwise returns nil and non-nil error if any occurred.
+func (s MongoStore) GetMany(
+	ctx context.Context,
+	keys []service.PermissionKey,
+) (found map[service.PermissionKey]service.Permission, err error) {
+	ctx, span := s.startSpan(ctx, "GetMany")
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	if len(keys) > MaxGetManyKeys {
+		return nil, status.Errorf(codes.InvalidArgument,
+			"at most %d keys are allowed, got %d", MaxGetManyKeys, len(keys))
+	}
+
+	found = make(map[service.PermissionKey]service.Permission, len(keys))
+	if len(keys) == 0 {
+		return found, nil
+	}
+
+	pairs := make(bson.A, 0, len(keys))
+	for _, key := range keys {
+		pairs = append(pairs, FilterByFileAndUser(key.FileID, key.UserID))
+	}
+
+	filter := bson.D{bson.E{Key: "$or", Value: pairs}}
+	permissions, err := s.find(ctx, active(filter), SortByID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, permission := range permissions {
+		key := service.PermissionKey{FileID: permission.GetFileID(), UserID: permission.GetUserID()}
+		found[key] = permission
+	}
+
+	return found, nil
+}
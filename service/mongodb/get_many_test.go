@@ -0,0 +1,52 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetManyOmitsAbsentKeys(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "user", pb.Role_READ)
+	createPermission(t, store, "file", "writer", pb.Role_WRITE)
+	createPermission(t, store, "other", "user", pb.Role_OWNER)
+
+	keys := []service.PermissionKey{
+		{FileID: "file", UserID: "user"},
+		{FileID: "other", UserID: "user"},
+		{FileID: "file", UserID: "stranger"},
+		{FileID: "missing", UserID: "user"},
+	}
+
+	found, err := store.GetMany(context.Background(), keys)
+	if err != nil {
+		t.Fatalf("GetMany() = %v, want nil", err)
+	}
+
+	want := map[service.PermissionKey]pb.Role{keys[0]: pb.Role_READ, keys[1]: pb.Role_OWNER}
+	if len(found) != len(want) {
+		t.Errorf("GetMany() returned %d permissions, want %d", len(found), len(want))
+	}
+
+	for key, role := range want {
+		if permission, ok := found[key]; !ok || permission.GetRole() != role {
+			t.Errorf("GetMany()[%v] = %v, want role %v", key, permission, role)
+		}
+	}
+}
+
+func TestGetManyRejectsTooManyKeys(t *testing.T) {
+	store, _ := tracedStore(t)
+
+	keys := make([]service.PermissionKey, MaxGetManyKeys+1)
+	if _, err := store.GetMany(context.Background(), keys); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("GetMany() of %d keys = %v, want %v", len(keys), err, codes.InvalidArgument)
+	}
+}
@@ -0,0 +1,27 @@
+package mongodb
+
+import "go.mongodb.org/mongo-driver/bson"
+
+// withGrantedBy returns a copy of update that also sets the grantedBy field to grantedBy only if the
+// permission is inserted, so recreating or updating a permission keeps the actor that first granted it.
+// Returns update as is if grantedBy is empty.
+func withGrantedBy(update bson.D, grantedBy string) bson.D {
+	if grantedBy == "" {
+		return update
+	}
+
+	return withSetOnInsert(update, bson.E{Key: PermissionBSONGrantedByField, Value: grantedBy})
+}
+
+// withSetOnInsert returns a copy of update that also sets field only if the permission is inserted.
+func withSetOnInsert(update bson.D, field bson.E) bson.D {
+	inserted := append(bson.D{}, update...)
+	for i, element := range inserted {
+		if fields, ok := element.Value.(bson.D); ok && element.Key == "$setOnInsert" {
+			inserted[i].Value = append(append(bson.D{}, fields...), field)
+			return inserted
+		}
+	}
+
+	return append(inserted, bson.E{Key: "$setOnInsert", Value: bson.D{field}})
+}
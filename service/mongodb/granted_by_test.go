@@ -0,0 +1,105 @@
+package mongodb
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/memory"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestWithGrantedBySetsGrantedByOnInsert(t *testing.T) {
+	permission := &BSON{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"}
+	update := permissionUpsert(permission, time.Now())
+	original := append(bson.D{}, update...)
+
+	granted := withGrantedBy(update, "actor")
+	if !reflect.DeepEqual(update, original) {
+		t.Errorf("withGrantedBy() modified update to %v, want it unchanged", update)
+	}
+
+	for _, element := range granted {
+		for _, field := range element.Value.(bson.D) {
+			if field.Key != PermissionBSONGrantedByField {
+				continue
+			}
+
+			if element.Key != "$setOnInsert" || field.Value != "actor" {
+				t.Errorf("withGrantedBy() sets %s to %v with %s, want actor with $setOnInsert",
+					field.Key, field.Value, element.Key)
+			}
+
+			return
+		}
+	}
+
+	t.Errorf("withGrantedBy() = %v, want it to set %s", granted, PermissionBSONGrantedByField)
+}
+
+func TestWithGrantedByWithoutActorKeepsUpdate(t *testing.T) {
+	permission := &BSON{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"}
+	update := permissionUpsert(permission, time.Now())
+
+	if granted := withGrantedBy(update, ""); !reflect.DeepEqual(granted, update) {
+		t.Errorf("withGrantedBy() = %v, want %v", granted, update)
+	}
+}
+
+func TestUpdatePermissionKeepsGrantedBy(t *testing.T) {
+	controller := NewController(memory.NewMemoryStore())
+	ctx := service.WithActorID(context.Background(), "granter")
+
+	_, err := controller.CreatePermission(
+		ctx, "file", "user", pb.Role_READ, "creator", time.Time{}, nil, "", "", false)
+	if err != nil {
+		t.Fatalf("CreatePermission() = %v, want nil", err)
+	}
+
+	updated, err := controller.UpdatePermission(
+		service.WithActorID(context.Background(), "updater"), "file", "user", pb.Role_WRITE, 0)
+	if err != nil {
+		t.Fatalf("UpdatePermission() = %v, want nil", err)
+	}
+
+	if updated.GetGrantedBy() != "granter" {
+		t.Errorf("UpdatePermission() grantedBy = %q, want granter", updated.GetGrantedBy())
+	}
+}
+
+func TestGrantedByIsKeptFromInsert(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	permission := &BSON{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"}
+	if _, err := store.Create(service.WithActorID(context.Background(), "granter"), permission); err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	ctx := service.WithActorID(context.Background(), "updater")
+	if _, err := store.UpdateRole(ctx, "file", "user", pb.Role_WRITE); err != nil {
+		t.Fatalf("UpdateRole() = %v, want nil", err)
+	}
+
+	// Recreating the permission updates it, so it's still granted by the actor that inserted it.
+	recreated, err := store.Create(ctx, permission)
+	if err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	if recreated.GetGrantedBy() != "granter" {
+		t.Errorf("Create() grantedBy = %q, want granter", recreated.GetGrantedBy())
+	}
+
+	found, err := store.Get(context.Background(), FilterByFileAndUser("file", "user"))
+	if err != nil {
+		t.Fatalf("Get() = %v, want nil", err)
+	}
+
+	if found.GetGrantedBy() != "granter" {
+		t.Errorf("Get() grantedBy = %q, want granter", found.GetGrantedBy())
+	}
+}
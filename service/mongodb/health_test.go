@@ -0,0 +1,114 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/meateam/permission-service/service"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestHealthStatusOfUnreachableDatabase(t *testing.T) {
+	store, _ := tracedStore(t)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+
+	health, err := store.HealthStatus(ctx)
+	if err == nil {
+		t.Fatalf("HealthStatus() of a disconnected store returned a nil error")
+	}
+
+	if health.State() != service.HealthNotServing {
+		t.Errorf("HealthStatus() state = %v, want %v", health.State(), service.HealthNotServing)
+	}
+
+	if result, err := store.HealthCheck(ctx); result.Healthy || err == nil {
+		t.Errorf("HealthCheck() = %+v, %v, want unhealthy and an error", result, err)
+	}
+}
+
+func TestHealthCheckTimeoutOfUnreachableDatabase(t *testing.T) {
+	client, err := mongo.Connect(context.Background(), options.Client().ApplyURI("mongodb://127.0.0.1:1"))
+	if err != nil {
+		t.Fatalf("mongo.Connect() = %v, want nil", err)
+	}
+	defer client.Disconnect(context.Background())
+
+	store := MongoStore{DB: client.Database("permission")}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	result, err := store.HealthCheck(ctx)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("HealthCheck() took %v, want it to return once the deadline passed", elapsed)
+	}
+
+	if err != nil {
+		t.Fatalf("HealthCheck() error = %v, want nil", err)
+	}
+
+	want := service.HealthResult{Healthy: false, Reason: service.HealthReasonPingTimeout}
+	if result != want {
+		t.Errorf("HealthCheck() = %+v, want %+v", result, want)
+	}
+}
+
+func TestHealthStatusDoesNotWaitForUnreachableSecondary(t *testing.T) {
+	store, cleanup := integrationStore(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*SecondaryPingTimeout)
+	defer cancel()
+
+	start := time.Now()
+	health, err := store.HealthStatus(ctx)
+	if err != nil {
+		t.Fatalf("HealthStatus() = %v, want nil", err)
+	}
+
+	if elapsed := time.Since(start); elapsed > 2*SecondaryPingTimeout {
+		t.Errorf("HealthStatus() took %v, want at most %v", elapsed, 2*SecondaryPingTimeout)
+	}
+
+	if !health.PrimaryReachable {
+		t.Errorf("HealthStatus() reported the primary as unreachable")
+	}
+}
+
+func TestHealthCheckOfReachablePrimaryBeforeSecondaryTimeout(t *testing.T) {
+	store, cleanup := integrationStore(t)
+	defer cleanup()
+
+	ctx, cancel := context.WithTimeout(context.Background(), SecondaryPingTimeout/2)
+	defer cancel()
+
+	result, err := store.HealthCheck(ctx)
+	if err != nil {
+		t.Fatalf("HealthCheck() error = %v, want nil", err)
+	}
+
+	if !result.Healthy {
+		t.Errorf("HealthCheck() = %+v, want healthy", result)
+	}
+}
+
+func TestHealthCheckOfMisconfiguredDatabase(t *testing.T) {
+	store, cleanup := integrationStore(t)
+	defer cleanup()
+
+	misconfigured := MongoStore{DB: store.DB.Client().Database(store.DB.Name() + "_missing")}
+	result, err := misconfigured.HealthCheck(context.Background())
+	if err == nil || result.Healthy {
+		t.Errorf("HealthCheck() of a database without the permissions collection = %+v, %v, "+
+			"want unhealthy and an error", result, err)
+	}
+
+	if result, err := store.HealthCheck(context.Background()); err != nil || !result.Healthy {
+		t.Errorf("HealthCheck() of the configured database = %+v, %v, want healthy", result, err)
+	}
+}
@@ -0,0 +1,81 @@
+package mongodb
+
+import (
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+func TestSameIndexKeys(t *testing.T) {
+	keys := bson.D{bson.E{Key: "fileID", Value: 1}, bson.E{Key: "userID", Value: 1}}
+
+	tests := []struct {
+		name  string
+		other bson.D
+		want  bool
+	}{
+		{
+			name:  "same keys of another numeric type",
+			other: bson.D{bson.E{Key: "fileID", Value: int64(1)}, bson.E{Key: "userID", Value: int32(1)}},
+			want:  true,
+		},
+		{
+			name:  "different order",
+			other: bson.D{bson.E{Key: "userID", Value: 1}, bson.E{Key: "fileID", Value: 1}},
+			want:  false,
+		},
+		{
+			name:  "different direction",
+			other: bson.D{bson.E{Key: "fileID", Value: 1}, bson.E{Key: "userID", Value: -1}},
+			want:  false,
+		},
+		{
+			name:  "prefix",
+			other: bson.D{bson.E{Key: "fileID", Value: 1}},
+			want:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		if got := sameIndexKeys(keys, tt.other); got != tt.want {
+			t.Errorf("%s: sameIndexKeys() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestSameIndexOptions(t *testing.T) {
+	zero := int64(0)
+	hour := int64(3600)
+
+	tests := []struct {
+		name  string
+		index existingIndex
+		opts  *options.IndexOptions
+		want  bool
+	}{
+		{name: "unique", index: existingIndex{Unique: true}, opts: options.Index().SetUnique(true), want: true},
+		{name: "not unique", index: existingIndex{}, opts: options.Index().SetUnique(true), want: false},
+		{name: "unexpectedly unique", index: existingIndex{Unique: true}, opts: nil, want: false},
+		{
+			name:  "same TTL",
+			index: existingIndex{ExpireAfterSeconds: &zero},
+			opts:  options.Index().SetExpireAfterSeconds(0),
+			want:  true,
+		},
+		{name: "no TTL", index: existingIndex{}, opts: options.Index().SetExpireAfterSeconds(0), want: false},
+		{
+			name:  "different TTL",
+			index: existingIndex{ExpireAfterSeconds: &hour},
+			opts:  options.Index().SetExpireAfterSeconds(0),
+			want:  false,
+		},
+		{name: "unexpected TTL", index: existingIndex{ExpireAfterSeconds: &zero}, opts: options.Index(), want: false},
+	}
+
+	for _, tt := range tests {
+		if got := sameIndexOptions(tt.index, tt.opts); got != tt.want {
+			t.Errorf("%s: sameIndexOptions() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package mongodb
+
+import (
+	"context"
+
+	"github.com/meateam/permission-service/service"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// GetEffectivePermission finds the permission of userID that applies to fileID, either its own
+// permission or the permission inherited from the closest of its ancestors that userID has a
+// permission to. ancestorIDs are the IDs of fileID's ancestors ordered from its parent to the root,
+// they're supplied by the caller since the store doesn't know the folder hierarchy.
+// If the same ID appears more than once in the chain then its closest position is used, and if
+// several permissions are equally close then the one with the highest role is returned.
+// A permission that denies access wins over every granting permission in the chain, however close.
+// If successful returns the permission, and a nil error, otherwise returns nil and
+// service.ErrPermissionNotFound if userID has no permission to fileID or its ancestors,
+// or the error that occurred.
+func (s MongoStore) GetEffectivePermission(
+	ctx context.Context,
+	fileID string,
+	userID string,
+	ancestorIDs []string,
+) (effective service.Permission, err error) {
+	ctx, span := s.startSpan(ctx, "GetEffectivePermission", idAttributes(fileID, userID)...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	// depths maps each file in the chain to its distance from fileID.
+	depths := make(map[string]int, len(ancestorIDs)+1)
+	fileIDs := make(bson.A, 0, len(ancestorIDs)+1)
+	for depth, id := range append([]string{fileID}, ancestorIDs...) {
+		if _, ok := depths[id]; !ok {
+			depths[id] = depth
+			fileIDs = append(fileIDs, id)
+		}
+	}
+
+	filter := bson.D{
+		bson.E{
+			Key:   PermissionBSONFileIDField,
+			Value: bson.D{bson.E{Key: "$in", Value: fileIDs}},
+		},
+		bson.E{
+			Key:   PermissionBSONUserIDField,
+			Value: userID,
+		},
+	}
+
+	permissions, err := s.find(ctx, active(filter), SortByID)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, permission := range permissions {
+		if effective == nil || precedes(permission, effective, depths) {
+			effective = permission
+		}
+	}
+
+	if effective == nil {
+		return nil, service.ErrPermissionNotFound
+	}
+
+	return effective, nil
+}
+
+// EffectiveRole is the role a user has to a file, either by the file's own permission
+// or inherited from one of its ancestors.
+type EffectiveRole struct {
+	// Role is the role of the permission that applies to the file.
+	Role service.Role
+
+	// InheritedFrom is the ID of the ancestor the role is inherited from,
+	// it's empty if the role is of the file's own permission.
+	InheritedFrom string
+
+	// Denied is true if the permission denies any access, regardless of Role.
+	Denied bool
+}
+
+// IsPermittedWithInheritance returns true if the effective role of userID to fileID includes required,
+// otherwise returns false. The effective role is found the same way GetEffectivePermission does,
+// the file's own permission applies if it exists, otherwise the permission of the closest of ancestors,
+// which are ordered from fileID's parent to the root. A permission that denies access to fileID or
+// any of ancestors wins, so userID isn't permitted regardless of any other permission.
+// If successful returns whether userID is permitted, the effective role, and a nil error,
+// the effective role is service.RoleNone if userID has no permission to fileID or its ancestors.
+// Otherwise returns false, an empty EffectiveRole and the error that occurred.
+func (s MongoStore) IsPermittedWithInheritance(
+	ctx context.Context,
+	fileID string,
+	userID string,
+	ancestors []string,
+	required service.Role,
+) (permitted bool, effective EffectiveRole, err error) {
+	ctx, span := s.startSpan(ctx, "IsPermittedWithInheritance", idAttributes(fileID, userID)...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	permission, err := s.GetEffectivePermission(ctx, fileID, userID, ancestors)
+	if err == service.ErrPermissionNotFound {
+		return false, EffectiveRole{Role: service.RoleNone}, nil
+	}
+
+	if err != nil {
+		return false, EffectiveRole{}, err
+	}
+
+	effective.Role = service.Role(permission.GetRole())
+	effective.Denied = permission.GetDeny()
+	if permission.GetFileID() != fileID {
+		effective.InheritedFrom = permission.GetFileID()
+	}
+
+	return !effective.Denied && effective.Role.Includes(required), effective, nil
+}
+
+// precedes returns true if permission takes precedence over current, either since it denies access
+// and current doesn't, or as closerOrHigher would if both or neither deny, otherwise returns false.
+func precedes(permission service.Permission, current service.Permission, depths map[string]int) bool {
+	if permission.GetDeny() != current.GetDeny() {
+		return permission.GetDeny()
+	}
+
+	return closerOrHigher(permission, current, depths)
+}
+
+// closerOrHigher returns true if permission is closer to the file than current by depths,
+// or as close with a higher role, otherwise returns false.
+func closerOrHigher(permission service.Permission, current service.Permission, depths map[string]int) bool {
+	depth, currentDepth := depths[permission.GetFileID()], depths[current.GetFileID()]
+	if depth != currentDepth {
+		return depth < currentDepth
+	}
+
+	role, currentRole := service.Role(permission.GetRole()), service.Role(current.GetRole())
+	return role.Includes(currentRole) && !currentRole.Includes(role)
+}
@@ -0,0 +1,118 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+)
+
+func TestEffectivePermissionDirectWins(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "user", pb.Role_READ)
+	createPermission(t, store, "parent", "user", pb.Role_OWNER)
+
+	permission, err := store.GetEffectivePermission(context.Background(), "file", "user", []string{"parent", "root"})
+	if err != nil {
+		t.Fatalf("GetEffectivePermission() = %v, want nil", err)
+	}
+
+	if permission.GetFileID() != "file" || permission.GetRole() != pb.Role_READ {
+		t.Errorf("GetEffectivePermission() = %s %v, want file %v",
+			permission.GetFileID(), permission.GetRole(), pb.Role_READ)
+	}
+}
+
+func TestEffectivePermissionInheritedFromGrandparent(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "grandparent", "user", pb.Role_WRITE)
+	createPermission(t, store, "root", "user", pb.Role_OWNER)
+	createPermission(t, store, "parent", "other", pb.Role_OWNER)
+
+	ancestors := []string{"parent", "grandparent", "root"}
+	permission, err := store.GetEffectivePermission(context.Background(), "file", "user", ancestors)
+	if err != nil {
+		t.Fatalf("GetEffectivePermission() = %v, want nil", err)
+	}
+
+	if permission.GetFileID() != "grandparent" || permission.GetRole() != pb.Role_WRITE {
+		t.Errorf("GetEffectivePermission() = %s %v, want grandparent %v",
+			permission.GetFileID(), permission.GetRole(), pb.Role_WRITE)
+	}
+
+	_, err = store.GetEffectivePermission(context.Background(), "file", "stranger", ancestors)
+	if err != service.ErrPermissionNotFound {
+		t.Errorf("GetEffectivePermission() without permissions = %v, want %v", err, service.ErrPermissionNotFound)
+	}
+}
+
+func TestIsPermittedWithInheritance(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "direct", pb.Role_WRITE)
+	createPermission(t, store, "parent", "direct", pb.Role_OWNER)
+	createPermission(t, store, "root", "inherited", pb.Role_WRITE)
+	createPermission(t, store, "unrelated", "stranger", pb.Role_OWNER)
+
+	ancestors := []string{"parent", "root"}
+	tests := []struct {
+		name          string
+		userID        string
+		required      service.Role
+		wantPermitted bool
+		wantEffective EffectiveRole
+	}{
+		{"direct", "direct", service.RoleWrite, true, EffectiveRole{Role: service.RoleWrite}},
+		{"direct overrides ancestors", "direct", service.RoleOwner, false, EffectiveRole{Role: service.RoleWrite}},
+		{
+			"inherited", "inherited", service.RoleRead, true,
+			EffectiveRole{Role: service.RoleWrite, InheritedFrom: "root"},
+		},
+		{"no access", "stranger", service.RoleRead, false, EffectiveRole{Role: service.RoleNone}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			permitted, effective, err := store.IsPermittedWithInheritance(
+				context.Background(), "file", tt.userID, ancestors, tt.required)
+			if err != nil {
+				t.Fatalf("IsPermittedWithInheritance() error = %v, want nil", err)
+			}
+
+			if permitted != tt.wantPermitted || effective != tt.wantEffective {
+				t.Errorf("IsPermittedWithInheritance() = %v, %+v, want %v, %+v",
+					permitted, effective, tt.wantPermitted, tt.wantEffective)
+			}
+		})
+	}
+}
+
+func TestCloserOrHigher(t *testing.T) {
+	depths := map[string]int{"file": 0, "parent": 1}
+	tests := []struct {
+		name       string
+		permission *BSON
+		current    *BSON
+		want       bool
+	}{
+		{"closer", &BSON{FileID: "file", Role: pb.Role_READ}, &BSON{FileID: "parent", Role: pb.Role_OWNER}, true},
+		{"farther", &BSON{FileID: "parent", Role: pb.Role_OWNER}, &BSON{FileID: "file", Role: pb.Role_READ}, false},
+		{"higher tie", &BSON{FileID: "file", Role: pb.Role_WRITE}, &BSON{FileID: "file", Role: pb.Role_READ}, true},
+		{"lower tie", &BSON{FileID: "file", Role: pb.Role_READ}, &BSON{FileID: "file", Role: pb.Role_WRITE}, false},
+		{"equal", &BSON{FileID: "file", Role: pb.Role_READ}, &BSON{FileID: "file", Role: pb.Role_READ}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := closerOrHigher(tt.permission, tt.current, depths); got != tt.want {
+				t.Errorf("closerOrHigher() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,82 @@
+package mongodb
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/memory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreatePermissionRoundTripsMetadata(t *testing.T) {
+	controller := NewController(memory.NewMemoryStore())
+	metadata := map[string]string{"sharedVia": "link", "note": "temporary"}
+
+	_, err := controller.CreatePermission(
+		context.Background(), "file", "user", pb.Role_READ, "creator", time.Time{}, metadata, "", "", false)
+	if err != nil {
+		t.Fatalf("CreatePermission() = %v, want nil", err)
+	}
+
+	permission, err := controller.GetByFileAndUser(context.Background(), "file", "user")
+	if err != nil {
+		t.Fatalf("GetByFileAndUser() = %v, want nil", err)
+	}
+
+	var marshaled pb.PermissionObject
+	if err := permission.MarshalProto(&marshaled); err != nil {
+		t.Fatalf("MarshalProto() = %v, want nil", err)
+	}
+
+	if !reflect.DeepEqual(marshaled.GetMetadata(), metadata) {
+		t.Errorf("MarshalProto() metadata = %v, want %v", marshaled.GetMetadata(), metadata)
+	}
+}
+
+func TestCreatePermissionRejectsOversizedMetadata(t *testing.T) {
+	controller := NewController(memory.NewMemoryStore())
+	metadata := map[string]string{"note": strings.Repeat("x", service.MaxMetadataSize)}
+
+	_, err := controller.CreatePermission(
+		context.Background(), "file", "user", pb.Role_READ, "creator", time.Time{}, metadata, "", "", false)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("CreatePermission() = %v, want code %v", err, codes.InvalidArgument)
+	}
+}
+
+func TestMetadataIsStoredAndReplaced(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	metadata := map[string]string{"sharedVia": "link"}
+	permission := &BSON{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator", Metadata: metadata}
+	if _, err := store.Create(context.Background(), permission); err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	found, err := store.Get(context.Background(), FilterByFileAndUser("file", "user"))
+	if err != nil {
+		t.Fatalf("Get() = %v, want nil", err)
+	}
+
+	if !reflect.DeepEqual(found.GetMetadata(), metadata) {
+		t.Errorf("Get() metadata = %v, want %v", found.GetMetadata(), metadata)
+	}
+
+	// Creating the permission again without metadata removes the previous metadata.
+	permission.Metadata = nil
+	recreated, err := store.Create(context.Background(), permission)
+	if err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	if len(recreated.GetMetadata()) != 0 {
+		t.Errorf("Create() metadata = %v, want none", recreated.GetMetadata())
+	}
+}
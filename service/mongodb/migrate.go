@@ -0,0 +1,180 @@
+package mongodb
+
+import (
+	"context"
+	"strings"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/sirupsen/logrus"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// DefaultMigrationBatchSize is the default number of permissions a RoleMigration reads at once.
+const DefaultMigrationBatchSize = 500
+
+// DefaultLegacyRoles maps the legacy role names that aren't role names to the roles they grant.
+var DefaultLegacyRoles = map[string]service.Role{
+	"reader": service.RoleRead,
+	"viewer": service.RoleRead,
+	"writer": service.RoleWrite,
+	"editor": service.RoleWrite,
+}
+
+// RoleMigration normalizes the permissions whose roles were stored as strings before roles were
+// stored as pb.Role values. It can be run again safely, since only string roles are changed.
+type RoleMigration struct {
+	// Mapping maps legacy role strings, compared ignoring case and surrounding whitespace, to roles.
+	// Strings that aren't in it are mapped by their role name, such as "read" or "READ".
+	// If it's nil then DefaultLegacyRoles is used.
+	Mapping map[string]service.Role
+
+	// BatchSize is the number of permissions read and updated at once,
+	// if it's not positive then DefaultMigrationBatchSize is used.
+	BatchSize int64
+
+	// Logger logs the permissions whose roles can't be mapped, for manual review.
+	// If it's nil then a new logrus.Logger is used.
+	Logger *logrus.Logger
+}
+
+// RoleMigrationResult is the outcome of a RoleMigration.
+type RoleMigrationResult struct {
+	// Normalized is the number of permissions whose roles were normalized.
+	Normalized int64
+
+	// Unmappable is the number of permissions whose roles couldn't be mapped and were left as is.
+	Unmappable int64
+}
+
+// legacyPermission is the part of a stored permission that a RoleMigration reads.
+type legacyPermission struct {
+	ID   primitive.ObjectID `bson:"_id"`
+	Role string             `bson:"role"`
+}
+
+// NormalizeRoles normalizes the legacy string roles of store's permissions by a RoleMigration
+// with the default mapping, batch size and logger.
+func NormalizeRoles(ctx context.Context, store MongoStore) (RoleMigrationResult, error) {
+	return RoleMigration{}.NormalizeRoles(ctx, store)
+}
+
+// NormalizeRoles scans the permissions of store whose roles are strings in batches, ordered by their
+// IDs, and updates each batch in a single bulk write to the roles that m maps them to. The permissions
+// of all tenants are normalized, including soft deleted ones. Unmappable roles are logged and left as is.
+// Returns the number of normalized and unmappable permissions, and any error that occurred, in which
+// case the batches that were already written stay normalized.
+func (m RoleMigration) NormalizeRoles(
+	ctx context.Context,
+	store MongoStore,
+) (result RoleMigrationResult, err error) {
+	ctx, span := store.startSpan(ctx, "NormalizeRoles")
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	batchSize := m.BatchSize
+	if batchSize <= 0 {
+		batchSize = DefaultMigrationBatchSize
+	}
+
+	logger := m.Logger
+	if logger == nil {
+		logger = logrus.New()
+	}
+
+	collection := store.collection()
+	sort, err := sortDocument(SortByID)
+	if err != nil {
+		return result, err
+	}
+
+	stringRole := bson.E{
+		Key:   PermissionBSONRoleField,
+		Value: bson.D{bson.E{Key: "$type", Value: "string"}},
+	}
+
+	var lastID primitive.ObjectID
+	for {
+		filter := bson.D{stringRole}
+		if !lastID.IsZero() {
+			filter = append(filter, bson.E{
+				Key:   MongoObjectIDField,
+				Value: bson.D{bson.E{Key: "$gt", Value: lastID}},
+			})
+		}
+
+		opts := options.Find().SetSort(sort).SetLimit(batchSize)
+		cursor, err := collection.Find(ctx, filter, opts)
+		if err != nil {
+			return result, err
+		}
+
+		var batch []legacyPermission
+		if err := cursor.All(ctx, &batch); err != nil {
+			return result, err
+		}
+
+		models := make([]mongo.WriteModel, 0, len(batch))
+		for _, permission := range batch {
+			role, ok := m.mapRole(permission.Role)
+			if !ok {
+				logger.Warnf("permission %s has unmappable role %q, left as is", permission.ID.Hex(), permission.Role)
+				result.Unmappable++
+				continue
+			}
+
+			// The role is matched as well so a permission changed since it was read is left as is.
+			models = append(models, mongo.NewUpdateOneModel().
+				SetFilter(bson.D{
+					bson.E{Key: MongoObjectIDField, Value: permission.ID},
+					bson.E{Key: PermissionBSONRoleField, Value: permission.Role},
+				}).
+				SetUpdate(bson.D{
+					bson.E{
+						Key:   "$set",
+						Value: bson.D{bson.E{Key: PermissionBSONRoleField, Value: pb.Role(role)}},
+					},
+				}))
+		}
+
+		if len(models) > 0 {
+			written, err := collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+			if err != nil {
+				return result, err
+			}
+
+			result.Normalized += written.ModifiedCount
+		}
+
+		if int64(len(batch)) < batchSize {
+			return result, nil
+		}
+
+		lastID = batch[len(batch)-1].ID
+	}
+}
+
+// mapRole returns the role that legacy is mapped to by m's mapping or by its role name, and false
+// if it's not mapped to a role that can be granted.
+func (m RoleMigration) mapRole(legacy string) (service.Role, bool) {
+	mapping := m.Mapping
+	if mapping == nil {
+		mapping = DefaultLegacyRoles
+	}
+
+	normalized := strings.ToLower(strings.TrimSpace(legacy))
+	for name, role := range mapping {
+		if strings.ToLower(strings.TrimSpace(name)) == normalized {
+			return role, service.ValidateRole(pb.Role(role)) == nil
+		}
+	}
+
+	role, err := service.RoleFromString(legacy)
+	if err != nil {
+		return service.RoleNone, false
+	}
+
+	return role, service.ValidateRole(pb.Role(role)) == nil
+}
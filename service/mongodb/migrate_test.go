@@ -0,0 +1,109 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/sirupsen/logrus/hooks/test"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestRoleMigrationMapsLegacyRoles(t *testing.T) {
+	migration := RoleMigration{
+		Mapping: map[string]service.Role{"Editor": service.RoleWrite, "admin": service.RoleOwner},
+	}
+
+	tests := []struct {
+		legacy string
+		want   service.Role
+		ok     bool
+	}{
+		{legacy: "read", want: service.RoleRead, ok: true},
+		{legacy: "READ", want: service.RoleRead, ok: true},
+		{legacy: " editor ", want: service.RoleWrite, ok: true},
+		{legacy: "ADMIN", want: service.RoleOwner, ok: true},
+		{legacy: "owner", want: service.RoleOwner, ok: true},
+		{legacy: "viewer", ok: false},
+		{legacy: "none", ok: false},
+		{legacy: "", ok: false},
+	}
+
+	for _, test := range tests {
+		role, ok := migration.mapRole(test.legacy)
+		if ok != test.ok || (ok && role != test.want) {
+			t.Errorf("mapRole(%q) = %v, %t, want %v, %t", test.legacy, role, ok, test.want, test.ok)
+		}
+	}
+}
+
+func TestRoleMigrationDefaultMapping(t *testing.T) {
+	for legacy, want := range DefaultLegacyRoles {
+		if role, ok := (RoleMigration{}).mapRole(legacy); !ok || role != want {
+			t.Errorf("mapRole(%q) = %v, %t, want %v, true", legacy, role, ok, want)
+		}
+	}
+}
+
+func TestNormalizeRoles(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	legacyRoles := []string{"read", "READ", "Editor", "write", " viewer", "owner", "superuser"}
+	documents := make([]interface{}, 0, len(legacyRoles)+1)
+	for i, role := range legacyRoles {
+		documents = append(documents, bson.D{
+			bson.E{Key: PermissionBSONFileIDField, Value: "file"},
+			bson.E{Key: PermissionBSONUserIDField, Value: fmt.Sprintf("user%d", i)},
+			bson.E{Key: PermissionBSONRoleField, Value: role},
+			bson.E{Key: PermissionBSONCreatorField, Value: "creator"},
+		})
+	}
+
+	// A permission that's already canonical is left as is.
+	documents = append(documents, bson.D{
+		bson.E{Key: PermissionBSONFileIDField, Value: "file"},
+		bson.E{Key: PermissionBSONUserIDField, Value: "canonical"},
+		bson.E{Key: PermissionBSONRoleField, Value: pb.Role_READ},
+		bson.E{Key: PermissionBSONCreatorField, Value: "creator"},
+	})
+
+	if _, err := store.collection().InsertMany(context.Background(), documents); err != nil {
+		t.Fatalf("InsertMany() = %v, want nil", err)
+	}
+
+	logger, hook := test.NewNullLogger()
+	migration := RoleMigration{BatchSize: 2, Logger: logger}
+
+	result, err := migration.NormalizeRoles(context.Background(), store)
+	if err != nil {
+		t.Fatalf("NormalizeRoles() = %v, want nil", err)
+	}
+
+	if result.Normalized != 6 || result.Unmappable != 1 {
+		t.Errorf("NormalizeRoles() = %+v, want 6 normalized and 1 unmappable", result)
+	}
+
+	if len(hook.AllEntries()) != 1 {
+		t.Errorf("logged %d entries, want 1 of the unmappable role", len(hook.AllEntries()))
+	}
+
+	want := []pb.Role{pb.Role_READ, pb.Role_READ, pb.Role_WRITE, pb.Role_WRITE, pb.Role_READ, pb.Role_OWNER}
+	for i, role := range want {
+		if got := roleOf(t, store, "file", fmt.Sprintf("user%d", i)); got != role {
+			t.Errorf("role of legacy %q = %v, want %v", legacyRoles[i], got, role)
+		}
+	}
+
+	// Running the migration again only finds the unmappable role.
+	result, err = migration.NormalizeRoles(context.Background(), store)
+	if err != nil {
+		t.Fatalf("second NormalizeRoles() = %v, want nil", err)
+	}
+
+	if result.Normalized != 0 || result.Unmappable != 1 {
+		t.Errorf("second NormalizeRoles() = %+v, want 0 normalized and 1 unmappable", result)
+	}
+}
@@ -2,18 +2,31 @@ package mongodb
 
 import (
 	"fmt"
+	"time"
 
 	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
 // BSON is the structure that represents a permission as it's stored.
 type BSON struct {
-	ID      primitive.ObjectID `bson:"_id,omitempty"`
-	FileID  string             `bson:"fileID,omitempty"`
-	UserID  string             `bson:"userID,omitempty"`
-	Role    pb.Role            `bson:"role"`
-	Creator string             `bson:"creator"`
+	ID          primitive.ObjectID `bson:"_id,omitempty"`
+	FileID      string             `bson:"fileID,omitempty"`
+	UserID      string             `bson:"userID,omitempty"`
+	Role        pb.Role            `bson:"role"`
+	Creator     string             `bson:"creator"`
+	ExpiresAt   *time.Time         `bson:"expiresAt,omitempty"`
+	Version     int64              `bson:"version"`
+	DeletedAt   *time.Time         `bson:"deletedAt,omitempty"`
+	CreatedAt   *time.Time         `bson:"createdAt,omitempty"`
+	UpdatedAt   *time.Time         `bson:"updatedAt,omitempty"`
+	TenantID    string             `bson:"tenantID,omitempty"`
+	Metadata    map[string]string  `bson:"metadata,omitempty"`
+	Reason      string             `bson:"reason,omitempty"`
+	GrantedBy   string             `bson:"grantedBy,omitempty"`
+	SubjectType string             `bson:"subjectType,omitempty"`
+	Deny        bool               `bson:"deny,omitempty"`
 }
 
 // GetID returns the string value of the b.ID.
@@ -89,8 +102,8 @@ func (b *BSON) SetRole(role pb.Role) error {
 		panic("b == nil")
 	}
 
-	if pb.Role_name[int32(role)] == "" {
-		return fmt.Errorf("Role does not exist")
+	if err := service.ValidateRole(role); err != nil {
+		return err
 	}
 
 	b.Role = role
@@ -116,6 +129,169 @@ func (b *BSON) SetCreator(creator string) error {
 	return nil
 }
 
+// GetExpiresAt returns b.ExpiresAt, or the zero time if b never expires.
+func (b BSON) GetExpiresAt() time.Time {
+	if b.ExpiresAt == nil {
+		return time.Time{}
+	}
+
+	return *b.ExpiresAt
+}
+
+// SetExpiresAt sets b.ExpiresAt to expiresAt, the zero time means b never expires.
+func (b *BSON) SetExpiresAt(expiresAt time.Time) error {
+	if b == nil {
+		panic("b == nil")
+	}
+
+	if expiresAt.IsZero() {
+		b.ExpiresAt = nil
+		return nil
+	}
+
+	b.ExpiresAt = &expiresAt
+	return nil
+}
+
+// GetVersion returns b.Version.
+func (b BSON) GetVersion() int64 {
+	return b.Version
+}
+
+// SetVersion sets b.Version to version.
+func (b *BSON) SetVersion(version int64) error {
+	if b == nil {
+		panic("b == nil")
+	}
+
+	if version < 0 {
+		return fmt.Errorf("Version must not be negative")
+	}
+
+	b.Version = version
+	return nil
+}
+
+// GetMetadata returns b.Metadata.
+func (b BSON) GetMetadata() map[string]string {
+	return b.Metadata
+}
+
+// SetMetadata sets b.Metadata to metadata, which must be at most service.MaxMetadataSize bytes.
+func (b *BSON) SetMetadata(metadata map[string]string) error {
+	if b == nil {
+		panic("b == nil")
+	}
+
+	if err := service.ValidateMetadata(metadata); err != nil {
+		return err
+	}
+
+	b.Metadata = metadata
+	return nil
+}
+
+// GetReason returns b.Reason.
+func (b BSON) GetReason() string {
+	return b.Reason
+}
+
+// SetReason sets b.Reason to reason, which must be at most service.MaxReasonLength characters.
+func (b *BSON) SetReason(reason string) error {
+	if b == nil {
+		panic("b == nil")
+	}
+
+	if err := service.ValidateReason(reason); err != nil {
+		return err
+	}
+
+	b.Reason = reason
+	return nil
+}
+
+// GetGrantedBy returns b.GrantedBy, or an empty string if the actor that granted b is unknown.
+func (b BSON) GetGrantedBy() string {
+	return b.GrantedBy
+}
+
+// GetSubjectType returns b.SubjectType, or service.SubjectTypeUser if it's empty, since permissions
+// stored before groups were supported have none. b.UserID is the ID of a group if it's a group's.
+func (b BSON) GetSubjectType() string {
+	if b.SubjectType == "" {
+		return service.SubjectTypeUser
+	}
+
+	return b.SubjectType
+}
+
+// SetSubjectType sets b.SubjectType to subjectType, which must be empty, service.SubjectTypeUser
+// or service.SubjectTypeGroup. A user subject type is stored empty.
+func (b *BSON) SetSubjectType(subjectType string) error {
+	if b == nil {
+		panic("b == nil")
+	}
+
+	if err := service.ValidateSubjectType(subjectType); err != nil {
+		return err
+	}
+
+	if subjectType == service.SubjectTypeUser {
+		subjectType = ""
+	}
+
+	b.SubjectType = subjectType
+	return nil
+}
+
+// GetDeny returns b.Deny, which is true if b denies b.UserID any access to b.FileID regardless of
+// b.Role and of any other permission that would grant it.
+func (b BSON) GetDeny() bool {
+	return b.Deny
+}
+
+// SetDeny sets b.Deny to deny.
+func (b *BSON) SetDeny(deny bool) error {
+	if b == nil {
+		panic("b == nil")
+	}
+
+	b.Deny = deny
+	return nil
+}
+
+// GetDeletedAt returns b.DeletedAt, or the zero time if b was not soft deleted.
+func (b BSON) GetDeletedAt() time.Time {
+	if b.DeletedAt == nil {
+		return time.Time{}
+	}
+
+	return *b.DeletedAt
+}
+
+// GetCreatedAt returns b.CreatedAt, or the zero time if b was stored before it was tracked.
+func (b BSON) GetCreatedAt() time.Time {
+	if b.CreatedAt == nil {
+		return time.Time{}
+	}
+
+	return *b.CreatedAt
+}
+
+// GetUpdatedAt returns b.UpdatedAt, or the zero time if b was stored before it was tracked.
+func (b BSON) GetUpdatedAt() time.Time {
+	if b.UpdatedAt == nil {
+		return time.Time{}
+	}
+
+	return *b.UpdatedAt
+}
+
+// GetTenantID returns b.TenantID, or an empty string if b was stored without a tenant.
+func (b BSON) GetTenantID() string {
+	return b.TenantID
+}
+
 // MarshalProto marshals b into a permission.
 func (b BSON) MarshalProto(permission *pb.PermissionObject) error {
 	permission.Id = b.GetID()
@@ -123,6 +299,26 @@ func (b BSON) MarshalProto(permission *pb.PermissionObject) error {
 	permission.UserID = b.GetUserID()
 	permission.Role = b.GetRole()
 	permission.Creator = b.GetCreator()
+	permission.ExpiresAt = 0
+	if expiresAt := b.GetExpiresAt(); !expiresAt.IsZero() {
+		permission.ExpiresAt = expiresAt.Unix()
+	}
+
+	permission.Version = b.GetVersion()
+	permission.CreatedAt = 0
+	if createdAt := b.GetCreatedAt(); !createdAt.IsZero() {
+		permission.CreatedAt = createdAt.Unix()
+	}
+
+	permission.UpdatedAt = 0
+	if updatedAt := b.GetUpdatedAt(); !updatedAt.IsZero() {
+		permission.UpdatedAt = updatedAt.Unix()
+	}
 
+	permission.Metadata = b.GetMetadata()
+	permission.Reason = b.GetReason()
+	permission.GrantedBy = b.GetGrantedBy()
+	permission.SubjectType = b.GetSubjectType()
+	permission.Deny = b.GetDeny()
 	return nil
 }
@@ -0,0 +1,125 @@
+package mongodb
+
+import (
+	"strings"
+
+	"github.com/meateam/permission-service/service"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// IDNormalizer normalizes the fileIDs and userIDs a MongoStore stores and queries, so IDs that differ
+// only in their form, such as their casing, identify the same permission. It must be idempotent.
+type IDNormalizer interface {
+	// NormalizeID returns the normalized form of id of field, which is PermissionBSONFileIDField
+	// or PermissionBSONUserIDField.
+	NormalizeID(field string, id string) string
+}
+
+// normalizeID returns id of field normalized by s.Normalizer, or id as is if s has no Normalizer.
+func (s MongoStore) normalizeID(field string, id string) string {
+	if s.Normalizer == nil {
+		return id
+	}
+
+	return s.Normalizer.NormalizeID(field, id)
+}
+
+// normalizePermission returns a copy of permission with its fileID and userID normalized by s.Normalizer,
+// or permission as is if s has no Normalizer.
+func (s MongoStore) normalizePermission(permission service.Permission) (service.Permission, error) {
+	if s.Normalizer == nil {
+		return permission, nil
+	}
+
+	normalized := &BSON{
+		FileID:   s.normalizeID(PermissionBSONFileIDField, permission.GetFileID()),
+		UserID:   s.normalizeID(PermissionBSONUserIDField, permission.GetUserID()),
+		Role:     permission.GetRole(),
+		Creator:  permission.GetCreator(),
+		Version:  permission.GetVersion(),
+		Metadata: permission.GetMetadata(),
+		Reason:   permission.GetReason(),
+		Deny:     permission.GetDeny(),
+	}
+
+	if err := normalized.SetSubjectType(permission.GetSubjectType()); err != nil {
+		return nil, err
+	}
+
+	if err := normalized.SetExpiresAt(permission.GetExpiresAt()); err != nil {
+		return nil, err
+	}
+
+	return normalized, nil
+}
+
+// normalizePermissions returns the permissions normalized the same way normalizePermission does.
+func (s MongoStore) normalizePermissions(permissions []service.Permission) ([]service.Permission, error) {
+	if s.Normalizer == nil {
+		return permissions, nil
+	}
+
+	normalized := make([]service.Permission, 0, len(permissions))
+	for _, permission := range permissions {
+		normalizedPermission, err := s.normalizePermission(permission)
+		if err != nil {
+			return nil, err
+		}
+
+		normalized = append(normalized, normalizedPermission)
+	}
+
+	return normalized, nil
+}
+
+// normalizeFilter returns a copy of filter whose fileIDs and userIDs are normalized by s.Normalizer,
+// or filter as is if s has no Normalizer. A service.Filter is translated to its bson.D query first.
+func (s MongoStore) normalizeFilter(filter interface{}) interface{} {
+	filter = bsonFilter(filter)
+	if s.Normalizer == nil {
+		return filter
+	}
+
+	return normalizeFilterValue(s.Normalizer, "", filter)
+}
+
+// normalizeFilterValue returns a copy of value, a filter or a part of it, whose IDs are normalized by
+// normalizer. field is the ID field that value is matched against, or empty if it's not an ID's value.
+// Only bson.D and bson.A documents are traversed, other values are returned as is.
+func normalizeFilterValue(normalizer IDNormalizer, field string, value interface{}) interface{} {
+	switch value := value.(type) {
+	case string:
+		if field == "" {
+			return value
+		}
+
+		return normalizer.NormalizeID(field, value)
+	case bson.D:
+		normalized := make(bson.D, 0, len(value))
+		for _, element := range value {
+			// Operators such as $in match the values of the field they're applied to.
+			elementField := field
+			if element.Key == PermissionBSONFileIDField || element.Key == PermissionBSONUserIDField {
+				elementField = element.Key
+			} else if !strings.HasPrefix(element.Key, "$") {
+				elementField = ""
+			}
+
+			normalized = append(normalized, bson.E{
+				Key:   element.Key,
+				Value: normalizeFilterValue(normalizer, elementField, element.Value),
+			})
+		}
+
+		return normalized
+	case bson.A:
+		normalized := make(bson.A, 0, len(value))
+		for _, item := range value {
+			normalized = append(normalized, normalizeFilterValue(normalizer, field, item))
+		}
+
+		return normalized
+	}
+
+	return value
+}
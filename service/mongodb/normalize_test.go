@@ -0,0 +1,81 @@
+package mongodb
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// lowercaseNormalizer is an IDNormalizer that lowercases IDs.
+type lowercaseNormalizer struct{}
+
+// NormalizeID returns id in lowercase.
+func (lowercaseNormalizer) NormalizeID(field string, id string) string {
+	return strings.ToLower(id)
+}
+
+func TestNormalizeFilter(t *testing.T) {
+	store := MongoStore{Normalizer: lowercaseNormalizer{}}
+	filter := bson.D{
+		bson.E{Key: PermissionBSONFileIDField, Value: "File"},
+		bson.E{Key: PermissionBSONUserIDField, Value: bson.D{bson.E{Key: "$in", Value: bson.A{"Alice", "Bob"}}}},
+		bson.E{Key: PermissionBSONCreatorField, Value: "Carol"},
+	}
+
+	want := bson.D{
+		bson.E{Key: PermissionBSONFileIDField, Value: "file"},
+		bson.E{Key: PermissionBSONUserIDField, Value: bson.D{bson.E{Key: "$in", Value: bson.A{"alice", "bob"}}}},
+		bson.E{Key: PermissionBSONCreatorField, Value: "Carol"},
+	}
+
+	if got := store.normalizeFilter(notDeleted(filter)); !reflect.DeepEqual(got, notDeleted(want)) {
+		t.Errorf("normalizeFilter() = %v, want %v", got, notDeleted(want))
+	}
+}
+
+func TestNormalizeFilterWithoutNormalizer(t *testing.T) {
+	filter := FilterByFileAndUser("File", "Alice")
+
+	if got := (MongoStore{}).normalizeFilter(filter); !reflect.DeepEqual(got, filter) {
+		t.Errorf("normalizeFilter() = %v, want %v", got, filter)
+	}
+}
+
+func TestNormalizedIDsHitTheSameRecord(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	store.Normalizer = lowercaseNormalizer{}
+	ctx := context.Background()
+
+	permission := &BSON{FileID: "file", UserID: "Alice@corp", Role: pb.Role_READ, Creator: "creator"}
+	created, err := store.Create(ctx, permission)
+	if err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	if created.GetUserID() != "alice@corp" {
+		t.Errorf("Create() userID = %q, want %q", created.GetUserID(), "alice@corp")
+	}
+
+	found, err := store.Get(ctx, FilterByFileAndUser("file", "ALICE@corp"))
+	if err != nil {
+		t.Fatalf("Get() = %v, want nil", err)
+	}
+
+	if found.GetID() != created.GetID() {
+		t.Errorf("Get() = %s, want the created permission %s", found.GetID(), created.GetID())
+	}
+
+	if _, err := store.Delete(ctx, FilterByFileAndUser("file", "alice@CORP")); err != nil {
+		t.Fatalf("Delete() = %v, want nil", err)
+	}
+
+	if count, err := store.Count(ctx, FilterByFile("file")); err != nil || count != 0 {
+		t.Errorf("Count() = %d, %v, want 0 after the delete", count, err)
+	}
+}
@@ -0,0 +1,184 @@
+package mongodb
+
+import (
+	"context"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ownerIndexName is the name of the unique index that allows at most one OWNER permission per file
+// of a tenant.
+const ownerIndexName = "fileID_tenantID_owner"
+
+// legacyOwnerIndexName is the name of the owner index that was created before permissions had tenants.
+const legacyOwnerIndexName = "fileID_owner"
+
+// OwnerConflictPolicy is what a MongoStore does when a user is made the owner of a file
+// that already has another owner, since a file has at most one owner.
+type OwnerConflictPolicy int
+
+const (
+	// OwnerConflictDemote demotes the file's current owner to the store's DemotedOwnerRole,
+	// it's the default policy.
+	OwnerConflictDemote OwnerConflictPolicy = iota
+
+	// OwnerConflictReject rejects making another user the owner with a FailedPrecondition error,
+	// ownership must then be moved with TransferOwnership.
+	OwnerConflictReject
+)
+
+// ownerIndexModel returns the index that allows at most one OWNER permission per file of a tenant,
+// including the expired and soft deleted ones, so the invariant holds even under concurrent writes.
+func ownerIndexModel() mongo.IndexModel {
+	return mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{
+				Key:   PermissionBSONFileIDField,
+				Value: 1,
+			},
+			bson.E{
+				Key:   PermissionBSONTenantIDField,
+				Value: 1,
+			},
+		},
+		Options: options.Index().
+			SetName(ownerIndexName).
+			SetUnique(true).
+			SetPartialFilterExpression(bson.D{
+				bson.E{
+					Key:   PermissionBSONRoleField,
+					Value: pb.Role_OWNER,
+				},
+			}),
+	}
+}
+
+// demotedOwnerRole returns the role an owner is demoted to, s.DemotedOwnerRole or
+// DefaultDemotedOwnerRole if it's NONE, otherwise returns an error if it's not a valid role.
+func (s MongoStore) demotedOwnerRole() (pb.Role, error) {
+	demotedRole := s.DemotedOwnerRole
+	if demotedRole == pb.Role_NONE {
+		demotedRole = DefaultDemotedOwnerRole
+	}
+
+	if err := service.ValidateRole(demotedRole); err != nil {
+		return pb.Role_NONE, err
+	}
+
+	return demotedRole, nil
+}
+
+// withSingleOwner runs write, which sets the role of userID's permission to fileID to role.
+// If role is OWNER then write runs in a transaction after the other owners of fileID are resolved
+// by s.OwnerConflict, and the demoted owners are emitted to s.EventSink if the transaction commits.
+// The transaction may be retried so write must be safe to run more than once.
+func (s MongoStore) withSingleOwner(
+	ctx context.Context,
+	fileID string,
+	userID string,
+	role pb.Role,
+	write func(ctx context.Context) error,
+) error {
+	if role != pb.Role_OWNER {
+		return write(ctx)
+	}
+
+	var demoted []service.Permission
+	err := s.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		var err error
+		if demoted, err = s.resolveOwnerConflict(sessCtx, fileID, userID); err != nil {
+			return err
+		}
+
+		return write(sessCtx)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	s.emitUpdated(demoted...)
+	return nil
+}
+
+// resolveOwnerConflict makes way for userID to become the owner of fileID by s.OwnerConflict,
+// either demoting the file's other owners or rejecting with a FailedPrecondition error if one of
+// them is active. Inactive owners, expired or soft deleted, are always demoted since they
+// still take the file's slot in the owner index.
+// If successful returns the demoted permissions, otherwise returns nil and non-nil error if any occurred.
+func (s MongoStore) resolveOwnerConflict(
+	ctx context.Context,
+	fileID string,
+	userID string,
+) ([]service.Permission, error) {
+	collection := s.collection()
+	otherOwners := bson.D{
+		bson.E{
+			Key:   PermissionBSONFileIDField,
+			Value: fileID,
+		},
+		bson.E{
+			Key:   PermissionBSONUserIDField,
+			Value: bson.D{bson.E{Key: "$ne", Value: userID}},
+		},
+		bson.E{
+			Key:   PermissionBSONRoleField,
+			Value: pb.Role_OWNER,
+		},
+	}
+
+	tenantID, err := s.tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.OwnerConflict == OwnerConflictReject {
+		err := collection.FindOne(ctx, scopeToTenant(active(otherOwners), tenantID)).Err()
+		if err == nil {
+			return nil, status.Errorf(codes.FailedPrecondition, "file %s already has an owner", fileID)
+		}
+
+		if err != mongo.ErrNoDocuments {
+			return nil, err
+		}
+	}
+
+	demotedRole, err := s.demotedOwnerRole()
+	if err != nil {
+		return nil, err
+	}
+
+	demote := bson.D{
+		bson.E{
+			Key: "$set",
+			Value: bson.D{
+				bson.E{
+					Key:   PermissionBSONRoleField,
+					Value: demotedRole,
+				},
+			},
+		},
+		incrementVersion,
+		touchUpdatedAt,
+	}
+
+	// The owner index allows a single owner, so at most one permission is demoted.
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	permission := &BSON{}
+	err = collection.FindOneAndUpdate(ctx, scopeToTenant(otherOwners, tenantID), demote, opts).Decode(permission)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return []service.Permission{permission}, nil
+}
@@ -0,0 +1,129 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ownersOf returns the number of owners of fileID in store.
+func ownersOf(t *testing.T, store MongoStore, fileID string) int64 {
+	t.Helper()
+
+	owners, err := store.Count(context.Background(), FilterByFileAndRoles(fileID, []pb.Role{pb.Role_OWNER}))
+	if err != nil {
+		t.Fatalf("Count() = %v, want nil", err)
+	}
+
+	return owners
+}
+
+func TestConcurrentOwnersLeaveSingleOwner(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	const users = 5
+	var wg sync.WaitGroup
+	errs := make(chan error, users)
+	for i := 0; i < users; i++ {
+		wg.Add(1)
+		go func(userID string) {
+			defer wg.Done()
+
+			permission := &BSON{FileID: "file", UserID: userID, Role: pb.Role_OWNER, Creator: "creator"}
+			_, err := store.Create(context.Background(), permission)
+			errs <- err
+		}(fmt.Sprintf("user%d", i))
+	}
+
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("Create() = %v, want nil", err)
+		}
+	}
+
+	if owners := ownersOf(t, store, "file"); owners != 1 {
+		t.Errorf("file has %d owners, want 1", owners)
+	}
+
+	if count, _ := store.Count(context.Background(), FilterByFile("file")); count != users {
+		t.Errorf("file has %d permissions, want %d", count, users)
+	}
+}
+
+func TestUpdateRoleToOwnerDemotesOwner(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "owner", pb.Role_OWNER)
+	createPermission(t, store, "file", "reader", pb.Role_READ)
+
+	if _, err := store.UpdateRole(context.Background(), "file", "reader", pb.Role_OWNER); err != nil {
+		t.Fatalf("UpdateRole() = %v, want nil", err)
+	}
+
+	if role := roleOf(t, store, "file", "owner"); role != DefaultDemotedOwnerRole {
+		t.Errorf("previous owner role = %v, want %v", role, DefaultDemotedOwnerRole)
+	}
+
+	if role := roleOf(t, store, "file", "reader"); role != pb.Role_OWNER {
+		t.Errorf("new owner role = %v, want %v", role, pb.Role_OWNER)
+	}
+}
+
+func TestRejectOwnerConflict(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+	store.OwnerConflict = OwnerConflictReject
+
+	createPermission(t, store, "file", "owner", pb.Role_OWNER)
+	createPermission(t, store, "file", "reader", pb.Role_READ)
+
+	_, err := store.UpdateRole(context.Background(), "file", "reader", pb.Role_OWNER)
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("UpdateRole() = %v, want %v", err, codes.FailedPrecondition)
+	}
+
+	permission := &BSON{FileID: "file", UserID: "writer", Role: pb.Role_OWNER, Creator: "creator"}
+	if _, err := store.Create(context.Background(), permission); status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("Create() = %v, want %v", err, codes.FailedPrecondition)
+	}
+
+	if role := roleOf(t, store, "file", "owner"); role != pb.Role_OWNER {
+		t.Errorf("owner role = %v, want %v", role, pb.Role_OWNER)
+	}
+
+	if owners := ownersOf(t, store, "file"); owners != 1 {
+		t.Errorf("file has %d owners, want 1", owners)
+	}
+}
+
+func TestConcurrentRejectedOwnersLeaveSingleOwner(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+	store.OwnerConflict = OwnerConflictReject
+
+	const users = 5
+	var wg sync.WaitGroup
+	for i := 0; i < users; i++ {
+		wg.Add(1)
+		go func(userID string) {
+			defer wg.Done()
+
+			permission := &BSON{FileID: "file", UserID: userID, Role: pb.Role_OWNER, Creator: "creator"}
+			store.Create(context.Background(), permission)
+		}(fmt.Sprintf("user%d", i))
+	}
+
+	wg.Wait()
+	if owners := ownersOf(t, store, "file"); owners != 1 {
+		t.Errorf("file has %d owners, want 1", owners)
+	}
+}
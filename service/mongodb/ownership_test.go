@@ -0,0 +1,182 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"testing"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// mongoTestHostEnv is the environment variable of the connection string of the mongodb
+// the integration tests run against, transactions require it to be a replica set.
+const mongoTestHostEnv = "MONGO_TEST_HOST"
+
+// integrationStore returns a MongoStore of a new database in the mongodb of mongoTestHostEnv,
+// and a function that drops the database. The test is skipped if mongoTestHostEnv is not set.
+func integrationStore(t *testing.T) (MongoStore, func()) {
+	t.Helper()
+
+	connectionString := os.Getenv(mongoTestHostEnv)
+	if connectionString == "" {
+		t.Skipf("%s is not set", mongoTestHostEnv)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	client, err := mongo.Connect(ctx, options.Client().ApplyURI(connectionString))
+	if err != nil {
+		t.Fatalf("mongo.Connect() = %v, want nil", err)
+	}
+
+	db := client.Database(fmt.Sprintf("permission_test_%d", time.Now().UnixNano()))
+	store, err := NewMongoStore(db)
+	if err != nil {
+		t.Fatalf("NewMongoStore() = %v, want nil", err)
+	}
+
+	return store, func() {
+		db.Drop(context.Background())
+		client.Disconnect(context.Background())
+	}
+}
+
+// createPermission creates the permission of userID to fileID with role in store.
+func createPermission(t *testing.T, store MongoStore, fileID string, userID string, role pb.Role) {
+	t.Helper()
+
+	permission := &BSON{FileID: fileID, UserID: userID, Role: role, Creator: "creator"}
+	if _, err := store.Create(context.Background(), permission); err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+}
+
+// roleOf returns the role of the permission of userID to fileID in store.
+func roleOf(t *testing.T, store MongoStore, fileID string, userID string) pb.Role {
+	t.Helper()
+
+	permission, err := store.Get(context.Background(), FilterByFileAndUser(fileID, userID))
+	if err != nil {
+		t.Fatalf("Get() = %v, want nil", err)
+	}
+
+	return permission.GetRole()
+}
+
+func TestTransferOwnership(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "owner", pb.Role_OWNER)
+
+	if err := store.TransferOwnership(context.Background(), "file", "owner", "user"); err != nil {
+		t.Fatalf("TransferOwnership() = %v, want nil", err)
+	}
+
+	if role := roleOf(t, store, "file", "user"); role != pb.Role_OWNER {
+		t.Errorf("new owner's role = %v, want %v", role, pb.Role_OWNER)
+	}
+
+	if role := roleOf(t, store, "file", "owner"); role != DefaultDemotedOwnerRole {
+		t.Errorf("previous owner's role = %v, want %v", role, DefaultDemotedOwnerRole)
+	}
+}
+
+func TestTransferOwnershipDemotesToConfiguredRole(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	store.DemotedOwnerRole = pb.Role_READ
+	createPermission(t, store, "file", "owner", pb.Role_OWNER)
+	createPermission(t, store, "file", "user", pb.Role_WRITE)
+
+	if err := store.TransferOwnership(context.Background(), "file", "owner", "user"); err != nil {
+		t.Fatalf("TransferOwnership() = %v, want nil", err)
+	}
+
+	if role := roleOf(t, store, "file", "user"); role != pb.Role_OWNER {
+		t.Errorf("new owner's role = %v, want %v", role, pb.Role_OWNER)
+	}
+
+	if role := roleOf(t, store, "file", "owner"); role != pb.Role_READ {
+		t.Errorf("previous owner's role = %v, want %v", role, pb.Role_READ)
+	}
+}
+
+func TestTransferOwnershipNotCurrentlyOwner(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "writer", pb.Role_WRITE)
+
+	err := store.TransferOwnership(context.Background(), "file", "writer", "user")
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Fatalf("TransferOwnership() = %v, want a %v error", err, codes.FailedPrecondition)
+	}
+
+	if role := roleOf(t, store, "file", "writer"); role != pb.Role_WRITE {
+		t.Errorf("writer's role = %v, want it unchanged as %v", role, pb.Role_WRITE)
+	}
+
+	_, err = store.Get(context.Background(), FilterByFileAndUser("file", "user"))
+	if err != service.ErrPermissionNotFound {
+		t.Errorf("Get() of the new owner = %v, want %v", err, service.ErrPermissionNotFound)
+	}
+}
+
+func TestTransferOwnershipRollsBackWhenPromotionFails(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "owner", pb.Role_OWNER)
+
+	// Reject writes of the target's permission so the promotion fails after the demotion.
+	collMod := bson.D{
+		bson.E{Key: "collMod", Value: PermissionCollectionName},
+		bson.E{
+			Key: "validator",
+			Value: bson.D{
+				bson.E{
+					Key:   PermissionBSONUserIDField,
+					Value: bson.D{bson.E{Key: "$ne", Value: "rejected"}},
+				},
+			},
+		},
+		bson.E{Key: "validationAction", Value: "error"},
+	}
+
+	if err := store.DB.RunCommand(context.Background(), collMod).Err(); err != nil {
+		t.Fatalf("collMod = %v, want nil", err)
+	}
+
+	if err := store.TransferOwnership(context.Background(), "file", "owner", "rejected"); err == nil {
+		t.Fatalf("TransferOwnership() to a rejected user returned a nil error")
+	}
+
+	if role := roleOf(t, store, "file", "owner"); role != pb.Role_OWNER {
+		t.Errorf("owner's role = %v, want it rolled back to %v", role, pb.Role_OWNER)
+	}
+
+	_, err := store.Get(context.Background(), FilterByFileAndUser("file", "rejected"))
+	if err != service.ErrPermissionNotFound {
+		t.Errorf("Get() of the rejected user = %v, want %v", err, service.ErrPermissionNotFound)
+	}
+}
+
+func TestTransferOwnershipToSameUser(t *testing.T) {
+	store, _ := tracedStore(t)
+
+	err := store.TransferOwnership(context.Background(), "file", "owner", "owner")
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("TransferOwnership() = %v, want a %v error", err, codes.InvalidArgument)
+	}
+}
@@ -0,0 +1,75 @@
+package mongodb
+
+import (
+	"context"
+
+	"github.com/meateam/permission-service/service"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ProjectUserAndRole returns a projection of the permissions to their userID and role,
+// for callers that list who has access to a file without needing the rest of the permission.
+func ProjectUserAndRole() bson.D {
+	return bson.D{
+		bson.E{
+			Key:   PermissionBSONUserIDField,
+			Value: 1,
+		},
+		bson.E{
+			Key:   PermissionBSONRoleField,
+			Value: 1,
+		},
+	}
+}
+
+// ProjectFields returns a projection of the permissions to fields, which are named as in
+// service.MaskableFields, so a field mask of a request is read from the database as is.
+// Returns an InvalidArgumentError if any of fields isn't one of service.MaskableFields.
+func ProjectFields(fields ...string) (bson.D, error) {
+	if err := service.ValidateFieldMask(fields); err != nil {
+		return nil, err
+	}
+
+	projection := bson.D{}
+	for _, field := range fields {
+		// The permission's id is stored as its ObjectID, which is always projected anyway.
+		if field == "id" {
+			continue
+		}
+
+		projection = append(projection, bson.E{Key: field, Value: 1})
+	}
+
+	return projection, nil
+}
+
+// GetProjected finds one permission that matches filter the same way Get does, projected by projection,
+// such as the projection of ProjectFields. The fields the projection omits are left empty in the
+// permission, and its ID is always set. If projection is nil then the permission is complete.
+// If successful returns the permission, and a nil error,
+// if the permission is not found it would return nil and service.ErrPermissionNotFound,
+// otherwise returns nil and non-nil error if any occurred.
+func (s MongoStore) GetProjected(
+	ctx context.Context,
+	filter interface{},
+	projection interface{},
+) (found service.Permission, err error) {
+	ctx, span := s.startSpan(ctx, "GetProjected", filterAttributes(filter)...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	if projection == nil {
+		return s.Get(ctx, filter)
+	}
+
+	permissions, err := s.find(ctx, active(filter), SortByID, options.Find().SetProjection(projection).SetLimit(1))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(permissions) == 0 {
+		return nil, service.ErrPermissionNotFound
+	}
+
+	return permissions[0], nil
+}
@@ -0,0 +1,95 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/memory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetAllProjectedOmitsUnprojectedFields(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "user", pb.Role_WRITE)
+
+	permissions, err := store.GetAllProjected(context.Background(), FilterByFile("file"), ProjectUserAndRole())
+	if err != nil {
+		t.Fatalf("GetAllProjected() = %v, want nil", err)
+	}
+
+	if len(permissions) != 1 {
+		t.Fatalf("GetAllProjected() = %d permissions, want 1", len(permissions))
+	}
+
+	permission := permissions[0].(*BSON)
+	if permission.GetUserID() != "user" || permission.GetRole() != pb.Role_WRITE {
+		t.Errorf("GetAllProjected() = user %q with role %v, want user with %v",
+			permission.GetUserID(), permission.GetRole(), pb.Role_WRITE)
+	}
+
+	if permission.GetFileID() != "" || permission.GetCreator() != "" || !permission.GetCreatedAt().IsZero() {
+		t.Errorf("GetAllProjected() = file %q, creator %q and createdAt %v, want them empty",
+			permission.GetFileID(), permission.GetCreator(), permission.GetCreatedAt())
+	}
+}
+
+func TestGetProjectedOmitsUnprojectedFields(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "user", pb.Role_WRITE)
+
+	projection, err := ProjectFields("id", "role")
+	if err != nil {
+		t.Fatalf("ProjectFields() = %v, want nil", err)
+	}
+
+	permission, err := store.GetProjected(context.Background(), FilterByFileAndUser("file", "user"), projection)
+	if err != nil {
+		t.Fatalf("GetProjected() = %v, want nil", err)
+	}
+
+	if permission.GetID() == "" || permission.GetRole() != pb.Role_WRITE {
+		t.Errorf("GetProjected() = id %q with role %v, want an id with %v",
+			permission.GetID(), permission.GetRole(), pb.Role_WRITE)
+	}
+
+	if permission.GetUserID() != "" || permission.GetCreator() != "" || permission.GetVersion() != 0 {
+		t.Errorf("GetProjected() = user %q, creator %q and version %d, want them empty",
+			permission.GetUserID(), permission.GetCreator(), permission.GetVersion())
+	}
+}
+
+func TestProjectFieldsUnknownField(t *testing.T) {
+	if _, err := ProjectFields("role", "tenantID"); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("ProjectFields() of an unknown field = %v, want code %v", err, codes.InvalidArgument)
+	}
+}
+
+func TestGetPermissionFieldMask(t *testing.T) {
+	permissionService := service.NewService(NewController(memory.NewMemoryStore()), nil)
+	create := &pb.CreatePermissionRequest{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"}
+	if _, err := permissionService.CreatePermission(context.Background(), create); err != nil {
+		t.Fatalf("CreatePermission() = %v, want nil", err)
+	}
+
+	req := &pb.GetPermissionRequest{FileID: "file", UserID: "user", Fields: []string{"role"}}
+	permission, err := permissionService.GetPermission(context.Background(), req)
+	if err != nil {
+		t.Fatalf("GetPermission() = %v, want nil", err)
+	}
+
+	if permission.GetRole() != pb.Role_READ {
+		t.Errorf("GetPermission() role = %v, want %v", permission.GetRole(), pb.Role_READ)
+	}
+
+	if permission.GetId() != "" || permission.GetFileID() != "" || permission.GetCreator() != "" {
+		t.Errorf("GetPermission() = id %q, file %q and creator %q, want them empty",
+			permission.GetId(), permission.GetFileID(), permission.GetCreator())
+	}
+}
@@ -0,0 +1,54 @@
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+// readPreferenceKey is the context key of the read preference of a single request's reads.
+type readPreferenceKey struct{}
+
+// WithReadPreference returns a copy of ctx that makes the store's Get, GetAll, GetAllPaged, GetAllCursor
+// and Count read with readPreference instead of the store's ReadPreference. Reading from a secondary
+// offloads the primary at the cost of staleness, a secondary may lag behind the primary so the
+// permissions read from it may miss recent changes, such as a permission that was just revoked.
+// The driver sets the read preference of a collection rather than of a query, so it isn't a find option.
+func WithReadPreference(ctx context.Context, readPreference *readpref.ReadPref) context.Context {
+	return context.WithValue(ctx, readPreferenceKey{}, readPreference)
+}
+
+// WithPrimaryRead returns a copy of ctx that makes the store read from the primary regardless of
+// its ReadPreference, for reads that must see the latest writes. Reads inside a transaction must
+// use it if the store has a ReadPreference, since transactions only read from the primary.
+func WithPrimaryRead(ctx context.Context) context.Context {
+	return WithReadPreference(ctx, readpref.Primary())
+}
+
+// readPreference returns the read preference of the store's reads with ctx,
+// or nil if the database's read preference should be used.
+func (s MongoStore) readPreference(ctx context.Context) *readpref.ReadPref {
+	if readPreference, ok := ctx.Value(readPreferenceKey{}).(*readpref.ReadPref); ok && readPreference != nil {
+		return readPreference
+	}
+
+	return s.ReadPreference
+}
+
+// readCollectionOptions returns the options of the collection the store reads from with ctx.
+func (s MongoStore) readCollectionOptions(ctx context.Context) *options.CollectionOptions {
+	opts := options.Collection()
+	if readPreference := s.readPreference(ctx); readPreference != nil {
+		opts.SetReadPreference(readPreference)
+	}
+
+	return opts
+}
+
+// readCollection returns the store's collection with the read preference of ctx, writes must
+// use s.collection() so they're never sent to a secondary.
+func (s MongoStore) readCollection(ctx context.Context) *mongo.Collection {
+	return s.DB.Collection(s.CollectionName(), s.readCollectionOptions(ctx))
+}
@@ -0,0 +1,58 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+)
+
+func TestReadCollectionOptionsUseReadPreference(t *testing.T) {
+	store := MongoStore{ReadPreference: readpref.SecondaryPreferred()}
+
+	opts := store.readCollectionOptions(context.Background())
+	if opts.ReadPreference == nil || opts.ReadPreference.Mode() != readpref.SecondaryPreferredMode {
+		t.Errorf("read preference = %v, want %v", opts.ReadPreference, readpref.SecondaryPreferredMode)
+	}
+}
+
+func TestReadCollectionOptionsWithPrimaryRead(t *testing.T) {
+	store := MongoStore{ReadPreference: readpref.SecondaryPreferred()}
+
+	opts := store.readCollectionOptions(WithPrimaryRead(context.Background()))
+	if opts.ReadPreference == nil || opts.ReadPreference.Mode() != readpref.PrimaryMode {
+		t.Errorf("read preference = %v, want %v", opts.ReadPreference, readpref.PrimaryMode)
+	}
+}
+
+func TestReadCollectionOptionsDefaultToDatabase(t *testing.T) {
+	if opts := (MongoStore{}).readCollectionOptions(context.Background()); opts.ReadPreference != nil {
+		t.Errorf("read preference = %v, want nil", opts.ReadPreference)
+	}
+}
+
+func TestNewMongoStoreWithReadPref(t *testing.T) {
+	defaultStore, drop := integrationStore(t)
+	defer drop()
+
+	store, err := NewMongoStoreWithReadPref(defaultStore.DB, readpref.PrimaryPreferred())
+	if err != nil {
+		t.Fatalf("NewMongoStoreWithReadPref() = %v, want nil", err)
+	}
+
+	createPermission(t, store, "file", "user", pb.Role_READ)
+
+	if role := roleOf(t, store, "file", "user"); role != pb.Role_READ {
+		t.Errorf("role = %v, want %v", role, pb.Role_READ)
+	}
+}
+
+func TestReadCollectionOptionsWithReadPreference(t *testing.T) {
+	ctx := WithReadPreference(context.Background(), readpref.Nearest())
+
+	opts := (MongoStore{}).readCollectionOptions(ctx)
+	if opts.ReadPreference == nil || opts.ReadPreference.Mode() != readpref.NearestMode {
+		t.Errorf("read preference = %v, want %v", opts.ReadPreference, readpref.NearestMode)
+	}
+}
@@ -0,0 +1,99 @@
+package mongodb
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/memory"
+	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestCreatePermissionRoundTripsReason(t *testing.T) {
+	controller := NewController(memory.NewMemoryStore())
+	reason := "shared for the quarterly review"
+
+	_, err := controller.CreatePermission(
+		context.Background(), "file", "user", pb.Role_READ, "creator", time.Time{}, nil, reason, "", false)
+	if err != nil {
+		t.Fatalf("CreatePermission() = %v, want nil", err)
+	}
+
+	permission, err := controller.GetByFileAndUser(context.Background(), "file", "user")
+	if err != nil {
+		t.Fatalf("GetByFileAndUser() = %v, want nil", err)
+	}
+
+	var marshaled pb.PermissionObject
+	if err := permission.MarshalProto(&marshaled); err != nil {
+		t.Fatalf("MarshalProto() = %v, want nil", err)
+	}
+
+	if marshaled.GetReason() != reason {
+		t.Errorf("MarshalProto() reason = %q, want %q", marshaled.GetReason(), reason)
+	}
+}
+
+func TestCreatePermissionRejectsTooLongReason(t *testing.T) {
+	controller := NewController(memory.NewMemoryStore())
+
+	// The limit is in characters, so a reason of MaxReasonLength multi-byte characters is valid.
+	reason := strings.Repeat("é", service.MaxReasonLength)
+	_, err := controller.CreatePermission(
+		context.Background(), "file", "user", pb.Role_READ, "creator", time.Time{}, nil, reason, "", false)
+	if err != nil {
+		t.Fatalf("CreatePermission() of a %d characters reason = %v, want nil", service.MaxReasonLength, err)
+	}
+
+	_, err = controller.CreatePermission(
+		context.Background(), "file", "user", pb.Role_READ, "creator", time.Time{}, nil, reason+"x", "", false)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("CreatePermission() = %v, want code %v", err, codes.InvalidArgument)
+	}
+}
+
+func TestEmptyReasonIsOmittedFromBSON(t *testing.T) {
+	raw, err := bson.Marshal(BSON{FileID: "file", UserID: "user", Creator: "creator"})
+	if err != nil {
+		t.Fatalf("bson.Marshal() = %v, want nil", err)
+	}
+
+	if _, err := bson.Raw(raw).LookupErr(PermissionBSONReasonField); err == nil {
+		t.Errorf("bson.Marshal() of a permission without a reason has a %s field", PermissionBSONReasonField)
+	}
+}
+
+func TestReasonIsStoredAndReplaced(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	permission := &BSON{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator", Reason: "onboarding"}
+	if _, err := store.Create(context.Background(), permission); err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	found, err := store.Get(context.Background(), FilterByFileAndUser("file", "user"))
+	if err != nil {
+		t.Fatalf("Get() = %v, want nil", err)
+	}
+
+	if found.GetReason() != permission.Reason {
+		t.Errorf("Get() reason = %q, want %q", found.GetReason(), permission.Reason)
+	}
+
+	// Creating the permission again without a reason removes the previous reason.
+	permission.Reason = ""
+	recreated, err := store.Create(context.Background(), permission)
+	if err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	if recreated.GetReason() != "" {
+		t.Errorf("Create() reason = %q, want none", recreated.GetReason())
+	}
+}
@@ -0,0 +1,102 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+)
+
+func TestReassignFileWithCollidingOwners(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "old-file", "old-owner", pb.Role_OWNER)
+	createPermission(t, store, "old-file", "both", pb.Role_READ)
+	createPermission(t, store, "old-file", "moved", pb.Role_WRITE)
+	createPermission(t, store, "new-file", "new-owner", pb.Role_OWNER)
+	createPermission(t, store, "new-file", "both", pb.Role_WRITE)
+
+	moved, err := store.ReassignFile(context.Background(), "old-file", "new-file")
+	if err != nil {
+		t.Fatalf("ReassignFile() = %v, want nil", err)
+	}
+
+	if moved != 2 {
+		t.Errorf("ReassignFile() = %d, want 2", moved)
+	}
+
+	wantRoles := map[string]pb.Role{
+		"old-owner": pb.Role_OWNER,
+		"new-owner": DefaultDemotedOwnerRole,
+		"both":      pb.Role_WRITE,
+		"moved":     pb.Role_WRITE,
+	}
+	for userID, role := range wantRoles {
+		if got := roleOf(t, store, "new-file", userID); got != role {
+			t.Errorf("the role of %s after ReassignFile() = %v, want %v", userID, got, role)
+		}
+	}
+
+	left, err := store.GetAll(context.Background(), FilterByFile("old-file"))
+	if err != nil {
+		t.Fatalf("GetAll() = %v, want nil", err)
+	}
+
+	if len(left) != 0 {
+		t.Errorf("GetAll() of the reassigned file = %v, want none", left)
+	}
+}
+
+func TestReassignFileRejectedOwnerConflictMovesNothing(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	store.OwnerConflict = OwnerConflictReject
+	createPermission(t, store, "old-file", "old-owner", pb.Role_OWNER)
+	createPermission(t, store, "old-file", "both", pb.Role_READ)
+	createPermission(t, store, "new-file", "new-owner", pb.Role_OWNER)
+	createPermission(t, store, "new-file", "both", pb.Role_WRITE)
+
+	if _, err := store.ReassignFile(context.Background(), "old-file", "new-file"); err == nil {
+		t.Fatalf("ReassignFile() to a file with an owner = nil, want an error")
+	}
+
+	left, err := store.GetAll(context.Background(), FilterByFile("old-file"))
+	if err != nil {
+		t.Fatalf("GetAll() = %v, want nil", err)
+	}
+
+	if len(left) != 2 {
+		t.Errorf("GetAll() of the old file after a failed ReassignFile() = %v, want both permissions", left)
+	}
+}
+
+func TestReassignFileSoftDeletesCollidingPermissions(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	store.SoftDelete = true
+	createPermission(t, store, "old-file", "both", pb.Role_READ)
+	createPermission(t, store, "new-file", "both", pb.Role_WRITE)
+
+	if _, err := store.ReassignFile(context.Background(), "old-file", "new-file"); err != nil {
+		t.Fatalf("ReassignFile() = %v, want nil", err)
+	}
+
+	_, err := store.Get(context.Background(), FilterByFileAndUser("old-file", "both"))
+	if err != service.ErrPermissionNotFound {
+		t.Errorf("Get() of the colliding permission = %v, want %v", err, service.ErrPermissionNotFound)
+	}
+
+	colliding := &BSON{}
+	filter := FilterByFileAndUser("old-file", "both")
+	if err := store.collection().FindOne(context.Background(), filter).Decode(colliding); err != nil {
+		t.Fatalf("FindOne() of the colliding permission = %v, want it kept as deleted", err)
+	}
+
+	if colliding.GetDeletedAt().IsZero() {
+		t.Errorf("the colliding permission has no deletedAt time, want it marked as deleted")
+	}
+}
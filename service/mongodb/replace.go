@@ -0,0 +1,271 @@
+package mongodb
+
+import (
+	"context"
+	"reflect"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ReplaceResult is the difference between a file's permissions before and after ReplaceAll.
+type ReplaceResult struct {
+	// Added is the number of permissions of users that had no active permission to the file.
+	Added int64
+
+	// Updated is the number of permissions whose role or other values were changed.
+	Updated int64
+
+	// Removed is the number of permissions that were deleted since they're not in the new set.
+	Removed int64
+
+	// Unchanged is the number of permissions that were already stored as they are in the new set.
+	Unchanged int64
+}
+
+// ReplaceAll reconciles the active permissions of fileID to permissions in a transaction. The permissions
+// of users that are not in permissions are deleted, or marked as deleted if s.SoftDelete is set, the ones
+// that are new or differ from the stored ones are upserted, and the ones that are already stored as given
+// are left as they are. Each of permissions must be of fileID, and of a different user, and at most one
+// of them may be an OWNER. If ctx is a dry run then nothing is changed and the result that would be
+// is returned.
+// If successful returns the difference between the file's permissions before and after, and a nil error,
+// otherwise returns an empty ReplaceResult and non-nil error if any occurred.
+func (s MongoStore) ReplaceAll(
+	ctx context.Context,
+	fileID string,
+	permissions []service.Permission,
+) (result ReplaceResult, err error) {
+	ctx, span := s.startSpan(ctx, "ReplaceAll", idAttributes(fileID, "")...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	collection := s.collection()
+	if fileID == "" {
+		return ReplaceResult{}, status.Error(codes.InvalidArgument, "fileID is required")
+	}
+
+	fileID = s.normalizeID(PermissionBSONFileIDField, fileID)
+	permissions, err = s.normalizePermissions(permissions)
+	if err != nil {
+		return ReplaceResult{}, err
+	}
+
+	if err := validateReplacement(s.Validator, fileID, permissions); err != nil {
+		return ReplaceResult{}, err
+	}
+
+	tenantID, err := s.tenantID(ctx)
+	if err != nil {
+		return ReplaceResult{}, err
+	}
+
+	grantedBy := service.ActorIDFromContext(ctx)
+	var removed, upserted, demoted []service.Permission
+	reconcile := func(ctx context.Context) error {
+		current, err := s.find(WithPrimaryRead(ctx), active(FilterByFile(fileID)), SortByID)
+		if err != nil {
+			return err
+		}
+
+		var changes replaceChanges
+		changes, result = diffPermissions(current, permissions)
+		removed = changes.removed
+		if service.IsDryRun(ctx) {
+			return nil
+		}
+
+		if len(changes.removed) > 0 {
+			removedUsers := make(bson.A, 0, len(changes.removed))
+			for _, permission := range changes.removed {
+				removedUsers = append(removedUsers, permission.GetUserID())
+			}
+
+			removedFilter := append(FilterByFile(fileID), bson.E{
+				Key:   PermissionBSONUserIDField,
+				Value: bson.D{bson.E{Key: "$in", Value: removedUsers}},
+			})
+
+			if s.SoftDelete {
+				_, err = collection.UpdateMany(ctx, scopeToTenant(active(removedFilter), tenantID), markDeleted())
+			} else {
+				_, err = collection.DeleteMany(ctx, scopeToTenant(active(removedFilter), tenantID))
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+
+		// The owner is upserted last, once the previous owner was removed or got its new role,
+		// so the file's other owners are resolved only if they're still left.
+		upserted, demoted = make([]service.Permission, 0, len(changes.upserted)), nil
+		opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
+		for _, permission := range changes.upserted {
+			if permission.GetRole() == pb.Role_OWNER {
+				if demoted, err = s.resolveOwnerConflict(ctx, fileID, permission.GetUserID()); err != nil {
+					return err
+				}
+			}
+
+			filter := scopeToTenant(FilterByFileAndUser(fileID, permission.GetUserID()), tenantID)
+			update := withGrantedBy(withTenant(permissionUpsert(permission, time.Now()), tenantID), grantedBy)
+			stored := &BSON{}
+			if err := collection.FindOneAndUpdate(ctx, filter, update, opts).Decode(stored); err != nil {
+				return err
+			}
+
+			upserted = append(upserted, stored)
+		}
+
+		return nil
+	}
+
+	if service.IsDryRun(ctx) {
+		err = reconcile(ctx)
+	} else {
+		err = s.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+			return reconcile(sessCtx)
+		})
+	}
+
+	if err != nil {
+		return ReplaceResult{}, err
+	}
+
+	if !service.IsDryRun(ctx) {
+		s.emitDeleted(removed...)
+		s.emitUpdated(demoted...)
+		s.emitCreated(upserted...)
+	}
+
+	return result, nil
+}
+
+// replaceChanges are the changes ReplaceAll makes to a file's permissions.
+type replaceChanges struct {
+	// removed are the stored permissions of users that are not in the new set.
+	removed []service.Permission
+
+	// upserted are the permissions of the new set that are not stored as they are,
+	// with the OWNER permission, if any, last.
+	upserted []service.Permission
+}
+
+// diffPermissions returns the changes that make current, the active permissions of a file,
+// the same as desired, and the ReplaceResult of making them.
+func diffPermissions(current []service.Permission, desired []service.Permission) (replaceChanges, ReplaceResult) {
+	var changes replaceChanges
+	var result ReplaceResult
+	currentByUser := make(map[string]service.Permission, len(current))
+	for _, permission := range current {
+		currentByUser[permission.GetUserID()] = permission
+	}
+
+	var owner service.Permission
+	for _, permission := range desired {
+		stored, ok := currentByUser[permission.GetUserID()]
+		delete(currentByUser, permission.GetUserID())
+		switch {
+		case !ok:
+			result.Added++
+		case samePermission(stored, permission):
+			result.Unchanged++
+			continue
+		default:
+			result.Updated++
+		}
+
+		if permission.GetRole() == pb.Role_OWNER {
+			owner = permission
+			continue
+		}
+
+		changes.upserted = append(changes.upserted, permission)
+	}
+
+	if owner != nil {
+		changes.upserted = append(changes.upserted, owner)
+	}
+
+	for _, permission := range current {
+		if _, ok := currentByUser[permission.GetUserID()]; ok {
+			changes.removed = append(changes.removed, permission)
+		}
+	}
+
+	result.Removed = int64(len(changes.removed))
+	return changes, result
+}
+
+// samePermission returns true if upserting desired over stored wouldn't change any of its values.
+func samePermission(stored service.Permission, desired service.Permission) bool {
+	return stored.GetRole() == desired.GetRole() &&
+		stored.GetCreator() == desired.GetCreator() &&
+		stored.GetReason() == desired.GetReason() &&
+		stored.GetDeny() == desired.GetDeny() &&
+		subjectTypeOf(stored) == subjectTypeOf(desired) &&
+		sameMetadata(stored.GetMetadata(), desired.GetMetadata()) &&
+		sameTime(stored.GetExpiresAt(), desired.GetExpiresAt())
+}
+
+// subjectTypeOf returns permission's subject type, or service.SubjectTypeUser if it has none.
+func subjectTypeOf(permission service.Permission) string {
+	if subjectType := permission.GetSubjectType(); subjectType != "" {
+		return subjectType
+	}
+
+	return service.SubjectTypeUser
+}
+
+// sameTime returns true if a and b are the same time to the millisecond, the precision mongo stores.
+func sameTime(a time.Time, b time.Time) bool {
+	return a.Truncate(time.Millisecond).Equal(b.Truncate(time.Millisecond))
+}
+
+// sameMetadata returns true if a and b have the same entries, an empty metadata is the same as nil.
+func sameMetadata(a map[string]string, b map[string]string) bool {
+	if len(a) == 0 && len(b) == 0 {
+		return true
+	}
+
+	return reflect.DeepEqual(a, b)
+}
+
+// validateReplacement returns an InvalidArgument error if any of permissions is not valid,
+// is not of fileID, or is of a user that has another of permissions, or if more than one of
+// permissions is an OWNER.
+func validateReplacement(validator service.Validator, fileID string, permissions []service.Permission) error {
+	if err := service.ValidatePermissions(validator, permissions); err != nil {
+		return err
+	}
+
+	users := make(map[string]bool, len(permissions))
+	owners := 0
+	for _, permission := range permissions {
+		if permission.GetFileID() != fileID {
+			return status.Errorf(codes.InvalidArgument, "permission of user %s is of file %s, not of file %s",
+				permission.GetUserID(), permission.GetFileID(), fileID)
+		}
+
+		if users[permission.GetUserID()] {
+			return status.Errorf(codes.InvalidArgument, "user %s has more than one permission", permission.GetUserID())
+		}
+
+		users[permission.GetUserID()] = true
+		if permission.GetRole() == pb.Role_OWNER {
+			owners++
+		}
+	}
+
+	if owners > 1 {
+		return status.Errorf(codes.InvalidArgument, "file %s can have at most one owner", fileID)
+	}
+
+	return nil
+}
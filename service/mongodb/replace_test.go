@@ -0,0 +1,131 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestValidateReplacement(t *testing.T) {
+	tests := []struct {
+		name        string
+		permissions []service.Permission
+		wantCode    codes.Code
+	}{
+		{
+			name: "valid",
+			permissions: []service.Permission{
+				&BSON{FileID: "file", UserID: "owner", Role: pb.Role_OWNER, Creator: "creator"},
+				&BSON{FileID: "file", UserID: "reader", Role: pb.Role_READ, Creator: "creator"},
+			},
+			wantCode: codes.OK,
+		},
+		{
+			name: "another file",
+			permissions: []service.Permission{
+				&BSON{FileID: "other", UserID: "reader", Role: pb.Role_READ, Creator: "creator"},
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "duplicate user",
+			permissions: []service.Permission{
+				&BSON{FileID: "file", UserID: "reader", Role: pb.Role_READ, Creator: "creator"},
+				&BSON{FileID: "file", UserID: "reader", Role: pb.Role_WRITE, Creator: "creator"},
+			},
+			wantCode: codes.InvalidArgument,
+		},
+		{
+			name: "two owners",
+			permissions: []service.Permission{
+				&BSON{FileID: "file", UserID: "owner", Role: pb.Role_OWNER, Creator: "creator"},
+				&BSON{FileID: "file", UserID: "other", Role: pb.Role_OWNER, Creator: "creator"},
+			},
+			wantCode: codes.InvalidArgument,
+		},
+	}
+
+	for _, tt := range tests {
+		err := validateReplacement(nil, "file", tt.permissions)
+		if code := status.Code(err); code != tt.wantCode {
+			t.Errorf("%s: validateReplacement() = %v, want code %v", tt.name, err, tt.wantCode)
+		}
+	}
+}
+
+func TestReplaceAll(t *testing.T) {
+	store, cleanup := integrationStore(t)
+	defer cleanup()
+
+	for _, permission := range []*BSON{
+		{FileID: "file", UserID: "owner", Role: pb.Role_OWNER, Creator: "creator"},
+		{FileID: "file", UserID: "kept", Role: pb.Role_READ, Creator: "creator"},
+		{FileID: "file", UserID: "promoted", Role: pb.Role_READ, Creator: "creator"},
+		{FileID: "file", UserID: "removed", Role: pb.Role_READ, Creator: "creator"},
+	} {
+		if _, err := store.Create(context.Background(), permission); err != nil {
+			t.Fatalf("Create() = %v, want nil", err)
+		}
+	}
+
+	desired := []service.Permission{
+		&BSON{FileID: "file", UserID: "owner", Role: pb.Role_WRITE, Creator: "creator"},
+		&BSON{FileID: "file", UserID: "kept", Role: pb.Role_READ, Creator: "creator"},
+		&BSON{FileID: "file", UserID: "promoted", Role: pb.Role_OWNER, Creator: "creator"},
+		&BSON{FileID: "file", UserID: "added", Role: pb.Role_WRITE, Creator: "creator"},
+	}
+
+	dryRun, err := store.ReplaceAll(service.WithDryRun(context.Background()), "file", desired)
+	if err != nil {
+		t.Fatalf("ReplaceAll() of a dry run = %v, want nil", err)
+	}
+
+	result, err := store.ReplaceAll(context.Background(), "file", desired)
+	if err != nil {
+		t.Fatalf("ReplaceAll() = %v, want nil", err)
+	}
+
+	want := ReplaceResult{Added: 1, Updated: 2, Removed: 1, Unchanged: 1}
+	if result != want {
+		t.Errorf("ReplaceAll() = %+v, want %+v", result, want)
+	}
+
+	if dryRun != want {
+		t.Errorf("ReplaceAll() of a dry run = %+v, want %+v", dryRun, want)
+	}
+
+	wantRoles := map[string]pb.Role{
+		"owner":    pb.Role_WRITE,
+		"kept":     pb.Role_READ,
+		"promoted": pb.Role_OWNER,
+		"added":    pb.Role_WRITE,
+	}
+	permissions, err := store.GetAll(context.Background(), FilterByFile("file"))
+	if err != nil {
+		t.Fatalf("GetAll() = %v, want nil", err)
+	}
+
+	if len(permissions) != len(wantRoles) {
+		t.Errorf("GetAll() after ReplaceAll() returned %d permissions, want %d", len(permissions), len(wantRoles))
+	}
+
+	for _, permission := range permissions {
+		if role, ok := wantRoles[permission.GetUserID()]; !ok || role != permission.GetRole() {
+			t.Errorf("the permission of user %s has role %v after ReplaceAll(), want %v",
+				permission.GetUserID(), permission.GetRole(), role)
+		}
+	}
+
+	result, err = store.ReplaceAll(context.Background(), "file", desired)
+	if err != nil {
+		t.Fatalf("ReplaceAll() of the same permissions = %v, want nil", err)
+	}
+
+	if want := (ReplaceResult{Unchanged: int64(len(desired))}); result != want {
+		t.Errorf("ReplaceAll() of the same permissions = %+v, want %+v", result, want)
+	}
+}
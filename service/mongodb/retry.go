@@ -0,0 +1,69 @@
+package mongodb
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// DefaultMaxRetries is the default number of times a store operation is retried
+	// after failing with a transient error.
+	DefaultMaxRetries = 2
+
+	// DefaultRetryBaseDelay is the default delay before the first retry of a store operation,
+	// the delay is doubled on every following retry.
+	DefaultRetryBaseDelay = 100 * time.Millisecond
+)
+
+// retryableErrorLabels are the error labels set by the driver and server on transient errors.
+var retryableErrorLabels = []string{"NetworkError", "RetryableWriteError", "TransientTransactionError"}
+
+// retry runs op, and while op fails with a retryable error it retries op with exponential
+// backoff up to s.MaxRetries times. Retrying stops early if ctx is done or if ctx's
+// deadline would pass before the next attempt. Returns the error of the last attempt.
+// Only reads are retried, a write may have been applied before the error was returned
+// so retrying it could delete nothing and report not found, or increment a version twice.
+func (s MongoStore) retry(ctx context.Context, op func() error) error {
+	delay := s.RetryBaseDelay
+	err := op()
+	for attempt := 0; attempt < s.MaxRetries && IsRetryable(err); attempt++ {
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+			return err
+		}
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		delay *= 2
+		err = op()
+	}
+
+	return err
+}
+
+// IsRetryable returns true if err is a transient error that the failed operation can be retried on,
+// such as a network error or an error labeled retryable by the driver, otherwise returns false.
+func IsRetryable(err error) bool {
+	switch e := err.(type) {
+	case mongo.CommandError:
+		for _, label := range e.Labels {
+			for _, retryableLabel := range retryableErrorLabels {
+				if label == retryableLabel {
+					return true
+				}
+			}
+		}
+	case net.Error:
+		return e.Timeout() || e.Temporary()
+	}
+
+	return false
+}
@@ -0,0 +1,83 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// transientError is a server error labeled as safe to retry.
+var transientError = mongo.CommandError{Code: 91, Message: "shutting down", Labels: []string{"NetworkError"}}
+
+// failingOp returns an operation that fails with err on its first failures calls and then succeeds,
+// and a pointer to the number of times it was called.
+func failingOp(failures int, err error) (func() error, *int) {
+	calls := 0
+	return func() error {
+		calls++
+		if calls <= failures {
+			return err
+		}
+
+		return nil
+	}, &calls
+}
+
+func TestRetrySucceedsAfterTransientErrors(t *testing.T) {
+	s := MongoStore{MaxRetries: 2, RetryBaseDelay: time.Millisecond}
+	op, calls := failingOp(2, transientError)
+
+	if err := s.retry(context.Background(), op); err != nil {
+		t.Fatalf("retry() = %v, want nil", err)
+	}
+
+	if *calls != 3 {
+		t.Errorf("op was called %d times, want 3", *calls)
+	}
+}
+
+func TestRetryGivesUpAfterMaxRetries(t *testing.T) {
+	s := MongoStore{MaxRetries: 2, RetryBaseDelay: time.Millisecond}
+	op, calls := failingOp(3, transientError)
+
+	if err := s.retry(context.Background(), op); err == nil {
+		t.Fatal("retry() = nil, want the last error")
+	}
+
+	if *calls != 3 {
+		t.Errorf("op was called %d times, want 3", *calls)
+	}
+}
+
+func TestRetryDoesNotRetryPermanentErrors(t *testing.T) {
+	s := MongoStore{MaxRetries: 2, RetryBaseDelay: time.Millisecond}
+	permanentErr := errors.New("permanent")
+	op, calls := failingOp(1, permanentErr)
+
+	if err := s.retry(context.Background(), op); err != permanentErr {
+		t.Fatalf("retry() = %v, want %v", err, permanentErr)
+	}
+
+	if *calls != 1 {
+		t.Errorf("op was called %d times, want 1", *calls)
+	}
+}
+
+func TestRetryStopsWhenDeadlineWouldPass(t *testing.T) {
+	s := MongoStore{MaxRetries: 2, RetryBaseDelay: time.Hour}
+	op, calls := failingOp(2, transientError)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	if err := s.retry(ctx, op); err == nil {
+		t.Fatal("retry() = nil, want the first error")
+	}
+
+	if *calls != 1 {
+		t.Errorf("op was called %d times, want 1", *calls)
+	}
+}
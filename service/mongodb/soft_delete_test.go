@@ -0,0 +1,176 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestSoftDeleteThenExclusion(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	store.SoftDelete = true
+	createPermission(t, store, "file", "user", pb.Role_READ)
+	createPermission(t, store, "file", "other", pb.Role_READ)
+
+	deleted, err := store.Delete(context.Background(), FilterByFileAndUser("file", "user"))
+	if err != nil {
+		t.Fatalf("Delete() = %v, want nil", err)
+	}
+
+	if deleted.(*BSON).GetDeletedAt().IsZero() {
+		t.Errorf("Delete() returned a permission without a deletedAt time")
+	}
+
+	if _, err := store.Get(context.Background(), FilterByFileAndUser("file", "user")); err != service.ErrPermissionNotFound {
+		t.Errorf("Get() of a deleted permission = %v, want %v", err, service.ErrPermissionNotFound)
+	}
+
+	permissions, err := store.GetAll(context.Background(), FilterByFile("file"))
+	if err != nil {
+		t.Fatalf("GetAll() = %v, want nil", err)
+	}
+
+	if len(permissions) != 1 || permissions[0].GetUserID() != "other" {
+		t.Errorf("GetAll() = %v, want only the permission of other", permissions)
+	}
+
+	withDeleted, err := store.GetAllIncludingDeleted(context.Background(), FilterByFile("file"))
+	if err != nil {
+		t.Fatalf("GetAllIncludingDeleted() = %v, want nil", err)
+	}
+
+	if len(withDeleted) != 2 {
+		t.Errorf("GetAllIncludingDeleted() returned %d permissions, want 2", len(withDeleted))
+	}
+
+	if _, err := store.Delete(context.Background(), FilterByFileAndUser("file", "user")); err != service.ErrPermissionNotFound {
+		t.Errorf("Delete() of a deleted permission = %v, want %v", err, service.ErrPermissionNotFound)
+	}
+}
+
+func TestSoftDeleteThenRestore(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	store.SoftDelete = true
+	createPermission(t, store, "file", "user", pb.Role_WRITE)
+
+	if _, err := store.Delete(context.Background(), FilterByFileAndUser("file", "user")); err != nil {
+		t.Fatalf("Delete() = %v, want nil", err)
+	}
+
+	restored, err := store.Restore(context.Background(), "file", "user")
+	if err != nil {
+		t.Fatalf("Restore() = %v, want nil", err)
+	}
+
+	if !restored.(*BSON).GetDeletedAt().IsZero() {
+		t.Errorf("Restore() returned a permission that is still deleted")
+	}
+
+	if role := roleOf(t, store, "file", "user"); role != pb.Role_WRITE {
+		t.Errorf("restored permission's role = %v, want %v", role, pb.Role_WRITE)
+	}
+
+	if _, err := store.Restore(context.Background(), "file", "user"); err != service.ErrPermissionNotFound {
+		t.Errorf("Restore() of a permission that isn't deleted = %v, want %v", err, service.ErrPermissionNotFound)
+	}
+}
+
+func TestRestoreNormalizesIDs(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	store.SoftDelete = true
+	store.Normalizer = lowercaseNormalizer{}
+	createPermission(t, store, "file", "alice@corp", pb.Role_READ)
+
+	if _, err := store.Delete(context.Background(), FilterByFileAndUser("file", "alice@corp")); err != nil {
+		t.Fatalf("Delete() = %v, want nil", err)
+	}
+
+	restored, err := store.Restore(context.Background(), "FILE", "Alice@Corp")
+	if err != nil {
+		t.Fatalf("Restore() of un-normalized IDs = %v, want nil", err)
+	}
+
+	if restored.GetUserID() != "alice@corp" {
+		t.Errorf("Restore() restored the permission of %q, want %q", restored.GetUserID(), "alice@corp")
+	}
+}
+
+func TestHardDeleteRemovesPermission(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "user", pb.Role_READ)
+
+	if _, err := store.Delete(context.Background(), FilterByFileAndUser("file", "user")); err != nil {
+		t.Fatalf("Delete() = %v, want nil", err)
+	}
+
+	withDeleted, err := store.GetAllIncludingDeleted(context.Background(), FilterByFile("file"))
+	if err != nil {
+		t.Fatalf("GetAllIncludingDeleted() = %v, want nil", err)
+	}
+
+	if len(withDeleted) != 0 {
+		t.Errorf("GetAllIncludingDeleted() returned %d permissions, want 0", len(withDeleted))
+	}
+}
+
+func TestPermissionUpsertRestoresDeletedPermission(t *testing.T) {
+	permission := &BSON{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"}
+	update := permissionUpsert(permission, time.Now())
+
+	for _, element := range update {
+		if element.Key != "$unset" {
+			continue
+		}
+
+		for _, unset := range element.Value.(bson.D) {
+			if unset.Key == PermissionBSONDeletedAtField {
+				return
+			}
+		}
+	}
+
+	t.Errorf("permissionUpsert() = %v, want it to unset %s", update, PermissionBSONDeletedAtField)
+}
+
+func TestPurgeDeleted(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	store.SoftDelete = true
+	createPermission(t, store, "file", "deleted", pb.Role_READ)
+	createPermission(t, store, "file", "active", pb.Role_READ)
+
+	if _, err := store.Delete(context.Background(), FilterByFileAndUser("file", "deleted")); err != nil {
+		t.Fatalf("Delete() = %v, want nil", err)
+	}
+
+	purged, err := store.PurgeDeleted(context.Background(), time.Now().Add(-time.Hour))
+	if err != nil || purged != 0 {
+		t.Errorf("PurgeDeleted() of an hour ago = %d, %v, want 0, nil", purged, err)
+	}
+
+	purged, err = store.PurgeDeleted(context.Background(), time.Now().Add(time.Second))
+	if err != nil || purged != 1 {
+		t.Errorf("PurgeDeleted() = %d, %v, want 1, nil", purged, err)
+	}
+
+	if _, err := store.Restore(context.Background(), "file", "deleted"); err != service.ErrPermissionNotFound {
+		t.Errorf("Restore() of a purged permission = %v, want %v", err, service.ErrPermissionNotFound)
+	}
+
+	if role := roleOf(t, store, "file", "active"); role != pb.Role_READ {
+		t.Errorf("active permission's role = %v, want %v", role, pb.Role_READ)
+	}
+}
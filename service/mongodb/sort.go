@@ -0,0 +1,63 @@
+package mongodb
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/meateam/permission-service/service"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// SortBy is the order GetAllSorted returns permissions in.
+type SortBy = service.SortBy
+
+// The orders of SortBy, see service.SortBy.
+const (
+	SortByID                     = service.SortByID
+	SortByRoleAscending          = service.SortByRoleAscending
+	SortByRoleDescending         = service.SortByRoleDescending
+	SortByUserIDAscending        = service.SortByUserIDAscending
+	SortByUserIDDescending       = service.SortByUserIDDescending
+	SortByCreationTimeAscending  = service.SortByCreationTimeAscending
+	SortByCreationTimeDescending = service.SortByCreationTimeDescending
+)
+
+// sortDocument returns the sort document of sortBy. Permissions are sorted by their ObjectID
+// after the sortBy field, so permissions with equal fields are always returned in the same order.
+// The creation time of a permission is the timestamp of its ObjectID.
+// The stored role values aren't ordered by the access they grant, so the roles are sorted
+// by sortRoles after the permissions are found sorted by their ObjectID.
+func sortDocument(sortBy SortBy) (bson.D, error) {
+	idAscending := bson.E{Key: MongoObjectIDField, Value: 1}
+
+	switch sortBy {
+	case SortByID, SortByCreationTimeAscending, SortByRoleAscending, SortByRoleDescending:
+		return bson.D{idAscending}, nil
+	case SortByCreationTimeDescending:
+		return bson.D{bson.E{Key: MongoObjectIDField, Value: -1}}, nil
+	case SortByUserIDAscending:
+		return bson.D{bson.E{Key: PermissionBSONUserIDField, Value: 1}, idAscending}, nil
+	case SortByUserIDDescending:
+		return bson.D{bson.E{Key: PermissionBSONUserIDField, Value: -1}, idAscending}, nil
+	default:
+		return nil, fmt.Errorf("unknown sort order %d", sortBy)
+	}
+}
+
+// sortRoles stably sorts permissions by their roles if sortBy is a role order,
+// otherwise permissions are left as they are.
+func sortRoles(permissions []service.Permission, sortBy SortBy) {
+	var less func(a service.Role, b service.Role) bool
+	switch sortBy {
+	case SortByRoleAscending:
+		less = func(a service.Role, b service.Role) bool { return !a.Includes(b) }
+	case SortByRoleDescending:
+		less = func(a service.Role, b service.Role) bool { return !b.Includes(a) }
+	default:
+		return
+	}
+
+	sort.SliceStable(permissions, func(i, j int) bool {
+		return less(service.Role(permissions[i].GetRole()), service.Role(permissions[j].GetRole()))
+	})
+}
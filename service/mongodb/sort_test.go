@@ -0,0 +1,101 @@
+package mongodb
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+)
+
+func TestSortDocumentBreaksTiesByID(t *testing.T) {
+	for _, sortBy := range []SortBy{SortByUserIDAscending, SortByUserIDDescending} {
+		sort, err := sortDocument(sortBy)
+		if err != nil {
+			t.Fatalf("sortDocument(%d) = %v, want nil", sortBy, err)
+		}
+
+		if last := sort[len(sort)-1]; last.Key != MongoObjectIDField {
+			t.Errorf("sortDocument(%d) = %v, want it to end with %s", sortBy, sort, MongoObjectIDField)
+		}
+	}
+}
+
+func TestSortRoles(t *testing.T) {
+	newPermissions := func() []service.Permission {
+		return []service.Permission{
+			&BSON{UserID: "owner", Role: pb.Role_OWNER},
+			&BSON{UserID: "reader", Role: pb.Role_READ},
+			&BSON{UserID: "writer", Role: pb.Role_WRITE},
+			&BSON{UserID: "other reader", Role: pb.Role_READ},
+		}
+	}
+
+	tests := []struct {
+		sortBy SortBy
+		want   []string
+	}{
+		{sortBy: SortByRoleAscending, want: []string{"reader", "other reader", "writer", "owner"}},
+		{sortBy: SortByRoleDescending, want: []string{"owner", "writer", "reader", "other reader"}},
+		{sortBy: SortByUserIDAscending, want: []string{"owner", "reader", "writer", "other reader"}},
+	}
+
+	for _, tt := range tests {
+		permissions := newPermissions()
+		sortRoles(permissions, tt.sortBy)
+
+		if userIDs := userIDsOf(permissions); !reflect.DeepEqual(userIDs, tt.want) {
+			t.Errorf("sortRoles(%d) sorted users %v, want %v", tt.sortBy, userIDs, tt.want)
+		}
+	}
+}
+
+func TestSortDocumentUnknown(t *testing.T) {
+	if _, err := sortDocument(SortBy(-1)); err == nil {
+		t.Errorf("sortDocument() of an unknown order returned a nil error")
+	}
+}
+
+func TestGetAllSorted(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "b", pb.Role_OWNER)
+	createPermission(t, store, "file", "c", pb.Role_READ)
+	createPermission(t, store, "file", "a", pb.Role_WRITE)
+
+	tests := []struct {
+		sortBy SortBy
+		want   []string
+	}{
+		{sortBy: SortByID, want: []string{"b", "c", "a"}},
+		{sortBy: SortByCreationTimeAscending, want: []string{"b", "c", "a"}},
+		{sortBy: SortByCreationTimeDescending, want: []string{"a", "c", "b"}},
+		{sortBy: SortByUserIDAscending, want: []string{"a", "b", "c"}},
+		{sortBy: SortByUserIDDescending, want: []string{"c", "b", "a"}},
+		{sortBy: SortByRoleAscending, want: []string{"c", "a", "b"}},
+		{sortBy: SortByRoleDescending, want: []string{"b", "a", "c"}},
+	}
+
+	for _, tt := range tests {
+		permissions, err := store.GetAllSorted(context.Background(), FilterByFile("file"), tt.sortBy)
+		if err != nil {
+			t.Fatalf("GetAllSorted(%d) = %v, want nil", tt.sortBy, err)
+		}
+
+		if userIDs := userIDsOf(permissions); !reflect.DeepEqual(userIDs, tt.want) {
+			t.Errorf("GetAllSorted(%d) returned users %v, want %v", tt.sortBy, userIDs, tt.want)
+		}
+	}
+}
+
+// userIDsOf returns the user IDs of permissions in their order.
+func userIDsOf(permissions []service.Permission) []string {
+	userIDs := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		userIDs = append(userIDs, permission.GetUserID())
+	}
+
+	return userIDs
+}
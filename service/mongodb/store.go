@@ -3,20 +3,28 @@ package mongodb
 import (
 	"context"
 	"fmt"
+	"strings"
+	"sync"
+	"time"
 
 	pb "github.com/meateam/permission-service/proto"
 	"github.com/meateam/permission-service/service"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo"
 	"go.mongodb.org/mongo-driver/mongo/options"
 	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 const (
 	// MongoObjectIDField is the default mongodb unique key.
 	MongoObjectIDField = "_id"
 
-	// PermissionCollectionName is the name of the permissions collection.
+	// PermissionCollectionName is the default name of the permissions collection.
 	PermissionCollectionName = "permissions"
 
 	// PermissionBSONFileIDField is the name of the fileID field in BSON.
@@ -30,17 +38,193 @@ const (
 
 	// PermissionBSONCreatorField is the name of the creator field in BSON.
 	PermissionBSONCreatorField = "creator"
+
+	// PermissionBSONExpiresAtField is the name of the expiresAt field in BSON.
+	PermissionBSONExpiresAtField = "expiresAt"
+
+	// PermissionBSONVersionField is the name of the version field in BSON.
+	PermissionBSONVersionField = "version"
+
+	// PermissionBSONDeletedAtField is the name of the deletedAt field in BSON.
+	PermissionBSONDeletedAtField = "deletedAt"
+
+	// PermissionBSONCreatedAtField is the name of the createdAt field in BSON.
+	PermissionBSONCreatedAtField = "createdAt"
+
+	// PermissionBSONUpdatedAtField is the name of the updatedAt field in BSON.
+	PermissionBSONUpdatedAtField = "updatedAt"
+
+	// PermissionBSONTenantIDField is the name of the tenantID field in BSON.
+	PermissionBSONTenantIDField = "tenantID"
+
+	// PermissionBSONMetadataField is the name of the metadata field in BSON.
+	PermissionBSONMetadataField = "metadata"
+
+	// PermissionBSONReasonField is the name of the reason field in BSON.
+	PermissionBSONReasonField = "reason"
+
+	// PermissionBSONGrantedByField is the name of the grantedBy field in BSON.
+	PermissionBSONGrantedByField = "grantedBy"
+
+	// PermissionBSONSubjectTypeField is the name of the subjectType field in BSON.
+	PermissionBSONSubjectTypeField = "subjectType"
+
+	// PermissionBSONDenyField is the name of the deny field in BSON.
+	PermissionBSONDenyField = "deny"
+)
+
+const (
+	// indexOptionsConflictCode is the server error code of creating an index that already exists with other options.
+	indexOptionsConflictCode = 85
+
+	// indexKeySpecsConflictCode is the server error code of creating an index with a name that's taken.
+	indexKeySpecsConflictCode = 86
+
+	// indexNotFoundCode is the server error code of dropping an index that doesn't exist.
+	indexNotFoundCode = 27
+
+	// duplicateKeyCode is the server error code of a write that violates a unique index.
+	duplicateKeyCode = 11000
 )
 
+// legacyFileUserIndexName is the name of the unique index of fileID and userID that was created
+// before permissions had tenants.
+const legacyFileUserIndexName = "fileID_1_userID_1"
+
+// DefaultDemotedOwnerRole is the default role TransferOwnership leaves the previous owner with.
+const DefaultDemotedOwnerRole = pb.Role_WRITE
+
+// SecondaryPingTimeout is the longest HealthStatus waits for a secondary to respond, so a replica set
+// without a reachable secondary doesn't delay the health of the primary until the deadline of the check.
+const SecondaryPingTimeout = time.Second
+
+// incrementVersion is the update element that increments a permission's version on every write.
+var incrementVersion = bson.E{
+	Key: "$inc",
+	Value: bson.D{
+		bson.E{
+			Key:   PermissionBSONVersionField,
+			Value: 1,
+		},
+	},
+}
+
+// touchUpdatedAt is the update element that sets a permission's updatedAt time to the server's
+// current time on every write.
+var touchUpdatedAt = bson.E{
+	Key: "$currentDate",
+	Value: bson.D{
+		bson.E{
+			Key:   PermissionBSONUpdatedAtField,
+			Value: true,
+		},
+	},
+}
+
 // MongoStore holds the mongodb database and implements Store interface.
 type MongoStore struct {
 	DB *mongo.Database
+
+	// MaxRetries is the number of times a read is retried after a transient error.
+	MaxRetries int
+
+	// RetryBaseDelay is the delay before the first retry, doubled on every following retry.
+	RetryBaseDelay time.Duration
+
+	// SoftDelete makes the deletes mark permissions as deleted by setting their deletedAt time
+	// instead of removing them, so they can be audited and restored.
+	SoftDelete bool
+
+	// DemotedOwnerRole is the role TransferOwnership leaves the previous owner with,
+	// if it's NONE then DefaultDemotedOwnerRole is used.
+	DemotedOwnerRole pb.Role
+
+	// TracerProvider provides the tracer of the spans of the store's operations,
+	// if it's nil then the global tracer provider is used.
+	TracerProvider trace.TracerProvider
+
+	// Validator validates the fileIDs and userIDs of the permissions that Create, CreateMany,
+	// UpdateRole and UpdateRoleIfVersion write, if it's nil then service.DefaultValidator is used.
+	Validator service.Validator
+
+	// OwnerConflict is what Create, UpdateRole and UpdateRoleIfVersion do when a user is made the
+	// owner of a file that already has another owner. CreateMany doesn't resolve owner conflicts,
+	// a permission that would be the file's second owner fails with a duplicate key error.
+	OwnerConflict OwnerConflictPolicy
+
+	// MultiTenant scopes every operation to the permissions of the tenant of its context, set by
+	// service.WithTenantID, and rejects the operations whose context carries no tenant.
+	// Permissions are created with the tenant of their context.
+	MultiTenant bool
+
+	// Normalizer normalizes the fileIDs and userIDs of the permissions the store writes and of its
+	// queries, if it's nil then the IDs are used as is. The decorators of the store, such as the cache,
+	// see the IDs as they're given.
+	Normalizer IDNormalizer
+
+	// EventSink is notified of the permissions that were changed through the store,
+	// if it's nil then no events are emitted. ReassignFile doesn't emit events.
+	EventSink PermissionEventSink
+
+	// ResumeTokens keeps the resume token of the last event of Watch, so a single long-lived watcher, such as
+	// a cache invalidator, resumes after it once the process restarts. If it's nil then Watch starts from now
+	// unless its context has a resume token. It must be nil for a store that several watchers share.
+	ResumeTokens ResumeTokenStore
+
+	// ReadPreference is the read preference of Get, GetAll, GetAllPaged, GetAllCursor and Count,
+	// such as readpref.SecondaryPreferred() to offload reporting queries from the primary, if it's nil
+	// then the database's read preference is used, which is the primary by default. Writes and the
+	// reads that follow them always use the primary, and WithReadPreference overrides it for a single
+	// request, see it for the staleness of reading from secondaries.
+	ReadPreference *readpref.ReadPref
+
+	// WriteConcern is the write concern of the store's writes, such as MajorityWriteConcern for writes
+	// that must survive a failover, if it's nil then the database's write concern is used.
+	WriteConcern *writeconcern.WriteConcern
+
+	// collectionName is the name of the store's collection, if it's empty then
+	// PermissionCollectionName is used.
+	collectionName string
+}
+
+// NewMongoStore returns a new store of the PermissionCollectionName collection of db,
+// the same way NewMongoStoreWithCollection does.
+func NewMongoStore(db *mongo.Database) (MongoStore, error) {
+	return NewMongoStoreWithCollection(db, PermissionCollectionName)
+}
+
+// NewMongoStoreWithReadPref returns a new store of the PermissionCollectionName collection of db,
+// the same way NewMongoStore does, that reads with readPreference.
+func NewMongoStoreWithReadPref(db *mongo.Database, readPreference *readpref.ReadPref) (MongoStore, error) {
+	store, err := NewMongoStore(db)
+	if err != nil {
+		return MongoStore{}, err
+	}
+
+	store.ReadPreference = readPreference
+	return store, nil
+}
+
+// NewMongoStoreWithCollection returns a new store of the collectionName collection of db, so several
+// stores can share a database, creating the indexes it relies on in the collection if they don't exist.
+// If collectionName is empty then PermissionCollectionName is used.
+// Creating the owner index fails if a file already has more than one owner.
+func NewMongoStoreWithCollection(db *mongo.Database, collectionName string) (MongoStore, error) {
+	return newMongoStore(context.Background(), db, collectionName)
 }
 
-// newMongoStore returns a new store.
-func newMongoStore(db *mongo.Database) (MongoStore, error) {
-	collection := db.Collection(PermissionCollectionName)
+// newMongoStore returns a new store of the collectionName collection of db, creating its indexes
+// the same way NewMongoStoreWithCollection does until ctx is done.
+func newMongoStore(ctx context.Context, db *mongo.Database, collectionName string) (MongoStore, error) {
+	if collectionName == "" {
+		collectionName = PermissionCollectionName
+	}
+
+	collection := db.Collection(collectionName)
 	indexes := collection.Indexes()
+
+	// A user has a single permission to a file of a tenant, permissions stored without a tenant
+	// are indexed with a null tenantID. The tenantID is last so queries by fileID can use the index.
 	indexModel := mongo.IndexModel{
 		Keys: bson.D{
 			bson.E{
@@ -51,110 +235,643 @@ func newMongoStore(db *mongo.Database) (MongoStore, error) {
 				Key:   PermissionBSONUserIDField,
 				Value: 1,
 			},
+			bson.E{
+				Key:   PermissionBSONTenantIDField,
+				Value: 1,
+			},
 		},
 		Options: options.Index().SetUnique(true),
 	}
 
-	_, err := indexes.CreateOne(context.Background(), indexModel)
-	if err != nil {
+	if err := createIndex(ctx, indexes, indexModel); err != nil {
+		return MongoStore{}, err
+	}
+
+	// Let mongodb remove expired permissions by itself.
+	expiresAtIndexModel := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{
+				Key:   PermissionBSONExpiresAtField,
+				Value: 1,
+			},
+		},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+
+	if err := createIndex(ctx, indexes, expiresAtIndexModel); err != nil {
+		return MongoStore{}, err
+	}
+
+	if err := createIndex(ctx, indexes, ownerIndexModel()); err != nil {
+		return MongoStore{}, err
+	}
+
+	// The indexes that were unique regardless of the tenant are replaced by the ones above,
+	// they're dropped only after those are created so the uniqueness is always enforced.
+	for _, name := range []string{legacyFileUserIndexName, legacyOwnerIndexName} {
+		if err := dropIndex(ctx, indexes, name); err != nil {
+			return MongoStore{}, err
+		}
+	}
+
+	// The fileID and userID index can't serve queries by userID alone.
+	userIDIndexModel := mongo.IndexModel{
+		Keys: bson.D{
+			bson.E{
+				Key:   PermissionBSONUserIDField,
+				Value: 1,
+			},
+		},
+	}
+
+	if err := createIndex(ctx, indexes, userIDIndexModel); err != nil {
 		return MongoStore{}, err
 	}
 
-	return MongoStore{DB: db}, nil
+	return MongoStore{
+		DB:             db,
+		MaxRetries:     DefaultMaxRetries,
+		RetryBaseDelay: DefaultRetryBaseDelay,
+		collectionName: collectionName,
+	}, nil
+}
+
+// CollectionName returns the name of the store's collection, PermissionCollectionName unless
+// the store was created with another by NewMongoStoreWithCollection.
+func (s MongoStore) CollectionName() string {
+	if s.collectionName == "" {
+		return PermissionCollectionName
+	}
+
+	return s.collectionName
+}
+
+// Close disconnects the mongo client of the store's database, waiting for its in-use connections to
+// be returned until ctx is done. The client is shared by every store of its databases, which fail with
+// mongo.ErrClientDisconnected once it's closed. Closing a closed store does nothing and returns nil.
+// If successful returns nil, otherwise returns the error that occurred.
+func (s MongoStore) Close(ctx context.Context) error {
+	if err := s.DB.Client().Disconnect(ctx); err != nil && err != mongo.ErrClientDisconnected {
+		return err
+	}
+
+	return nil
+}
+
+// collection returns the store's collection with the store's write concern.
+func (s MongoStore) collection() *mongo.Collection {
+	return s.DB.Collection(s.CollectionName(), s.collectionOptions())
+}
+
+// createIndex creates indexModel in indexes. Some server versions fail with an index conflict
+// when an index over the same keys already exists under a different name, in which case the
+// existing index is kept and no error is returned if it has the same uniqueness and expiration
+// options as indexModel. Otherwise the conflict is returned, since the existing index doesn't
+// enforce what the store relies on.
+func createIndex(ctx context.Context, indexes mongo.IndexView, indexModel mongo.IndexModel) error {
+	_, err := indexes.CreateOne(ctx, indexModel)
+	commandErr, ok := err.(mongo.CommandError)
+	if !ok || (commandErr.Code != indexOptionsConflictCode && commandErr.Code != indexKeySpecsConflictCode) {
+		return err
+	}
+
+	exists, listErr := hasIndex(ctx, indexes, indexModel)
+	if listErr != nil {
+		return fmt.Errorf("failed listing indexes after %v: %v", err, listErr)
+	}
+
+	if !exists {
+		return err
+	}
+
+	return nil
 }
 
-// HealthCheck checks the health of the service, returns true if healthy, or false otherwise.
-func (s MongoStore) HealthCheck(ctx context.Context) (bool, error) {
-	if err := s.DB.Client().Ping(ctx, readpref.Primary()); err != nil {
+// dropIndex drops the index of indexes that's named name, if it exists.
+func dropIndex(ctx context.Context, indexes mongo.IndexView, name string) error {
+	_, err := indexes.DropOne(ctx, name)
+	if commandErr, ok := err.(mongo.CommandError); ok && commandErr.Code == indexNotFoundCode {
+		return nil
+	}
+
+	return err
+}
+
+// existingIndex is an index as it's listed by the server.
+type existingIndex struct {
+	Key                bson.D `bson:"key"`
+	Unique             bool   `bson:"unique"`
+	ExpireAfterSeconds *int64 `bson:"expireAfterSeconds"`
+}
+
+// hasIndex returns true if indexes has an index over exactly the keys of indexModel that has
+// the same uniqueness and expiration options, otherwise returns false and any error if occurred.
+func hasIndex(ctx context.Context, indexes mongo.IndexView, indexModel mongo.IndexModel) (bool, error) {
+	keys, ok := indexModel.Keys.(bson.D)
+	if !ok {
+		return false, nil
+	}
+
+	cur, err := indexes.List(ctx)
+	if err != nil {
 		return false, err
 	}
+	defer cur.Close(ctx)
+
+	for cur.Next(ctx) {
+		index := existingIndex{}
+		if err := cur.Decode(&index); err != nil {
+			return false, err
+		}
+
+		if sameIndexKeys(index.Key, keys) && sameIndexOptions(index, indexModel.Options) {
+			return true, nil
+		}
+	}
+
+	return false, cur.Err()
+}
+
+// sameIndexOptions returns true if index is unique and expires documents the same way
+// as an index created with opts would.
+func sameIndexOptions(index existingIndex, opts *options.IndexOptions) bool {
+	unique := opts != nil && opts.Unique != nil && *opts.Unique
+	if index.Unique != unique {
+		return false
+	}
+
+	var expireAfterSeconds *int32
+	if opts != nil {
+		expireAfterSeconds = opts.ExpireAfterSeconds
+	}
+
+	if expireAfterSeconds == nil || index.ExpireAfterSeconds == nil {
+		return expireAfterSeconds == nil && index.ExpireAfterSeconds == nil
+	}
 
-	return true, nil
+	return int64(*expireAfterSeconds) == *index.ExpireAfterSeconds
+}
+
+// sameIndexKeys returns true if a and b index the same fields in the same order and direction.
+// The directions are compared by their string value since the server may return them as a different
+// numeric type than they were created with.
+func sameIndexKeys(a bson.D, b bson.D) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		if a[i].Key != b[i].Key || fmt.Sprint(a[i].Value) != fmt.Sprint(b[i].Value) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// HealthCheck checks the health of the service, it's healthy if HealthStatus reports the primary
+// as reachable without an error, however slow the secondaries are. If the primary failed since the
+// deadline of ctx passed before it responded it's reported as unhealthy with
+// service.HealthReasonPingTimeout and a nil error, so a slow database isn't mistaken for a failure
+// of the check. Otherwise returns an unhealthy result and the error of HealthStatus if any occurred.
+func (s MongoStore) HealthCheck(ctx context.Context) (service.HealthResult, error) {
+	health, err := s.HealthStatus(ctx)
+	if err != nil && ctx.Err() == context.DeadlineExceeded {
+		return service.HealthResult{Reason: service.HealthReasonPingTimeout}, nil
+	}
+
+	if err != nil {
+		return service.HealthResult{Reason: err.Error()}, err
+	}
+
+	if !health.PrimaryReachable {
+		return service.HealthResult{Reason: "primary unreachable"}, nil
+	}
+
+	return service.HealthResult{Healthy: true}, nil
+}
+
+// HealthStatus pings the primary and a secondary concurrently and reports which of them are
+// reachable and how long the primary, or the secondary if the primary is unreachable, took to respond.
+// A replica set without secondaries is reported as degraded. The secondary is pinged with its own
+// SecondaryPingTimeout, so an unreachable secondary doesn't hold the primary's result until ctx is done.
+// Besides pinging the primary, it checks that the store's collection exists and can be queried,
+// so a missing database or collection, or insufficient privileges are reported as an error.
+// Returns the status, and the error of the primary if it's unreachable or the collection check failed.
+func (s MongoStore) HealthStatus(ctx context.Context) (health service.HealthStatus, err error) {
+	ctx, span := s.startSpan(ctx, "HealthStatus")
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	var primaryLatency, secondaryLatency time.Duration
+	var primaryErr, secondaryErr, collectionErr error
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		primaryLatency, primaryErr = s.ping(ctx, readpref.Primary())
+		if primaryErr == nil {
+			collectionErr = s.checkCollection(ctx)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		secondaryCtx, cancel := context.WithTimeout(ctx, SecondaryPingTimeout)
+		defer cancel()
+
+		secondaryLatency, secondaryErr = s.ping(secondaryCtx, readpref.Secondary())
+	}()
+	wg.Wait()
+
+	health.PrimaryReachable = primaryErr == nil
+	health.SecondaryReachable = secondaryErr == nil
+	health.Latency = secondaryLatency
+	if health.PrimaryReachable {
+		health.Latency = primaryLatency
+	}
+
+	if primaryErr != nil {
+		return health, primaryErr
+	}
+
+	return health, collectionErr
+}
+
+// ping pings the member of the database selected by readPreference,
+// returns the round trip time, and any error if occurred.
+func (s MongoStore) ping(ctx context.Context, readPreference *readpref.ReadPref) (time.Duration, error) {
+	start := time.Now()
+	err := s.DB.Client().Ping(ctx, readPreference)
+	return time.Since(start), err
+}
+
+// checkCollection returns an error if the permissions collection doesn't exist or can't be queried.
+func (s MongoStore) checkCollection(ctx context.Context) error {
+	// Querying a collection that doesn't exist succeeds, so its existence is checked explicitly.
+	collectionFilter := bson.D{bson.E{Key: "name", Value: s.CollectionName()}}
+	names, err := s.DB.ListCollectionNames(ctx, collectionFilter)
+	if err != nil {
+		return fmt.Errorf("failed listing collections of database %s: %v", s.DB.Name(), err)
+	}
+
+	if len(names) == 0 {
+		return fmt.Errorf("collection %s does not exist in database %s", s.CollectionName(), s.DB.Name())
+	}
+
+	collection := s.collection()
+	if _, err := collection.CountDocuments(ctx, bson.D{}, options.Count().SetLimit(1)); err != nil {
+		return fmt.Errorf("failed querying %s collection: %v", s.CollectionName(), err)
+	}
+
+	return nil
 }
 
 // Create creates a permission of a file to a user,
 // If permission already exists then it's updated to have permission values,
+// except for the actor of ctx that granted it, which is recorded only when it's inserted.
 // If successful returns the permission and a nil error,
 // otherwise returns empty string and non-nil error if any occurred.
-func (s MongoStore) Create(ctx context.Context, permission service.Permission) (service.Permission, error) {
-	collection := s.DB.Collection(PermissionCollectionName)
-	fileID := permission.GetFileID()
-	if fileID == "" {
-		return nil, fmt.Errorf("fileID is required")
+func (s MongoStore) Create(
+	ctx context.Context,
+	permission service.Permission,
+) (created service.Permission, err error) {
+	ctx, span := s.startSpan(ctx, "Create", idAttributes(permission.GetFileID(), permission.GetUserID())...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	created, _, _, err = s.upsert(ctx, permission, false)
+	return created, err
+}
+
+// CreateIfAbsent creates a permission of a file to a user the same way Create does, unless the user
+// already has a permission to the file, in which case it's left as is and an AlreadyExists error is
+// returned, so a file that is shared twice can be told apart from a new share. A permission that
+// expired or was soft deleted is absent, and is replaced as if it was inserted.
+// If successful returns the permission and a nil error,
+// otherwise returns nil and non-nil error if any occurred.
+func (s MongoStore) CreateIfAbsent(
+	ctx context.Context,
+	permission service.Permission,
+) (created service.Permission, err error) {
+	ctx, span := s.startSpan(ctx, "CreateIfAbsent", idAttributes(permission.GetFileID(), permission.GetUserID())...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	created, _, _, err = s.upsert(ctx, permission, true)
+	return created, err
+}
+
+// Upsert creates a permission of a file to a user, or updates the existing permission to have
+// permission's values, the same way Create does.
+// If successful returns the permission, whether it was inserted, the previous role of the permission
+// and a nil error. A permission that was expired or soft deleted is replaced as if it was inserted,
+// and the previous role of an inserted permission is NONE.
+// Otherwise returns nil, false, NONE and non-nil error if any occurred.
+func (s MongoStore) Upsert(
+	ctx context.Context,
+	permission service.Permission,
+) (upserted service.Permission, inserted bool, previousRole pb.Role, err error) {
+	ctx, span := s.startSpan(ctx, "Upsert", idAttributes(permission.GetFileID(), permission.GetUserID())...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	return s.upsert(ctx, permission, false)
+}
+
+// upsert creates or updates permission and returns the same values as Upsert. If ifAbsent is set then
+// only an inactive permission is replaced, and an AlreadyExists error is returned if an active one exists.
+func (s MongoStore) upsert(
+	ctx context.Context,
+	permission service.Permission,
+	ifAbsent bool,
+) (service.Permission, bool, pb.Role, error) {
+	collection := s.collection()
+	permission, err := s.normalizePermission(permission)
+	if err != nil {
+		return nil, false, pb.Role_NONE, err
 	}
 
-	userID := permission.GetUserID()
-	if userID == "" {
-		return nil, fmt.Errorf("userID is required")
+	if err := service.ValidatePermission(s.Validator, permission); err != nil {
+		return nil, false, pb.Role_NONE, err
 	}
 
-	role := permission.GetRole()
-	if pb.Role_name[int32(role)] == "" {
-		return nil, fmt.Errorf("role does not exist")
+	tenantID, err := s.tenantID(ctx)
+	if err != nil {
+		return nil, false, pb.Role_NONE, err
 	}
 
-	creator := permission.GetCreator()
-	if userID == "" {
-		return nil, fmt.Errorf("creator is required")
+	// The subject type is part of the key so a user's permission never overwrites the permission of
+	// a group with the same ID, or the opposite, instead they collide on the unique index.
+	keyFilter := FilterBySubjectType(FilterByFileAndUser(permission.GetFileID(), permission.GetUserID()),
+		permission.GetSubjectType())
+	filter := scopeToTenant(keyFilter, tenantID)
+
+	// An active permission isn't matched so upserting collides with it on the unique index.
+	upsertFilter := filter
+	if ifAbsent {
+		upsertFilter = scopeToTenant(inactive(keyFilter), tenantID)
 	}
 
-	filter := bson.D{
-		bson.E{
-			Key:   PermissionBSONFileIDField,
-			Value: fileID,
-		},
-		bson.E{
-			Key:   PermissionBSONUserIDField,
-			Value: userID,
-		},
+	// The ID and creation time of an inserted permission are chosen here, so the permission can be
+	// returned without reading it again after the write.
+	id, now := primitive.NewObjectID(), time.Now().Truncate(time.Millisecond)
+	grantedBy := service.ActorIDFromContext(ctx)
+	update := withTenant(permissionUpsert(permission, now), tenantID)
+	update = withSetOnInsert(withGrantedBy(update, grantedBy), bson.E{Key: MongoObjectIDField, Value: id})
+	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.Before)
+	var previous *BSON
+	err = s.withSingleOwner(ctx, permission.GetFileID(), permission.GetUserID(), permission.GetRole(),
+		func(ctx context.Context) error {
+			previous = &BSON{}
+			err := collection.FindOneAndUpdate(ctx, upsertFilter, update, opts).Decode(previous)
+			if err == mongo.ErrNoDocuments {
+				previous = nil
+				return nil
+			}
+
+			return err
+		})
+	if isDuplicateKeyError(err) {
+		return nil, false, pb.Role_NONE, s.upsertConflict(ctx, permission, tenantID, ifAbsent, err)
+	}
+
+	if err != nil {
+		return nil, false, pb.Role_NONE, err
+	}
+
+	newPermission := upsertedPermission(previous, permission, id, now, tenantID, grantedBy)
+	s.emitCreated(newPermission)
+	if previous == nil || !isActive(previous) {
+		return newPermission, true, pb.Role_NONE, nil
+	}
+
+	return newPermission, false, previous.GetRole(), nil
+}
+
+// upsertConflict returns the error of upserting permission, which collided with a stored permission
+// on the unique index of fileID and userID. If the stored permission is of another subject type, such as
+// a group with the same ID as the user, an AlreadyExists error is returned since a user and a group
+// that share an ID can't both have permissions to the same file. Otherwise if ifAbsent is set the stored
+// permission is active and an AlreadyExists error is returned, if not err is returned as is.
+func (s MongoStore) upsertConflict(
+	ctx context.Context,
+	permission service.Permission,
+	tenantID string,
+	ifAbsent bool,
+	err error,
+) error {
+	stored := &BSON{}
+	keyFilter := scopeToTenant(FilterByFileAndUser(permission.GetFileID(), permission.GetUserID()), tenantID)
+	findErr := s.readCollection(WithPrimaryRead(ctx)).FindOne(ctx, keyFilter).Decode(stored)
+	if findErr == nil && stored.GetSubjectType() != permission.GetSubjectType() {
+		return status.Errorf(codes.AlreadyExists, "file %s already has a permission of %s %s",
+			permission.GetFileID(), strings.ToLower(stored.GetSubjectType()), permission.GetUserID())
+	}
+
+	if ifAbsent {
+		return status.Errorf(codes.AlreadyExists, "permission of user %s to file %s already exists",
+			permission.GetUserID(), permission.GetFileID())
+	}
+
+	return err
+}
+
+// isActive returns true if permission has not expired and was not soft deleted, the same
+// permissions that active matches.
+func isActive(permission *BSON) bool {
+	expiresAt := permission.GetExpiresAt()
+	return permission.GetDeletedAt().IsZero() && (expiresAt.IsZero() || expiresAt.After(time.Now()))
+}
+
+// upsertedPermission returns the permission that upserting permission with permissionUpsert stored, given
+// previous, the permission it updated, or nil if it was inserted with id. now is the time of the write,
+// which an inserted permission is created at, and tenantID and grantedBy are the values it was upserted with.
+// The returned updatedAt time is now, rather than the database's time the write was stored at.
+func upsertedPermission(
+	previous *BSON,
+	permission service.Permission,
+	id primitive.ObjectID,
+	now time.Time,
+	tenantID string,
+	grantedBy string,
+) *BSON {
+	upserted := &BSON{ID: id, CreatedAt: &now, GrantedBy: grantedBy}
+	if previous != nil {
+		copied := *previous
+		upserted = &copied
+	}
+
+	upserted.FileID = permission.GetFileID()
+	upserted.UserID = permission.GetUserID()
+	upserted.Role = permission.GetRole()
+	upserted.Creator = permission.GetCreator()
+	upserted.Reason = permission.GetReason()
+	upserted.Deny = permission.GetDeny()
+	upserted.Metadata = nil
+	if metadata := permission.GetMetadata(); len(metadata) > 0 {
+		upserted.Metadata = metadata
+	}
+
+	upserted.SubjectType = ""
+	if subjectType := permission.GetSubjectType(); subjectType != service.SubjectTypeUser {
+		upserted.SubjectType = subjectType
+	}
+
+	upserted.ExpiresAt = nil
+	if expiresAt := permission.GetExpiresAt(); !expiresAt.IsZero() {
+		upserted.ExpiresAt = &expiresAt
+	}
+
+	if tenantID != "" {
+		upserted.TenantID = tenantID
 	}
 
+	upserted.DeletedAt = nil
+	upserted.UpdatedAt = &now
+	upserted.Version++
+	return upserted
+}
+
+// permissionUpsert returns the update document that sets the stored permission to permission's values,
+// its createdAt time is set to now only if it's inserted.
+func permissionUpsert(permission service.Permission, now time.Time) bson.D {
 	permissionUpdate := bson.D{
 		bson.E{
 			Key:   PermissionBSONFileIDField,
-			Value: fileID,
+			Value: permission.GetFileID(),
 		},
 		bson.E{
 			Key:   PermissionBSONUserIDField,
-			Value: userID,
+			Value: permission.GetUserID(),
 		},
 		bson.E{
 			Key:   PermissionBSONRoleField,
-			Value: role,
+			Value: permission.GetRole(),
 		},
 		bson.E{
 			Key:   PermissionBSONCreatorField,
-			Value: creator,
+			Value: permission.GetCreator(),
+		},
+	}
+
+	// Creating a permission that was soft deleted restores it.
+	permissionUnset := bson.D{
+		bson.E{
+			Key:   PermissionBSONDeletedAtField,
+			Value: "",
 		},
 	}
 
+	// The metadata replaces any previous metadata.
+	if metadata := permission.GetMetadata(); len(metadata) == 0 {
+		permissionUnset = append(permissionUnset, bson.E{
+			Key:   PermissionBSONMetadataField,
+			Value: "",
+		})
+	} else {
+		permissionUpdate = append(permissionUpdate, bson.E{
+			Key:   PermissionBSONMetadataField,
+			Value: metadata,
+		})
+	}
+
+	// The reason replaces any previous reason, as it's the reason of the latest grant.
+	if reason := permission.GetReason(); reason == "" {
+		permissionUnset = append(permissionUnset, bson.E{
+			Key:   PermissionBSONReasonField,
+			Value: "",
+		})
+	} else {
+		permissionUpdate = append(permissionUpdate, bson.E{
+			Key:   PermissionBSONReasonField,
+			Value: reason,
+		})
+	}
+
+	// A user's permission is stored without a subject type, the same as permissions stored before
+	// groups were supported.
+	if subjectType := permission.GetSubjectType(); subjectType == service.SubjectTypeUser {
+		permissionUnset = append(permissionUnset, bson.E{
+			Key:   PermissionBSONSubjectTypeField,
+			Value: "",
+		})
+	} else {
+		permissionUpdate = append(permissionUpdate, bson.E{
+			Key:   PermissionBSONSubjectTypeField,
+			Value: subjectType,
+		})
+	}
+
+	// A granting permission is stored without the deny field, so creating it over a denying one
+	// lifts the denial.
+	if permission.GetDeny() {
+		permissionUpdate = append(permissionUpdate, bson.E{
+			Key:   PermissionBSONDenyField,
+			Value: true,
+		})
+	} else {
+		permissionUnset = append(permissionUnset, bson.E{
+			Key:   PermissionBSONDenyField,
+			Value: "",
+		})
+	}
+
+	// A permission without an expiration time never expires, so remove any previous one.
+	if expiresAt := permission.GetExpiresAt(); expiresAt.IsZero() {
+		permissionUnset = append(permissionUnset, bson.E{
+			Key:   PermissionBSONExpiresAtField,
+			Value: "",
+		})
+	} else {
+		permissionUpdate = append(permissionUpdate, bson.E{
+			Key:   PermissionBSONExpiresAtField,
+			Value: expiresAt,
+		})
+	}
+
 	update := bson.D{
 		bson.E{
 			Key:   "$set",
 			Value: permissionUpdate,
 		},
+		bson.E{
+			Key: "$setOnInsert",
+			Value: bson.D{
+				bson.E{
+					Key:   PermissionBSONCreatedAtField,
+					Value: now,
+				},
+			},
+		},
+		incrementVersion,
+		touchUpdatedAt,
+		bson.E{
+			Key:   "$unset",
+			Value: permissionUnset,
+		},
 	}
 
-	opts := options.FindOneAndUpdate().SetUpsert(true).SetReturnDocument(options.After)
-	result := collection.FindOneAndUpdate(ctx, filter, update, opts)
-	newPermission := &BSON{}
-	err := result.Decode(newPermission)
-	if err != nil {
-		return nil, err
-	}
-
-	return newPermission, nil
+	return update
 }
 
 // Get finds one permission that matches filter,
 // if successful returns the permission, and a nil error,
-// if the permission is not found it would return nil and NotFound error,
+// if the permission is not found it would return nil and service.ErrPermissionNotFound,
 // otherwise returns nil and non-nil error if any occurred.
-func (s MongoStore) Get(ctx context.Context, filter interface{}) (service.Permission, error) {
-	collection := s.DB.Collection(PermissionCollectionName)
+func (s MongoStore) Get(ctx context.Context, filter interface{}) (found service.Permission, err error) {
+	ctx, span := s.startSpan(ctx, "Get", filterAttributes(filter)...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	collection := s.readCollection(ctx)
+	filter, err = s.scope(ctx, active(filter))
+	if err != nil {
+		return nil, err
+	}
 
 	permission := &BSON{}
-	err := collection.FindOne(ctx, filter).Decode(permission)
+	err = s.retry(ctx, func() error {
+		return collection.FindOne(ctx, filter).Decode(permission)
+	})
+	if err == mongo.ErrNoDocuments {
+		return nil, service.ErrPermissionNotFound
+	}
+
 	if err != nil {
 		return nil, err
 	}
@@ -162,23 +879,172 @@ func (s MongoStore) Get(ctx context.Context, filter interface{}) (service.Permis
 	return permission, nil
 }
 
-// GetAll finds all permissions that matches filter,
-// if successful returns the permissions, and a nil error,
-// otherwise returns nil and non-nil error if any occurred.
-func (s MongoStore) GetAll(ctx context.Context, filter interface{}) ([]service.Permission, error) {
-	collection := s.DB.Collection(PermissionCollectionName)
+// IsPermitted returns true if the permission of userID to fileID grants at least the required role
+// and doesn't deny access, which is checked by ExistsMatching without reading the permission,
+// if no such permission exists it would return false and a nil error,
+// otherwise returns false and non-nil error if any occurred.
+func (s MongoStore) IsPermitted(
+	ctx context.Context,
+	fileID string,
+	userID string,
+	required pb.Role,
+) (permitted bool, err error) {
+	ctx, span := s.startSpan(ctx, "IsPermitted", idAttributes(fileID, userID)...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
 
-	cur, err := collection.Find(ctx, filter)
-	if err != nil {
-		return nil, err
+	roles := service.RolesIncluding(service.Role(required))
+	if len(roles) == 0 {
+		return false, nil
 	}
-	defer cur.Close(ctx)
 
-	permissions := []service.Permission{}
-	for cur.Next(ctx) {
-		permission := &BSON{}
-		err := cur.Decode(permission)
-		if err != nil {
+	return s.ExistsMatching(ctx, notDenied(FilterByFileUserAndRoles(fileID, userID, roles)))
+}
+
+// GetAll finds all permissions that matches filter, soft deleted permissions are excluded,
+// the permissions are sorted by SortByID so the order is stable.
+// If successful returns the permissions, and a nil error,
+// otherwise returns nil and non-nil error if any occurred.
+func (s MongoStore) GetAll(ctx context.Context, filter interface{}) (found []service.Permission, err error) {
+	ctx, span := s.startSpan(ctx, "GetAll", filterAttributes(filter)...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	return s.find(ctx, active(filter), SortByID)
+}
+
+// GetAllSorted finds all permissions that matches filter the same way GetAll does,
+// sorted by sortBy.
+// If successful returns the permissions, and a nil error,
+// otherwise returns nil and non-nil error if any occurred.
+func (s MongoStore) GetAllSorted(
+	ctx context.Context,
+	filter interface{},
+	sortBy SortBy,
+) (found []service.Permission, err error) {
+	ctx, span := s.startSpan(ctx, "GetAllSorted", filterAttributes(filter)...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	return s.find(ctx, active(filter), sortBy)
+}
+
+// GetAllProjected finds all permissions that matches filter the same way GetAll does,
+// projected by projection, such as ProjectUserAndRole(), to transfer less data for large results.
+// The fields the projection omits are left empty in the permissions, and their IDs are always set.
+// If projection is nil then the permissions are complete.
+// If successful returns the permissions, and a nil error,
+// otherwise returns nil and non-nil error if any occurred.
+func (s MongoStore) GetAllProjected(
+	ctx context.Context,
+	filter interface{},
+	projection interface{},
+) (found []service.Permission, err error) {
+	ctx, span := s.startSpan(ctx, "GetAllProjected", filterAttributes(filter)...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	if projection == nil {
+		return s.find(ctx, active(filter), SortByID)
+	}
+
+	return s.find(ctx, active(filter), SortByID, options.Find().SetProjection(projection))
+}
+
+// GetByUserID finds all permissions of userID, the same way GetAll does,
+// if successful returns the permissions, and a nil error,
+// otherwise returns nil and non-nil error if any occurred.
+func (s MongoStore) GetByUserID(ctx context.Context, userID string) ([]service.Permission, error) {
+	return s.GetAll(ctx, FilterByUser(userID))
+}
+
+// GetFilesForUser finds the permissions of userID that grant at least minRole, by the role hierarchy,
+// for listing the files shared with userID. If minRole is NONE then all of userID's permissions are found.
+// Permissions that deny userID access are not found, since they don't share the file with userID.
+// The permissions are projected to their fileID and role, and are sorted by SortByID.
+// If successful returns the permissions, and a nil error,
+// otherwise returns nil and non-nil error if any occurred.
+func (s MongoStore) GetFilesForUser(
+	ctx context.Context,
+	userID string,
+	minRole service.Role,
+) (found []service.Permission, err error) {
+	ctx, span := s.startSpan(ctx, "GetFilesForUser", idAttributes("", userID)...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	if userID == "" {
+		return nil, status.Error(codes.InvalidArgument, "userID is required")
+	}
+
+	filter := FilterByUser(userID)
+	if minRole != service.RoleNone {
+		filter = append(filter, bson.E{
+			Key:   PermissionBSONRoleField,
+			Value: bson.D{bson.E{Key: "$in", Value: service.RolesIncluding(minRole)}},
+		})
+	}
+
+	projection := bson.D{
+		bson.E{
+			Key:   PermissionBSONFileIDField,
+			Value: 1,
+		},
+		bson.E{
+			Key:   PermissionBSONRoleField,
+			Value: 1,
+		},
+	}
+
+	return s.find(ctx, active(notDenied(filter)), SortByID, options.Find().SetProjection(projection))
+}
+
+// GetAllIncludingDeleted finds all permissions that matches filter the same way GetAll does,
+// but includes the soft deleted permissions, whose GetDeletedAt is set, for auditing.
+// If successful returns the permissions, and a nil error,
+// otherwise returns nil and non-nil error if any occurred.
+func (s MongoStore) GetAllIncludingDeleted(
+	ctx context.Context,
+	filter interface{},
+) (found []service.Permission, err error) {
+	ctx, span := s.startSpan(ctx, "GetAllIncludingDeleted", filterAttributes(filter)...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	return s.find(ctx, notExpired(filter), SortByID)
+}
+
+// find returns all permissions that match filter as is, scoped to the tenant of ctx, sorted by sortBy,
+// with the additional find options of opts, such as a projection.
+func (s MongoStore) find(
+	ctx context.Context,
+	filter interface{},
+	sortBy SortBy,
+	opts ...*options.FindOptions,
+) ([]service.Permission, error) {
+	collection := s.readCollection(ctx)
+	filter, err := s.scope(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	sort, err := sortDocument(sortBy)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	findOpts := options.MergeFindOptions(append([]*options.FindOptions{options.Find().SetSort(sort)}, opts...)...)
+
+	var cur *mongo.Cursor
+	err = s.retry(ctx, func() error {
+		var err error
+		cur, err = collection.Find(ctx, filter, findOpts)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	permissions := []service.Permission{}
+	for cur.Next(ctx) {
+		permission := &BSON{}
+		err := cur.Decode(permission)
+		if err != nil {
 			return nil, err
 		}
 
@@ -189,18 +1055,1081 @@ func (s MongoStore) GetAll(ctx context.Context, filter interface{}) ([]service.P
 		return nil, err
 	}
 
+	sortRoles(permissions, sortBy)
 	return permissions, nil
 }
 
-// Delete finds the first permission that matches filter and deletes it,
-// if successful returns the deleted permission, otherwise returns nil,
+// GetAllPaged finds a page of at most pageSize permissions that match filter,
+// starting right after the page that pageToken was returned with, an empty pageToken
+// starts from the first page. Permissions are sorted by their ascending ObjectID so
+// pages remain stable when permissions are added during iteration.
+// If successful returns the permissions, the token of the next page and a nil error,
+// an empty next page token is returned on the last page,
+// otherwise returns nil, an empty token and non-nil error if any occurred.
+func (s MongoStore) GetAllPaged(
+	ctx context.Context,
+	filter interface{},
+	pageSize int64,
+	pageToken string,
+) (found []service.Permission, nextPageToken string, err error) {
+	ctx, span := s.startSpan(ctx, "GetAllPaged", filterAttributes(filter)...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	collection := s.readCollection(ctx)
+	if pageSize <= 0 {
+		return nil, "", status.Error(codes.InvalidArgument, "pageSize must be positive")
+	}
+
+	pageFilter, err := s.scope(ctx, active(filter))
+	if err != nil {
+		return nil, "", err
+	}
+
+	if pageToken != "" {
+		lastID, err := primitive.ObjectIDFromHex(pageToken)
+		if err != nil {
+			return nil, "", status.Errorf(codes.InvalidArgument, "invalid page token %s", pageToken)
+		}
+
+		pageFilter = bson.D{
+			bson.E{
+				Key: "$and",
+				Value: bson.A{
+					pageFilter,
+					bson.D{
+						bson.E{
+							Key:   MongoObjectIDField,
+							Value: bson.D{bson.E{Key: "$gt", Value: lastID}},
+						},
+					},
+				},
+			},
+		}
+	}
+
+	// Fetch one extra permission to know whether there's a next page.
+	opts := options.Find().
+		SetSort(bson.D{bson.E{Key: MongoObjectIDField, Value: 1}}).
+		SetLimit(pageSize + 1)
+
+	var cur *mongo.Cursor
+	err = s.retry(ctx, func() error {
+		var err error
+		cur, err = collection.Find(ctx, pageFilter, opts)
+		return err
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	defer cur.Close(ctx)
+
+	permissions := []service.Permission{}
+	for cur.Next(ctx) {
+		permission := &BSON{}
+		err := cur.Decode(permission)
+		if err != nil {
+			return nil, "", err
+		}
+
+		permissions = append(permissions, permission)
+	}
+
+	if err := cur.Err(); err != nil {
+		return nil, "", err
+	}
+
+	if int64(len(permissions)) <= pageSize {
+		return permissions, "", nil
+	}
+
+	permissions = permissions[:pageSize]
+	return permissions, permissions[pageSize-1].GetID(), nil
+}
+
+// Count returns the number of permissions that match filter,
+// otherwise returns 0 and non-nil error if any occurred.
+func (s MongoStore) Count(ctx context.Context, filter interface{}) (count int64, err error) {
+	ctx, span := s.startSpan(ctx, "Count", filterAttributes(filter)...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	collection := s.readCollection(ctx)
+	filter, err = s.scope(ctx, active(filter))
+	if err != nil {
+		return 0, err
+	}
+
+	err = s.retry(ctx, func() error {
+		var err error
+		count, err = collection.CountDocuments(ctx, filter)
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Exists returns true if userID has a permission to fileID, checked by ExistsMatching, or false and
+// a nil error if it has none, otherwise returns false and non-nil error if any occurred.
+func (s MongoStore) Exists(ctx context.Context, fileID string, userID string) (exists bool, err error) {
+	ctx, span := s.startSpan(ctx, "Exists", idAttributes(fileID, userID)...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	return s.ExistsMatching(ctx, FilterByFileAndUser(fileID, userID))
+}
+
+// ExistsMatching returns true if any permission matches filter, the same permissions Count counts,
+// without decoding it. The count stops at the first match so it's cheaper than Count for large results.
+// Otherwise returns false and non-nil error if any occurred.
+func (s MongoStore) ExistsMatching(ctx context.Context, filter interface{}) (exists bool, err error) {
+	ctx, span := s.startSpan(ctx, "ExistsMatching", filterAttributes(filter)...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	collection := s.readCollection(ctx)
+	filter, err = s.scope(ctx, active(filter))
+	if err != nil {
+		return false, err
+	}
+
+	var count int64
+	err = s.retry(ctx, func() error {
+		var err error
+		count, err = collection.CountDocuments(ctx, filter, options.Count().SetLimit(1))
+		return err
+	})
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// active returns a filter that matches the permissions that match filter, have not expired yet
+// and were not soft deleted.
+func active(filter interface{}) bson.D {
+	return notExpired(notDeleted(filter))
+}
+
+// inactive returns a filter that matches the permissions that match filter and have expired
+// or were soft deleted, the permissions that active doesn't match.
+func inactive(filter interface{}) bson.D {
+	return bson.D{
+		bson.E{
+			Key: "$and",
+			Value: bson.A{
+				bsonFilter(filter),
+				bson.D{
+					bson.E{
+						Key:   "$nor",
+						Value: bson.A{active(bson.D{})},
+					},
+				},
+			},
+		},
+	}
+}
+
+// isDuplicateKeyError returns true if err is the error of a write that violates a unique index,
+// otherwise returns false.
+func isDuplicateKeyError(err error) bool {
+	switch e := err.(type) {
+	case mongo.CommandError:
+		return e.Code == duplicateKeyCode
+	case mongo.WriteException:
+		for _, writeErr := range e.WriteErrors {
+			if writeErr.Code == duplicateKeyCode {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// notDenied returns a filter that matches the permissions that match filter and don't deny access,
+// granting permissions are stored without the deny field.
+func notDenied(filter bson.D) bson.D {
+	return append(filter, bson.E{
+		Key:   PermissionBSONDenyField,
+		Value: bson.D{bson.E{Key: "$ne", Value: true}},
+	})
+}
+
+// notDeleted returns a filter that matches the permissions that match filter and were not soft deleted.
+func notDeleted(filter interface{}) bson.D {
+	return bson.D{
+		bson.E{
+			Key: "$and",
+			Value: bson.A{
+				bsonFilter(filter),
+				bson.D{
+					bson.E{
+						Key:   PermissionBSONDeletedAtField,
+						Value: bson.D{bson.E{Key: "$exists", Value: false}},
+					},
+				},
+			},
+		},
+	}
+}
+
+// markDeleted is the update that soft deletes a permission.
+func markDeleted() bson.D {
+	return bson.D{
+		bson.E{
+			Key: "$set",
+			Value: bson.D{
+				bson.E{
+					Key:   PermissionBSONDeletedAtField,
+					Value: time.Now(),
+				},
+			},
+		},
+		incrementVersion,
+		touchUpdatedAt,
+	}
+}
+
+// notExpired returns a filter that matches the permissions that match filter and have not expired yet,
+// mongodb's TTL monitor removes expired permissions only periodically so they must be filtered explicitly.
+func notExpired(filter interface{}) bson.D {
+	return bson.D{
+		bson.E{
+			Key: "$and",
+			Value: bson.A{
+				bsonFilter(filter),
+				bson.D{
+					bson.E{
+						Key: "$or",
+						Value: bson.A{
+							bson.D{
+								bson.E{
+									Key:   PermissionBSONExpiresAtField,
+									Value: bson.D{bson.E{Key: "$exists", Value: false}},
+								},
+							},
+							bson.D{
+								bson.E{
+									Key:   PermissionBSONExpiresAtField,
+									Value: bson.D{bson.E{Key: "$gt", Value: time.Now()}},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+// Delete finds the first permission that matches filter and deletes it, if s.SoftDelete is set
+// then the permission is marked as deleted instead of being removed.
+// If successful returns the deleted permission, if the permission is not found
+// it would return nil and service.ErrPermissionNotFound, otherwise returns nil,
 // and non-nil error if any occurred.
-func (s MongoStore) Delete(ctx context.Context, filter interface{}) (service.Permission, error) {
-	collection := s.DB.Collection(PermissionCollectionName)
+func (s MongoStore) Delete(ctx context.Context, filter interface{}) (deleted service.Permission, err error) {
+	ctx, span := s.startSpan(ctx, "Delete", filterAttributes(filter)...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	collection := s.collection()
+	tenantID, err := s.tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	filter = s.normalizeFilter(filter)
+	permission := &BSON{}
+	if s.SoftDelete {
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		scoped := scopeToTenant(notDeleted(filter), tenantID)
+		err = collection.FindOneAndUpdate(ctx, scoped, markDeleted(), opts).Decode(permission)
+	} else {
+		err = collection.FindOneAndDelete(ctx, scopeToTenant(filter, tenantID)).Decode(permission)
+	}
+
+	if err == mongo.ErrNoDocuments {
+		return nil, service.ErrPermissionNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.emitDeleted(permission)
+	return permission, nil
+}
+
+// DeleteMany deletes all permissions that match filter, if s.SoftDelete is set then
+// they're marked as deleted instead of being removed.
+// If successful returns the number of deleted permissions and a nil error,
+// if filter is empty it would return 0 and an InvalidArgument error, so a missing filter
+// never deletes all of the permissions, otherwise returns 0 and non-nil error if any occurred.
+func (s MongoStore) DeleteMany(ctx context.Context, filter interface{}) (deleted int64, err error) {
+	ctx, span := s.startSpan(ctx, "DeleteMany", filterAttributes(filter)...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	if err := service.RequireFilter(filter); err != nil {
+		return 0, err
+	}
+
+	return s.deleteMany(ctx, s.normalizeFilter(filter))
+}
+
+// DeleteAllByFileID deletes all permissions of fileID, if s.SoftDelete is set then
+// they're marked as deleted instead of being removed.
+// If successful returns the number of deleted permissions and a nil error,
+// otherwise returns 0 and non-nil error if any occurred.
+func (s MongoStore) DeleteAllByFileID(ctx context.Context, fileID string) (deleted int64, err error) {
+	ctx, span := s.startSpan(ctx, "DeleteAllByFileID", idAttributes(fileID, "")...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	if fileID == "" {
+		return 0, status.Error(codes.InvalidArgument, "fileID is required")
+	}
+
+	return s.deleteMany(ctx, FilterByFile(s.normalizeID(PermissionBSONFileIDField, fileID)))
+}
+
+// DeleteAllByUserID deletes all permissions of userID, if s.SoftDelete is set then
+// they're marked as deleted instead of being removed.
+// If successful returns the number of deleted permissions and a nil error,
+// otherwise returns 0 and non-nil error if any occurred.
+func (s MongoStore) DeleteAllByUserID(ctx context.Context, userID string) (deleted int64, err error) {
+	ctx, span := s.startSpan(ctx, "DeleteAllByUserID", idAttributes("", userID)...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	if userID == "" {
+		return 0, status.Error(codes.InvalidArgument, "userID is required")
+	}
+
+	return s.deleteMany(ctx, FilterByUser(s.normalizeID(PermissionBSONUserIDField, userID)))
+}
+
+// deleteMany deletes all permissions that match filter, if s.SoftDelete is set then they're
+// marked as deleted instead of being removed. If s.EventSink is set then the permissions are
+// read before they're deleted, so a permission that is created concurrently may be deleted
+// without an event being emitted for it.
+// If ctx is a dry run then nothing is deleted and the number of permissions that would be deleted is returned.
+// If successful returns the number of deleted permissions and a nil error,
+// otherwise returns 0 and non-nil error if any occurred.
+func (s MongoStore) deleteMany(ctx context.Context, filter interface{}) (int64, error) {
+	collection := s.collection()
+	tenantID, err := s.tenantID(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if service.IsDryRun(ctx) {
+		return collection.CountDocuments(ctx, scopeToTenant(notDeleted(filter), tenantID))
+	}
+
+	var permissions []service.Permission
+	if s.EventSink != nil {
+		if permissions, err = s.find(WithPrimaryRead(ctx), notDeleted(filter), SortByID); err != nil {
+			return 0, err
+		}
+	}
+
+	var deleted int64
+	if s.SoftDelete {
+		result, err := collection.UpdateMany(ctx, scopeToTenant(notDeleted(filter), tenantID), markDeleted())
+		if err != nil {
+			return 0, err
+		}
+
+		deleted = result.ModifiedCount
+	} else {
+		result, err := collection.DeleteMany(ctx, scopeToTenant(filter, tenantID))
+		if err != nil {
+			return 0, err
+		}
+
+		deleted = result.DeletedCount
+	}
+
+	s.emitDeleted(permissions...)
+	return deleted, nil
+}
+
+// Restore undeletes the soft deleted permission of userID to fileID,
+// if successful returns the restored permission and a nil error,
+// if no such soft deleted permission exists it would return nil and service.ErrPermissionNotFound,
+// otherwise returns nil and non-nil error if any occurred.
+func (s MongoStore) Restore(
+	ctx context.Context,
+	fileID string,
+	userID string,
+) (restored service.Permission, err error) {
+	ctx, span := s.startSpan(ctx, "Restore", idAttributes(fileID, userID)...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	collection := s.collection()
+	if fileID == "" {
+		return nil, status.Error(codes.InvalidArgument, "fileID is required")
+	}
+
+	if userID == "" {
+		return nil, status.Error(codes.InvalidArgument, "userID is required")
+	}
+
+	fileID = s.normalizeID(PermissionBSONFileIDField, fileID)
+	userID = s.normalizeID(PermissionBSONUserIDField, userID)
+	filter := append(FilterByFileAndUser(fileID, userID), bson.E{
+		Key:   PermissionBSONDeletedAtField,
+		Value: bson.D{bson.E{Key: "$exists", Value: true}},
+	})
+
+	update := bson.D{
+		bson.E{
+			Key: "$unset",
+			Value: bson.D{
+				bson.E{
+					Key:   PermissionBSONDeletedAtField,
+					Value: "",
+				},
+			},
+		},
+		incrementVersion,
+		touchUpdatedAt,
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	permission := &BSON{}
+	scoped, err := s.scope(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+
+	err = collection.FindOneAndUpdate(ctx, scoped, update, opts).Decode(permission)
+	if err == mongo.ErrNoDocuments {
+		return nil, service.ErrPermissionNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.emitCreated(permission)
+	return permission, nil
+}
+
+// PurgeDeleted permanently deletes the permissions that were soft deleted before olderThan,
+// after which they can no longer be restored or audited.
+// If successful returns the number of purged permissions and a nil error,
+// otherwise returns 0 and non-nil error if any occurred.
+func (s MongoStore) PurgeDeleted(ctx context.Context, olderThan time.Time) (purged int64, err error) {
+	ctx, span := s.startSpan(ctx, "PurgeDeleted")
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	collection := s.collection()
+	filter := bson.D{
+		bson.E{
+			Key:   PermissionBSONDeletedAtField,
+			Value: bson.D{bson.E{Key: "$lt", Value: olderThan}},
+		},
+	}
+
+	scoped, err := s.scope(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	result, err := collection.DeleteMany(ctx, scoped)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
+
+// DeleteExpired permanently deletes the permissions that expired before expiredBefore, of every tenant
+// and even if s.SoftDelete is set, since expired permissions are never read anyway. No events are emitted
+// for them, the same as for the permissions removed by the TTL index.
+// If successful returns the number of deleted permissions and a nil error,
+// otherwise returns 0 and non-nil error if any occurred.
+func (s MongoStore) DeleteExpired(ctx context.Context, expiredBefore time.Time) (deleted int64, err error) {
+	ctx, span := s.startSpan(ctx, "DeleteExpired")
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	filter := bson.D{
+		bson.E{
+			Key:   PermissionBSONExpiresAtField,
+			Value: bson.D{bson.E{Key: "$lt", Value: expiredBefore}},
+		},
+	}
+
+	result, err := s.collection().DeleteMany(ctx, filter)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.DeletedCount, nil
+}
+
+// ReassignFile moves all permissions of oldFileID to newFileID in a single transaction, for example when
+// the file's ID changes during a migration. A user that already has a permission to newFileID keeps it,
+// and the user's permission to oldFileID is deleted, or marked as deleted if s.SoftDelete is set, instead
+// of moved, so the unique index of fileID and userID is never violated. If the owner of oldFileID is moved
+// then the owner of newFileID is resolved by s.OwnerConflict as it would be for any new owner, and the
+// demoted owner is emitted to s.EventSink as updated. Soft deleted permissions of oldFileID are moved
+// as well so they can still be restored, but they're not counted as moved.
+// If ctx is a dry run then nothing is moved or deleted and the number of permissions that would be moved
+// is returned.
+// If successful returns the number of moved permissions that are not soft deleted and a nil error,
+// otherwise returns 0 and non-nil error if any occurred.
+func (s MongoStore) ReassignFile(
+	ctx context.Context,
+	oldFileID string,
+	newFileID string,
+) (moved int64, err error) {
+	ctx, span := s.startSpan(ctx, "ReassignFile", idAttributes(oldFileID, "")...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	collection := s.collection()
+	if oldFileID == "" {
+		return 0, status.Error(codes.InvalidArgument, "oldFileID is required")
+	}
+
+	if newFileID == "" {
+		return 0, status.Error(codes.InvalidArgument, "newFileID is required")
+	}
+
+	oldFileID = s.normalizeID(PermissionBSONFileIDField, oldFileID)
+	newFileID = s.normalizeID(PermissionBSONFileIDField, newFileID)
+	if oldFileID == newFileID {
+		return 0, nil
+	}
+
+	tenantID, err := s.tenantID(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	if service.IsDryRun(ctx) {
+		existingUserIDs, err := s.fileUserIDs(ctx, newFileID, tenantID)
+		if err != nil {
+			return 0, err
+		}
+
+		movedPermissions := scopeToTenant(notDeleted(movedFilter(oldFileID, existingUserIDs)), tenantID)
+		return collection.CountDocuments(ctx, movedPermissions)
+	}
+
+	var demoted []service.Permission
+	err = s.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		existingUserIDs, err := s.fileUserIDs(sessCtx, newFileID, tenantID)
+		if err != nil {
+			return err
+		}
+
+		if len(existingUserIDs) > 0 {
+			collidingFilter := bson.D{
+				bson.E{
+					Key:   PermissionBSONFileIDField,
+					Value: oldFileID,
+				},
+				bson.E{
+					Key:   PermissionBSONUserIDField,
+					Value: bson.D{bson.E{Key: "$in", Value: existingUserIDs}},
+				},
+			}
+
+			if s.SoftDelete {
+				_, err = collection.UpdateMany(sessCtx, scopeToTenant(active(collidingFilter), tenantID), markDeleted())
+			} else {
+				_, err = collection.DeleteMany(sessCtx, scopeToTenant(collidingFilter, tenantID))
+			}
+
+			if err != nil {
+				return err
+			}
+		}
+
+		movedOwner := &BSON{}
+		ownerFilter := append(movedFilter(oldFileID, existingUserIDs), bson.E{
+			Key:   PermissionBSONRoleField,
+			Value: pb.Role_OWNER,
+		})
+
+		err = collection.FindOne(sessCtx, scopeToTenant(ownerFilter, tenantID)).Decode(movedOwner)
+		if err != nil && err != mongo.ErrNoDocuments {
+			return err
+		}
+
+		if err == nil {
+			if demoted, err = s.resolveOwnerConflict(sessCtx, newFileID, movedOwner.GetUserID()); err != nil {
+				return err
+			}
+		}
+
+		update := bson.D{
+			bson.E{
+				Key: "$set",
+				Value: bson.D{
+					bson.E{
+						Key:   PermissionBSONFileIDField,
+						Value: newFileID,
+					},
+				},
+			},
+			incrementVersion,
+			touchUpdatedAt,
+		}
+
+		movedPermissions := scopeToTenant(notDeleted(movedFilter(oldFileID, existingUserIDs)), tenantID)
+		result, err := collection.UpdateMany(sessCtx, movedPermissions, update)
+		if err != nil {
+			return err
+		}
+
+		moved = result.ModifiedCount
+
+		// The permissions that are not soft deleted were already moved, so only the soft deleted are left.
+		deletedPermissions := scopeToTenant(movedFilter(oldFileID, existingUserIDs), tenantID)
+		_, err = collection.UpdateMany(sessCtx, deletedPermissions, update)
+		return err
+	})
+
+	if err != nil {
+		return 0, err
+	}
+
+	s.emitUpdated(demoted...)
+	return moved, nil
+}
+
+// movedFilter returns a filter that matches the permissions of oldFileID that ReassignFile
+// moves, which are those whose userID isn't in existingUserIDs.
+func movedFilter(oldFileID string, existingUserIDs bson.A) bson.D {
+	return bson.D{
+		bson.E{
+			Key:   PermissionBSONFileIDField,
+			Value: oldFileID,
+		},
+		bson.E{
+			Key:   PermissionBSONUserIDField,
+			Value: bson.D{bson.E{Key: "$nin", Value: existingUserIDs}},
+		},
+	}
+}
+
+// fileUserIDs returns the userIDs of all permissions of fileID in tenantID. Expired and soft deleted
+// permissions are included since they still occupy their slot in the unique index until they're removed.
+func (s MongoStore) fileUserIDs(ctx context.Context, fileID string, tenantID string) (bson.A, error) {
+	opts := options.Find().SetProjection(bson.D{bson.E{Key: PermissionBSONUserIDField, Value: 1}})
+	cur, err := s.collection().Find(ctx, scopeToTenant(FilterByFile(fileID), tenantID), opts)
+	if err != nil {
+		return nil, err
+	}
+	defer cur.Close(ctx)
+
+	userIDs := bson.A{}
+	for cur.Next(ctx) {
+		permission := &BSON{}
+		if err := cur.Decode(permission); err != nil {
+			return nil, err
+		}
+
+		userIDs = append(userIDs, permission.GetUserID())
+	}
+
+	return userIDs, cur.Err()
+}
+
+// TransferOwnership makes toUserID the owner of fileID and demotes fromUserID, the current owner,
+// to s.DemotedOwnerRole. Both updates are applied in a single transaction so the file never has
+// two owners or none. A permission of toUserID is created if it doesn't exist, with fromUserID as its creator.
+// Both permissions are emitted to s.EventSink as updated, even if toUserID's permission was created.
+// If ctx is a dry run then only the inputs and fromUserID's ownership are checked, nothing is updated.
+// If successful returns a nil error, if fromUserID is not the owner of fileID it would return a
+// FailedPrecondition error, otherwise returns non-nil error if any occurred.
+func (s MongoStore) TransferOwnership(
+	ctx context.Context,
+	fileID string,
+	fromUserID string,
+	toUserID string,
+) (err error) {
+	ctx, span := s.startSpan(ctx, "TransferOwnership", idAttributes(fileID, fromUserID)...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	collection := s.collection()
+	if fileID == "" {
+		return status.Error(codes.InvalidArgument, "fileID is required")
+	}
+
+	if fromUserID == "" {
+		return status.Error(codes.InvalidArgument, "fromUserID is required")
+	}
+
+	if toUserID == "" {
+		return status.Error(codes.InvalidArgument, "toUserID is required")
+	}
+
+	fileID = s.normalizeID(PermissionBSONFileIDField, fileID)
+	fromUserID = s.normalizeID(PermissionBSONUserIDField, fromUserID)
+	toUserID = s.normalizeID(PermissionBSONUserIDField, toUserID)
+	if fromUserID == toUserID {
+		return status.Error(codes.InvalidArgument, "fromUserID and toUserID must be different users")
+	}
+
+	demotedRole, err := s.demotedOwnerRole()
+	if err != nil {
+		return err
+	}
+
+	tenantID, err := s.tenantID(ctx)
+	if err != nil {
+		return err
+	}
+
+	ownerFilter := append(FilterByFileAndUser(fileID, fromUserID), bson.E{
+		Key:   PermissionBSONRoleField,
+		Value: pb.Role_OWNER,
+	})
+
+	demote := bson.D{
+		bson.E{
+			Key: "$set",
+			Value: bson.D{
+				bson.E{
+					Key:   PermissionBSONRoleField,
+					Value: demotedRole,
+				},
+			},
+		},
+		incrementVersion,
+		touchUpdatedAt,
+	}
+
+	// The new owner's permission never expires, isn't deleted, and keeps its creator if it already exists.
+	promote := bson.D{
+		bson.E{
+			Key: "$set",
+			Value: bson.D{
+				bson.E{
+					Key:   PermissionBSONRoleField,
+					Value: pb.Role_OWNER,
+				},
+			},
+		},
+		bson.E{
+			Key: "$setOnInsert",
+			Value: bson.D{
+				bson.E{
+					Key:   PermissionBSONCreatorField,
+					Value: fromUserID,
+				},
+				bson.E{
+					Key:   PermissionBSONCreatedAtField,
+					Value: time.Now(),
+				},
+			},
+		},
+		bson.E{
+			Key: "$unset",
+			Value: bson.D{
+				bson.E{
+					Key:   PermissionBSONExpiresAtField,
+					Value: "",
+				},
+				bson.E{
+					Key:   PermissionBSONDeletedAtField,
+					Value: "",
+				},
+				// The owner has access to the file, so promoting a denied user lifts the denial.
+				bson.E{
+					Key:   PermissionBSONDenyField,
+					Value: "",
+				},
+			},
+		},
+		incrementVersion,
+		touchUpdatedAt,
+	}
+
+	scopedOwnerFilter := scopeToTenant(active(ownerFilter), tenantID)
+	notOwner := status.Errorf(codes.FailedPrecondition, "user %s is not the owner of file %s", fromUserID, fileID)
+	if service.IsDryRun(ctx) {
+		err := collection.FindOne(ctx, scopedOwnerFilter).Err()
+		if err == mongo.ErrNoDocuments {
+			return notOwner
+		}
+
+		return err
+	}
+
+	var demoted, promoted *BSON
+	err = s.WithTransaction(ctx, func(sessCtx mongo.SessionContext) error {
+		demoted, promoted = &BSON{}, &BSON{}
+		opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+		err := collection.FindOneAndUpdate(sessCtx, scopedOwnerFilter, demote, opts).Decode(demoted)
+		if err == mongo.ErrNoDocuments {
+			return notOwner
+		}
+
+		if err != nil {
+			return err
+		}
+
+		opts.SetUpsert(true)
+		newOwnerFilter := scopeToTenant(FilterByFileAndUser(fileID, toUserID), tenantID)
+		return collection.FindOneAndUpdate(sessCtx, newOwnerFilter, withTenant(promote, tenantID), opts).
+			Decode(promoted)
+	})
+
+	if err != nil {
+		return err
+	}
+
+	s.emitUpdated(demoted, promoted)
+	return nil
+}
+
+// UpdateRole updates the role of the permission of userID to fileID, expired permissions are not updated,
+// if successful returns the updated permission and a nil error,
+// if the permission does not exist it would return nil and service.ErrPermissionNotFound,
+// otherwise returns nil and non-nil error if any occurred.
+func (s MongoStore) UpdateRole(
+	ctx context.Context,
+	fileID string,
+	userID string,
+	role pb.Role,
+) (updated service.Permission, err error) {
+	ctx, span := s.startSpan(ctx, "UpdateRole", idAttributes(fileID, userID)...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	collection := s.collection()
+	fileID = s.normalizeID(PermissionBSONFileIDField, fileID)
+	userID = s.normalizeID(PermissionBSONUserIDField, userID)
+	if err := service.ValidateID(s.Validator, "fileID", fileID); err != nil {
+		return nil, err
+	}
+
+	if err := service.ValidateID(s.Validator, "userID", userID); err != nil {
+		return nil, err
+	}
+
+	if err := service.ValidateRole(role); err != nil {
+		return nil, err
+	}
+
+	filter := FilterByFileAndUser(fileID, userID)
+
+	update := bson.D{
+		bson.E{
+			Key: "$set",
+			Value: bson.D{
+				bson.E{
+					Key:   PermissionBSONRoleField,
+					Value: role,
+				},
+			},
+		},
+		incrementVersion,
+		touchUpdatedAt,
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
 	permission := &BSON{}
-	if err := collection.FindOneAndDelete(ctx, filter).Decode(permission); err != nil {
+	scoped, err := s.scope(ctx, active(filter))
+	if err != nil {
 		return nil, err
 	}
 
+	err = s.withSingleOwner(ctx, fileID, userID, role, func(ctx context.Context) error {
+		return collection.FindOneAndUpdate(ctx, scoped, update, opts).Decode(permission)
+	})
+	if err == mongo.ErrNoDocuments {
+		return nil, service.ErrPermissionNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	s.emitUpdated(permission)
 	return permission, nil
 }
+
+// UpdateRoleIfVersion updates the role of the permission of userID to fileID only if its
+// version is still expectedVersion, which lets callers safely read, modify and write a permission.
+// If successful returns the updated permission and a nil error,
+// if the permission does not exist it would return nil and service.ErrPermissionNotFound,
+// if the permission's version changed it would return nil and an Aborted error,
+// otherwise returns nil and non-nil error if any occurred.
+func (s MongoStore) UpdateRoleIfVersion(
+	ctx context.Context,
+	fileID string,
+	userID string,
+	role pb.Role,
+	expectedVersion int64,
+) (updated service.Permission, err error) {
+	ctx, span := s.startSpan(ctx, "UpdateRoleIfVersion", idAttributes(fileID, userID)...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	collection := s.collection()
+	fileID = s.normalizeID(PermissionBSONFileIDField, fileID)
+	userID = s.normalizeID(PermissionBSONUserIDField, userID)
+	if err := service.ValidateID(s.Validator, "fileID", fileID); err != nil {
+		return nil, err
+	}
+
+	if err := service.ValidateID(s.Validator, "userID", userID); err != nil {
+		return nil, err
+	}
+
+	if err := service.ValidateRole(role); err != nil {
+		return nil, err
+	}
+
+	// Permissions that were stored before versioning was added have no version field.
+	var versionFilter interface{} = expectedVersion
+	if expectedVersion == 0 {
+		versionFilter = bson.D{bson.E{Key: "$in", Value: bson.A{0, nil}}}
+	}
+
+	filter := append(FilterByFileAndUser(fileID, userID), bson.E{
+		Key:   PermissionBSONVersionField,
+		Value: versionFilter,
+	})
+
+	update := bson.D{
+		bson.E{
+			Key: "$set",
+			Value: bson.D{
+				bson.E{
+					Key:   PermissionBSONRoleField,
+					Value: role,
+				},
+			},
+		},
+		incrementVersion,
+		touchUpdatedAt,
+	}
+
+	opts := options.FindOneAndUpdate().SetReturnDocument(options.After)
+	permission := &BSON{}
+	scoped, err := s.scope(ctx, active(filter))
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.withSingleOwner(ctx, fileID, userID, role, func(ctx context.Context) error {
+		return collection.FindOneAndUpdate(ctx, scoped, update, opts).Decode(permission)
+	})
+	if err != nil && err != mongo.ErrNoDocuments {
+		return nil, err
+	}
+
+	if err == nil {
+		s.emitUpdated(permission)
+		return permission, nil
+	}
+
+	current, err := s.Get(WithPrimaryRead(ctx), FilterByFileAndUser(fileID, userID))
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, status.Errorf(
+		codes.Aborted,
+		"permission of user %s to file %s was modified, expected version %d but found %d",
+		userID,
+		fileID,
+		expectedVersion,
+		current.GetVersion(),
+	)
+}
+
+// CreateMany creates permissions in a single bulk write, each permission is upserted
+// the same way Create does.
+// If successful returns the created permissions in the order they were given and a nil error,
+// if some of the permissions failed returns the ones that succeeded and a *service.CreateManyError,
+// if the permissions were written but reading them back failed returns nil and a *service.CreateManyError
+// whose ReadErr is set, in which case no events are emitted for them,
+// otherwise returns nil and non-nil error if any occurred.
+func (s MongoStore) CreateMany(
+	ctx context.Context,
+	permissions []service.Permission,
+) (created []service.Permission, err error) {
+	ctx, span := s.startSpan(ctx, "CreateMany")
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	collection := s.collection()
+	if len(permissions) == 0 {
+		return []service.Permission{}, nil
+	}
+
+	if permissions, err = s.normalizePermissions(permissions); err != nil {
+		return nil, err
+	}
+
+	if err := service.ValidatePermissions(s.Validator, permissions); err != nil {
+		return nil, err
+	}
+
+	tenantID, err := s.tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	models := make([]mongo.WriteModel, 0, len(permissions))
+	for _, permission := range permissions {
+		keyFilter := FilterByFileAndUser(permission.GetFileID(), permission.GetUserID())
+		filter := scopeToTenant(FilterBySubjectType(keyFilter, permission.GetSubjectType()), tenantID)
+
+		update := withTenant(permissionUpsert(permission, time.Now()), tenantID)
+		update = withGrantedBy(update, service.ActorIDFromContext(ctx))
+		models = append(models, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(update).SetUpsert(true))
+	}
+
+	failed := make(map[int]error)
+	_, err = collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+	if bulkErr, ok := err.(mongo.BulkWriteException); ok && bulkErr.WriteConcernError == nil {
+		for _, writeErr := range bulkErr.WriteErrors {
+			failed[writeErr.Index] = writeErr
+		}
+	} else if err != nil {
+		return nil, err
+	}
+
+	createdFilters := make(bson.A, 0, len(permissions)-len(failed))
+	for i, permission := range permissions {
+		if _, ok := failed[i]; ok {
+			continue
+		}
+
+		createdFilters = append(createdFilters, FilterByFileAndUser(permission.GetFileID(), permission.GetUserID()))
+	}
+
+	created = []service.Permission{}
+	if len(createdFilters) > 0 {
+		found, err := s.GetAll(WithPrimaryRead(ctx), bson.D{bson.E{Key: "$or", Value: createdFilters}})
+		if err != nil {
+			return nil, &service.CreateManyError{Failed: failed, ReadErr: err}
+		}
+
+		foundByKey := make(map[[2]string]service.Permission, len(found))
+		for _, permission := range found {
+			foundByKey[[2]string{permission.GetFileID(), permission.GetUserID()}] = permission
+		}
+
+		for i, permission := range permissions {
+			if _, ok := failed[i]; ok {
+				continue
+			}
+
+			if createdPermission, ok := foundByKey[[2]string{permission.GetFileID(), permission.GetUserID()}]; ok {
+				created = append(created, createdPermission)
+			}
+		}
+	}
+
+	s.emitCreated(created...)
+	if len(failed) > 0 {
+		return created, &service.CreateManyError{Failed: failed}
+	}
+
+	return created, nil
+}
@@ -0,0 +1,570 @@
+package mongodb
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestGetNotFound(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "user", pb.Role_READ)
+
+	filters := map[string]interface{}{
+		"bson":   FilterByFileAndUser("file", "other"),
+		"filter": service.And(service.ByFile("other"), service.ByUser("user")),
+	}
+
+	for name, filter := range filters {
+		permission, err := store.Get(context.Background(), filter)
+		if status.Code(err) != codes.NotFound {
+			t.Errorf("%s: Get() of a missing permission = %v, want code %v", name, err, codes.NotFound)
+		}
+
+		if permission != nil {
+			t.Errorf("%s: Get() of a missing permission = %v, want nil", name, permission)
+		}
+	}
+}
+
+func TestGetByFileAndUserNotFoundNamesLookup(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	_, err := NewController(store).GetByFileAndUser(context.Background(), "missing-file", "missing-user")
+	if status.Code(err) != codes.NotFound {
+		t.Fatalf("GetByFileAndUser() of a missing permission = %v, want code %v", err, codes.NotFound)
+	}
+
+	message := status.Convert(err).Message()
+	if !strings.Contains(message, "missing-file") || !strings.Contains(message, "missing-user") {
+		t.Errorf("GetByFileAndUser() error message = %q, want it to name the file and the user", message)
+	}
+}
+
+func TestGetNotFoundIsErrPermissionNotFound(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	permission, err := store.Get(context.Background(), FilterByFileAndUser("file", "user"))
+	st, ok := status.FromError(err)
+	if !ok || st.Code() != codes.NotFound {
+		t.Errorf("Get() of a missing permission = %v, want a status error of code %v", err, codes.NotFound)
+	}
+
+	if err != service.ErrPermissionNotFound || permission != nil {
+		t.Errorf("Get() of a missing permission = %v, %v, want nil, %v",
+			permission, err, service.ErrPermissionNotFound)
+	}
+}
+
+func TestUpdateRole(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "user", pb.Role_READ)
+	created, err := store.Get(context.Background(), FilterByFileAndUser("file", "user"))
+	if err != nil {
+		t.Fatalf("Get() = %v, want nil", err)
+	}
+
+	updated, err := store.UpdateRole(context.Background(), "file", "user", pb.Role_WRITE)
+	if err != nil {
+		t.Fatalf("UpdateRole() = %v, want nil", err)
+	}
+
+	if updated.GetRole() != pb.Role_WRITE || updated.GetID() != created.GetID() {
+		t.Errorf("UpdateRole() = %v of permission %s, want WRITE of permission %s",
+			updated.GetRole(), updated.GetID(), created.GetID())
+	}
+
+	if role := roleOf(t, store, "file", "user"); role != pb.Role_WRITE {
+		t.Errorf("role after UpdateRole() = %v, want %v", role, pb.Role_WRITE)
+	}
+}
+
+func TestUpdateRoleNotFoundDoesNotUpsert(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	updated, err := store.UpdateRole(context.Background(), "file", "user", pb.Role_WRITE)
+	if status.Code(err) != codes.NotFound || updated != nil {
+		t.Errorf("UpdateRole() of a missing permission = %v, %v, want nil and code %v", updated, err, codes.NotFound)
+	}
+
+	if exists, err := store.Exists(context.Background(), "file", "user"); err != nil || exists {
+		t.Errorf("Exists() after UpdateRole() of a missing permission = %v, %v, want false, nil", exists, err)
+	}
+}
+
+func TestCreateManyUpsertsNewAndExisting(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "existing", pb.Role_READ)
+
+	created, err := store.CreateMany(context.Background(), []service.Permission{
+		&BSON{FileID: "file", UserID: "existing", Role: pb.Role_WRITE, Creator: "creator"},
+		&BSON{FileID: "file", UserID: "new", Role: pb.Role_READ, Creator: "creator"},
+	})
+	if err != nil {
+		t.Fatalf("CreateMany() = %v, want nil", err)
+	}
+
+	if len(created) != 2 || created[0].GetUserID() != "existing" || created[1].GetUserID() != "new" {
+		t.Fatalf("CreateMany() = %v, want the permissions of existing and new in order", created)
+	}
+
+	if role := roleOf(t, store, "file", "existing"); role != pb.Role_WRITE {
+		t.Errorf("role of the existing permission after CreateMany() = %v, want %v", role, pb.Role_WRITE)
+	}
+
+	if count, err := store.Count(context.Background(), FilterByFile("file")); err != nil || count != 2 {
+		t.Errorf("Count() after CreateMany() = %d, %v, want 2, nil", count, err)
+	}
+}
+
+func TestCreateManyRejectsMissingIDs(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	_, err := store.CreateMany(context.Background(), []service.Permission{
+		&BSON{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"},
+		&BSON{FileID: "", UserID: "user", Role: pb.Role_READ, Creator: "creator"},
+	})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("CreateMany() with an empty fileID = %v, want code %v", err, codes.InvalidArgument)
+	}
+
+	if count, err := store.Count(context.Background(), FilterByUser("user")); err != nil || count != 0 {
+		t.Errorf("Count() after a rejected CreateMany() = %d, %v, want 0, nil", count, err)
+	}
+}
+
+func TestCreateManyReportsFailedIndexes(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "shared", pb.Role_READ)
+
+	group := &BSON{FileID: "file", UserID: "shared", Role: pb.Role_WRITE, Creator: "creator"}
+	if err := group.SetSubjectType(service.SubjectTypeGroup); err != nil {
+		t.Fatalf("SetSubjectType() = %v, want nil", err)
+	}
+
+	created, err := store.CreateMany(context.Background(), []service.Permission{
+		group,
+		&BSON{FileID: "file", UserID: "other", Role: pb.Role_READ, Creator: "creator"},
+	})
+
+	createErr, ok := err.(*service.CreateManyError)
+	if !ok {
+		t.Fatalf("CreateMany() with a conflicting permission = %v, want a *service.CreateManyError", err)
+	}
+
+	if indexes := createErr.FailedIndexes(); len(indexes) != 1 || indexes[0] != 0 {
+		t.Errorf("FailedIndexes() = %v, want [0]", indexes)
+	}
+
+	if len(created) != 1 || created[0].GetUserID() != "other" {
+		t.Errorf("CreateMany() created %v, want only the permission of other", created)
+	}
+}
+
+func TestCreatePermissionsReportsFailedPermissions(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "shared", pb.Role_READ)
+
+	req := &pb.CreatePermissionsRequest{Permissions: []*pb.CreatePermissionRequest{
+		{FileID: "file", UserID: "other", Role: pb.Role_READ, Creator: "creator"},
+		{
+			FileID:      "file",
+			UserID:      "shared",
+			Role:        pb.Role_WRITE,
+			Creator:     "creator",
+			SubjectType: service.SubjectTypeGroup,
+		},
+	}}
+
+	_, err := service.NewService(NewController(store), nil).CreatePermissions(context.Background(), req)
+	if status.Code(err) != codes.Aborted {
+		t.Fatalf("CreatePermissions() with a conflicting permission = %v, want code %v", err, codes.Aborted)
+	}
+
+	var fields []string
+	for _, detail := range status.Convert(err).Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			for _, violation := range badRequest.GetFieldViolations() {
+				fields = append(fields, violation.GetField())
+			}
+		}
+	}
+
+	if len(fields) != 1 || fields[0] != "permissions[1]" {
+		t.Errorf("CreatePermissions() field violations = %v, want a single violation of permissions[1]", fields)
+	}
+}
+
+func TestGetAllPagedWalksAllPages(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	users := []string{"a", "b", "c", "d", "e"}
+	for _, userID := range users {
+		createPermission(t, store, "file", userID, pb.Role_READ)
+	}
+	createPermission(t, store, "other", "a", pb.Role_READ)
+
+	seen := make(map[string]bool, len(users))
+	pages := 0
+	pageToken := ""
+	for {
+		permissions, nextPageToken, err := store.GetAllPaged(context.Background(), FilterByFile("file"), 2, pageToken)
+		if err != nil {
+			t.Fatalf("GetAllPaged() of page %d = %v, want nil", pages, err)
+		}
+
+		pages++
+		for _, permission := range permissions {
+			if seen[permission.GetUserID()] {
+				t.Errorf("GetAllPaged() returned the permission of %s twice", permission.GetUserID())
+			}
+
+			seen[permission.GetUserID()] = true
+		}
+
+		if nextPageToken == "" {
+			break
+		}
+
+		if pages > len(users) {
+			t.Fatalf("GetAllPaged() returned more pages than permissions")
+		}
+
+		pageToken = nextPageToken
+	}
+
+	if pages != 3 {
+		t.Errorf("GetAllPaged() returned %d pages, want 3", pages)
+	}
+
+	if len(seen) != len(users) {
+		t.Errorf("GetAllPaged() returned the permissions of %v, want all of %v", seen, users)
+	}
+}
+
+func TestCountScopedToFile(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	for _, userID := range []string{"a", "b", "c"} {
+		createPermission(t, store, "file", userID, pb.Role_READ)
+	}
+	createPermission(t, store, "other", "a", pb.Role_READ)
+
+	counts := map[string]int64{"file": 3, "other": 1, "missing": 0}
+	svc := service.NewService(NewController(store), nil)
+	for fileID, want := range counts {
+		if count, err := store.Count(context.Background(), FilterByFile(fileID)); err != nil || count != want {
+			t.Errorf("Count() of %s = %d, %v, want %d, nil", fileID, count, err, want)
+		}
+
+		res, err := svc.CountPermissions(context.Background(), &pb.CountPermissionsRequest{FileID: fileID})
+		if err != nil || res.GetCount() != want {
+			t.Errorf("CountPermissions() of %s = %d, %v, want %d, nil", fileID, res.GetCount(), err, want)
+		}
+	}
+}
+
+func TestExpiredPermissionIsNotReturned(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "current", pb.Role_READ)
+	expired := &BSON{FileID: "file", UserID: "expired", Role: pb.Role_READ, Creator: "creator"}
+	if err := expired.SetExpiresAt(time.Now().Add(-time.Minute)); err != nil {
+		t.Fatalf("SetExpiresAt() = %v, want nil", err)
+	}
+
+	if _, err := store.Create(context.Background(), expired); err != nil {
+		t.Fatalf("Create() of an expired permission = %v, want nil", err)
+	}
+
+	_, err := store.Get(context.Background(), FilterByFileAndUser("file", "expired"))
+	if err != service.ErrPermissionNotFound {
+		t.Errorf("Get() of an expired permission = %v, want %v", err, service.ErrPermissionNotFound)
+	}
+
+	permissions, err := store.GetAll(context.Background(), FilterByFile("file"))
+	if err != nil {
+		t.Fatalf("GetAll() = %v, want nil", err)
+	}
+
+	if len(permissions) != 1 || permissions[0].GetUserID() != "current" {
+		t.Errorf("GetAll() = %v, want only the permission of current", permissions)
+	}
+}
+
+func TestDeleteAllByFileIDRejectsEmptyFileID(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "user", pb.Role_READ)
+
+	deleted, err := store.DeleteAllByFileID(context.Background(), "")
+	if status.Code(err) != codes.InvalidArgument || deleted != 0 {
+		t.Errorf("DeleteAllByFileID() of an empty fileID = %d, %v, want 0 and code %v",
+			deleted, err, codes.InvalidArgument)
+	}
+
+	if count, err := store.Count(context.Background(), bson.D{}); err != nil || count != 1 {
+		t.Errorf("Count() after DeleteAllByFileID() of an empty fileID = %d, %v, want 1, nil", count, err)
+	}
+}
+
+func TestDeleteFilePermissionsReturnsDeleted(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "user", pb.Role_READ)
+
+	svc := service.NewService(NewController(store), nil)
+	res, err := svc.DeleteFilePermissions(context.Background(), &pb.DeleteFilePermissionsRequest{FileID: "file"})
+	if err != nil {
+		t.Fatalf("DeleteFilePermissions() = %v, want nil", err)
+	}
+
+	if len(res.GetPermissions()) != 1 || res.GetPermissions()[0].GetUserID() != "user" {
+		t.Errorf("DeleteFilePermissions() = %v, want the permission of user", res.GetPermissions())
+	}
+
+	if exists, err := store.Exists(context.Background(), "file", "user"); err != nil || exists {
+		t.Errorf("Exists() after DeleteFilePermissions() = %v, %v, want false, nil", exists, err)
+	}
+}
+
+func TestCountByFilter(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	if count, err := store.Count(context.Background(), FilterByFile("file")); err != nil || count != 0 {
+		t.Errorf("Count() of an empty collection = %d, %v, want 0, nil", count, err)
+	}
+
+	createPermission(t, store, "file", "reader", pb.Role_READ)
+	createPermission(t, store, "file", "writer", pb.Role_WRITE)
+	createPermission(t, store, "other", "reader", pb.Role_WRITE)
+
+	tests := []struct {
+		name   string
+		filter interface{}
+		want   int64
+	}{
+		{"file", FilterByFile("file"), 2},
+		{"user", FilterByUser("reader"), 2},
+		{"role", service.ByRole(pb.Role_WRITE), 2},
+		{"file and role", FilterByFileAndRole("file", pb.Role_WRITE), 1},
+		{"no match", FilterByUserAndRole("writer", pb.Role_OWNER), 0},
+	}
+
+	for _, tt := range tests {
+		if count, err := store.Count(context.Background(), tt.filter); err != nil || count != tt.want {
+			t.Errorf("%s: Count() = %d, %v, want %d, nil", tt.name, count, err, tt.want)
+		}
+	}
+}
+
+func TestDeleteAllByUserIDRejectsEmptyUserID(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "user", pb.Role_READ)
+
+	deleted, err := store.DeleteAllByUserID(context.Background(), "")
+	if status.Code(err) != codes.InvalidArgument || deleted != 0 {
+		t.Errorf("DeleteAllByUserID() of an empty userID = %d, %v, want 0 and code %v",
+			deleted, err, codes.InvalidArgument)
+	}
+
+	if count, err := store.Count(context.Background(), bson.D{}); err != nil || count != 1 {
+		t.Errorf("Count() after DeleteAllByUserID() of an empty userID = %d, %v, want 1, nil", count, err)
+	}
+}
+
+func TestDeleteUserPermissionsReturnsAffectedFiles(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "first", "user", pb.Role_READ)
+	createPermission(t, store, "second", "user", pb.Role_WRITE)
+
+	svc := service.NewService(NewController(store), nil)
+	res, err := svc.DeleteUserPermissions(context.Background(), &pb.DeleteUserPermissionsRequest{UserID: "user"})
+	if err != nil {
+		t.Fatalf("DeleteUserPermissions() = %v, want nil", err)
+	}
+
+	fileIDs := make(map[string]bool, len(res.GetPermissions()))
+	for _, permission := range res.GetPermissions() {
+		fileIDs[permission.GetFileID()] = true
+	}
+
+	if len(fileIDs) != 2 || !fileIDs["first"] || !fileIDs["second"] {
+		t.Errorf("DeleteUserPermissions() returned the files %v, want first and second", fileIDs)
+	}
+}
+
+func TestDeleteAllByFileIDDeletesOnlyTargetFile(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "first", pb.Role_OWNER)
+	createPermission(t, store, "file", "second", pb.Role_READ)
+	createPermission(t, store, "other", "first", pb.Role_READ)
+
+	deleted, err := store.DeleteAllByFileID(context.Background(), "file")
+	if err != nil || deleted != 2 {
+		t.Fatalf("DeleteAllByFileID() = %d, %v, want 2, nil", deleted, err)
+	}
+
+	if count, err := store.Count(context.Background(), FilterByFile("file")); err != nil || count != 0 {
+		t.Errorf("Count() of the deleted file = %d, %v, want 0, nil", count, err)
+	}
+
+	if exists, err := store.Exists(context.Background(), "other", "first"); err != nil || !exists {
+		t.Errorf("Exists() of another file's permission = %v, %v, want true, nil", exists, err)
+	}
+}
+
+func TestDeleteAllByUserIDDeletesOnlyTargetUser(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "first", "leaving", pb.Role_READ)
+	createPermission(t, store, "second", "leaving", pb.Role_WRITE)
+	createPermission(t, store, "first", "staying", pb.Role_OWNER)
+
+	deleted, err := store.DeleteAllByUserID(context.Background(), "leaving")
+	if err != nil || deleted != 2 {
+		t.Fatalf("DeleteAllByUserID() = %d, %v, want 2, nil", deleted, err)
+	}
+
+	if count, err := store.Count(context.Background(), FilterByUser("leaving")); err != nil || count != 0 {
+		t.Errorf("Count() of the deleted user = %d, %v, want 0, nil", count, err)
+	}
+
+	if exists, err := store.Exists(context.Background(), "first", "staying"); err != nil || !exists {
+		t.Errorf("Exists() of another user's permission = %v, %v, want true, nil", exists, err)
+	}
+}
+
+func TestExpiresAtTTLIndex(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	ttlIndexModel := mongo.IndexModel{
+		Keys:    bson.D{bson.E{Key: PermissionBSONExpiresAtField, Value: 1}},
+		Options: options.Index().SetExpireAfterSeconds(0),
+	}
+
+	ok, err := hasIndex(context.Background(), store.collection().Indexes(), ttlIndexModel)
+	if err != nil || !ok {
+		t.Errorf("hasIndex() of the expiresAt TTL index = %v, %v, want true, nil", ok, err)
+	}
+}
+
+func TestCreatePermissionExpiredIsInvisible(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	svc := service.NewService(NewController(store), nil)
+	create := &pb.CreatePermissionRequest{
+		FileID:    "file",
+		UserID:    "user",
+		Role:      pb.Role_READ,
+		Creator:   "creator",
+		ExpiresAt: time.Now().Add(-time.Minute).Unix(),
+	}
+
+	if _, err := svc.CreatePermission(context.Background(), create); err != nil {
+		t.Fatalf("CreatePermission() = %v, want nil", err)
+	}
+
+	_, err := svc.GetPermission(context.Background(), &pb.GetPermissionRequest{FileID: "file", UserID: "user"})
+	if status.Code(err) != codes.NotFound {
+		t.Errorf("GetPermission() of an expired permission = %v, want code %v", err, codes.NotFound)
+	}
+}
+
+func TestGetAllReturnsFindError(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	// An unknown query operator fails Find on the server.
+	invalidFilter := bson.D{bson.E{Key: "$unknownOperator", Value: 1}}
+	if _, err := store.GetAll(context.Background(), invalidFilter); err == nil {
+		t.Errorf("GetAll() of an invalid filter = nil, want the error of Find")
+	}
+}
+
+func TestGetAllOfDisconnectedClientReturnsNilAndError(t *testing.T) {
+	// The client of tracedStore is never connected, so Find fails without a cursor.
+	store, _ := tracedStore(t)
+
+	found, err := store.GetAll(context.Background(), FilterByFile("file"))
+	if err == nil || found != nil {
+		t.Errorf("GetAll() of a disconnected client = %v, %v, want nil and an error", found, err)
+	}
+}
+
+func TestCreateReturnsNewPermission(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	permission := &BSON{FileID: "file", UserID: "user", Role: pb.Role_WRITE, Creator: "creator"}
+	created, err := store.Create(context.Background(), permission)
+	if err != nil {
+		t.Fatalf("Create() of a new permission = %v, want nil", err)
+	}
+
+	if created.GetRole() != pb.Role_WRITE || created.GetID() == "" {
+		t.Errorf("Create() of a new permission = role %v and ID %q, want WRITE and an ID",
+			created.GetRole(), created.GetID())
+	}
+}
+
+func TestCreateReturnsUpdatedPermission(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "user", pb.Role_READ)
+	existing, err := store.Get(context.Background(), FilterByFileAndUser("file", "user"))
+	if err != nil {
+		t.Fatalf("Get() = %v, want nil", err)
+	}
+
+	permission := &BSON{FileID: "file", UserID: "user", Role: pb.Role_OWNER, Creator: "creator"}
+	updated, err := store.Create(context.Background(), permission)
+	if err != nil {
+		t.Fatalf("Create() of an existing permission = %v, want nil", err)
+	}
+
+	if updated.GetRole() != pb.Role_OWNER || updated.GetID() != existing.GetID() {
+		t.Errorf("Create() of an existing permission = %v of permission %s, want OWNER of permission %s",
+			updated.GetRole(), updated.GetID(), existing.GetID())
+	}
+}
@@ -0,0 +1,61 @@
+package mongodb
+
+import (
+	"context"
+
+	"github.com/meateam/permission-service/service"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// FilterByFileAndSubjects returns a filter that matches the permissions of fileID given to any of
+// subjectIDs, which are the IDs of users or groups. If subjectIDs is empty it matches no permissions.
+func FilterByFileAndSubjects(fileID string, subjectIDs []string) bson.D {
+	values := make(bson.A, 0, len(subjectIDs))
+	for _, subjectID := range subjectIDs {
+		values = append(values, subjectID)
+	}
+
+	return append(FilterByFile(fileID), bson.E{
+		Key:   PermissionBSONUserIDField,
+		Value: bson.D{bson.E{Key: "$in", Value: values}},
+	})
+}
+
+// FilterBySubjectType returns a filter that matches the permissions that match filter and are given
+// to subjects of subjectType. A user's permission is stored without a subject type, so an empty
+// subjectType or service.SubjectTypeUser matches them.
+func FilterBySubjectType(filter bson.D, subjectType string) bson.D {
+	var value interface{} = subjectType
+	if subjectType == "" || subjectType == service.SubjectTypeUser {
+		value = nil
+	}
+
+	return append(filter, bson.E{
+		Key:   PermissionBSONSubjectTypeField,
+		Value: value,
+	})
+}
+
+// GetAllForSubjects finds the permissions of fileID given to any of subjectIDs in a single query,
+// such as a user's ID and the IDs of all the groups the user is a member of, which are supplied by
+// the caller since the store doesn't know the groups' members. Subject IDs are matched regardless of
+// their subject type, which is safe since a user and a group that share an ID can't both have
+// permissions to the same file, creating the second is rejected with an AlreadyExists error.
+// The permissions are filtered and sorted the same way GetAll does, the effective access of the user
+// is the highest of their roles, unless any of the permissions denies access, in which case the user has none.
+// If successful returns the permissions, and a nil error,
+// otherwise returns nil and non-nil error if any occurred.
+func (s MongoStore) GetAllForSubjects(
+	ctx context.Context,
+	fileID string,
+	subjectIDs []string,
+) (found []service.Permission, err error) {
+	ctx, span := s.startSpan(ctx, "GetAllForSubjects", idAttributes(fileID, "")...)
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	if len(subjectIDs) == 0 {
+		return []service.Permission{}, nil
+	}
+
+	return s.find(ctx, active(FilterByFileAndSubjects(fileID, subjectIDs)), SortByID)
+}
@@ -0,0 +1,137 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/memory"
+	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestSubjectTypeDefaultsToUser(t *testing.T) {
+	permission := &BSON{FileID: "file", UserID: "user", Creator: "creator"}
+	if subjectType := permission.GetSubjectType(); subjectType != service.SubjectTypeUser {
+		t.Errorf("GetSubjectType() = %q, want %q", subjectType, service.SubjectTypeUser)
+	}
+
+	if err := permission.SetSubjectType(service.SubjectTypeUser); err != nil {
+		t.Fatalf("SetSubjectType() = %v, want nil", err)
+	}
+
+	raw, err := bson.Marshal(permission)
+	if err != nil {
+		t.Fatalf("bson.Marshal() = %v, want nil", err)
+	}
+
+	if _, err := bson.Raw(raw).LookupErr(PermissionBSONSubjectTypeField); err == nil {
+		t.Errorf("bson.Marshal() of a user's permission has a %s field", PermissionBSONSubjectTypeField)
+	}
+
+	if err := permission.SetSubjectType("TEAM"); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("SetSubjectType(TEAM) = %v, want code %v", err, codes.InvalidArgument)
+	}
+}
+
+func TestCreatePermissionOfGroup(t *testing.T) {
+	controller := NewController(memory.NewMemoryStore())
+
+	_, err := controller.CreatePermission(
+		context.Background(), "file", "team", pb.Role_READ, "creator", time.Time{}, nil, "",
+		service.SubjectTypeGroup, false)
+	if err != nil {
+		t.Fatalf("CreatePermission() = %v, want nil", err)
+	}
+
+	permission, err := controller.GetByFileAndUser(context.Background(), "file", "team")
+	if err != nil {
+		t.Fatalf("GetByFileAndUser() = %v, want nil", err)
+	}
+
+	var marshaled pb.PermissionObject
+	if err := permission.MarshalProto(&marshaled); err != nil {
+		t.Fatalf("MarshalProto() = %v, want nil", err)
+	}
+
+	if marshaled.GetSubjectType() != service.SubjectTypeGroup {
+		t.Errorf("MarshalProto() subjectType = %q, want %q", marshaled.GetSubjectType(), service.SubjectTypeGroup)
+	}
+}
+
+func TestGetAllForSubjectsResolvesGroups(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	group := service.SubjectTypeGroup
+	for _, permission := range []*BSON{
+		{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"},
+		{FileID: "file", UserID: "team", Role: pb.Role_WRITE, Creator: "creator", SubjectType: group},
+		{FileID: "file", UserID: "other", Role: pb.Role_WRITE, Creator: "creator", SubjectType: group},
+		{FileID: "another", UserID: "team", Role: pb.Role_READ, Creator: "creator", SubjectType: group},
+	} {
+		if _, err := store.Create(context.Background(), permission); err != nil {
+			t.Fatalf("Create() = %v, want nil", err)
+		}
+	}
+
+	// The user is a member of team and of unshared, which has no permission to the file.
+	permissions, err := store.GetAllForSubjects(context.Background(), "file", []string{"user", "team", "unshared"})
+	if err != nil {
+		t.Fatalf("GetAllForSubjects() = %v, want nil", err)
+	}
+
+	subjectTypes := make(map[string]string, len(permissions))
+	for _, permission := range permissions {
+		subjectTypes[permission.GetUserID()] = permission.GetSubjectType()
+	}
+
+	want := map[string]string{"user": service.SubjectTypeUser, "team": service.SubjectTypeGroup}
+	if len(subjectTypes) != len(want) || len(permissions) != len(want) {
+		t.Fatalf("GetAllForSubjects() = permissions of %v, want of %v", subjectTypes, want)
+	}
+
+	for subjectID, subjectType := range want {
+		if subjectTypes[subjectID] != subjectType {
+			t.Errorf("GetAllForSubjects() subject %s type = %q, want %q",
+				subjectID, subjectTypes[subjectID], subjectType)
+		}
+	}
+}
+
+func TestGetAllForNoSubjects(t *testing.T) {
+	store, _ := tracedStore(t)
+
+	permissions, err := store.GetAllForSubjects(context.Background(), "file", nil)
+	if err != nil || len(permissions) != 0 {
+		t.Errorf("GetAllForSubjects() of no subjects = %v, %v, want none and nil", permissions, err)
+	}
+}
+
+func TestCreatePermissionOfGroupWithUsersID(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "shared-id", pb.Role_WRITE)
+
+	group := &BSON{FileID: "file", UserID: "shared-id", Role: pb.Role_READ, Creator: "creator"}
+	if err := group.SetSubjectType(service.SubjectTypeGroup); err != nil {
+		t.Fatalf("SetSubjectType() = %v, want nil", err)
+	}
+
+	if _, err := store.Create(context.Background(), group); status.Code(err) != codes.AlreadyExists {
+		t.Errorf("Create() of a group with a user's ID = %v, want code %v", err, codes.AlreadyExists)
+	}
+
+	permission, err := store.Get(context.Background(), FilterByFileAndUser("file", "shared-id"))
+	if err != nil {
+		t.Fatalf("Get() = %v, want nil", err)
+	}
+
+	if permission.GetSubjectType() != service.SubjectTypeUser || permission.GetRole() != pb.Role_WRITE {
+		t.Errorf("Get() = %v, want the user's permission unchanged", permission)
+	}
+}
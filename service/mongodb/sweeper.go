@@ -0,0 +1,89 @@
+package mongodb
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultSweepInterval is the default interval between the passes of a Sweeper.
+const DefaultSweepInterval = 10 * time.Second
+
+// ExpiredDeleter deletes the permissions that expired before a time, such as MongoStore.
+type ExpiredDeleter interface {
+	DeleteExpired(ctx context.Context, expiredBefore time.Time) (int64, error)
+}
+
+// Sweeper periodically deletes expired permissions. The TTL index removes them only once a minute or so,
+// and expired permissions are already filtered from reads, so the Sweeper makes their removal timely
+// for the queries and reports that read the collection directly.
+type Sweeper struct {
+	// Store is the store whose expired permissions are deleted.
+	Store ExpiredDeleter
+
+	// Interval is the time between the start of a pass and the next, if it's not positive
+	// then DefaultSweepInterval is used.
+	Interval time.Duration
+
+	// Logger logs the number of permissions deleted by each pass and the passes that failed.
+	// If it's nil then a new logrus.Logger is used.
+	Logger *logrus.Logger
+
+	// running is 1 while a pass is running, so passes never overlap.
+	running int32
+}
+
+// NewSweeper returns a new Sweeper of store's expired permissions that sweeps every interval.
+func NewSweeper(store ExpiredDeleter, interval time.Duration, logger *logrus.Logger) *Sweeper {
+	return &Sweeper{Store: store, Interval: interval, Logger: logger}
+}
+
+// Run sweeps every s.Interval until ctx is done, the first pass is after the first interval.
+// A pass that takes longer than the interval delays the next one instead of overlapping it.
+// Returns once ctx is done and the pass that is running, if any, returned.
+func (s *Sweeper) Run(ctx context.Context) {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = DefaultSweepInterval
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s.Sweep(ctx)
+		}
+	}
+}
+
+// Sweep deletes the permissions that have expired by now, and logs how many were deleted or the error that
+// occurred. If another pass of s is running then it's skipped, and 0 and a nil error are returned.
+// If successful returns the number of deleted permissions and a nil error,
+// otherwise returns 0 and non-nil error if any occurred.
+func (s *Sweeper) Sweep(ctx context.Context) (int64, error) {
+	logger := s.Logger
+	if logger == nil {
+		logger = logrus.New()
+	}
+
+	if !atomic.CompareAndSwapInt32(&s.running, 0, 1) {
+		logger.Debugf("skipped sweeping expired permissions, the previous pass is still running")
+		return 0, nil
+	}
+	defer atomic.StoreInt32(&s.running, 0)
+
+	deleted, err := s.Store.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		logger.Errorf("failed sweeping expired permissions: %v", err)
+		return 0, err
+	}
+
+	logger.Infof("swept %d expired permissions", deleted)
+	return deleted, nil
+}
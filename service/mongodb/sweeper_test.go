@@ -0,0 +1,141 @@
+package mongodb
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/sirupsen/logrus/hooks/test"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// blockingDeleter is an ExpiredDeleter whose deletes block until release is closed,
+// recording the most deletes that ran at once.
+type blockingDeleter struct {
+	release chan struct{}
+	running int32
+	maxSeen int32
+	calls   int32
+}
+
+func (d *blockingDeleter) DeleteExpired(ctx context.Context, expiredBefore time.Time) (int64, error) {
+	atomic.AddInt32(&d.calls, 1)
+	running := atomic.AddInt32(&d.running, 1)
+	defer atomic.AddInt32(&d.running, -1)
+
+	for {
+		maxSeen := atomic.LoadInt32(&d.maxSeen)
+		if running <= maxSeen || atomic.CompareAndSwapInt32(&d.maxSeen, maxSeen, running) {
+			break
+		}
+	}
+
+	<-d.release
+	return 1, nil
+}
+
+func TestSweeperSkipsOverlappingPasses(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+	deleter := &blockingDeleter{release: make(chan struct{})}
+	sweeper := NewSweeper(deleter, time.Millisecond, logger)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			sweeper.Sweep(context.Background())
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(deleter.release)
+	wg.Wait()
+
+	if maxSeen := atomic.LoadInt32(&deleter.maxSeen); maxSeen != 1 {
+		t.Errorf("Sweep() ran %d passes at once, want 1", maxSeen)
+	}
+}
+
+func TestSweeperRunStopsOnCancel(t *testing.T) {
+	logger, _ := test.NewNullLogger()
+	deleter := &blockingDeleter{release: make(chan struct{})}
+	close(deleter.release)
+	sweeper := NewSweeper(deleter, time.Millisecond, logger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() {
+		sweeper.Run(ctx)
+		close(stopped)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	cancel()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Second):
+		t.Fatalf("Run() didn't return after its context was canceled")
+	}
+
+	if atomic.LoadInt32(&deleter.calls) == 0 {
+		t.Errorf("Run() never swept")
+	}
+}
+
+func TestSweeperDeletesOnlyExpired(t *testing.T) {
+	store, cleanup := integrationStore(t)
+	defer cleanup()
+
+	expired := time.Now().Add(-time.Hour)
+	live := time.Now().Add(time.Hour)
+	expiries := map[string]time.Time{"expired": expired, "live": live, "never": {}}
+	for userID, expiresAt := range expiries {
+		permission := &BSON{FileID: "file", UserID: userID, Role: pb.Role_READ, Creator: "creator"}
+		if err := permission.SetExpiresAt(expiresAt); err != nil {
+			t.Fatalf("SetExpiresAt() = %v, want nil", err)
+		}
+
+		if _, err := store.Create(context.Background(), permission); err != nil {
+			t.Fatalf("Create() = %v, want nil", err)
+		}
+	}
+
+	logger, _ := test.NewNullLogger()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go NewSweeper(store, 10*time.Millisecond, logger).Run(ctx)
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		count, err := store.collection().CountDocuments(context.Background(), bson.D{})
+		if err != nil {
+			t.Fatalf("CountDocuments() = %v, want nil", err)
+		}
+
+		if count == 2 {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("%d permissions are stored after sweeping, want 2", count)
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	for _, userID := range []string{"live", "never"} {
+		exists, err := store.Exists(context.Background(), "file", userID)
+		if err != nil {
+			t.Fatalf("Exists() = %v, want nil", err)
+		}
+
+		if !exists {
+			t.Errorf("the %s permission was swept", userID)
+		}
+	}
+}
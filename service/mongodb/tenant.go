@@ -0,0 +1,79 @@
+package mongodb
+
+import (
+	"context"
+
+	"github.com/meateam/permission-service/service"
+	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// tenantID returns the ID of the tenant of ctx if s is multi-tenant, otherwise returns an empty string.
+// Returns an InvalidArgument error if s is multi-tenant and ctx carries no tenant.
+func (s MongoStore) tenantID(ctx context.Context) (string, error) {
+	if !s.MultiTenant {
+		return "", nil
+	}
+
+	tenantID := service.TenantIDFromContext(ctx)
+	if tenantID == "" {
+		return "", status.Error(codes.InvalidArgument, "tenantID is required")
+	}
+
+	return tenantID, nil
+}
+
+// scope returns a filter that matches the permissions that match filter, with its IDs normalized by
+// s.Normalizer, and belong to the tenant of ctx if s is multi-tenant.
+// Returns an InvalidArgument error if s is multi-tenant and ctx carries no tenant.
+func (s MongoStore) scope(ctx context.Context, filter interface{}) (interface{}, error) {
+	tenantID, err := s.tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	return scopeToTenant(s.normalizeFilter(filter), tenantID), nil
+}
+
+// scopeToTenant returns a filter that matches the permissions that match filter and belong to tenantID,
+// if tenantID is empty then filter is returned as is.
+func scopeToTenant(filter interface{}, tenantID string) interface{} {
+	if tenantID == "" {
+		return filter
+	}
+
+	return bson.D{
+		bson.E{
+			Key: "$and",
+			Value: bson.A{
+				filter,
+				bson.D{
+					bson.E{
+						Key:   PermissionBSONTenantIDField,
+						Value: tenantID,
+					},
+				},
+			},
+		},
+	}
+}
+
+// withTenant returns a copy of update that also sets the tenantID field to tenantID, so an upserted
+// permission belongs to the tenant. Returns update as is if tenantID is empty.
+func withTenant(update bson.D, tenantID string) bson.D {
+	if tenantID == "" {
+		return update
+	}
+
+	tenantUpdate := bson.E{Key: PermissionBSONTenantIDField, Value: tenantID}
+	scoped := append(bson.D{}, update...)
+	for i, element := range scoped {
+		if fields, ok := element.Value.(bson.D); ok && element.Key == "$set" {
+			scoped[i].Value = append(append(bson.D{}, fields...), tenantUpdate)
+			return scoped
+		}
+	}
+
+	return append(scoped, bson.E{Key: "$set", Value: bson.D{tenantUpdate}})
+}
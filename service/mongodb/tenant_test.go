@@ -0,0 +1,116 @@
+package mongodb
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestWithTenantSetsTenantID(t *testing.T) {
+	permission := &BSON{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"}
+	update := permissionUpsert(permission, time.Now())
+	original := append(bson.D{}, update...)
+
+	scoped := withTenant(update, "tenant")
+	if !reflect.DeepEqual(update, original) {
+		t.Errorf("withTenant() modified update to %v, want it unchanged", update)
+	}
+
+	for _, element := range scoped {
+		if element.Key != "$set" {
+			continue
+		}
+
+		for _, field := range element.Value.(bson.D) {
+			if field.Key == PermissionBSONTenantIDField && field.Value == "tenant" {
+				return
+			}
+		}
+	}
+
+	t.Errorf("withTenant() = %v, want it to set %s to tenant", scoped, PermissionBSONTenantIDField)
+}
+
+func TestScopeWithoutTenancyKeepsFilter(t *testing.T) {
+	filter := FilterByFileAndUser("file", "user")
+
+	scoped, err := MongoStore{}.scope(service.WithTenantID(context.Background(), "tenant"), filter)
+	if err != nil {
+		t.Fatalf("scope() = %v, want nil", err)
+	}
+
+	if !reflect.DeepEqual(scoped, filter) {
+		t.Errorf("scope() = %v, want %v", scoped, filter)
+	}
+}
+
+func TestMultiTenantRejectsMissingTenant(t *testing.T) {
+	store, _ := tracedStore(t)
+	store.MultiTenant = true
+	ctx := context.Background()
+	filter := FilterByFileAndUser("file", "user")
+
+	_, createErr := store.Create(ctx, &BSON{FileID: "file", UserID: "user", Role: pb.Role_READ})
+	_, getErr := store.Get(ctx, filter)
+	_, getAllErr := store.GetAll(ctx, FilterByFile("file"))
+	_, deleteErr := store.Delete(ctx, filter)
+
+	errs := map[string]error{"Create": createErr, "Get": getErr, "GetAll": getAllErr, "Delete": deleteErr}
+	for operation, err := range errs {
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("%s() without a tenant = %v, want code %v", operation, err, codes.InvalidArgument)
+		}
+	}
+}
+
+func TestCrossTenantReadsReturnNothing(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	store.MultiTenant = true
+	tenantCtx := service.WithTenantID(context.Background(), "tenant")
+	otherCtx := service.WithTenantID(context.Background(), "other-tenant")
+
+	created, err := store.Create(tenantCtx, &BSON{FileID: "file", UserID: "user", Role: pb.Role_READ})
+	if err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	if tenantID := created.(*BSON).GetTenantID(); tenantID != "tenant" {
+		t.Errorf("Create() tenantID = %q, want %q", tenantID, "tenant")
+	}
+
+	filter := FilterByFileAndUser("file", "user")
+	if _, err := store.Get(otherCtx, filter); err != service.ErrPermissionNotFound {
+		t.Errorf("Get() of another tenant = %v, want %v", err, service.ErrPermissionNotFound)
+	}
+
+	if permissions, err := store.GetAll(otherCtx, FilterByFile("file")); err != nil || len(permissions) != 0 {
+		t.Errorf("GetAll() of another tenant = %v, %v, want no permissions", permissions, err)
+	}
+
+	if _, err := store.Delete(otherCtx, filter); err != service.ErrPermissionNotFound {
+		t.Errorf("Delete() of another tenant = %v, want %v", err, service.ErrPermissionNotFound)
+	}
+
+	// The same user may have a permission to a file of the same ID in another tenant.
+	if _, err := store.Create(otherCtx, &BSON{FileID: "file", UserID: "user", Role: pb.Role_WRITE}); err != nil {
+		t.Fatalf("Create() of another tenant = %v, want nil", err)
+	}
+
+	permission, err := store.Get(tenantCtx, filter)
+	if err != nil {
+		t.Fatalf("Get() = %v, want nil", err)
+	}
+
+	if permission.GetRole() != pb.Role_READ {
+		t.Errorf("Get() role = %v, want the tenant's %v", permission.GetRole(), pb.Role_READ)
+	}
+}
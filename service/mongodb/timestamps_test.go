@@ -0,0 +1,89 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestPermissionUpsertSetsCreatedAtOnInsert(t *testing.T) {
+	permission := &BSON{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"}
+	update := permissionUpsert(permission, time.Now())
+
+	var setsCreatedAt, touchesUpdatedAt bool
+	for _, element := range update {
+		for _, field := range element.Value.(bson.D) {
+			switch {
+			case element.Key == "$setOnInsert" && field.Key == PermissionBSONCreatedAtField:
+				setsCreatedAt = true
+			case element.Key == "$currentDate" && field.Key == PermissionBSONUpdatedAtField:
+				touchesUpdatedAt = true
+			case field.Key == PermissionBSONCreatedAtField:
+				t.Errorf("permissionUpsert() sets %s with %s, want only $setOnInsert", field.Key, element.Key)
+			}
+		}
+	}
+
+	if !setsCreatedAt {
+		t.Errorf("permissionUpsert() = %v, want it to set %s on insert", update, PermissionBSONCreatedAtField)
+	}
+
+	if !touchesUpdatedAt {
+		t.Errorf("permissionUpsert() = %v, want it to set %s to the current date",
+			update, PermissionBSONUpdatedAtField)
+	}
+}
+
+func TestMarshalProtoTimestamps(t *testing.T) {
+	createdAt := time.Unix(1000, 0)
+	updatedAt := time.Unix(2000, 0)
+	permission := BSON{CreatedAt: &createdAt, UpdatedAt: &updatedAt}
+
+	var marshaled pb.PermissionObject
+	if err := permission.MarshalProto(&marshaled); err != nil {
+		t.Fatalf("MarshalProto() = %v, want nil", err)
+	}
+
+	if marshaled.GetCreatedAt() != 1000 || marshaled.GetUpdatedAt() != 2000 {
+		t.Errorf("MarshalProto() createdAt = %d and updatedAt = %d, want 1000 and 2000",
+			marshaled.GetCreatedAt(), marshaled.GetUpdatedAt())
+	}
+}
+
+func TestUpdateKeepsCreatedAtAndAdvancesUpdatedAt(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	created, err := store.Create(
+		context.Background(),
+		&BSON{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"},
+	)
+	if err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	createdAt := created.(*BSON).GetCreatedAt()
+	firstUpdatedAt := created.(*BSON).GetUpdatedAt()
+	if createdAt.IsZero() || firstUpdatedAt.IsZero() {
+		t.Fatalf("Create() returned createdAt %v and updatedAt %v, want both set", createdAt, firstUpdatedAt)
+	}
+
+	// The stored times have millisecond precision.
+	time.Sleep(10 * time.Millisecond)
+
+	updated, err := store.UpdateRole(context.Background(), "file", "user", pb.Role_WRITE)
+	if err != nil {
+		t.Fatalf("UpdateRole() = %v, want nil", err)
+	}
+
+	if got := updated.(*BSON).GetCreatedAt(); !got.Equal(createdAt) {
+		t.Errorf("UpdateRole() changed createdAt from %v to %v", createdAt, got)
+	}
+
+	if got := updated.(*BSON).GetUpdatedAt(); !got.After(firstUpdatedAt) {
+		t.Errorf("UpdateRole() updatedAt = %v, want it after %v", got, firstUpdatedAt)
+	}
+}
@@ -0,0 +1,104 @@
+package mongodb
+
+import (
+	"context"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	// tracerName is the name of the tracer that starts the store's spans.
+	tracerName = "github.com/meateam/permission-service/service/mongodb"
+
+	// spanPrefix is the prefix of the names of the store's spans.
+	spanPrefix = "mongo."
+)
+
+const (
+	// CollectionAttributeKey is the span attribute of the collection an operation ran on.
+	CollectionAttributeKey = attribute.Key("db.mongodb.collection")
+
+	// FileIDAttributeKey is the span attribute of the fileID an operation ran on.
+	FileIDAttributeKey = attribute.Key("permission.fileID")
+
+	// UserIDAttributeKey is the span attribute of the userID an operation ran on.
+	UserIDAttributeKey = attribute.Key("permission.userID")
+
+	// ResultCodeAttributeKey is the span attribute of the gRPC code of an operation's result.
+	ResultCodeAttributeKey = attribute.Key("permission.result_code")
+)
+
+// startSpan starts the span of operation, which is named by the operation and has the collection
+// name and attrs as its attributes. The span is started by s.TracerProvider, or by the global
+// tracer provider if it's nil.
+func (s MongoStore) startSpan(
+	ctx context.Context,
+	operation string,
+	attrs ...attribute.KeyValue,
+) (context.Context, trace.Span) {
+	tracerProvider := s.TracerProvider
+	if tracerProvider == nil {
+		tracerProvider = otel.GetTracerProvider()
+	}
+
+	attrs = append([]attribute.KeyValue{CollectionAttributeKey.String(s.CollectionName())}, attrs...)
+
+	return tracerProvider.Tracer(tracerName).Start(ctx, spanPrefix+operation, trace.WithAttributes(attrs...))
+}
+
+// endSpan tags span with the gRPC code of err, records err on span and sets its status to error
+// if err is non-nil, and ends span.
+func endSpan(span trace.Span, err error) {
+	span.SetAttributes(ResultCodeAttributeKey.String(status.Code(err).String()))
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(otelcodes.Error, err.Error())
+	}
+
+	span.End()
+}
+
+// idAttributes returns the span attributes of fileID and userID, empty IDs are left out.
+func idAttributes(fileID string, userID string) []attribute.KeyValue {
+	attrs := []attribute.KeyValue{}
+	if fileID != "" {
+		attrs = append(attrs, FileIDAttributeKey.String(fileID))
+	}
+
+	if userID != "" {
+		attrs = append(attrs, UserIDAttributeKey.String(userID))
+	}
+
+	return attrs
+}
+
+// filterAttributes returns the span attributes of the fileID and userID that filter matches exactly,
+// such as the filters built by FilterByFile, FilterByUser and FilterByFileAndUser.
+func filterAttributes(filter interface{}) []attribute.KeyValue {
+	elements, ok := bsonFilter(filter).(bson.D)
+	if !ok {
+		return nil
+	}
+
+	fileID, userID := "", ""
+	for _, element := range elements {
+		value, ok := element.Value.(string)
+		if !ok {
+			continue
+		}
+
+		switch element.Key {
+		case PermissionBSONFileIDField:
+			fileID = value
+		case PermissionBSONUserIDField:
+			userID = value
+		}
+	}
+
+	return idAttributes(fileID, userID)
+}
@@ -0,0 +1,144 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.opentelemetry.io/otel/attribute"
+	otelcodes "go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+// tracedStore returns a MongoStore of a client that was never connected, so all of its
+// operations fail, and the recorder of the store's spans.
+func tracedStore(t *testing.T) (MongoStore, *tracetest.SpanRecorder) {
+	t.Helper()
+
+	client, err := mongo.NewClient(options.Client().ApplyURI("mongodb://localhost:27017"))
+	if err != nil {
+		t.Fatalf("mongo.NewClient() = %v, want nil", err)
+	}
+
+	recorder := tracetest.NewSpanRecorder()
+	store := MongoStore{
+		DB:             client.Database("permission"),
+		TracerProvider: sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)),
+	}
+
+	return store, recorder
+}
+
+// spanAttributes returns the attributes of span by their keys.
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]string {
+	attrs := make(map[attribute.Key]string)
+	for _, attr := range span.Attributes() {
+		attrs[attr.Key] = attr.Value.Emit()
+	}
+
+	return attrs
+}
+
+func TestGetSpanRecordsIDsAndError(t *testing.T) {
+	store, recorder := tracedStore(t)
+
+	if _, err := store.Get(context.Background(), FilterByFileAndUser("file", "user")); err == nil {
+		t.Fatalf("Get() of a disconnected store returned a nil error")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+
+	if name := spans[0].Name(); name != "mongo.Get" {
+		t.Errorf("span name = %s, want mongo.Get", name)
+	}
+
+	attrs := spanAttributes(spans[0])
+	want := map[attribute.Key]string{
+		CollectionAttributeKey: PermissionCollectionName,
+		FileIDAttributeKey:     "file",
+		UserIDAttributeKey:     "user",
+		ResultCodeAttributeKey: "Unknown",
+	}
+
+	for key, value := range want {
+		if attrs[key] != value {
+			t.Errorf("span attribute %s = %q, want %q", key, attrs[key], value)
+		}
+	}
+
+	if code := spans[0].Status().Code; code != otelcodes.Error {
+		t.Errorf("span status = %v, want %v", code, otelcodes.Error)
+	}
+}
+
+func TestIsPermittedSpanParentsExistsMatchingSpan(t *testing.T) {
+	store, recorder := tracedStore(t)
+
+	if _, err := store.IsPermitted(context.Background(), "file", "user", pb.Role_READ); err == nil {
+		t.Fatalf("IsPermitted() of a disconnected store returned a nil error")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("recorded %d spans, want 2", len(spans))
+	}
+
+	exists, isPermitted := spans[0], spans[1]
+	if exists.Name() != "mongo.ExistsMatching" || isPermitted.Name() != "mongo.IsPermitted" {
+		t.Fatalf("span names = %s, %s, want mongo.ExistsMatching, mongo.IsPermitted",
+			exists.Name(), isPermitted.Name())
+	}
+
+	if exists.Parent().SpanID() != isPermitted.SpanContext().SpanID() {
+		t.Errorf("mongo.ExistsMatching span is not a child of the mongo.IsPermitted span")
+	}
+}
+
+func TestDeleteAllByUserIDSpanOmitsFileID(t *testing.T) {
+	store, recorder := tracedStore(t)
+
+	if _, err := store.DeleteAllByUserID(context.Background(), ""); err == nil {
+		t.Fatalf("DeleteAllByUserID() of an empty userID returned a nil error")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+
+	attrs := spanAttributes(spans[0])
+	if _, ok := attrs[FileIDAttributeKey]; ok {
+		t.Errorf("span has a %s attribute, want none", FileIDAttributeKey)
+	}
+
+	if _, ok := attrs[UserIDAttributeKey]; ok {
+		t.Errorf("span has a %s attribute for an empty userID, want none", UserIDAttributeKey)
+	}
+
+	if code := spans[0].Status().Code; code != otelcodes.Error {
+		t.Errorf("span status = %v, want %v", code, otelcodes.Error)
+	}
+}
+
+func TestSpanRecordsResultCode(t *testing.T) {
+	store, recorder := tracedStore(t)
+
+	if _, err := store.Restore(context.Background(), "", "user"); err == nil {
+		t.Fatalf("Restore() without a fileID returned a nil error")
+	}
+
+	spans := recorder.Ended()
+	if len(spans) != 1 {
+		t.Fatalf("recorded %d spans, want 1", len(spans))
+	}
+
+	if code := spanAttributes(spans[0])[ResultCodeAttributeKey]; code != "InvalidArgument" {
+		t.Errorf("span attribute %s = %q, want %q", ResultCodeAttributeKey, code, "InvalidArgument")
+	}
+}
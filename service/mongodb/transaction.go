@@ -0,0 +1,84 @@
+package mongodb
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+const (
+	// transientTransactionErrorLabel is the error label of a transaction that failed
+	// on a transient error and can be retried as a whole.
+	transientTransactionErrorLabel = "TransientTransactionError"
+
+	// unknownCommitResultLabel is the error label of a commit whose result is unknown,
+	// which can be retried by committing again.
+	unknownCommitResultLabel = "UnknownTransactionCommitResult"
+
+	// transactionRetryTimeout is the time after which a failed transaction or commit is no longer retried,
+	// it's the same as the driver's.
+	transactionRetryTimeout = 120 * time.Second
+)
+
+// WithTransaction runs fn inside a transaction of a new session and commits it if fn succeeds,
+// the operations of fn must use sessCtx for them to be part of the transaction. If fn fails the
+// transaction is aborted, so none of its writes persist, and fn's error is returned.
+// The whole transaction, fn included, is retried while it fails with a TransientTransactionError,
+// and the commit is retried while its result is unknown, until ctx is done or
+// transactionRetryTimeout passes, so fn may run more than once.
+func (s MongoStore) WithTransaction(ctx context.Context, fn func(sessCtx mongo.SessionContext) error) error {
+	session, err := s.DB.Client().StartSession(s.sessionOptions())
+	if err != nil {
+		return err
+	}
+	defer session.EndSession(ctx)
+
+	deadline := time.Now().Add(transactionRetryTimeout)
+	return mongo.WithSession(ctx, session, func(sessCtx mongo.SessionContext) error {
+		for {
+			err := runTransaction(sessCtx, fn)
+			if !hasErrorLabel(err, transientTransactionErrorLabel) || !canRetryTransaction(ctx, deadline) {
+				return err
+			}
+		}
+	})
+}
+
+// runTransaction runs fn inside a single transaction of sessCtx's session, and commits it if fn succeeds,
+// retrying the commit while its result is unknown. Returns the error of fn or of the commit.
+func runTransaction(sessCtx mongo.SessionContext, fn func(sessCtx mongo.SessionContext) error) error {
+	if err := sessCtx.StartTransaction(); err != nil {
+		return err
+	}
+
+	if err := fn(sessCtx); err != nil {
+		// The abort error is ignored since the server aborts the transaction by itself
+		// if it's never committed.
+		_ = sessCtx.AbortTransaction(context.Background())
+		return err
+	}
+
+	deadline := time.Now().Add(transactionRetryTimeout)
+	for {
+		err := sessCtx.CommitTransaction(sessCtx)
+
+		// A commit that exceeded its maxTimeMS would exceed it again.
+		commandErr, _ := err.(mongo.CommandError)
+		if !hasErrorLabel(err, unknownCommitResultLabel) || commandErr.IsMaxTimeMSExpiredError() ||
+			!canRetryTransaction(sessCtx, deadline) {
+			return err
+		}
+	}
+}
+
+// canRetryTransaction returns true if ctx isn't done and deadline didn't pass.
+func canRetryTransaction(ctx context.Context, deadline time.Time) bool {
+	return ctx.Err() == nil && time.Now().Before(deadline)
+}
+
+// hasErrorLabel returns true if err is a command error labeled with label, otherwise returns false.
+func hasErrorLabel(err error, label string) bool {
+	commandErr, ok := err.(mongo.CommandError)
+	return ok && commandErr.HasErrorLabel(label)
+}
@@ -0,0 +1,97 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestWithTransactionAbortDiscardsWrites(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "owner", pb.Role_OWNER)
+	collection := store.DB.Collection(PermissionCollectionName)
+	errAbort := errors.New("abort")
+
+	err := store.WithTransaction(context.Background(), func(sessCtx mongo.SessionContext) error {
+		permission := &BSON{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "owner"}
+		if _, err := collection.InsertOne(sessCtx, permission); err != nil {
+			return err
+		}
+
+		if _, err := collection.DeleteOne(sessCtx, FilterByFileAndUser("file", "owner")); err != nil {
+			return err
+		}
+
+		return errAbort
+	})
+
+	if err != errAbort {
+		t.Fatalf("WithTransaction() = %v, want %v", err, errAbort)
+	}
+
+	_, err = store.Get(context.Background(), FilterByFileAndUser("file", "user"))
+	if err != service.ErrPermissionNotFound {
+		t.Errorf("Get() of the permission inserted in the aborted transaction = %v, want %v",
+			err, service.ErrPermissionNotFound)
+	}
+
+	if role := roleOf(t, store, "file", "owner"); role != pb.Role_OWNER {
+		t.Errorf("role of the permission deleted in the aborted transaction = %v, want %v", role, pb.Role_OWNER)
+	}
+}
+
+func TestWithTransactionRetriesTransientErrors(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	collection := store.DB.Collection(PermissionCollectionName)
+	attempts := 0
+
+	err := store.WithTransaction(context.Background(), func(sessCtx mongo.SessionContext) error {
+		attempts++
+		permission := &BSON{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"}
+		if _, err := collection.InsertOne(sessCtx, permission); err != nil {
+			return err
+		}
+
+		if attempts == 1 {
+			return mongo.CommandError{Message: "transient", Labels: []string{transientTransactionErrorLabel}}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		t.Fatalf("WithTransaction() = %v, want nil", err)
+	}
+
+	if attempts != 2 {
+		t.Errorf("WithTransaction() ran the callback %d times, want 2", attempts)
+	}
+
+	if count, err := store.Count(context.Background(), FilterByFile("file")); err != nil || count != 1 {
+		t.Errorf("Count() = %d, %v, want 1 permission written by the retried transaction", count, err)
+	}
+}
+
+func TestHasErrorLabel(t *testing.T) {
+	labeled := mongo.CommandError{Labels: []string{transientTransactionErrorLabel}}
+
+	if !hasErrorLabel(labeled, transientTransactionErrorLabel) {
+		t.Errorf("hasErrorLabel() of a labeled error = false, want true")
+	}
+
+	if hasErrorLabel(labeled, unknownCommitResultLabel) {
+		t.Errorf("hasErrorLabel() of another label = true, want false")
+	}
+
+	if hasErrorLabel(errors.New(transientTransactionErrorLabel), transientTransactionErrorLabel) {
+		t.Errorf("hasErrorLabel() of a non command error = true, want false")
+	}
+}
@@ -0,0 +1,75 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+)
+
+func TestUpsertInsertsPermission(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	permission := &BSON{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"}
+	upserted, inserted, previousRole, err := store.Upsert(context.Background(), permission)
+	if err != nil {
+		t.Fatalf("Upsert() = %v, want nil", err)
+	}
+
+	if !inserted || previousRole != pb.Role_NONE {
+		t.Errorf("Upsert() inserted = %t with previous role %v, want true with %v",
+			inserted, previousRole, pb.Role_NONE)
+	}
+
+	if upserted.GetRole() != pb.Role_READ {
+		t.Errorf("Upsert() role = %v, want %v", upserted.GetRole(), pb.Role_READ)
+	}
+}
+
+func TestUpsertReturnsPreviousRole(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "user", pb.Role_READ)
+
+	permission := &BSON{FileID: "file", UserID: "user", Role: pb.Role_WRITE, Creator: "creator"}
+	upserted, inserted, previousRole, err := store.Upsert(context.Background(), permission)
+	if err != nil {
+		t.Fatalf("Upsert() = %v, want nil", err)
+	}
+
+	if inserted || previousRole != pb.Role_READ {
+		t.Errorf("Upsert() inserted = %t with previous role %v, want false with %v",
+			inserted, previousRole, pb.Role_READ)
+	}
+
+	if upserted.GetRole() != pb.Role_WRITE {
+		t.Errorf("Upsert() role = %v, want %v", upserted.GetRole(), pb.Role_WRITE)
+	}
+}
+
+func TestUpsertReturnsStoredPermission(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	for _, role := range []pb.Role{pb.Role_READ, pb.Role_WRITE} {
+		permission := &BSON{FileID: "file", UserID: "user", Role: role, Creator: "creator", Reason: "shared"}
+		upserted, _, _, err := store.Upsert(context.Background(), permission)
+		if err != nil {
+			t.Fatalf("Upsert() = %v, want nil", err)
+		}
+
+		stored, err := store.Get(context.Background(), FilterByFileAndUser("file", "user"))
+		if err != nil {
+			t.Fatalf("Get() = %v, want nil", err)
+		}
+
+		if upserted.GetID() != stored.GetID() || upserted.GetRole() != stored.GetRole() ||
+			upserted.GetReason() != stored.GetReason() ||
+			upserted.(*BSON).GetVersion() != stored.(*BSON).GetVersion() ||
+			!upserted.(*BSON).GetCreatedAt().Equal(stored.(*BSON).GetCreatedAt()) {
+			t.Errorf("Upsert() = %+v, want the stored permission %+v", upserted, stored)
+		}
+	}
+}
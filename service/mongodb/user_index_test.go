@@ -0,0 +1,84 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// indexScans returns the key patterns of the index scan stages of the query plan stage and its input stages.
+func indexScans(stage bson.M) []bson.M {
+	var scans []bson.M
+	if stage["stage"] == "IXSCAN" {
+		if keyPattern, ok := stage["keyPattern"].(bson.M); ok {
+			scans = append(scans, keyPattern)
+		}
+	}
+
+	if input, ok := stage["inputStage"].(bson.M); ok {
+		scans = append(scans, indexScans(input)...)
+	}
+
+	if inputs, ok := stage["inputStages"].(bson.A); ok {
+		for _, input := range inputs {
+			if input, ok := input.(bson.M); ok {
+				scans = append(scans, indexScans(input)...)
+			}
+		}
+	}
+
+	return scans
+}
+
+func TestQueryByUserIDUsesUserIDIndex(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "user", pb.Role_READ)
+	createPermission(t, store, "file", "other", pb.Role_READ)
+
+	explain := bson.D{
+		bson.E{
+			Key: "explain",
+			Value: bson.D{
+				bson.E{Key: "find", Value: PermissionCollectionName},
+				bson.E{Key: "filter", Value: active(FilterByUser("user"))},
+			},
+		},
+		bson.E{Key: "verbosity", Value: "queryPlanner"},
+	}
+
+	var result bson.M
+	if err := store.DB.RunCommand(context.Background(), explain).Decode(&result); err != nil {
+		t.Fatalf("explain = %v, want nil", err)
+	}
+
+	queryPlanner, _ := result["queryPlanner"].(bson.M)
+	winningPlan, _ := queryPlanner["winningPlan"].(bson.M)
+
+	// Servers that run the query with the slot based engine nest the plan.
+	if queryPlan, ok := winningPlan["queryPlan"].(bson.M); ok {
+		winningPlan = queryPlan
+	}
+
+	scans := indexScans(winningPlan)
+	for _, keyPattern := range scans {
+		if len(keyPattern) == 1 && keyPattern[PermissionBSONUserIDField] != nil {
+			return
+		}
+	}
+
+	t.Errorf("winning plan %v scans indexes %v, want the %s index", winningPlan, scans, PermissionBSONUserIDField)
+}
+
+func TestNewMongoStoreIsIdempotent(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	// A restart creates the indexes of the same database again.
+	if _, err := NewMongoStore(store.DB); err != nil {
+		t.Errorf("second NewMongoStore() = %v, want nil", err)
+	}
+}
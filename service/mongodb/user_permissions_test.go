@@ -0,0 +1,115 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+)
+
+func TestGetByUserID(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "a", "user", pb.Role_READ)
+	createPermission(t, store, "b", "user", pb.Role_WRITE)
+	createPermission(t, store, "a", "other", pb.Role_READ)
+
+	permissions, err := store.GetByUserID(context.Background(), "user")
+	if err != nil {
+		t.Fatalf("GetByUserID() = %v, want nil", err)
+	}
+
+	if len(permissions) != 2 {
+		t.Fatalf("GetByUserID() returned %d permissions, want 2", len(permissions))
+	}
+
+	for _, permission := range permissions {
+		if permission.GetUserID() != "user" {
+			t.Errorf("GetByUserID() returned a permission of %s, want user", permission.GetUserID())
+		}
+	}
+}
+
+func TestGetUserPermissionsPaged(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	for _, fileID := range []string{"a", "b", "c"} {
+		createPermission(t, store, fileID, "user", pb.Role_READ)
+	}
+
+	controller := NewController(store)
+	seen := map[string]bool{}
+	pageToken := ""
+	for pages := 0; pages == 0 || pageToken != ""; pages++ {
+		if pages > 3 {
+			t.Fatalf("GetUserPermissions() didn't finish paging after %d pages", pages)
+		}
+
+		permissions, nextPageToken, err := controller.GetUserPermissions(context.Background(), "user", 2, pageToken)
+		if err != nil {
+			t.Fatalf("GetUserPermissions() = %v, want nil", err)
+		}
+
+		for _, permission := range permissions {
+			if seen[permission.GetFileID()] {
+				t.Errorf("GetUserPermissions() returned %s twice", permission.GetFileID())
+			}
+
+			seen[permission.GetFileID()] = true
+		}
+
+		pageToken = nextPageToken
+	}
+
+	if len(seen) != 3 {
+		t.Errorf("GetUserPermissions() returned %d files, want 3", len(seen))
+	}
+}
+
+func TestGetFilesForUserByMinRole(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "read", "user", pb.Role_READ)
+	createPermission(t, store, "write", "user", pb.Role_WRITE)
+	createPermission(t, store, "owner", "user", pb.Role_OWNER)
+	createPermission(t, store, "other", "other", pb.Role_OWNER)
+
+	tests := []struct {
+		minRole service.Role
+		want    map[string]pb.Role
+	}{
+		{
+			minRole: service.RoleNone,
+			want:    map[string]pb.Role{"read": pb.Role_READ, "write": pb.Role_WRITE, "owner": pb.Role_OWNER},
+		},
+		{minRole: service.RoleWrite, want: map[string]pb.Role{"write": pb.Role_WRITE, "owner": pb.Role_OWNER}},
+		{minRole: service.RoleOwner, want: map[string]pb.Role{"owner": pb.Role_OWNER}},
+	}
+
+	for _, tt := range tests {
+		permissions, err := store.GetFilesForUser(context.Background(), "user", tt.minRole)
+		if err != nil {
+			t.Fatalf("GetFilesForUser(%v) = %v, want nil", tt.minRole, err)
+		}
+
+		if len(permissions) != len(tt.want) {
+			t.Errorf("GetFilesForUser(%v) returned %d permissions, want %d",
+				tt.minRole, len(permissions), len(tt.want))
+		}
+
+		for _, permission := range permissions {
+			if role, ok := tt.want[permission.GetFileID()]; !ok || permission.GetRole() != role {
+				t.Errorf("GetFilesForUser(%v) returned %s %v, want it only with role %v",
+					tt.minRole, permission.GetFileID(), permission.GetRole(), role)
+			}
+
+			if permission.GetUserID() != "" {
+				t.Errorf("GetFilesForUser() returned userID %s, want it projected out", permission.GetUserID())
+			}
+		}
+	}
+}
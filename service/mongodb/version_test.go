@@ -0,0 +1,66 @@
+package mongodb
+
+import (
+	"context"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/memory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// assertStaleUpdateRejected reads the permission of user to file from store twice, as two concurrent
+// writers would, and asserts only the first of their updates succeeds.
+func assertStaleUpdateRejected(t *testing.T, store service.Store) {
+	t.Helper()
+
+	read, err := store.Get(context.Background(), FilterByFileAndUser("file", "user"))
+	if err != nil {
+		t.Fatalf("Get() = %v, want nil", err)
+	}
+
+	version := read.GetVersion()
+	controller := NewController(store)
+	updated, err := controller.UpdatePermission(context.Background(), "file", "user", pb.Role_WRITE, version)
+	if err != nil {
+		t.Fatalf("first UpdatePermission() = %v, want nil", err)
+	}
+
+	if updated.GetVersion() != version+1 {
+		t.Errorf("first UpdatePermission() version = %d, want %d", updated.GetVersion(), version+1)
+	}
+
+	_, err = controller.UpdatePermission(context.Background(), "file", "user", pb.Role_OWNER, version)
+	if status.Code(err) != codes.Aborted {
+		t.Errorf("stale UpdatePermission() = %v, want %v", err, codes.Aborted)
+	}
+
+	current, err := store.Get(context.Background(), FilterByFileAndUser("file", "user"))
+	if err != nil {
+		t.Fatalf("Get() = %v, want nil", err)
+	}
+
+	if current.GetRole() != pb.Role_WRITE {
+		t.Errorf("role after the stale update = %v, want %v", current.GetRole(), pb.Role_WRITE)
+	}
+}
+
+func TestStaleUpdateRejected(t *testing.T) {
+	store, drop := integrationStore(t)
+	defer drop()
+
+	createPermission(t, store, "file", "user", pb.Role_READ)
+	assertStaleUpdateRejected(t, store)
+}
+
+func TestStaleUpdateRejectedByMemoryStore(t *testing.T) {
+	store := memory.NewMemoryStore()
+	permission := &memory.Permission{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"}
+	if _, err := store.Create(context.Background(), permission); err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	assertStaleUpdateRejected(t, store)
+}
@@ -0,0 +1,327 @@
+package mongodb
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// ResumeTokenCollectionName is the name of the collection of the resume tokens of a MongoResumeTokenStore.
+	ResumeTokenCollectionName = "resumeTokens"
+
+	// watchMaxDelay is the longest delay between attempts to reopen a change stream that failed.
+	watchMaxDelay = 30 * time.Second
+
+	// changeStreamFatalErrorCode and changeStreamHistoryLostCode are the codes of the errors of a change
+	// stream that can't be resumed, since the oplog no longer has the changes after its resume token.
+	changeStreamFatalErrorCode  = 280
+	changeStreamHistoryLostCode = 286
+)
+
+// ResumeTokenStore keeps the resume token of the last event of a watch, so watching can resume after it
+// once the process restarts.
+type ResumeTokenStore interface {
+	// LoadResumeToken returns the stored resume token, or nil if none was stored.
+	LoadResumeToken(ctx context.Context) ([]byte, error)
+
+	// SaveResumeToken stores token, replacing the stored resume token.
+	SaveResumeToken(ctx context.Context, token []byte) error
+}
+
+// MongoResumeTokenStore is a ResumeTokenStore that keeps a named resume token in a mongodb collection.
+type MongoResumeTokenStore struct {
+	collection *mongo.Collection
+	name       string
+}
+
+// NewMongoResumeTokenStore returns a MongoResumeTokenStore of the resume token named name in the
+// ResumeTokenCollectionName collection of db. Every watcher must have a name of its own.
+func NewMongoResumeTokenStore(db *mongo.Database, name string) MongoResumeTokenStore {
+	return MongoResumeTokenStore{collection: db.Collection(ResumeTokenCollectionName), name: name}
+}
+
+// LoadResumeToken returns the resume token named s.name, or nil if it was never saved.
+func (s MongoResumeTokenStore) LoadResumeToken(ctx context.Context) ([]byte, error) {
+	var stored struct {
+		Token bson.Raw `bson:"token"`
+	}
+
+	err := s.collection.FindOne(ctx, bson.D{bson.E{Key: "_id", Value: s.name}}).Decode(&stored)
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	return []byte(stored.Token), nil
+}
+
+// SaveResumeToken upserts the resume token named s.name to token.
+func (s MongoResumeTokenStore) SaveResumeToken(ctx context.Context, token []byte) error {
+	update := bson.D{bson.E{Key: "$set", Value: bson.D{bson.E{Key: "token", Value: bson.Raw(token)}}}}
+	_, err := s.collection.UpdateOne(
+		ctx,
+		bson.D{bson.E{Key: "_id", Value: s.name}},
+		update,
+		options.Update().SetUpsert(true),
+	)
+
+	return err
+}
+
+// changeEvent is the part of a change stream's event that is reported as a service.PermissionEvent.
+type changeEvent struct {
+	OperationType string `bson:"operationType"`
+	FullDocument  *BSON  `bson:"fullDocument"`
+	DocumentKey   struct {
+		ID primitive.ObjectID `bson:"_id"`
+	} `bson:"documentKey"`
+	UpdateDescription struct {
+		RemovedFields []string `bson:"removedFields"`
+	} `bson:"updateDescription"`
+}
+
+// Watch returns a channel of the creations, updates and deletions of the permissions of the store's
+// collection, backed by a mongodb change stream, which requires a replica set. Watching starts after
+// the resume token of ctx set by service.WithResumeToken, otherwise after the token loaded from
+// s.ResumeTokens if it's set, otherwise from now. If the change stream fails it's reopened after the
+// last event with exponential backoff, so no events are lost across reconnects, and if s.ResumeTokens
+// is set then the resume token of each event is saved to it after the event is received.
+// A hard deleted permission is reported by its ID alone since the change stream doesn't keep the
+// deleted document, a soft deleted permission is reported with its file and user.
+// If s.MultiTenant is set then only the permissions of ctx's tenant are reported, along with the hard
+// deletions of every tenant.
+// The channel is closed once ctx is done, or after an event with Err set if the collection is dropped,
+// the resume token is too old to resume after or saving the resume token failed.
+// If successful returns the channel and a nil error, otherwise returns nil and the error that occurred.
+func (s MongoStore) Watch(ctx context.Context) (events <-chan service.PermissionEvent, err error) {
+	ctx, span := s.startSpan(ctx, "Watch")
+	defer func() { err = contextError(ctx, err); endSpan(span, err) }()
+
+	tenantID, err := s.tenantID(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resumeToken := service.ResumeToken(ctx)
+	if resumeToken == nil && s.ResumeTokens != nil {
+		if resumeToken, err = s.ResumeTokens.LoadResumeToken(ctx); err != nil {
+			return nil, err
+		}
+	}
+
+	stream, err := s.openChangeStream(ctx, tenantID, resumeToken)
+	if err != nil {
+		return nil, err
+	}
+
+	watched := make(chan service.PermissionEvent)
+	go s.watch(ctx, stream, tenantID, resumeToken, watched)
+	return watched, nil
+}
+
+// watch sends the events of stream, which started after resumeToken, to events until ctx is done or
+// watching fails, reopening stream after its last event when it fails. events is closed when watch returns.
+func (s MongoStore) watch(
+	ctx context.Context,
+	stream *mongo.ChangeStream,
+	tenantID string,
+	resumeToken []byte,
+	events chan<- service.PermissionEvent,
+) {
+	defer close(events)
+
+	fail := func(err error) {
+		select {
+		case events <- service.PermissionEvent{Err: err}:
+		case <-ctx.Done():
+		}
+	}
+
+	baseDelay := s.RetryBaseDelay
+	if baseDelay <= 0 {
+		baseDelay = DefaultRetryBaseDelay
+	}
+
+	for {
+		for stream.Next(ctx) {
+			event, err := decodeChangeEvent(stream)
+			if err != nil {
+				stream.Close(context.Background())
+				fail(err)
+				return
+			}
+
+			select {
+			case events <- event:
+			case <-ctx.Done():
+				stream.Close(context.Background())
+				return
+			}
+
+			if s.ResumeTokens != nil {
+				if err := s.ResumeTokens.SaveResumeToken(ctx, event.ResumeToken); err != nil {
+					stream.Close(context.Background())
+					fail(fmt.Errorf("failed saving resume token: %v", err))
+					return
+				}
+			}
+		}
+
+		err := stream.Err()
+		if token := stream.ResumeToken(); token != nil {
+			resumeToken = []byte(token)
+		}
+
+		stream.Close(context.Background())
+		if ctx.Err() != nil {
+			return
+		}
+
+		if err != nil && !isResumable(err) {
+			fail(err)
+			return
+		}
+
+		if stream, err = s.reopenChangeStream(ctx, tenantID, resumeToken, baseDelay); err != nil {
+			if ctx.Err() == nil {
+				fail(err)
+			}
+
+			return
+		}
+	}
+}
+
+// reopenChangeStream opens a change stream that starts after resumeToken the same way openChangeStream
+// does, retrying with exponential backoff from baseDelay while opening it fails with a resumable error.
+// If successful returns the change stream and a nil error, otherwise returns nil and the error of the
+// last attempt, or ctx's error if ctx is done.
+func (s MongoStore) reopenChangeStream(
+	ctx context.Context,
+	tenantID string,
+	resumeToken []byte,
+	baseDelay time.Duration,
+) (*mongo.ChangeStream, error) {
+	for delay := baseDelay; ; {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		case <-timer.C:
+		}
+
+		stream, err := s.openChangeStream(ctx, tenantID, resumeToken)
+		if err == nil || !isResumable(err) {
+			return stream, err
+		}
+
+		if delay *= 2; delay > watchMaxDelay {
+			delay = watchMaxDelay
+		}
+	}
+}
+
+// openChangeStream opens a change stream of the store's collection that starts after resumeToken,
+// or from now if it's nil, and reports only the changes of tenantID's permissions and hard deletions
+// if tenantID isn't empty.
+func (s MongoStore) openChangeStream(
+	ctx context.Context,
+	tenantID string,
+	resumeToken []byte,
+) (*mongo.ChangeStream, error) {
+	pipeline := mongo.Pipeline{}
+	if tenantID != "" {
+		pipeline = append(pipeline, bson.D{
+			bson.E{
+				Key: "$match",
+				Value: bson.D{
+					bson.E{
+						Key: "$or",
+						Value: bson.A{
+							bson.D{bson.E{Key: "fullDocument." + PermissionBSONTenantIDField, Value: tenantID}},
+							bson.D{bson.E{
+								Key:   "operationType",
+								Value: bson.D{bson.E{Key: "$in", Value: bson.A{"delete", "invalidate"}}},
+							}},
+						},
+					},
+				},
+			},
+		})
+	}
+
+	opts := options.ChangeStream().SetFullDocument(options.UpdateLookup)
+	if resumeToken != nil {
+		opts.SetResumeAfter(bson.Raw(resumeToken))
+	}
+
+	return s.collection().Watch(ctx, pipeline, opts)
+}
+
+// decodeChangeEvent decodes the current event of stream to a service.PermissionEvent.
+// If successful returns the event and a nil error, if the stream was invalidated since the
+// collection was dropped or renamed it would return an error, otherwise returns the decoding error.
+func decodeChangeEvent(stream *mongo.ChangeStream) (service.PermissionEvent, error) {
+	var change changeEvent
+	if err := stream.Decode(&change); err != nil {
+		return service.PermissionEvent{}, err
+	}
+
+	event := service.PermissionEvent{ResumeToken: []byte(stream.ResumeToken())}
+	if !change.DocumentKey.ID.IsZero() {
+		event.ID = change.DocumentKey.ID.Hex()
+	}
+
+	switch change.OperationType {
+	case "insert":
+		event.Type = service.PermissionEventCreated
+	case "update", "replace":
+		event.Type = service.PermissionEventUpdated
+		for _, field := range change.UpdateDescription.RemovedFields {
+			if field == PermissionBSONDeletedAtField {
+				event.Type = service.PermissionEventCreated
+			}
+		}
+	case "delete":
+		event.Type = service.PermissionEventDeleted
+		return event, nil
+	default:
+		return service.PermissionEvent{}, fmt.Errorf("change stream ended by a %s event", change.OperationType)
+	}
+
+	// The full document of an update is looked up after the update, so it's missing if the permission
+	// was deleted since, in which case the event is reported by the permission's ID alone.
+	if permission := change.FullDocument; permission != nil {
+		event.FileID = permission.GetFileID()
+		event.UserID = permission.GetUserID()
+		event.Role = permission.GetRole()
+		if !permission.GetDeletedAt().IsZero() {
+			event.Type = service.PermissionEventDeleted
+			event.Role = pb.Role_NONE
+		}
+	}
+
+	return event, nil
+}
+
+// isResumable returns true if a change stream that failed with err can be reopened after its
+// last event, which is any error other than the oplog no longer having the changes after it.
+func isResumable(err error) bool {
+	if commandErr, ok := err.(mongo.CommandError); ok {
+		return commandErr.Code != changeStreamFatalErrorCode && commandErr.Code != changeStreamHistoryLostCode
+	}
+
+	return err != nil
+}
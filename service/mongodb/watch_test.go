@@ -0,0 +1,242 @@
+package mongodb
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/memory"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// changeStreamsNotSupportedCode is the code of the error of opening a change stream on a standalone server.
+const changeStreamsNotSupportedCode = 40573
+
+// channelWatcher is a PermissionWatcher that reports the events sent to its channel.
+type channelWatcher chan service.PermissionEvent
+
+func (w channelWatcher) Watch(ctx context.Context) (<-chan service.PermissionEvent, error) {
+	return w, nil
+}
+
+func TestWatchFilePermissions(t *testing.T) {
+	store := memory.NewMemoryStore()
+	existing, err := store.Create(
+		context.Background(),
+		&memory.Permission{FileID: "file", UserID: "existing", Role: pb.Role_READ, Creator: "creator"},
+	)
+	if err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	watcher := make(channelWatcher, 5)
+	watcher <- service.PermissionEvent{Type: service.PermissionEventCreated, ID: "1", FileID: "file", UserID: "a"}
+	watcher <- service.PermissionEvent{Type: service.PermissionEventCreated, ID: "2", FileID: "other", UserID: "a"}
+	watcher <- service.PermissionEvent{Type: service.PermissionEventDeleted, ID: existing.GetID()}
+	watcher <- service.PermissionEvent{Type: service.PermissionEventDeleted, ID: "1"}
+	watcher <- service.PermissionEvent{Type: service.PermissionEventDeleted, ID: "2"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var sent []service.PermissionEvent
+	err = NewController(store).WithWatcher(watcher).WatchFilePermissions(
+		ctx,
+		"file",
+		func(event service.PermissionEvent) error {
+			sent = append(sent, event)
+			if len(sent) == 3 {
+				cancel()
+				close(watcher)
+			}
+
+			return nil
+		},
+	)
+	if err != context.Canceled {
+		t.Errorf("WatchFilePermissions() = %v, want %v", err, context.Canceled)
+	}
+
+	want := []service.PermissionEvent{
+		{Type: service.PermissionEventCreated, ID: "1", FileID: "file", UserID: "a"},
+		{Type: service.PermissionEventDeleted, ID: existing.GetID(), FileID: "file", UserID: "existing"},
+		{Type: service.PermissionEventDeleted, ID: "1", FileID: "file", UserID: "a"},
+	}
+	if !reflect.DeepEqual(sent, want) {
+		t.Errorf("WatchFilePermissions() sent %v, want %v", sent, want)
+	}
+}
+
+func TestWatchFilePermissionsReturnsErrors(t *testing.T) {
+	controller := NewController(memory.NewMemoryStore())
+	send := func(service.PermissionEvent) error { return nil }
+
+	err := controller.WatchFilePermissions(context.Background(), "file", send)
+	if status.Code(err) != codes.Unimplemented {
+		t.Errorf("WatchFilePermissions() without a watcher = %v, want code %v", err, codes.Unimplemented)
+	}
+
+	watchErr := errors.New("history lost")
+	watcher := make(channelWatcher, 1)
+	watcher <- service.PermissionEvent{Err: watchErr}
+	err = controller.WithWatcher(watcher).WatchFilePermissions(context.Background(), "file", send)
+	if err != watchErr {
+		t.Errorf("WatchFilePermissions() = %v, want %v", err, watchErr)
+	}
+}
+
+func TestIsResumable(t *testing.T) {
+	tests := []struct {
+		err  error
+		want bool
+	}{
+		{err: nil, want: false},
+		{err: errors.New("connection reset"), want: true},
+		{err: mongo.CommandError{Code: 11600, Name: "InterruptedAtShutdown"}, want: true},
+		{err: mongo.CommandError{Code: changeStreamHistoryLostCode, Name: "ChangeStreamHistoryLost"}, want: false},
+		{err: mongo.CommandError{Code: changeStreamFatalErrorCode, Name: "ChangeStreamFatalError"}, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isResumable(tt.err); got != tt.want {
+			t.Errorf("isResumable(%v) = %v, want %v", tt.err, got, tt.want)
+		}
+	}
+}
+
+// watchStore returns an integration store and its events from now, skipping the test if the
+// server doesn't support change streams.
+func watchStore(t *testing.T, ctx context.Context) (MongoStore, <-chan service.PermissionEvent, func()) {
+	t.Helper()
+
+	store, drop := integrationStore(t)
+	events, err := store.Watch(ctx)
+	if commandErr, ok := err.(mongo.CommandError); ok && commandErr.Code == changeStreamsNotSupportedCode {
+		drop()
+		t.Skip("change streams require a replica set")
+	}
+
+	if err != nil {
+		drop()
+		t.Fatalf("Watch() = %v, want nil", err)
+	}
+
+	return store, events, drop
+}
+
+// nextEvent returns the next event of events, failing the test if none arrives in time.
+func nextEvent(t *testing.T, events <-chan service.PermissionEvent) service.PermissionEvent {
+	t.Helper()
+
+	select {
+	case event, ok := <-events:
+		if !ok {
+			t.Fatal("events closed, want an event")
+		}
+
+		if event.Err != nil {
+			t.Fatalf("event.Err = %v, want nil", event.Err)
+		}
+
+		return event
+	case <-time.After(10 * time.Second):
+		t.Fatal("no event after 10s")
+	}
+
+	return service.PermissionEvent{}
+}
+
+func TestWatch(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store, events, drop := watchStore(t, ctx)
+	defer drop()
+
+	createPermission(t, store, "file", "user", pb.Role_READ)
+	if _, err := store.UpdateRole(context.Background(), "file", "user", pb.Role_WRITE); err != nil {
+		t.Fatalf("UpdateRole() = %v, want nil", err)
+	}
+
+	if _, err := store.Delete(context.Background(), FilterByFileAndUser("file", "user")); err != nil {
+		t.Fatalf("Delete() = %v, want nil", err)
+	}
+
+	created := nextEvent(t, events)
+	if created.Type != service.PermissionEventCreated || created.FileID != "file" || created.UserID != "user" ||
+		created.Role != pb.Role_READ {
+		t.Errorf("first event = %+v, want the creation of user's READ permission to file", created)
+	}
+
+	updated := nextEvent(t, events)
+	if updated.Type != service.PermissionEventUpdated || updated.ID != created.ID {
+		t.Errorf("second event = %+v, want an update of %s", updated, created.ID)
+	}
+
+	deleted := nextEvent(t, events)
+	if deleted.Type != service.PermissionEventDeleted || deleted.ID != created.ID || deleted.Role != pb.Role_NONE {
+		t.Errorf("third event = %+v, want the deletion of %s", deleted, created.ID)
+	}
+
+	// Resuming after the creation reports the update and deletion again.
+	resumed, err := store.Watch(service.WithResumeToken(ctx, created.ResumeToken))
+	if err != nil {
+		t.Fatalf("Watch() after a resume token = %v, want nil", err)
+	}
+
+	if event := nextEvent(t, resumed); event.Type != service.PermissionEventUpdated || event.ID != created.ID {
+		t.Errorf("first resumed event = %+v, want an update of %s", event, created.ID)
+	}
+}
+
+func TestWatchResumesFromStoredToken(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store, _, drop := watchStore(t, ctx)
+	defer drop()
+
+	store.ResumeTokens = NewMongoResumeTokenStore(store.DB, t.Name())
+	defer store.DB.Collection(ResumeTokenCollectionName).Drop(context.Background())
+
+	watchCtx, stop := context.WithCancel(ctx)
+	watched, err := store.Watch(watchCtx)
+	if err != nil {
+		t.Fatalf("Watch() = %v, want nil", err)
+	}
+
+	createPermission(t, store, "file", "a", pb.Role_READ)
+	event := nextEvent(t, watched)
+
+	// The resume token is saved after the event is received, so wait for it before stopping.
+	for deadline := time.Now().Add(10 * time.Second); ; time.Sleep(10 * time.Millisecond) {
+		token, err := store.ResumeTokens.LoadResumeToken(context.Background())
+		if err != nil {
+			t.Fatalf("LoadResumeToken() = %v, want nil", err)
+		}
+
+		if reflect.DeepEqual(token, event.ResumeToken) {
+			break
+		}
+
+		if time.Now().After(deadline) {
+			t.Fatalf("LoadResumeToken() = %v, want %v", token, event.ResumeToken)
+		}
+	}
+	stop()
+
+	// A permission created while nothing watches is reported once watching restarts.
+	createPermission(t, store, "file", "b", pb.Role_READ)
+	restarted, err := store.Watch(ctx)
+	if err != nil {
+		t.Fatalf("Watch() after a restart = %v, want nil", err)
+	}
+
+	if event := nextEvent(t, restarted); event.UserID != "b" {
+		t.Errorf("first event after a restart = %+v, want the creation of b's permission", event)
+	}
+}
@@ -0,0 +1,44 @@
+package mongodb
+
+import (
+	"fmt"
+	"time"
+
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// DefaultWriteTimeout is the default time a majority write waits to be replicated before it fails.
+const DefaultWriteTimeout = 5 * time.Second
+
+// MajorityWriteConcern returns a write concern that acknowledges writes once they're replicated to a
+// majority of the replica set, so they aren't rolled back if the primary fails, or fails them after timeout.
+// Returns an error if timeout is not positive, since a majority write without a timeout may block forever.
+func MajorityWriteConcern(timeout time.Duration) (*writeconcern.WriteConcern, error) {
+	if timeout <= 0 {
+		return nil, fmt.Errorf("write timeout must be positive, got %v", timeout)
+	}
+
+	return writeconcern.New(writeconcern.WMajority(), writeconcern.WTimeout(timeout)), nil
+}
+
+// collectionOptions returns the options of the collection the store writes to.
+func (s MongoStore) collectionOptions() *options.CollectionOptions {
+	opts := options.Collection()
+	if s.WriteConcern != nil {
+		opts.SetWriteConcern(s.WriteConcern)
+	}
+
+	return opts
+}
+
+// sessionOptions returns the options of the sessions of the store's transactions, whose writes are
+// committed with the store's write concern rather than the write concern of their collection.
+func (s MongoStore) sessionOptions() *options.SessionOptions {
+	opts := options.Session()
+	if s.WriteConcern != nil {
+		opts.SetDefaultWriteConcern(s.WriteConcern)
+	}
+
+	return opts
+}
@@ -0,0 +1,52 @@
+package mongodb
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMajorityWriteConcern(t *testing.T) {
+	writeConcern, err := MajorityWriteConcern(time.Second)
+	if err != nil {
+		t.Fatalf("MajorityWriteConcern() = %v, want nil", err)
+	}
+
+	if writeConcern.GetW() != "majority" || writeConcern.GetWTimeout() != time.Second {
+		t.Errorf("MajorityWriteConcern() = w %v with timeout %v, want majority with 1s",
+			writeConcern.GetW(), writeConcern.GetWTimeout())
+	}
+}
+
+func TestMajorityWriteConcernRequiresPositiveTimeout(t *testing.T) {
+	for _, timeout := range []time.Duration{0, -time.Second} {
+		if _, err := MajorityWriteConcern(timeout); err == nil {
+			t.Errorf("MajorityWriteConcern(%v) = nil, want an error", timeout)
+		}
+	}
+}
+
+func TestCollectionOptionsUseWriteConcern(t *testing.T) {
+	writeConcern, err := MajorityWriteConcern(DefaultWriteTimeout)
+	if err != nil {
+		t.Fatalf("MajorityWriteConcern() = %v, want nil", err)
+	}
+
+	if opts := (MongoStore{WriteConcern: writeConcern}).collectionOptions(); opts.WriteConcern != writeConcern {
+		t.Errorf("write concern = %v, want %v", opts.WriteConcern, writeConcern)
+	}
+
+	if opts := (MongoStore{}).collectionOptions(); opts.WriteConcern != nil {
+		t.Errorf("write concern = %v, want nil", opts.WriteConcern)
+	}
+}
+
+func TestSessionOptionsUseWriteConcern(t *testing.T) {
+	writeConcern, err := MajorityWriteConcern(DefaultWriteTimeout)
+	if err != nil {
+		t.Fatalf("MajorityWriteConcern() = %v, want nil", err)
+	}
+
+	if opts := (MongoStore{WriteConcern: writeConcern}).sessionOptions(); opts.DefaultWriteConcern != writeConcern {
+		t.Errorf("default write concern = %v, want %v", opts.DefaultWriteConcern, writeConcern)
+	}
+}
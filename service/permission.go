@@ -1,6 +1,8 @@
 package service
 
 import (
+	"time"
+
 	pb "github.com/meateam/permission-service/proto"
 )
 
@@ -26,5 +28,37 @@ type Permission interface {
 
 	SetCreator(creator string) error
 
+	GetExpiresAt() time.Time
+
+	SetExpiresAt(expiresAt time.Time) error
+
+	GetVersion() int64
+
+	SetVersion(version int64) error
+
+	GetMetadata() map[string]string
+
+	SetMetadata(metadata map[string]string) error
+
+	GetReason() string
+
+	GetGrantedBy() string
+
+	GetSubjectType() string
+
+	SetSubjectType(subjectType string) error
+
+	GetDeny() bool
+
+	SetDeny(deny bool) error
+
+	SetReason(reason string) error
+
 	MarshalProto(permission *pb.PermissionObject) error
 }
+
+// PermissionKey identifies the permission of a user to a file.
+type PermissionKey struct {
+	FileID string
+	UserID string
+}
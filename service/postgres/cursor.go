@@ -0,0 +1,60 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/meateam/permission-service/service"
+)
+
+// rowsIterator is a PermissionIterator that scans the permissions of query rows one at a time.
+type rowsIterator struct {
+	rows    *sql.Rows
+	current service.Permission
+	err     error
+}
+
+// Next advances the iterator to the next permission and scans it, returns false when there are
+// no more permissions or an error occurred, in which case the rows are closed.
+func (i *rowsIterator) Next(ctx context.Context) bool {
+	if i.err != nil {
+		return false
+	}
+
+	if err := ctx.Err(); err != nil {
+		i.err = err
+		i.rows.Close()
+		return false
+	}
+
+	if !i.rows.Next() {
+		i.err = i.rows.Err()
+		i.rows.Close()
+		return false
+	}
+
+	permission, err := scanPermission(i.rows)
+	if err != nil {
+		i.err = err
+		i.rows.Close()
+		return false
+	}
+
+	i.current = permission
+	return true
+}
+
+// Permission returns the permission the iterator was advanced to by Next.
+func (i *rowsIterator) Permission() service.Permission {
+	return i.current
+}
+
+// Err returns the error that stopped the iteration, or nil if there was none.
+func (i *rowsIterator) Err() error {
+	return i.err
+}
+
+// Close closes the rows, it's safe to call more than once.
+func (i *rowsIterator) Close(ctx context.Context) error {
+	return i.rows.Close()
+}
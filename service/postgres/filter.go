@@ -0,0 +1,59 @@
+package postgres
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/meateam/permission-service/service"
+)
+
+// filterColumns maps the fields of a service.Filter to the columns of the permissions table they match.
+var filterColumns = map[service.FilterField]string{
+	service.FilterFieldFileID: "file_id",
+	service.FilterFieldUserID: "user_id",
+	service.FilterFieldRole:   "role",
+	service.FilterFieldDeny:   "deny",
+}
+
+// where translates filter to the condition of a WHERE clause that matches the same permissions,
+// its placeholders are numbered after args and its values are appended to args.
+// Only service.Filter filters are supported since Mongo's bson filters can't be translated,
+// a nil filter matches every permission.
+// If successful returns the condition, args with the values appended and a nil error,
+// otherwise returns an empty condition, nil and the error that occurred.
+func where(filter interface{}, args []interface{}) (string, []interface{}, error) {
+	var conditions []service.FilterCondition
+	switch f := filter.(type) {
+	case nil:
+	case service.Filter:
+		conditions = f.Conditions()
+	default:
+		return "", nil, fmt.Errorf("unsupported filter type %T", filter)
+	}
+
+	if len(conditions) == 0 {
+		return "TRUE", args, nil
+	}
+
+	clauses := make([]string, 0, len(conditions))
+	for _, condition := range conditions {
+		column, ok := filterColumns[condition.Field]
+		if !ok {
+			return "", nil, fmt.Errorf("unsupported filter field %s", condition.Field)
+		}
+
+		placeholders := make([]string, 0, len(condition.Values))
+		for _, value := range condition.Values {
+			args = append(args, value)
+			placeholders = append(placeholders, fmt.Sprintf("$%d", len(args)))
+		}
+
+		if len(placeholders) == 1 {
+			clauses = append(clauses, fmt.Sprintf("%s = %s", column, placeholders[0]))
+		} else {
+			clauses = append(clauses, fmt.Sprintf("%s IN (%s)", column, strings.Join(placeholders, ", ")))
+		}
+	}
+
+	return strings.Join(clauses, " AND "), args, nil
+}
@@ -0,0 +1,258 @@
+package postgres
+
+import (
+	"fmt"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+)
+
+// Permission is the structure that represents a permission as it's stored in a row of the
+// permissions table of a PostgresStore.
+type Permission struct {
+	ID          string
+	FileID      string
+	UserID      string
+	Role        pb.Role
+	Creator     string
+	ExpiresAt   time.Time
+	Version     int64
+	Metadata    map[string]string
+	Reason      string
+	GrantedBy   string
+	SubjectType string
+	Deny        bool
+}
+
+// GetID returns p.ID.
+func (p Permission) GetID() string {
+	return p.ID
+}
+
+// SetID sets p.ID to id.
+func (p *Permission) SetID(id string) error {
+	if p == nil {
+		panic("p == nil")
+	}
+
+	p.ID = id
+	return nil
+}
+
+// GetFileID returns p.FileID.
+func (p Permission) GetFileID() string {
+	return p.FileID
+}
+
+// SetFileID sets p.FileID to fileID.
+func (p *Permission) SetFileID(fileID string) error {
+	if p == nil {
+		panic("p == nil")
+	}
+
+	if fileID == "" {
+		return fmt.Errorf("FileID is required")
+	}
+
+	p.FileID = fileID
+	return nil
+}
+
+// GetUserID returns p.UserID.
+func (p Permission) GetUserID() string {
+	return p.UserID
+}
+
+// SetUserID sets p.UserID to userID.
+func (p *Permission) SetUserID(userID string) error {
+	if p == nil {
+		panic("p == nil")
+	}
+
+	if userID == "" {
+		return fmt.Errorf("UserID is required")
+	}
+
+	p.UserID = userID
+	return nil
+}
+
+// GetRole returns p.Role.
+func (p Permission) GetRole() pb.Role {
+	return p.Role
+}
+
+// SetRole sets p.Role to role.
+func (p *Permission) SetRole(role pb.Role) error {
+	if p == nil {
+		panic("p == nil")
+	}
+
+	if err := service.ValidateRole(role); err != nil {
+		return err
+	}
+
+	p.Role = role
+	return nil
+}
+
+// GetCreator returns p.Creator.
+func (p Permission) GetCreator() string {
+	return p.Creator
+}
+
+// SetCreator sets p.Creator to creator.
+func (p *Permission) SetCreator(creator string) error {
+	if p == nil {
+		panic("p == nil")
+	}
+
+	if creator == "" {
+		return fmt.Errorf("Creator is required")
+	}
+
+	p.Creator = creator
+	return nil
+}
+
+// GetExpiresAt returns p.ExpiresAt, or the zero time if p never expires.
+func (p Permission) GetExpiresAt() time.Time {
+	return p.ExpiresAt
+}
+
+// SetExpiresAt sets p.ExpiresAt to expiresAt, the zero time means p never expires.
+func (p *Permission) SetExpiresAt(expiresAt time.Time) error {
+	if p == nil {
+		panic("p == nil")
+	}
+
+	p.ExpiresAt = expiresAt
+	return nil
+}
+
+// GetVersion returns p.Version.
+func (p Permission) GetVersion() int64 {
+	return p.Version
+}
+
+// SetVersion sets p.Version to version.
+func (p *Permission) SetVersion(version int64) error {
+	if p == nil {
+		panic("p == nil")
+	}
+
+	if version < 0 {
+		return fmt.Errorf("Version must not be negative")
+	}
+
+	p.Version = version
+	return nil
+}
+
+// GetMetadata returns p.Metadata.
+func (p Permission) GetMetadata() map[string]string {
+	return p.Metadata
+}
+
+// SetMetadata sets p.Metadata to metadata, which must be at most service.MaxMetadataSize bytes.
+func (p *Permission) SetMetadata(metadata map[string]string) error {
+	if p == nil {
+		panic("p == nil")
+	}
+
+	if err := service.ValidateMetadata(metadata); err != nil {
+		return err
+	}
+
+	p.Metadata = metadata
+	return nil
+}
+
+// GetReason returns p.Reason.
+func (p Permission) GetReason() string {
+	return p.Reason
+}
+
+// SetReason sets p.Reason to reason, which must be at most service.MaxReasonLength characters.
+func (p *Permission) SetReason(reason string) error {
+	if p == nil {
+		panic("p == nil")
+	}
+
+	if err := service.ValidateReason(reason); err != nil {
+		return err
+	}
+
+	p.Reason = reason
+	return nil
+}
+
+// GetGrantedBy returns p.GrantedBy, or an empty string if the actor that granted p is unknown.
+func (p Permission) GetGrantedBy() string {
+	return p.GrantedBy
+}
+
+// GetSubjectType returns p.SubjectType, or service.SubjectTypeUser if it's empty.
+func (p Permission) GetSubjectType() string {
+	if p.SubjectType == "" {
+		return service.SubjectTypeUser
+	}
+
+	return p.SubjectType
+}
+
+// SetSubjectType sets p.SubjectType to subjectType, which must be empty, service.SubjectTypeUser
+// or service.SubjectTypeGroup. A user subject type is stored empty.
+func (p *Permission) SetSubjectType(subjectType string) error {
+	if p == nil {
+		panic("p == nil")
+	}
+
+	if err := service.ValidateSubjectType(subjectType); err != nil {
+		return err
+	}
+
+	if subjectType == service.SubjectTypeUser {
+		subjectType = ""
+	}
+
+	p.SubjectType = subjectType
+	return nil
+}
+
+// GetDeny returns p.Deny.
+func (p Permission) GetDeny() bool {
+	return p.Deny
+}
+
+// SetDeny sets p.Deny to deny.
+func (p *Permission) SetDeny(deny bool) error {
+	if p == nil {
+		panic("p == nil")
+	}
+
+	p.Deny = deny
+	return nil
+}
+
+// MarshalProto marshals p into a permission.
+func (p Permission) MarshalProto(permission *pb.PermissionObject) error {
+	permission.Id = p.GetID()
+	permission.FileID = p.GetFileID()
+	permission.UserID = p.GetUserID()
+	permission.Role = p.GetRole()
+	permission.Creator = p.GetCreator()
+	permission.ExpiresAt = 0
+	if expiresAt := p.GetExpiresAt(); !expiresAt.IsZero() {
+		permission.ExpiresAt = expiresAt.Unix()
+	}
+
+	permission.Version = p.GetVersion()
+	permission.Metadata = p.GetMetadata()
+	permission.Reason = p.GetReason()
+	permission.GrantedBy = p.GetGrantedBy()
+	permission.SubjectType = p.GetSubjectType()
+	permission.Deny = p.GetDeny()
+	return nil
+}
@@ -0,0 +1,767 @@
+package postgres
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// PermissionTableName is the name of the table that permissions are stored in.
+const PermissionTableName = "permissions"
+
+// schema creates the permissions table and its indexes if they don't exist. A user has a single
+// permission to a file, which the upsert of Create relies on. IDs are generated the same way
+// mongodb.MongoStore's are so they sort by creation time and page tokens look the same.
+const schema = `
+CREATE TABLE IF NOT EXISTS permissions (
+	id CHAR(24) PRIMARY KEY,
+	file_id TEXT NOT NULL,
+	user_id TEXT NOT NULL,
+	role INTEGER NOT NULL,
+	creator TEXT NOT NULL,
+	expires_at TIMESTAMPTZ,
+	version BIGINT NOT NULL,
+	metadata JSONB,
+	reason TEXT NOT NULL DEFAULT '',
+	granted_by TEXT NOT NULL DEFAULT '',
+	subject_type TEXT NOT NULL DEFAULT '',
+	deny BOOLEAN NOT NULL DEFAULT FALSE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+	CONSTRAINT permissions_file_id_user_id_key UNIQUE (file_id, user_id)
+);
+CREATE INDEX IF NOT EXISTS permissions_user_id_idx ON permissions (user_id);
+`
+
+// permissionColumns are the columns that a Permission is scanned from, in the order scanPermission expects.
+const permissionColumns = "id, file_id, user_id, role, creator, expires_at, version, metadata, reason, " +
+	"granted_by, subject_type, deny"
+
+// active is the condition that matches the permissions that have not expired yet.
+const active = "(expires_at IS NULL OR expires_at > now())"
+
+// upsertStatement inserts a permission, or updates the existing permission of its user to its file to have
+// its values, the same fields mongodb.MongoStore updates. The actor that granted it is set only on insert.
+const upsertStatement = `
+INSERT INTO permissions (id, file_id, user_id, role, creator, expires_at, version, metadata, reason,
+	granted_by, subject_type, deny)
+VALUES ($1, $2, $3, $4, $5, $6, 1, $7, $8, $9, $10, $11)
+ON CONFLICT (file_id, user_id) DO UPDATE SET
+	role = EXCLUDED.role,
+	creator = EXCLUDED.creator,
+	expires_at = EXCLUDED.expires_at,
+	version = permissions.version + 1,
+	metadata = EXCLUDED.metadata,
+	reason = EXCLUDED.reason,
+	subject_type = EXCLUDED.subject_type,
+	deny = EXCLUDED.deny,
+	updated_at = now()`
+
+// upsertQuery upserts a permission by upsertStatement and returns it.
+const upsertQuery = upsertStatement + `
+RETURNING ` + permissionColumns
+
+// insertIfAbsentQuery upserts a permission by upsertStatement only if the existing permission of its user
+// to its file expired, so no row is returned if the user has an active permission to the file.
+const insertIfAbsentQuery = upsertStatement + `
+WHERE permissions.expires_at <= now()
+RETURNING ` + permissionColumns
+
+// PostgresStore is an implementation of the Store interface over the permissions table of a PostgreSQL
+// database, for deployments that can't run MongoDB. It behaves the same way mongodb.MongoStore does,
+// but accepts only service.Filter filters.
+type PostgresStore struct {
+	DB *sql.DB
+}
+
+var _ service.Store = PostgresStore{}
+
+// NewPostgresStore returns a new store of the permissions table of db,
+// creating the table and its indexes if they don't exist.
+// db must be opened with a PostgreSQL driver, such as github.com/lib/pq's.
+func NewPostgresStore(db *sql.DB) (PostgresStore, error) {
+	if _, err := db.ExecContext(context.Background(), schema); err != nil {
+		return PostgresStore{}, fmt.Errorf("failed creating the %s table: %v", PermissionTableName, err)
+	}
+
+	return PostgresStore{DB: db}, nil
+}
+
+// HealthCheck checks the health of the service, it's healthy if the database responds to a ping.
+// If the deadline of ctx passed before the database responded it's reported as unhealthy with
+// service.HealthReasonPingTimeout and a nil error, the same as mongodb.MongoStore reports it.
+// Otherwise returns an unhealthy result and the error of the ping if any occurred.
+func (s PostgresStore) HealthCheck(ctx context.Context) (service.HealthResult, error) {
+	err := s.DB.PingContext(ctx)
+	if ctx.Err() == context.DeadlineExceeded {
+		return service.HealthResult{Reason: service.HealthReasonPingTimeout}, nil
+	}
+
+	if err != nil {
+		return service.HealthResult{Reason: err.Error()}, err
+	}
+
+	return service.HealthResult{Healthy: true}, nil
+}
+
+// HealthStatus pings the database and returns its health, the database has no secondaries
+// so they're reported as reachable if the primary is.
+func (s PostgresStore) HealthStatus(ctx context.Context) (service.HealthStatus, error) {
+	start := time.Now()
+	if err := s.DB.PingContext(ctx); err != nil {
+		return service.HealthStatus{}, err
+	}
+
+	return service.HealthStatus{PrimaryReachable: true, SecondaryReachable: true, Latency: time.Since(start)}, nil
+}
+
+// Create creates a permission of a file to a user,
+// If permission already exists then it's updated to have permission values,
+// If successful returns the permission and a nil error,
+// otherwise returns nil and non-nil error if any occurred.
+func (s PostgresStore) Create(ctx context.Context, permission service.Permission) (service.Permission, error) {
+	if err := service.ValidatePermission(nil, permission); err != nil {
+		return nil, err
+	}
+
+	return upsert(ctx, s.DB, upsertQuery, permission)
+}
+
+// CreateIfAbsent creates a permission of a file to a user the same way Create does, unless the user
+// already has a permission to the file, in which case it's left as is and an AlreadyExists error is
+// returned. An expired permission is absent, and is replaced as if it was inserted.
+// If successful returns the permission and a nil error,
+// otherwise returns nil and non-nil error if any occurred.
+func (s PostgresStore) CreateIfAbsent(
+	ctx context.Context,
+	permission service.Permission,
+) (service.Permission, error) {
+	if err := service.ValidatePermission(nil, permission); err != nil {
+		return nil, err
+	}
+
+	created, err := upsert(ctx, s.DB, insertIfAbsentQuery, permission)
+	if err == service.ErrPermissionNotFound {
+		return nil, status.Errorf(codes.AlreadyExists, "permission of user %s to file %s already exists",
+			permission.GetUserID(), permission.GetFileID())
+	}
+
+	return created, err
+}
+
+// CreateMany creates permissions the same way Create does in a single transaction, all of them are
+// validated before any of them is created, and none of them is created if any of them fails.
+// If successful returns the created permissions in the order they were given and a nil error,
+// otherwise returns nil and non-nil error if any occurred.
+func (s PostgresStore) CreateMany(
+	ctx context.Context,
+	permissions []service.Permission,
+) ([]service.Permission, error) {
+	if err := service.ValidatePermissions(nil, permissions); err != nil {
+		return nil, err
+	}
+
+	created := make([]service.Permission, 0, len(permissions))
+	err := s.transaction(ctx, func(tx *sql.Tx) error {
+		for _, permission := range permissions {
+			upserted, err := upsert(ctx, tx, upsertQuery, permission)
+			if err != nil {
+				return err
+			}
+
+			created = append(created, upserted)
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return created, nil
+}
+
+// queryRower is a *sql.DB or a *sql.Tx, whichever a query runs on.
+type queryRower interface {
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// upsert creates permission or updates the existing permission of its user to its file on db by query,
+// upsertQuery or insertIfAbsentQuery, a created permission is granted by the actor of ctx.
+// Returns the stored permission, otherwise returns nil and the error that occurred.
+func upsert(
+	ctx context.Context,
+	db queryRower,
+	query string,
+	permission service.Permission,
+) (service.Permission, error) {
+	metadata, err := encodeMetadata(permission.GetMetadata())
+	if err != nil {
+		return nil, err
+	}
+
+	// A user's permission is stored without a subject type, the same as mongodb.MongoStore stores it.
+	subjectType := permission.GetSubjectType()
+	if subjectType == service.SubjectTypeUser {
+		subjectType = ""
+	}
+
+	row := db.QueryRowContext(
+		ctx,
+		query,
+		primitive.NewObjectID().Hex(),
+		permission.GetFileID(),
+		permission.GetUserID(),
+		permission.GetRole(),
+		permission.GetCreator(),
+		nullTime(permission.GetExpiresAt()),
+		metadata,
+		permission.GetReason(),
+		service.ActorIDFromContext(ctx),
+		subjectType,
+		permission.GetDeny(),
+	)
+
+	return scanPermission(row)
+}
+
+// Get finds one permission that matches filter,
+// if successful returns the permission, and a nil error,
+// if the permission is not found it would return nil and service.ErrPermissionNotFound,
+// otherwise returns nil and non-nil error if any occurred.
+func (s PostgresStore) Get(ctx context.Context, filter interface{}) (service.Permission, error) {
+	condition, args, err := where(filter, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(
+		"SELECT %s FROM permissions WHERE %s AND %s ORDER BY id LIMIT 1", permissionColumns, condition, active)
+
+	return scanPermission(s.DB.QueryRowContext(ctx, query, args...))
+}
+
+// GetAll finds all permissions that matches filter, sorted by their ID,
+// if successful returns the permissions, and a nil error,
+// otherwise returns nil and non-nil error if any occurred.
+func (s PostgresStore) GetAll(ctx context.Context, filter interface{}) ([]service.Permission, error) {
+	iterator, err := s.GetAllCursor(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	defer iterator.Close(ctx)
+
+	permissions := []service.Permission{}
+	for iterator.Next(ctx) {
+		permissions = append(permissions, iterator.Permission())
+	}
+
+	if err := iterator.Err(); err != nil {
+		return nil, err
+	}
+
+	return permissions, nil
+}
+
+// sortOrders maps each sort order to the ORDER BY clause that sorts by it. The role orders are sorted by id
+// and then by role after reading, since the stored role values aren't ordered by the access they grant.
+var sortOrders = map[service.SortBy]string{
+	service.SortByID:                     "id",
+	service.SortByRoleAscending:          "id",
+	service.SortByRoleDescending:         "id",
+	service.SortByUserIDAscending:        "user_id, id",
+	service.SortByUserIDDescending:       "user_id DESC, id",
+	service.SortByCreationTimeAscending:  "id",
+	service.SortByCreationTimeDescending: "id DESC",
+}
+
+// GetAllSorted finds all permissions that match filter the same way GetAll does, sorted by sortBy,
+// if successful returns the permissions, and a nil error,
+// otherwise returns nil and non-nil error if any occurred.
+func (s PostgresStore) GetAllSorted(
+	ctx context.Context,
+	filter interface{},
+	sortBy service.SortBy,
+) ([]service.Permission, error) {
+	orderBy, ok := sortOrders[sortBy]
+	if !ok {
+		return nil, status.Errorf(codes.InvalidArgument, "unknown sort order %d", sortBy)
+	}
+
+	condition, args, err := where(filter, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(
+		"SELECT %s FROM permissions WHERE %s AND %s ORDER BY %s", permissionColumns, condition, active, orderBy)
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	iterator := &rowsIterator{rows: rows}
+	defer iterator.Close(ctx)
+
+	permissions := []service.Permission{}
+	for iterator.Next(ctx) {
+		permissions = append(permissions, iterator.Permission())
+	}
+
+	if err := iterator.Err(); err != nil {
+		return nil, err
+	}
+
+	if sortBy == service.SortByRoleAscending || sortBy == service.SortByRoleDescending {
+		if err := service.SortPermissions(permissions, sortBy); err != nil {
+			return nil, err
+		}
+	}
+
+	return permissions, nil
+}
+
+// GetAllCursor finds all permissions that match filter the same way GetAll does, but returns an iterator
+// that reads them from the database as it's advanced instead of loading all of them at once.
+// The iterator must be closed.
+// If successful returns the iterator, and a nil error,
+// otherwise returns nil and non-nil error if any occurred.
+func (s PostgresStore) GetAllCursor(ctx context.Context, filter interface{}) (service.PermissionIterator, error) {
+	condition, args, err := where(filter, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(
+		"SELECT %s FROM permissions WHERE %s AND %s ORDER BY id", permissionColumns, condition, active)
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rowsIterator{rows: rows}, nil
+}
+
+// GetAllPaged finds a page of at most pageSize permissions that match filter, sorted by their ID,
+// starting right after the page that pageToken was returned with, an empty pageToken
+// starts from the first page.
+// If successful returns the permissions, the token of the next page and a nil error,
+// an empty next page token is returned on the last page,
+// otherwise returns nil, an empty token and non-nil error if any occurred.
+func (s PostgresStore) GetAllPaged(
+	ctx context.Context,
+	filter interface{},
+	pageSize int64,
+	pageToken string,
+) ([]service.Permission, string, error) {
+	if pageSize <= 0 {
+		return nil, "", status.Error(codes.InvalidArgument, "pageSize must be positive")
+	}
+
+	condition, args, err := where(filter, nil)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if pageToken != "" {
+		if _, err := primitive.ObjectIDFromHex(pageToken); err != nil {
+			return nil, "", status.Errorf(codes.InvalidArgument, "invalid page token %s", pageToken)
+		}
+
+		args = append(args, pageToken)
+		condition = fmt.Sprintf("%s AND id > $%d", condition, len(args))
+	}
+
+	// Fetch one extra permission to know whether there's a next page.
+	args = append(args, pageSize+1)
+	query := fmt.Sprintf(
+		"SELECT %s FROM permissions WHERE %s AND %s ORDER BY id LIMIT $%d",
+		permissionColumns, condition, active, len(args))
+
+	rows, err := s.DB.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, "", err
+	}
+
+	iterator := &rowsIterator{rows: rows}
+	defer iterator.Close(ctx)
+
+	permissions := []service.Permission{}
+	for iterator.Next(ctx) {
+		permissions = append(permissions, iterator.Permission())
+	}
+
+	if err := iterator.Err(); err != nil {
+		return nil, "", err
+	}
+
+	if int64(len(permissions)) <= pageSize {
+		return permissions, "", nil
+	}
+
+	permissions = permissions[:pageSize]
+	return permissions, permissions[pageSize-1].GetID(), nil
+}
+
+// Count returns the number of permissions that match filter,
+// otherwise returns 0 and non-nil error if any occurred.
+func (s PostgresStore) Count(ctx context.Context, filter interface{}) (int64, error) {
+	condition, args, err := where(filter, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	var count int64
+	query := fmt.Sprintf("SELECT COUNT(*) FROM permissions WHERE %s AND %s", condition, active)
+	if err := s.DB.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// Exists returns true if userID has a permission to fileID, the same permission Get would find,
+// or false and a nil error if it has none.
+func (s PostgresStore) Exists(ctx context.Context, fileID string, userID string) (bool, error) {
+	var exists bool
+	query := fmt.Sprintf(
+		"SELECT EXISTS (SELECT 1 FROM permissions WHERE file_id = $1 AND user_id = $2 AND %s)", active)
+	if err := s.DB.QueryRowContext(ctx, query, fileID, userID).Scan(&exists); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// ExistsMatching returns true if any permission matches filter, the same permissions Count counts,
+// otherwise returns false and non-nil error if any occurred.
+func (s PostgresStore) ExistsMatching(ctx context.Context, filter interface{}) (bool, error) {
+	condition, args, err := where(filter, nil)
+	if err != nil {
+		return false, err
+	}
+
+	var exists bool
+	query := fmt.Sprintf("SELECT EXISTS (SELECT 1 FROM permissions WHERE %s AND %s)", condition, active)
+	if err := s.DB.QueryRowContext(ctx, query, args...).Scan(&exists); err != nil {
+		return false, err
+	}
+
+	return exists, nil
+}
+
+// Delete finds the first permission that matches filter and deletes it, expired permissions
+// that were not removed yet are matched as well, the same as they are by mongodb.MongoStore.
+// If successful returns the deleted permission, if the permission is not found
+// it would return nil and service.ErrPermissionNotFound, otherwise returns nil,
+// and non-nil error if any occurred.
+func (s PostgresStore) Delete(ctx context.Context, filter interface{}) (service.Permission, error) {
+	condition, args, err := where(filter, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(
+		"DELETE FROM permissions WHERE id = (SELECT id FROM permissions WHERE %s ORDER BY id LIMIT 1) RETURNING %s",
+		condition, permissionColumns)
+
+	return scanPermission(s.DB.QueryRowContext(ctx, query, args...))
+}
+
+// DeleteMany deletes all permissions that match filter, expired permissions that were not removed yet
+// are matched as well, the same as they are by Delete.
+// If successful returns the number of deleted permissions and a nil error,
+// if filter is empty it would return 0 and an InvalidArgument error,
+// otherwise returns 0 and non-nil error if any occurred.
+func (s PostgresStore) DeleteMany(ctx context.Context, filter interface{}) (int64, error) {
+	if err := service.RequireFilter(filter); err != nil {
+		return 0, err
+	}
+
+	condition, args, err := where(filter, nil)
+	if err != nil {
+		return 0, err
+	}
+
+	return s.deleteWhere(ctx, condition, args...)
+}
+
+// DeleteAllByFileID deletes all permissions of fileID,
+// if successful returns the number of deleted permissions and a nil error,
+// otherwise returns 0 and non-nil error if any occurred.
+func (s PostgresStore) DeleteAllByFileID(ctx context.Context, fileID string) (int64, error) {
+	if fileID == "" {
+		return 0, status.Error(codes.InvalidArgument, "fileID is required")
+	}
+
+	return s.deleteWhere(ctx, "file_id = $1", fileID)
+}
+
+// DeleteAllByUserID deletes all permissions of userID,
+// if successful returns the number of deleted permissions and a nil error,
+// otherwise returns 0 and non-nil error if any occurred.
+func (s PostgresStore) DeleteAllByUserID(ctx context.Context, userID string) (int64, error) {
+	if userID == "" {
+		return 0, status.Error(codes.InvalidArgument, "userID is required")
+	}
+
+	return s.deleteWhere(ctx, "user_id = $1", userID)
+}
+
+// deleteWhere deletes all permissions that match condition with args, or only counts them if
+// ctx is a dry run. Returns the number of matched permissions, or 0 and the error that occurred.
+func (s PostgresStore) deleteWhere(ctx context.Context, condition string, args ...interface{}) (int64, error) {
+	if service.IsDryRun(ctx) {
+		var count int64
+		query := "SELECT COUNT(*) FROM permissions WHERE " + condition
+		if err := s.DB.QueryRowContext(ctx, query, args...).Scan(&count); err != nil {
+			return 0, err
+		}
+
+		return count, nil
+	}
+
+	result, err := s.DB.ExecContext(ctx, "DELETE FROM permissions WHERE "+condition, args...)
+	if err != nil {
+		return 0, err
+	}
+
+	return result.RowsAffected()
+}
+
+// UpdateRole updates the role of the permission of userID to fileID,
+// if successful returns the updated permission and a nil error,
+// if the permission does not exist it would return nil and service.ErrPermissionNotFound,
+// otherwise returns nil and non-nil error if any occurred.
+func (s PostgresStore) UpdateRole(
+	ctx context.Context,
+	fileID string,
+	userID string,
+	role pb.Role,
+) (service.Permission, error) {
+	if err := validateUpdate(fileID, userID, role); err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(
+		"UPDATE permissions SET role = $3, version = version + 1, updated_at = now() "+
+			"WHERE file_id = $1 AND user_id = $2 AND %s RETURNING %s",
+		active, permissionColumns)
+
+	return scanPermission(s.DB.QueryRowContext(ctx, query, fileID, userID, role))
+}
+
+// UpdateRoleIfVersion updates the role of the permission of userID to fileID only if its
+// version is still expectedVersion.
+// If successful returns the updated permission and a nil error,
+// if the permission does not exist it would return nil and service.ErrPermissionNotFound,
+// if the permission's version changed it would return nil and an Aborted error,
+// otherwise returns nil and non-nil error if any occurred.
+func (s PostgresStore) UpdateRoleIfVersion(
+	ctx context.Context,
+	fileID string,
+	userID string,
+	role pb.Role,
+	expectedVersion int64,
+) (service.Permission, error) {
+	if err := validateUpdate(fileID, userID, role); err != nil {
+		return nil, err
+	}
+
+	query := fmt.Sprintf(
+		"UPDATE permissions SET role = $3, version = version + 1, updated_at = now() "+
+			"WHERE file_id = $1 AND user_id = $2 AND version = $4 AND %s RETURNING %s",
+		active, permissionColumns)
+
+	permission, err := scanPermission(s.DB.QueryRowContext(ctx, query, fileID, userID, role, expectedVersion))
+	if err != service.ErrPermissionNotFound {
+		return permission, err
+	}
+
+	current, err := s.Get(ctx, service.And(service.ByFile(fileID), service.ByUser(userID)))
+	if err != nil {
+		return nil, err
+	}
+
+	return nil, status.Errorf(
+		codes.Aborted,
+		"permission of user %s to file %s was modified, expected version %d but found %d",
+		userID,
+		fileID,
+		expectedVersion,
+		current.GetVersion(),
+	)
+}
+
+// validateUpdate returns an error if fileID or userID is empty or role can't be granted, otherwise returns nil.
+func validateUpdate(fileID string, userID string, role pb.Role) error {
+	if fileID == "" {
+		return fmt.Errorf("fileID is required")
+	}
+
+	if userID == "" {
+		return fmt.Errorf("userID is required")
+	}
+
+	return service.ValidateRole(role)
+}
+
+// TransferOwnership makes toUserID the owner of fileID and demotes fromUserID, the current owner,
+// to WRITE, the same way mongodb.MongoStore does by default. Both permissions are updated in a single
+// transaction so the file never has two owners or none. A permission of toUserID is created if it
+// doesn't exist, with fromUserID as its creator.
+// If successful returns a nil error, if fromUserID is not the owner of fileID it would return a
+// FailedPrecondition error, otherwise returns non-nil error if any occurred.
+func (s PostgresStore) TransferOwnership(
+	ctx context.Context,
+	fileID string,
+	fromUserID string,
+	toUserID string,
+) error {
+	if fileID == "" {
+		return status.Error(codes.InvalidArgument, "fileID is required")
+	}
+
+	if fromUserID == "" {
+		return status.Error(codes.InvalidArgument, "fromUserID is required")
+	}
+
+	if toUserID == "" {
+		return status.Error(codes.InvalidArgument, "toUserID is required")
+	}
+
+	if fromUserID == toUserID {
+		return status.Error(codes.InvalidArgument, "fromUserID and toUserID must be different users")
+	}
+
+	return s.transaction(ctx, func(tx *sql.Tx) error {
+		demote := fmt.Sprintf(
+			"UPDATE permissions SET role = $3, version = version + 1, updated_at = now() "+
+				"WHERE file_id = $1 AND user_id = $2 AND role = $4 AND %s",
+			active)
+		result, err := tx.ExecContext(ctx, demote, fileID, fromUserID, pb.Role_WRITE, pb.Role_OWNER)
+		if err != nil {
+			return err
+		}
+
+		if demoted, err := result.RowsAffected(); err != nil {
+			return err
+		} else if demoted == 0 {
+			return status.Errorf(codes.FailedPrecondition, "user %s is not the owner of file %s", fromUserID, fileID)
+		}
+
+		_, err = tx.ExecContext(
+			ctx,
+			"INSERT INTO permissions (id, file_id, user_id, role, creator, version) "+
+				"VALUES ($1, $2, $3, $4, $5, 1) "+
+				"ON CONFLICT (file_id, user_id) DO UPDATE SET "+
+				"role = EXCLUDED.role, expires_at = NULL, version = permissions.version + 1, updated_at = now()",
+			primitive.NewObjectID().Hex(),
+			fileID,
+			toUserID,
+			pb.Role_OWNER,
+			fromUserID,
+		)
+
+		return err
+	})
+}
+
+// transaction runs fn in a transaction that's committed if fn succeeds, otherwise it's rolled back.
+// A dry run is always rolled back so nothing is changed. Returns the error of fn or of the commit.
+func (s PostgresStore) transaction(ctx context.Context, fn func(tx *sql.Tx) error) error {
+	tx, err := s.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if service.IsDryRun(ctx) {
+		return tx.Rollback()
+	}
+
+	return tx.Commit()
+}
+
+// rowScanner is a *sql.Row or *sql.Rows, whichever a permission is scanned from.
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanPermission scans a permission of permissionColumns from row.
+// If successful returns the permission and a nil error, if there is no row it would return nil
+// and service.ErrPermissionNotFound, otherwise returns nil and the error that occurred.
+func scanPermission(row rowScanner) (service.Permission, error) {
+	permission := &Permission{}
+	var expiresAt sql.NullTime
+	var metadata []byte
+	err := row.Scan(
+		&permission.ID,
+		&permission.FileID,
+		&permission.UserID,
+		&permission.Role,
+		&permission.Creator,
+		&expiresAt,
+		&permission.Version,
+		&metadata,
+		&permission.Reason,
+		&permission.GrantedBy,
+		&permission.SubjectType,
+		&permission.Deny,
+	)
+	if err == sql.ErrNoRows {
+		return nil, service.ErrPermissionNotFound
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	if expiresAt.Valid {
+		permission.ExpiresAt = expiresAt.Time
+	}
+
+	if len(metadata) > 0 {
+		if err := json.Unmarshal(metadata, &permission.Metadata); err != nil {
+			return nil, fmt.Errorf("failed decoding the metadata of permission %s: %v", permission.ID, err)
+		}
+	}
+
+	return permission, nil
+}
+
+// encodeMetadata returns metadata encoded as JSON for the metadata column,
+// or nil if it's empty so it's stored as NULL.
+func encodeMetadata(metadata map[string]string) (interface{}, error) {
+	if len(metadata) == 0 {
+		return nil, nil
+	}
+
+	encoded, err := json.Marshal(metadata)
+	if err != nil {
+		return nil, err
+	}
+
+	return string(encoded), nil
+}
+
+// nullTime returns t, or nil if it's the zero time so it's stored as NULL.
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+
+	return t
+}
@@ -0,0 +1,103 @@
+package postgres
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	_ "github.com/lib/pq"
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/storetest"
+)
+
+// postgresTestHostEnv is the environment variable of the key=value connection string of the PostgreSQL
+// server that integration tests run against, they're skipped if it's not set.
+const postgresTestHostEnv = "POSTGRES_TEST_HOST"
+
+// integrationStore returns a store of a new schema of the server of postgresTestHostEnv,
+// and a function that drops the schema.
+func integrationStore(t *testing.T) (PostgresStore, func()) {
+	t.Helper()
+
+	connectionString := os.Getenv(postgresTestHostEnv)
+	if connectionString == "" {
+		t.Skipf("%s is not set", postgresTestHostEnv)
+	}
+
+	admin, err := sql.Open("postgres", connectionString)
+	if err != nil {
+		t.Fatalf("sql.Open() = %v, want nil", err)
+	}
+	defer admin.Close()
+
+	schemaName := fmt.Sprintf("permission_test_%d", time.Now().UnixNano())
+	if _, err := admin.Exec("CREATE SCHEMA " + schemaName); err != nil {
+		t.Fatalf("CREATE SCHEMA = %v, want nil", err)
+	}
+
+	// Each connection of the store uses the new schema, so tests don't share a table.
+	db, err := sql.Open("postgres", connectionString+" search_path="+schemaName)
+	if err != nil {
+		t.Fatalf("sql.Open() = %v, want nil", err)
+	}
+
+	store, err := NewPostgresStore(db)
+	if err != nil {
+		t.Fatalf("NewPostgresStore() = %v, want nil", err)
+	}
+
+	return store, func() {
+		db.Exec("DROP SCHEMA " + schemaName + " CASCADE")
+		db.Close()
+	}
+}
+
+func TestStoreConformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) (service.Store, func()) {
+		return integrationStore(t)
+	})
+}
+
+func TestWhere(t *testing.T) {
+	tests := []struct {
+		name          string
+		filter        interface{}
+		wantCondition string
+		wantArgs      []interface{}
+	}{
+		{"nil", nil, "TRUE", []interface{}{"first"}},
+		{"empty", service.Filter{}, "TRUE", []interface{}{"first"}},
+		{
+			"file and user",
+			service.And(service.ByFile("file"), service.ByUser("user")),
+			"file_id = $2 AND user_id = $3",
+			[]interface{}{"first", "file", "user"},
+		},
+		{
+			"roles",
+			service.ByRoles(pb.Role_READ, pb.Role_WRITE),
+			"role IN ($2, $3)",
+			[]interface{}{"first", pb.Role_READ, pb.Role_WRITE},
+		},
+		{"deny", service.ByDeny(false), "deny = $2", []interface{}{"first", false}},
+	}
+
+	for _, tt := range tests {
+		condition, args, err := where(tt.filter, []interface{}{"first"})
+		if err != nil {
+			t.Fatalf("%s: where() error = %v, want nil", tt.name, err)
+		}
+
+		if condition != tt.wantCondition || !reflect.DeepEqual(args, tt.wantArgs) {
+			t.Errorf("%s: where() = %q, %v, want %q, %v", tt.name, condition, args, tt.wantCondition, tt.wantArgs)
+		}
+	}
+
+	if _, _, err := where(map[string]string{"fileID": "file"}, nil); err == nil {
+		t.Errorf("where() of a map filter returned a nil error")
+	}
+}
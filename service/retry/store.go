@@ -0,0 +1,298 @@
+package retry
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/mongodb"
+)
+
+const (
+	// DefaultMaxAttempts is the default number of times a RetryingStore operation is attempted.
+	DefaultMaxAttempts = 3
+
+	// DefaultBaseDelay is the default delay before the first retry of a RetryingStore operation,
+	// the delay is doubled on every following retry.
+	DefaultBaseDelay = 100 * time.Millisecond
+)
+
+// RetryingStore is a Store that retries the operations of the Store it wraps when they fail with a
+// transient error, by mongodb.IsRetryable, with exponential backoff. Other errors fail immediately.
+// CreateIfAbsent, Delete, UpdateRoleIfVersion and TransferOwnership are not retried since they aren't
+// idempotent, their first attempt may have been applied before it failed so a retry would fail even
+// though the operation succeeded.
+type RetryingStore struct {
+	inner       service.Store
+	maxAttempts int
+	baseDelay   time.Duration
+}
+
+// NewRetryingStore returns a RetryingStore that attempts each operation of inner up to maxAttempts
+// times, waiting baseDelay before the first retry. If maxAttempts is not positive then
+// DefaultMaxAttempts is used, and if baseDelay is not positive then DefaultBaseDelay is used.
+func NewRetryingStore(inner service.Store, maxAttempts int, baseDelay time.Duration) RetryingStore {
+	if maxAttempts <= 0 {
+		maxAttempts = DefaultMaxAttempts
+	}
+
+	if baseDelay <= 0 {
+		baseDelay = DefaultBaseDelay
+	}
+
+	return RetryingStore{inner: inner, maxAttempts: maxAttempts, baseDelay: baseDelay}
+}
+
+// Create runs inner's Create, retrying it on transient errors.
+func (s RetryingStore) Create(ctx context.Context, permission service.Permission) (service.Permission, error) {
+	var created service.Permission
+	err := s.retry(ctx, func() error {
+		var err error
+		created, err = s.inner.Create(ctx, permission)
+		return err
+	})
+
+	return created, err
+}
+
+// CreateIfAbsent runs inner's CreateIfAbsent without retrying it.
+func (s RetryingStore) CreateIfAbsent(
+	ctx context.Context,
+	permission service.Permission,
+) (service.Permission, error) {
+	return s.inner.CreateIfAbsent(ctx, permission)
+}
+
+// CreateMany runs inner's CreateMany, retrying it on transient errors.
+func (s RetryingStore) CreateMany(
+	ctx context.Context,
+	permissions []service.Permission,
+) ([]service.Permission, error) {
+	var created []service.Permission
+	err := s.retry(ctx, func() error {
+		var err error
+		created, err = s.inner.CreateMany(ctx, permissions)
+		return err
+	})
+
+	return created, err
+}
+
+// Get runs inner's Get, retrying it on transient errors.
+func (s RetryingStore) Get(ctx context.Context, filter interface{}) (service.Permission, error) {
+	var permission service.Permission
+	err := s.retry(ctx, func() error {
+		var err error
+		permission, err = s.inner.Get(ctx, filter)
+		return err
+	})
+
+	return permission, err
+}
+
+// GetAll runs inner's GetAll, retrying it on transient errors.
+func (s RetryingStore) GetAll(ctx context.Context, filter interface{}) ([]service.Permission, error) {
+	var permissions []service.Permission
+	err := s.retry(ctx, func() error {
+		var err error
+		permissions, err = s.inner.GetAll(ctx, filter)
+		return err
+	})
+
+	return permissions, err
+}
+
+// GetAllSorted runs inner's GetAllSorted, retrying it on transient errors.
+func (s RetryingStore) GetAllSorted(
+	ctx context.Context,
+	filter interface{},
+	sortBy service.SortBy,
+) ([]service.Permission, error) {
+	var permissions []service.Permission
+	err := s.retry(ctx, func() error {
+		var err error
+		permissions, err = s.inner.GetAllSorted(ctx, filter, sortBy)
+		return err
+	})
+
+	return permissions, err
+}
+
+// GetAllCursor runs inner's GetAllCursor, retrying it on transient errors,
+// the iteration itself is not retried.
+func (s RetryingStore) GetAllCursor(ctx context.Context, filter interface{}) (service.PermissionIterator, error) {
+	var iterator service.PermissionIterator
+	err := s.retry(ctx, func() error {
+		var err error
+		iterator, err = s.inner.GetAllCursor(ctx, filter)
+		return err
+	})
+
+	return iterator, err
+}
+
+// GetAllPaged runs inner's GetAllPaged, retrying it on transient errors.
+func (s RetryingStore) GetAllPaged(
+	ctx context.Context,
+	filter interface{},
+	pageSize int64,
+	pageToken string,
+) ([]service.Permission, string, error) {
+	var permissions []service.Permission
+	var nextPageToken string
+	err := s.retry(ctx, func() error {
+		var err error
+		permissions, nextPageToken, err = s.inner.GetAllPaged(ctx, filter, pageSize, pageToken)
+		return err
+	})
+
+	return permissions, nextPageToken, err
+}
+
+// Delete runs inner's Delete without retrying it.
+func (s RetryingStore) Delete(ctx context.Context, filter interface{}) (service.Permission, error) {
+	return s.inner.Delete(ctx, filter)
+}
+
+// DeleteMany runs inner's DeleteMany, retrying it on transient errors.
+func (s RetryingStore) DeleteMany(ctx context.Context, filter interface{}) (int64, error) {
+	var deleted int64
+	err := s.retry(ctx, func() error {
+		var err error
+		deleted, err = s.inner.DeleteMany(ctx, filter)
+		return err
+	})
+
+	return deleted, err
+}
+
+// UpdateRole runs inner's UpdateRole, retrying it on transient errors.
+func (s RetryingStore) UpdateRole(
+	ctx context.Context,
+	fileID string,
+	userID string,
+	role pb.Role,
+) (service.Permission, error) {
+	var updated service.Permission
+	err := s.retry(ctx, func() error {
+		var err error
+		updated, err = s.inner.UpdateRole(ctx, fileID, userID, role)
+		return err
+	})
+
+	return updated, err
+}
+
+// UpdateRoleIfVersion runs inner's UpdateRoleIfVersion without retrying it.
+func (s RetryingStore) UpdateRoleIfVersion(
+	ctx context.Context,
+	fileID string,
+	userID string,
+	role pb.Role,
+	expectedVersion int64,
+) (service.Permission, error) {
+	return s.inner.UpdateRoleIfVersion(ctx, fileID, userID, role, expectedVersion)
+}
+
+// Count runs inner's Count, retrying it on transient errors.
+func (s RetryingStore) Count(ctx context.Context, filter interface{}) (int64, error) {
+	var count int64
+	err := s.retry(ctx, func() error {
+		var err error
+		count, err = s.inner.Count(ctx, filter)
+		return err
+	})
+
+	return count, err
+}
+
+// Exists runs inner's Exists, retrying it on transient errors.
+func (s RetryingStore) Exists(ctx context.Context, fileID string, userID string) (bool, error) {
+	var exists bool
+	err := s.retry(ctx, func() error {
+		var err error
+		exists, err = s.inner.Exists(ctx, fileID, userID)
+		return err
+	})
+
+	return exists, err
+}
+
+// ExistsMatching runs inner's ExistsMatching, retrying it on transient errors.
+func (s RetryingStore) ExistsMatching(ctx context.Context, filter interface{}) (bool, error) {
+	var exists bool
+	err := s.retry(ctx, func() error {
+		var err error
+		exists, err = s.inner.ExistsMatching(ctx, filter)
+		return err
+	})
+
+	return exists, err
+}
+
+// DeleteAllByFileID runs inner's DeleteAllByFileID, retrying it on transient errors.
+func (s RetryingStore) DeleteAllByFileID(ctx context.Context, fileID string) (int64, error) {
+	var deleted int64
+	err := s.retry(ctx, func() error {
+		var err error
+		deleted, err = s.inner.DeleteAllByFileID(ctx, fileID)
+		return err
+	})
+
+	return deleted, err
+}
+
+// DeleteAllByUserID runs inner's DeleteAllByUserID, retrying it on transient errors.
+func (s RetryingStore) DeleteAllByUserID(ctx context.Context, userID string) (int64, error) {
+	var deleted int64
+	err := s.retry(ctx, func() error {
+		var err error
+		deleted, err = s.inner.DeleteAllByUserID(ctx, userID)
+		return err
+	})
+
+	return deleted, err
+}
+
+// TransferOwnership runs inner's TransferOwnership without retrying it.
+func (s RetryingStore) TransferOwnership(
+	ctx context.Context,
+	fileID string,
+	fromUserID string,
+	toUserID string,
+) error {
+	return s.inner.TransferOwnership(ctx, fileID, fromUserID, toUserID)
+}
+
+// HealthCheck runs inner's HealthCheck without retrying it, so failures are reported promptly.
+func (s RetryingStore) HealthCheck(ctx context.Context) (service.HealthResult, error) {
+	return s.inner.HealthCheck(ctx)
+}
+
+// HealthStatus runs inner's HealthStatus without retrying it, the same as HealthCheck.
+func (s RetryingStore) HealthStatus(ctx context.Context) (service.HealthStatus, error) {
+	return s.inner.HealthStatus(ctx)
+}
+
+// retry runs op, and while op fails with a retryable error it retries op with exponential backoff
+// until it was attempted s.maxAttempts times. Retrying stops if ctx is done while waiting for the
+// next attempt. Returns the error of the last attempt.
+func (s RetryingStore) retry(ctx context.Context, op func() error) error {
+	delay := s.baseDelay
+	err := op()
+	for attempt := 1; attempt < s.maxAttempts && mongodb.IsRetryable(err); attempt++ {
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+
+		delay *= 2
+		err = op()
+	}
+
+	return err
+}
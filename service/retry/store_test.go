@@ -0,0 +1,134 @@
+package retry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/memory"
+	"github.com/meateam/permission-service/service/mongodb"
+	"go.mongodb.org/mongo-driver/mongo"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// transientError is a server error labeled as safe to retry.
+var transientError = mongo.CommandError{Code: 91, Message: "shutting down", Labels: []string{"NetworkError"}}
+
+// flakyStore is a Store whose Get and Delete fail with err on their first failures calls.
+type flakyStore struct {
+	service.Store
+	failures int
+	err      error
+	calls    int
+}
+
+// Get fails with s.err on the first s.failures calls, then returns the inner store's Get.
+func (s *flakyStore) Get(ctx context.Context, filter interface{}) (service.Permission, error) {
+	s.calls++
+	if s.calls <= s.failures {
+		return nil, s.err
+	}
+
+	return s.Store.Get(ctx, filter)
+}
+
+// Delete fails with s.err on the first s.failures calls, then returns the inner store's Delete.
+func (s *flakyStore) Delete(ctx context.Context, filter interface{}) (service.Permission, error) {
+	s.calls++
+	if s.calls <= s.failures {
+		return nil, s.err
+	}
+
+	return s.Store.Delete(ctx, filter)
+}
+
+// newFlakyStore returns a flakyStore with the permission of user to file that fails with err
+// on its first failures calls.
+func newFlakyStore(t *testing.T, failures int, err error) *flakyStore {
+	t.Helper()
+
+	inner := memory.NewMemoryStore()
+	permission := &memory.Permission{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"}
+	if _, err := inner.Create(context.Background(), permission); err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	return &flakyStore{Store: inner, failures: failures, err: err}
+}
+
+func TestRetriesTransientErrors(t *testing.T) {
+	inner := newFlakyStore(t, 2, transientError)
+	store := NewRetryingStore(inner, 3, time.Millisecond)
+
+	permission, err := store.Get(context.Background(), mongodb.FilterByFileAndUser("file", "user"))
+	if err != nil {
+		t.Fatalf("Get() = %v, want nil", err)
+	}
+
+	if permission.GetRole() != pb.Role_READ {
+		t.Errorf("Get() role = %v, want %v", permission.GetRole(), pb.Role_READ)
+	}
+
+	if inner.calls != 3 {
+		t.Errorf("inner store Get calls = %d, want 3", inner.calls)
+	}
+}
+
+func TestGivesUpAfterMaxAttempts(t *testing.T) {
+	inner := newFlakyStore(t, 2, transientError)
+	store := NewRetryingStore(inner, 2, time.Millisecond)
+
+	if _, err := store.Get(context.Background(), mongodb.FilterByFileAndUser("file", "user")); err == nil {
+		t.Fatal("Get() = nil, want the last error")
+	}
+
+	if inner.calls != 2 {
+		t.Errorf("inner store Get calls = %d, want 2", inner.calls)
+	}
+}
+
+func TestPermanentErrorsFailFast(t *testing.T) {
+	invalid := status.Error(codes.InvalidArgument, "invalid")
+	inner := newFlakyStore(t, 2, invalid)
+	store := NewRetryingStore(inner, 3, time.Millisecond)
+
+	if _, err := store.Get(context.Background(), mongodb.FilterByFileAndUser("file", "user")); err != invalid {
+		t.Fatalf("Get() = %v, want %v", err, invalid)
+	}
+
+	if inner.calls != 1 {
+		t.Errorf("inner store Get calls = %d, want 1", inner.calls)
+	}
+}
+
+func TestStopsRetryingWhenContextIsDone(t *testing.T) {
+	inner := newFlakyStore(t, 2, transientError)
+	store := NewRetryingStore(inner, 3, time.Hour)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if _, err := store.Get(ctx, mongodb.FilterByFileAndUser("file", "user")); err == nil {
+		t.Fatal("Get() = nil, want the first error")
+	}
+
+	if inner.calls != 1 {
+		t.Errorf("inner store Get calls = %d, want 1", inner.calls)
+	}
+}
+
+func TestDeleteIsNotRetried(t *testing.T) {
+	inner := newFlakyStore(t, 1, transientError)
+	store := NewRetryingStore(inner, 3, time.Millisecond)
+
+	if _, err := store.Delete(context.Background(), mongodb.FilterByFileAndUser("file", "user")); err == nil {
+		t.Fatal("Delete() = nil, want the transient error")
+	}
+
+	if inner.calls != 1 {
+		t.Errorf("inner store Delete calls = %d, want 1", inner.calls)
+	}
+}
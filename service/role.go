@@ -0,0 +1,135 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+
+	pb "github.com/meateam/permission-service/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Role is the access that a permission grants, its values are the same as pb.Role's values.
+type Role pb.Role
+
+const (
+	// RoleNone grants no access.
+	RoleNone = Role(pb.Role_NONE)
+
+	// RoleRead grants read access.
+	RoleRead = Role(pb.Role_READ)
+
+	// RoleWrite grants write access, and read access.
+	RoleWrite = Role(pb.Role_WRITE)
+
+	// RoleOwner grants full access to a file.
+	RoleOwner = Role(pb.Role_OWNER)
+)
+
+// roleRanks maps each role to its rank in the role hierarchy,
+// a role grants the access of every role ranked below it.
+var roleRanks = map[Role]int{
+	RoleNone:  0,
+	RoleRead:  1,
+	RoleWrite: 2,
+	RoleOwner: 3,
+}
+
+// grantableRoles are the roles a permission can grant, ordered from the lowest ranked.
+var grantableRoles = []Role{RoleRead, RoleWrite, RoleOwner}
+
+// Roles returns the roles a permission can grant, ordered from the lowest ranked,
+// each role includes every role before it. NONE is not included since it can't be granted.
+func Roles() []Role {
+	return append([]Role(nil), grantableRoles...)
+}
+
+// RoleRank returns the rank of role in the role hierarchy, a role grants the access of every
+// role ranked below it and NONE is ranked the lowest, returns ok as false if role doesn't exist.
+func RoleRank(role Role) (rank int, ok bool) {
+	rank, ok = roleRanks[role]
+	return rank, ok
+}
+
+// String returns the canonical name of r.
+func (r Role) String() string {
+	return pb.Role(r).String()
+}
+
+// Includes returns true if r grants at least the access that other grants, so WRITE includes READ,
+// otherwise returns false. NONE is never included, even in itself.
+func (r Role) Includes(other Role) bool {
+	if other == RoleNone {
+		return false
+	}
+
+	rank, ok := roleRanks[r]
+	if !ok {
+		return false
+	}
+
+	otherRank, ok := roleRanks[other]
+	if !ok {
+		return false
+	}
+
+	return rank >= otherRank
+}
+
+// RolesIncluding returns the roles that include r, ordered from the lowest ranked,
+// NONE is never included so no roles include it.
+func RolesIncluding(r Role) []pb.Role {
+	roles := []pb.Role{}
+	for _, role := range grantableRoles {
+		if role.Includes(r) {
+			roles = append(roles, pb.Role(role))
+		}
+	}
+
+	return roles
+}
+
+// RoleFromString returns the role named name, ignoring case and surrounding whitespace,
+// returns an InvalidArgument error if no such role exists.
+func RoleFromString(name string) (Role, error) {
+	role, ok := pb.Role_value[strings.ToUpper(strings.TrimSpace(name))]
+	if !ok {
+		return RoleNone, status.Errorf(codes.InvalidArgument, "role %q does not exist", name)
+	}
+
+	return Role(role), nil
+}
+
+// ValidateRole returns an InvalidArgumentError if role can't be granted by a permission, since it's
+// not a known role or it's NONE, otherwise returns nil.
+func ValidateRole(role pb.Role) error {
+	if description := roleViolation(role); description != "" {
+		return InvalidArgumentError("role", description)
+	}
+
+	return nil
+}
+
+// roleViolation returns the reason role can't be granted by a permission along with the roles that
+// can be, or an empty string if it can be.
+func roleViolation(role pb.Role) string {
+	if pb.Role_name[int32(role)] == "" {
+		return fmt.Sprintf("%d does not exist, must be one of %s", role, grantableRoleNames())
+	}
+
+	if role == pb.Role_NONE {
+		return fmt.Sprintf("must not be NONE, must be one of %s", grantableRoleNames())
+	}
+
+	return ""
+}
+
+// grantableRoleNames returns the comma separated names of the roles a permission can grant.
+func grantableRoleNames() string {
+	names := make([]string, 0, len(grantableRoles))
+	for _, role := range grantableRoles {
+		names = append(names, role.String())
+	}
+
+	return strings.Join(names, ", ")
+}
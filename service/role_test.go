@@ -0,0 +1,119 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRoleIncludes(t *testing.T) {
+	tests := []struct {
+		role  Role
+		other Role
+		want  bool
+	}{
+		{role: RoleOwner, other: RoleWrite, want: true},
+		{role: RoleOwner, other: RoleRead, want: true},
+		{role: RoleWrite, other: RoleRead, want: true},
+		{role: RoleWrite, other: RoleWrite, want: true},
+		{role: RoleRead, other: RoleWrite, want: false},
+		{role: RoleWrite, other: RoleOwner, want: false},
+		{role: RoleNone, other: RoleRead, want: false},
+		{role: RoleOwner, other: RoleNone, want: false},
+		{role: RoleNone, other: RoleNone, want: false},
+		{role: Role(42), other: RoleRead, want: false},
+	}
+
+	for _, tt := range tests {
+		if got := tt.role.Includes(tt.other); got != tt.want {
+			t.Errorf("%v.Includes(%v) = %v, want %v", tt.role, tt.other, got, tt.want)
+		}
+	}
+}
+
+func TestRoleFromString(t *testing.T) {
+	tests := []struct {
+		name string
+		want Role
+	}{
+		{name: "READ", want: RoleRead},
+		{name: "read", want: RoleRead},
+		{name: " Write ", want: RoleWrite},
+		{name: "owner", want: RoleOwner},
+	}
+
+	for _, tt := range tests {
+		got, err := RoleFromString(tt.name)
+		if err != nil {
+			t.Errorf("RoleFromString(%q) error = %v", tt.name, err)
+			continue
+		}
+
+		if got != tt.want {
+			t.Errorf("RoleFromString(%q) = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := RoleFromString("Reader"); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("RoleFromString(%q) error code = %v, want %v", "Reader", status.Code(err), codes.InvalidArgument)
+	}
+}
+
+func TestRolesIncluding(t *testing.T) {
+	tests := []struct {
+		role Role
+		want []pb.Role
+	}{
+		{role: RoleRead, want: []pb.Role{pb.Role_READ, pb.Role_WRITE, pb.Role_OWNER}},
+		{role: RoleWrite, want: []pb.Role{pb.Role_WRITE, pb.Role_OWNER}},
+		{role: RoleOwner, want: []pb.Role{pb.Role_OWNER}},
+		{role: RoleNone, want: []pb.Role{}},
+	}
+
+	for _, tt := range tests {
+		if got := RolesIncluding(tt.role); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("RolesIncluding(%v) = %v, want %v", tt.role, got, tt.want)
+		}
+	}
+}
+
+func TestRoles(t *testing.T) {
+	want := []Role{RoleRead, RoleWrite, RoleOwner}
+	if got := Roles(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("Roles() = %v, want %v", got, want)
+	}
+
+	Roles()[0] = RoleOwner
+	if got := Roles(); !reflect.DeepEqual(got, want) {
+		t.Errorf("Roles() after modifying a previous result = %v, want %v", got, want)
+	}
+}
+
+func TestRoleRank(t *testing.T) {
+	ranks := map[int]Role{}
+	previous := -1
+	for _, role := range append([]Role{RoleNone}, Roles()...) {
+		rank, ok := RoleRank(role)
+		if !ok {
+			t.Errorf("RoleRank(%v) = _, false, want true", role)
+			continue
+		}
+
+		if other, ok := ranks[rank]; ok {
+			t.Errorf("RoleRank(%v) = %d, want a unique rank but %v has it too", role, rank, other)
+		}
+		ranks[rank] = role
+
+		if rank <= previous {
+			t.Errorf("RoleRank(%v) = %d, want it ranked above %d", role, rank, previous)
+		}
+		previous = rank
+	}
+
+	if _, ok := RoleRank(Role(42)); ok {
+		t.Errorf("RoleRank(%v) = _, true, want false", Role(42))
+	}
+}
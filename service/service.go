@@ -7,25 +7,44 @@ import (
 
 	pb "github.com/meateam/permission-service/proto"
 	"github.com/sirupsen/logrus"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 // Service is a structure used for handling Permission Service grpc requests.
 type Service struct {
 	controller Controller
 	logger     *logrus.Logger
+	validator  Validator
 }
 
 // HealthCheck checks the health of the service, returns true if healthy, or false otherwise.
+// The reason the service is unhealthy is logged.
 func (s Service) HealthCheck(mongoClientPingTimeout time.Duration) bool {
 	timeoutCtx, cancel := context.WithTimeout(context.TODO(), mongoClientPingTimeout)
 	defer cancel()
-	healthy, err := s.controller.HealthCheck(timeoutCtx)
+	result, err := s.controller.HealthCheck(timeoutCtx)
 	if err != nil {
 		s.logger.Errorf("%v", err)
 		return false
 	}
 
-	return healthy
+	if !result.Healthy {
+		s.logger.Warnf("unhealthy: %s", result.Reason)
+	}
+
+	return result.Healthy
+}
+
+// HealthStatus returns the health of the database the service depends on, and the error that occurred
+// while checking it if any, such as the primary's permissions collection being missing or unqueryable
+// while the primary itself is reachable.
+func (s Service) HealthStatus(mongoClientPingTimeout time.Duration) (HealthStatus, error) {
+	timeoutCtx, cancel := context.WithTimeout(context.TODO(), mongoClientPingTimeout)
+	defer cancel()
+
+	return s.controller.HealthStatus(timeoutCtx)
 }
 
 // NewService creates a Service and returns it.
@@ -33,7 +52,15 @@ func NewService(controller Controller, logger *logrus.Logger) Service {
 	return Service{controller: controller, logger: logger}
 }
 
-// CreatePermission is the request handler for creating a permission of a file to user.
+// WithValidator returns a copy of s that validates the fileIDs and userIDs of requests with validator
+// instead of DefaultValidator.
+func (s Service) WithValidator(validator Validator) Service {
+	s.validator = validator
+	return s
+}
+
+// CreatePermission is the request handler for creating a permission of a file to user,
+// if failIfExists is set it fails with AlreadyExists instead of updating an existing permission.
 func (s Service) CreatePermission(
 	ctx context.Context,
 	req *pb.CreatePermissionRequest,
@@ -42,23 +69,37 @@ func (s Service) CreatePermission(
 	userID := req.GetUserID()
 	role := req.GetRole()
 	creator := req.GetCreator()
-	if userID == "" {
-		return nil, fmt.Errorf("userID is required")
+	expiresAt := req.GetExpiresAt()
+	if err := validatePermissionFields(s.validator, "", req); err != nil {
+		return nil, err
 	}
 
-	if fileID == "" {
-		return nil, fmt.Errorf("fileID is required")
+	if expiresAt < 0 {
+		return nil, InvalidArgumentError("expiresAt", "must not be negative")
 	}
 
-	if pb.Role_name[int32(role)] == "" {
-		return nil, fmt.Errorf("role does not exist")
+	var expiresAtTime time.Time
+	if expiresAt != 0 {
+		expiresAtTime = time.Unix(expiresAt, 0)
 	}
 
-	if creator == "" {
-		return nil, fmt.Errorf("creator is required")
+	createPermission := s.controller.CreatePermission
+	if req.GetFailIfExists() {
+		createPermission = s.controller.CreatePermissionIfAbsent
 	}
 
-	permission, err := s.controller.CreatePermission(ctx, fileID, userID, role, creator)
+	permission, err := createPermission(
+		ctx,
+		fileID,
+		userID,
+		role,
+		creator,
+		expiresAtTime,
+		req.GetMetadata(),
+		req.GetReason(),
+		req.GetSubjectType(),
+		req.GetDeny(),
+	)
 	if err != nil {
 		return nil, err
 	}
@@ -71,22 +112,177 @@ func (s Service) CreatePermission(
 	return &response, nil
 }
 
+// CreatePermissions is the request handler for creating many permissions at once.
+func (s Service) CreatePermissions(
+	ctx context.Context,
+	req *pb.CreatePermissionsRequest,
+) (*pb.CreatePermissionsResponse, error) {
+	requestedPermissions := req.GetPermissions()
+	for i, permission := range requestedPermissions {
+		prefix := fmt.Sprintf("permissions[%d].", i)
+		if err := validatePermissionFields(s.validator, prefix, permission); err != nil {
+			return nil, err
+		}
+
+		if permission.GetExpiresAt() < 0 {
+			return nil, InvalidArgumentError(prefix+"expiresAt", "must not be negative")
+		}
+	}
+
+	permissions, err := s.controller.CreatePermissions(ctx, requestedPermissions)
+	createErr, partial := err.(*CreateManyError)
+	if err != nil && !partial {
+		return nil, err
+	}
+
+	response := &pb.CreatePermissionsResponse{Permissions: make([]*pb.PermissionObject, 0, len(permissions))}
+	for _, permission := range permissions {
+		var permissionObject pb.PermissionObject
+		if err := permission.MarshalProto(&permissionObject); err != nil {
+			return nil, err
+		}
+
+		response.Permissions = append(response.Permissions, &permissionObject)
+	}
+
+	if partial {
+		return nil, createManyStatusError(createErr, response)
+	}
+
+	return response, nil
+}
+
+// createManyStatusError returns an Aborted status error of err, with a BadRequest detail naming
+// each permission that failed by its index, and a detail of the created permissions so clients
+// know which of the permissions were created.
+// If the permissions were written but reading them back failed, it returns an Unavailable status
+// error without the created permissions detail, since it has no created permissions to report.
+// Creating the permissions is an upsert, so the client can safely retry the whole request.
+func createManyStatusError(err *CreateManyError, created *pb.CreatePermissionsResponse) error {
+	code := codes.Aborted
+	if err.ReadErr != nil {
+		code = codes.Unavailable
+	}
+
+	st := status.New(code, err.Error())
+	badRequest := &errdetails.BadRequest{}
+	for _, index := range err.FailedIndexes() {
+		badRequest.FieldViolations = append(badRequest.FieldViolations, &errdetails.BadRequest_FieldViolation{
+			Field:       fmt.Sprintf("permissions[%d]", index),
+			Description: err.Failed[index].Error(),
+		})
+	}
+
+	detailed, detailsErr := st.WithDetails(badRequest, created)
+	if err.ReadErr != nil {
+		detailed, detailsErr = st.WithDetails(badRequest)
+	}
+
+	if detailsErr != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
+
 // GetFilePermissions is the request handler for retrieving permissions of file by its ID.
 func (s Service) GetFilePermissions(
 	ctx context.Context,
 	req *pb.GetFilePermissionsRequest,
 ) (*pb.GetFilePermissionsResponse, error) {
 	fileID := req.GetFileID()
-	if fileID == "" {
-		return nil, fmt.Errorf("fileID is required")
+	pageSize := req.GetPageSize()
+	if err := ValidateID(s.validator, "fileID", fileID); err != nil {
+		return nil, err
+	}
+
+	if pageSize < 0 {
+		return nil, InvalidArgumentError("pageSize", "must not be negative")
+	}
+
+	if pageSize == 0 && req.GetPageToken() != "" {
+		return nil, InvalidArgumentError("pageToken", "requires a positive pageSize")
 	}
 
-	filePermissions, err := s.controller.GetFilePermissions(ctx, fileID)
+	for i, role := range req.GetRoles() {
+		if description := roleViolation(role); description != "" {
+			return nil, InvalidArgumentError(fmt.Sprintf("roles[%d]", i), description)
+		}
+	}
+
+	sortBy, err := ParseSortBy(req.GetSortBy(), req.GetSortDescending())
+	if err != nil {
+		return nil, err
+	}
+
+	// Pages are always sorted by creation order, since their tokens are the ID of their last permission.
+	if sortBy != SortByID && pageSize != 0 {
+		return nil, InvalidArgumentError("sortBy", "requires a pageSize of 0")
+	}
+
+	filePermissions, nextPageToken, err := s.controller.GetFilePermissions(
+		ctx,
+		fileID,
+		req.GetRoles(),
+		sortBy,
+		pageSize,
+		req.GetPageToken(),
+	)
 	if err != nil {
 		return nil, err
 	}
 
-	return &pb.GetFilePermissionsResponse{Permissions: filePermissions}, nil
+	return &pb.GetFilePermissionsResponse{Permissions: filePermissions, NextPageToken: nextPageToken}, nil
+}
+
+// StreamFilePermissions is the request handler for streaming the permissions of a file one by one,
+// the streaming stops if the client disconnects.
+func (s Service) StreamFilePermissions(
+	req *pb.StreamFilePermissionsRequest,
+	stream pb.Permission_StreamFilePermissionsServer,
+) error {
+	fileID := req.GetFileID()
+	if err := ValidateID(s.validator, "fileID", fileID); err != nil {
+		return err
+	}
+
+	return s.controller.StreamFilePermissions(stream.Context(), fileID, func(permission Permission) error {
+		var response pb.PermissionObject
+		if err := permission.MarshalProto(&response); err != nil {
+			return err
+		}
+
+		return stream.Send(&response)
+	})
+}
+
+// WatchPermissions is the request handler for streaming the changes of a file's permissions as they
+// happen, starting right after the request's resumeToken if it's set, the watching stops if the client
+// disconnects.
+func (s Service) WatchPermissions(
+	req *pb.WatchPermissionsRequest,
+	stream pb.Permission_WatchPermissionsServer,
+) error {
+	fileID := req.GetFileID()
+	if err := ValidateID(s.validator, "fileID", fileID); err != nil {
+		return err
+	}
+
+	ctx := stream.Context()
+	if resumeToken := req.GetResumeToken(); len(resumeToken) > 0 {
+		ctx = WithResumeToken(ctx, resumeToken)
+	}
+
+	return s.controller.WatchFilePermissions(ctx, fileID, func(event PermissionEvent) error {
+		return stream.Send(&pb.PermissionEvent{
+			Type:        string(event.Type),
+			Id:          event.ID,
+			FileID:      event.FileID,
+			UserID:      event.UserID,
+			Role:        event.Role,
+			ResumeToken: event.ResumeToken,
+		})
+	})
 }
 
 // DeletePermission is the request handler for deleting permission by its ID.
@@ -96,12 +292,12 @@ func (s Service) DeletePermission(
 	fileID := req.GetFileID()
 	userID := req.GetUserID()
 
-	if userID == "" {
-		return nil, fmt.Errorf("userID is required")
+	if err := ValidateID(s.validator, "userID", userID); err != nil {
+		return nil, err
 	}
 
-	if fileID == "" {
-		return nil, fmt.Errorf("fileID is required")
+	if err := ValidateID(s.validator, "fileID", fileID); err != nil {
+		return nil, err
 	}
 
 	permission, err := s.controller.DeletePermission(ctx, fileID, userID)
@@ -117,16 +313,59 @@ func (s Service) DeletePermission(
 	return &response, nil
 }
 
-// GetPermission is the request handler for retrieving a permission by a user and file ids.
+// UpdatePermission is the request handler for updating the role of an existing permission.
+func (s Service) UpdatePermission(
+	ctx context.Context,
+	req *pb.UpdatePermissionRequest,
+) (*pb.PermissionObject, error) {
+	fileID := req.GetFileID()
+	userID := req.GetUserID()
+	role := req.GetRole()
+	version := req.GetVersion()
+	if err := ValidateID(s.validator, "userID", userID); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateID(s.validator, "fileID", fileID); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateRole(role); err != nil {
+		return nil, err
+	}
+
+	if version < 0 {
+		return nil, InvalidArgumentError("version", "must not be negative")
+	}
+
+	permission, err := s.controller.UpdatePermission(ctx, fileID, userID, role, version)
+	if err != nil {
+		return nil, err
+	}
+
+	var response pb.PermissionObject
+	if err = permission.MarshalProto(&response); err != nil {
+		return nil, err
+	}
+
+	return &response, nil
+}
+
+// GetPermission is the request handler for retrieving a permission by a user and file ids,
+// with only the fields of the request's field mask if it has one.
 func (s Service) GetPermission(ctx context.Context, req *pb.GetPermissionRequest) (*pb.PermissionObject, error) {
 	fileID := req.GetFileID()
 	userID := req.GetUserID()
-	if userID == "" {
-		return nil, fmt.Errorf("UserID is required")
+	if err := ValidateID(s.validator, "userID", userID); err != nil {
+		return nil, err
 	}
 
-	if fileID == "" {
-		return nil, fmt.Errorf("FileID is required")
+	if err := ValidateID(s.validator, "fileID", fileID); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateFieldMask(req.GetFields()); err != nil {
+		return nil, err
 	}
 
 	permission, err := s.controller.GetByFileAndUser(ctx, fileID, userID)
@@ -139,7 +378,7 @@ func (s Service) GetPermission(ctx context.Context, req *pb.GetPermissionRequest
 		return nil, err
 	}
 
-	return &response, nil
+	return MaskPermissionObject(&response, req.GetFields()), nil
 }
 
 // IsPermitted is the request handler for checking user permission by userID and fileID.
@@ -147,24 +386,23 @@ func (s Service) IsPermitted(ctx context.Context, req *pb.IsPermittedRequest) (*
 	fileID := req.GetFileID()
 	userID := req.GetUserID()
 	role := req.GetRole()
-	if userID == "" {
-		return nil, fmt.Errorf("UserID is required")
+	if err := ValidateID(s.validator, "userID", userID); err != nil {
+		return nil, err
 	}
 
-	if fileID == "" {
-		return nil, fmt.Errorf("FileID is required")
+	if err := ValidateID(s.validator, "fileID", fileID); err != nil {
+		return nil, err
 	}
 
-	if pb.Role_name[int32(role)] == "" {
-		return nil, fmt.Errorf("role does not exist")
+	if err := ValidateRole(role); err != nil {
+		return nil, err
 	}
 
-	permission, err := s.controller.GetByFileAndUser(ctx, fileID, userID)
+	isPermitted, err := s.controller.IsPermitted(ctx, fileID, userID, role)
 	if err != nil {
 		return &pb.IsPermittedResponse{Permitted: false}, err
 	}
 
-	isPermitted := isSubRole(permission.GetRole(), role)
 	return &pb.IsPermittedResponse{Permitted: isPermitted}, nil
 }
 
@@ -173,26 +411,40 @@ func (s Service) GetUserPermissions(
 	ctx context.Context,
 	req *pb.GetUserPermissionsRequest) (*pb.GetUserPermissionsResponse, error) {
 	userID := req.GetUserID()
-	if userID == "" {
-		return nil, fmt.Errorf("userID is required")
+	pageSize := req.GetPageSize()
+	if err := ValidateID(s.validator, "userID", userID); err != nil {
+		return nil, err
+	}
+
+	if pageSize < 0 {
+		return nil, InvalidArgumentError("pageSize", "must not be negative")
+	}
+
+	if pageSize == 0 && req.GetPageToken() != "" {
+		return nil, InvalidArgumentError("pageToken", "requires a positive pageSize")
 	}
 
-	permissions, err := s.controller.GetUserPermissions(ctx, userID)
+	permissions, nextPageToken, err := s.controller.GetUserPermissions(ctx, userID, pageSize, req.GetPageToken())
 	if err != nil {
 		return nil, err
 	}
 
-	return &pb.GetUserPermissionsResponse{Permissions: permissions}, nil
+	return &pb.GetUserPermissionsResponse{Permissions: permissions, NextPageToken: nextPageToken}, nil
 }
 
-// DeleteFilePermissions is the request handler for deleting all permissions that exist for a certain file.
+// DeleteFilePermissions is the request handler for deleting all permissions that exist for a certain file,
+// a dry run returns the permissions that would be deleted without deleting them.
 func (s Service) DeleteFilePermissions(
 	ctx context.Context,
 	req *pb.DeleteFilePermissionsRequest,
 ) (*pb.DeleteFilePermissionsResponse, error) {
 	fileID := req.GetFileID()
-	if fileID == "" {
-		return nil, fmt.Errorf("fileID is required")
+	if err := ValidateID(s.validator, "fileID", fileID); err != nil {
+		return nil, err
+	}
+
+	if req.GetDryRun() {
+		ctx = WithDryRun(ctx)
 	}
 
 	permissions, err := s.controller.DeleteFilePermissions(ctx, fileID)
@@ -203,19 +455,79 @@ func (s Service) DeleteFilePermissions(
 	return &pb.DeleteFilePermissionsResponse{Permissions: permissions}, nil
 }
 
-func isSubRole(role pb.Role, wanted pb.Role) bool {
-	if wanted == pb.Role_NONE {
-		return false
+// DeleteUserPermissions is the request handler for deleting all permissions that a certain user has,
+// a dry run returns the permissions that would be deleted without deleting them.
+func (s Service) DeleteUserPermissions(
+	ctx context.Context,
+	req *pb.DeleteUserPermissionsRequest,
+) (*pb.DeleteUserPermissionsResponse, error) {
+	userID := req.GetUserID()
+	if err := ValidateID(s.validator, "userID", userID); err != nil {
+		return nil, err
 	}
 
-	switch role {
-	case pb.Role_NONE:
-		return false
-	case pb.Role_WRITE:
-		return wanted == pb.Role_WRITE || wanted == pb.Role_READ
-	case pb.Role_READ:
-		return wanted == pb.Role_READ
-	default:
-		return false
+	if req.GetDryRun() {
+		ctx = WithDryRun(ctx)
+	}
+
+	permissions, err := s.controller.DeleteUserPermissions(ctx, userID)
+	if err != nil {
+		return nil, err
 	}
+
+	return &pb.DeleteUserPermissionsResponse{Permissions: permissions}, nil
+}
+
+// CountPermissions is the request handler for counting the permissions that exist for a certain file.
+func (s Service) CountPermissions(
+	ctx context.Context,
+	req *pb.CountPermissionsRequest,
+) (*pb.CountPermissionsResponse, error) {
+	fileID := req.GetFileID()
+	if err := ValidateID(s.validator, "fileID", fileID); err != nil {
+		return nil, err
+	}
+
+	count, err := s.controller.CountFilePermissions(ctx, fileID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &pb.CountPermissionsResponse{Count: count}, nil
+}
+
+// TransferOwnership is the request handler for transferring the ownership of a file to another user,
+// a dry run only checks that the transfer would succeed.
+func (s Service) TransferOwnership(
+	ctx context.Context,
+	req *pb.TransferOwnershipRequest,
+) (*pb.TransferOwnershipResponse, error) {
+	fileID := req.GetFileID()
+	fromUserID := req.GetFromUserID()
+	toUserID := req.GetToUserID()
+	if err := ValidateID(s.validator, "fileID", fileID); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateID(s.validator, "fromUserID", fromUserID); err != nil {
+		return nil, err
+	}
+
+	if err := ValidateID(s.validator, "toUserID", toUserID); err != nil {
+		return nil, err
+	}
+
+	if fromUserID == toUserID {
+		return nil, InvalidArgumentError("toUserID", "must be different from fromUserID")
+	}
+
+	if req.GetDryRun() {
+		ctx = WithDryRun(ctx)
+	}
+
+	if err := s.controller.TransferOwnership(ctx, fileID, fromUserID, toUserID); err != nil {
+		return nil, err
+	}
+
+	return &pb.TransferOwnershipResponse{}, nil
 }
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"sort"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// SortBy is the order a Store's GetAllSorted returns permissions in.
+type SortBy int
+
+const (
+	// SortByID sorts permissions by their ascending ID, it's the default order
+	// and it's stable since IDs are unique.
+	SortByID SortBy = iota
+
+	// SortByRoleAscending sorts permissions from the role that grants the least access to the most.
+	SortByRoleAscending
+
+	// SortByRoleDescending sorts permissions from the role that grants the most access to the least,
+	// so the owner is first.
+	SortByRoleDescending
+
+	// SortByUserIDAscending sorts permissions by their ascending userID.
+	SortByUserIDAscending
+
+	// SortByUserIDDescending sorts permissions by their descending userID.
+	SortByUserIDDescending
+
+	// SortByCreationTimeAscending sorts permissions from the oldest to the newest.
+	SortByCreationTimeAscending
+
+	// SortByCreationTimeDescending sorts permissions from the newest to the oldest.
+	SortByCreationTimeDescending
+)
+
+// Sort fields are the fields permissions can be listed by, each is backed by an index so
+// sorting by it doesn't scan all of a file's permissions.
+const (
+	// SortFieldRole sorts permissions by the access their role grants.
+	SortFieldRole = "role"
+
+	// SortFieldUserID sorts permissions alphabetically by their userID.
+	SortFieldUserID = "userID"
+
+	// SortFieldCreatedAt sorts permissions by the time they were granted.
+	SortFieldCreatedAt = "createdAt"
+)
+
+// sortFieldOrders maps each allowed sort field to its ascending and descending orders.
+var sortFieldOrders = map[string][2]SortBy{
+	SortFieldRole:      {SortByRoleAscending, SortByRoleDescending},
+	SortFieldUserID:    {SortByUserIDAscending, SortByUserIDDescending},
+	SortFieldCreatedAt: {SortByCreationTimeAscending, SortByCreationTimeDescending},
+}
+
+// ParseSortBy returns the order of sorting by field, descending if descending is true.
+// An empty field is the default order, SortByID, which can't be descending.
+// Returns an InvalidArgumentError of the sortBy field if field isn't one of the sort fields.
+func ParseSortBy(field string, descending bool) (SortBy, error) {
+	if field == "" {
+		if descending {
+			return SortByID, InvalidArgumentError("sortDescending", "requires sortBy")
+		}
+
+		return SortByID, nil
+	}
+
+	orders, ok := sortFieldOrders[field]
+	if !ok {
+		return SortByID, InvalidArgumentError("sortBy",
+			"must be one of "+SortFieldRole+", "+SortFieldUserID+" or "+SortFieldCreatedAt)
+	}
+
+	if descending {
+		return orders[1], nil
+	}
+
+	return orders[0], nil
+}
+
+// SortPermissions stably sorts permissions by sortBy in memory, for the stores that can't sort
+// them while reading. Permission IDs are ObjectIDs, so sorting them sorts by creation time.
+// Permissions with equal fields are sorted by their ascending ID.
+// Returns an InvalidArgument error if sortBy is unknown, in which case permissions are left as they are.
+func SortPermissions(permissions []Permission, sortBy SortBy) error {
+	if sortBy < SortByID || sortBy > SortByCreationTimeDescending {
+		return status.Errorf(codes.InvalidArgument, "unknown sort order %d", sortBy)
+	}
+
+	sort.SliceStable(permissions, func(i, j int) bool {
+		return permissions[i].GetID() < permissions[j].GetID()
+	})
+
+	var less func(a Permission, b Permission) bool
+	switch sortBy {
+	case SortByRoleAscending:
+		less = func(a Permission, b Permission) bool { return !Role(a.GetRole()).Includes(Role(b.GetRole())) }
+	case SortByRoleDescending:
+		less = func(a Permission, b Permission) bool { return !Role(b.GetRole()).Includes(Role(a.GetRole())) }
+	case SortByUserIDAscending:
+		less = func(a Permission, b Permission) bool { return a.GetUserID() < b.GetUserID() }
+	case SortByUserIDDescending:
+		less = func(a Permission, b Permission) bool { return a.GetUserID() > b.GetUserID() }
+	case SortByCreationTimeDescending:
+		less = func(a Permission, b Permission) bool { return a.GetID() > b.GetID() }
+	default:
+		return nil
+	}
+
+	sort.SliceStable(permissions, func(i, j int) bool {
+		return less(permissions[i], permissions[j])
+	})
+
+	return nil
+}
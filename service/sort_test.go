@@ -0,0 +1,40 @@
+package service
+
+import (
+	"testing"
+)
+
+func TestParseSortBy(t *testing.T) {
+	tests := []struct {
+		field      string
+		descending bool
+		want       SortBy
+		invalid    bool
+	}{
+		{field: "", want: SortByID},
+		{field: "", descending: true, invalid: true},
+		{field: SortFieldRole, want: SortByRoleAscending},
+		{field: SortFieldRole, descending: true, want: SortByRoleDescending},
+		{field: SortFieldUserID, want: SortByUserIDAscending},
+		{field: SortFieldUserID, descending: true, want: SortByUserIDDescending},
+		{field: SortFieldCreatedAt, want: SortByCreationTimeAscending},
+		{field: SortFieldCreatedAt, descending: true, want: SortByCreationTimeDescending},
+		{field: "creator", invalid: true},
+		{field: "metadata.sharedBy", invalid: true},
+	}
+
+	for _, tt := range tests {
+		sortBy, err := ParseSortBy(tt.field, tt.descending)
+		if tt.invalid {
+			if err == nil {
+				t.Errorf("ParseSortBy(%q, %v) = %d, want an error", tt.field, tt.descending, sortBy)
+			}
+
+			continue
+		}
+
+		if err != nil || sortBy != tt.want {
+			t.Errorf("ParseSortBy(%q, %v) = %d, %v, want %d", tt.field, tt.descending, sortBy, err, tt.want)
+		}
+	}
+}
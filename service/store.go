@@ -2,13 +2,124 @@ package service
 
 import (
 	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	pb "github.com/meateam/permission-service/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
+// ErrPermissionNotFound is returned by a Store when no permission matches the given filter.
+var ErrPermissionNotFound = status.Error(codes.NotFound, "permission not found")
+
+// RequireFilter returns an InvalidArgument error if filter is nil, an empty document or an empty Filter,
+// which would match every permission, otherwise returns nil. It guards the operations that change
+// all the permissions they match.
+func RequireFilter(filter interface{}) error {
+	if filter == nil {
+		return status.Error(codes.InvalidArgument, "filter is required")
+	}
+
+	if f, ok := filter.(Filter); ok && f.IsEmpty() {
+		return status.Error(codes.InvalidArgument, "filter must not be empty")
+	}
+
+	value := reflect.ValueOf(filter)
+	if (value.Kind() == reflect.Slice || value.Kind() == reflect.Map) && value.Len() == 0 {
+		return status.Error(codes.InvalidArgument, "filter must not be empty")
+	}
+
+	return nil
+}
+
+// CreateManyError is returned by CreateMany when some of the permissions failed to be created,
+// or when the permissions were written but reading them back failed.
+type CreateManyError struct {
+	// Failed maps the index of each permission that failed to the error it failed with.
+	Failed map[int]error
+
+	// ReadErr is the error of reading back the permissions that were written. If it's set then the
+	// permissions that are not in Failed were committed, but CreateMany couldn't return them.
+	ReadErr error
+}
+
+// Error returns the string representation of e, naming the failed permission indexes.
+func (e *CreateManyError) Error() string {
+	indexes := e.FailedIndexes()
+	failures := make([]string, 0, len(indexes))
+	for _, index := range indexes {
+		failures = append(failures, fmt.Sprintf("permission %d: %v", index, e.Failed[index]))
+	}
+
+	message := fmt.Sprintf("failed creating %d permissions: %s", len(failures), strings.Join(failures, "; "))
+	if e.ReadErr == nil {
+		return message
+	}
+
+	readMessage := fmt.Sprintf("created the permissions but failed reading them back: %v", e.ReadErr)
+	if len(failures) == 0 {
+		return readMessage
+	}
+
+	return readMessage + "; " + message
+}
+
+// Written returns the permissions of the CreateMany call that failed with e which were written,
+// which are those that are not in e.Failed, in the order they were given.
+func (e *CreateManyError) Written(permissions []Permission) []Permission {
+	written := make([]Permission, 0, len(permissions))
+	for i, permission := range permissions {
+		if _, ok := e.Failed[i]; !ok {
+			written = append(written, permission)
+		}
+	}
+
+	return written
+}
+
+// FailedIndexes returns the indexes of the permissions that failed, in ascending order.
+func (e *CreateManyError) FailedIndexes() []int {
+	indexes := make([]int, 0, len(e.Failed))
+	for index := range e.Failed {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	return indexes
+}
+
 // Store is an interface for handling the storing of permissions.
 type Store interface {
 	Create(ctx context.Context, permission Permission) (Permission, error)
+	CreateIfAbsent(ctx context.Context, permission Permission) (Permission, error)
+	CreateMany(ctx context.Context, permissions []Permission) ([]Permission, error)
 	Get(ctx context.Context, filter interface{}) (Permission, error)
 	GetAll(ctx context.Context, filter interface{}) ([]Permission, error)
+	GetAllSorted(ctx context.Context, filter interface{}, sortBy SortBy) ([]Permission, error)
+	GetAllCursor(ctx context.Context, filter interface{}) (PermissionIterator, error)
+	GetAllPaged(
+		ctx context.Context,
+		filter interface{},
+		pageSize int64,
+		pageToken string) ([]Permission, string, error)
 	Delete(ctx context.Context, filter interface{}) (Permission, error)
-	HealthCheck(ctx context.Context) (bool, error)
+	DeleteMany(ctx context.Context, filter interface{}) (int64, error)
+	UpdateRole(ctx context.Context, fileID string, userID string, role pb.Role) (Permission, error)
+	UpdateRoleIfVersion(
+		ctx context.Context,
+		fileID string,
+		userID string,
+		role pb.Role,
+		expectedVersion int64) (Permission, error)
+	Count(ctx context.Context, filter interface{}) (int64, error)
+	Exists(ctx context.Context, fileID string, userID string) (bool, error)
+	ExistsMatching(ctx context.Context, filter interface{}) (bool, error)
+	DeleteAllByFileID(ctx context.Context, fileID string) (int64, error)
+	DeleteAllByUserID(ctx context.Context, userID string) (int64, error)
+	TransferOwnership(ctx context.Context, fileID string, fromUserID string, toUserID string) error
+	HealthCheck(ctx context.Context) (HealthResult, error)
+	HealthStatus(ctx context.Context) (HealthStatus, error)
 }
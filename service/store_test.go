@@ -0,0 +1,56 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func TestRequireFilter(t *testing.T) {
+	tests := []struct {
+		name   string
+		filter interface{}
+		want   codes.Code
+	}{
+		{name: "nil", filter: nil, want: codes.InvalidArgument},
+		{name: "empty D", filter: bson.D{}, want: codes.InvalidArgument},
+		{name: "empty M", filter: bson.M{}, want: codes.InvalidArgument},
+		{name: "nil D", filter: bson.D(nil), want: codes.InvalidArgument},
+		{name: "D", filter: bson.D{bson.E{Key: "fileID", Value: "file"}}, want: codes.OK},
+		{name: "M", filter: bson.M{"fileID": "file"}, want: codes.OK},
+		{name: "empty Filter", filter: And(), want: codes.InvalidArgument},
+		{name: "Filter", filter: ByFile("file"), want: codes.OK},
+	}
+
+	for _, tt := range tests {
+		if got := status.Code(RequireFilter(tt.filter)); got != tt.want {
+			t.Errorf("%s: RequireFilter() code = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCreateManyErrorOfReadBack(t *testing.T) {
+	err := &CreateManyError{Failed: map[int]error{}, ReadErr: errors.New("read failed")}
+	message := err.Error()
+	if !strings.Contains(message, "read failed") || strings.Contains(message, "failed creating") {
+		t.Errorf("Error() = %q, want only the read-back error", message)
+	}
+
+	err.Failed[1] = errors.New("write failed")
+	message = err.Error()
+	if !strings.Contains(message, "read failed") || !strings.Contains(message, "write failed") {
+		t.Errorf("Error() = %q, want both the read-back and the write errors", message)
+	}
+}
+
+func TestCreateManyErrorWritten(t *testing.T) {
+	permissions := []Permission{nil, nil, nil}
+	err := &CreateManyError{Failed: map[int]error{1: errors.New("write failed")}}
+	if written := err.Written(permissions); len(written) != 2 {
+		t.Errorf("Written() returned %d permissions, want 2", len(written))
+	}
+}
@@ -0,0 +1,546 @@
+// Package storetest is a conformance test suite of service.Store implementations, so every store
+// behaves the same way mongodb.MongoStore does. Stores are tested only with service.Filter filters,
+// which every store supports.
+package storetest
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/memory"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// NewStore returns a new empty store for a single test, and a function that releases it.
+type NewStore func(t *testing.T) (service.Store, func())
+
+// Run runs every conformance test as a subtest of t, each one with a new store of newStore.
+func Run(t *testing.T, newStore NewStore) {
+	tests := []struct {
+		name string
+		test func(t *testing.T, store service.Store)
+	}{
+		{"CreateUpserts", testCreateUpserts},
+		{"UnknownRolesRejected", testUnknownRolesRejected},
+		{"CreateIfAbsent", testCreateIfAbsent},
+		{"CreateMany", testCreateMany},
+		{"GetNotFound", testGetNotFound},
+		{"GetAllFilters", testGetAllFilters},
+		{"GetAllPaged", testGetAllPaged},
+		{"GetAllCursor", testGetAllCursor},
+		{"GetAllSorted", testGetAllSorted},
+		{"ExpiredPermissions", testExpiredPermissions},
+		{"Exists", testExists},
+		{"ExistsMatching", testExistsMatching},
+		{"Delete", testDelete},
+		{"DeleteMany", testDeleteMany},
+		{"DeleteAllByFileAndUser", testDeleteAllByFileAndUser},
+		{"UpdateRoleIfVersion", testUpdateRoleIfVersion},
+		{"TransferOwnership", testTransferOwnership},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store, release := newStore(t)
+			defer release()
+
+			tt.test(t, store)
+		})
+	}
+}
+
+// create creates the permission of userID to fileID with role in store and returns it.
+func create(t *testing.T, store service.Store, fileID string, userID string, role pb.Role) service.Permission {
+	t.Helper()
+
+	permission := &memory.Permission{FileID: fileID, UserID: userID, Role: role, Creator: "creator"}
+	created, err := store.Create(context.Background(), permission)
+	if err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	return created
+}
+
+// keys returns the fileID and userID of each of permissions, in their order.
+func keys(permissions []service.Permission) []string {
+	keys := make([]string, 0, len(permissions))
+	for _, permission := range permissions {
+		keys = append(keys, permission.GetFileID()+"/"+permission.GetUserID())
+	}
+
+	return keys
+}
+
+func testCreateUpserts(t *testing.T, store service.Store) {
+	ctx := service.WithActorID(context.Background(), "actor")
+	permission := &memory.Permission{
+		FileID:   "file",
+		UserID:   "user",
+		Role:     pb.Role_READ,
+		Creator:  "creator",
+		Metadata: map[string]string{"sharedBy": "link"},
+		Reason:   "review",
+	}
+
+	created, err := store.Create(ctx, permission)
+	if err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	if created.GetID() == "" || created.GetVersion() != 1 || created.GetGrantedBy() != "actor" {
+		t.Errorf("Create() = id %q version %d grantedBy %q, want an id, version 1 and grantedBy actor",
+			created.GetID(), created.GetVersion(), created.GetGrantedBy())
+	}
+
+	if !reflect.DeepEqual(created.GetMetadata(), permission.Metadata) || created.GetReason() != "review" {
+		t.Errorf("Create() = metadata %v reason %q, want %v and review",
+			created.GetMetadata(), created.GetReason(), permission.Metadata)
+	}
+
+	permission.Role = pb.Role_WRITE
+	permission.Metadata = nil
+	permission.Deny = true
+	updated, err := store.Create(service.WithActorID(context.Background(), "other"), permission)
+	if err != nil {
+		t.Fatalf("Create() of an existing permission = %v, want nil", err)
+	}
+
+	if updated.GetID() != created.GetID() || updated.GetVersion() != 2 || updated.GetRole() != pb.Role_WRITE {
+		t.Errorf("Create() of an existing permission = id %q version %d role %v, want id %q version 2 role %v",
+			updated.GetID(), updated.GetVersion(), updated.GetRole(), created.GetID(), pb.Role_WRITE)
+	}
+
+	if len(updated.GetMetadata()) != 0 || !updated.GetDeny() || updated.GetGrantedBy() != "actor" {
+		t.Errorf("Create() of an existing permission = metadata %v deny %v grantedBy %q, want none, true, actor",
+			updated.GetMetadata(), updated.GetDeny(), updated.GetGrantedBy())
+	}
+
+	if count, err := store.Count(context.Background(), service.ByFile("file")); err != nil || count != 1 {
+		t.Errorf("Count() = %d, %v, want 1, nil", count, err)
+	}
+}
+
+func testUnknownRolesRejected(t *testing.T, store service.Store) {
+	for _, role := range []pb.Role{pb.Role(42), pb.Role_NONE} {
+		permission := &memory.Permission{FileID: "file", UserID: "user", Role: role, Creator: "creator"}
+		_, err := store.Create(context.Background(), permission)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("Create() with role %v = %v, want code %v", role, err, codes.InvalidArgument)
+		}
+
+		if exists, err := store.Exists(context.Background(), "file", "user"); err != nil || exists {
+			t.Errorf("Exists() after Create() with role %v = %v, %v, want false, nil", role, exists, err)
+		}
+	}
+
+	create(t, store, "file", "user", pb.Role_READ)
+	for _, role := range []pb.Role{pb.Role(42), pb.Role_NONE} {
+		_, err := store.UpdateRole(context.Background(), "file", "user", role)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("UpdateRole() to role %v = %v, want code %v", role, err, codes.InvalidArgument)
+		}
+	}
+
+	permission, err := store.Get(context.Background(), service.And(service.ByFile("file"), service.ByUser("user")))
+	if err != nil || permission.GetRole() != pb.Role_READ {
+		t.Errorf("Get() after rejected updates = %v, %v, want the role unchanged as %v", permission, err, pb.Role_READ)
+	}
+}
+
+func testCreateIfAbsent(t *testing.T, store service.Store) {
+	permission := &memory.Permission{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"}
+	created, err := store.CreateIfAbsent(context.Background(), permission)
+	if err != nil {
+		t.Fatalf("CreateIfAbsent() = %v, want nil", err)
+	}
+
+	permission.Role = pb.Role_WRITE
+	_, err = store.CreateIfAbsent(context.Background(), permission)
+	if status.Code(err) != codes.AlreadyExists {
+		t.Errorf("CreateIfAbsent() of an existing permission = %v, want code %v", err, codes.AlreadyExists)
+	}
+
+	found, err := store.Get(context.Background(), service.And(service.ByFile("file"), service.ByUser("user")))
+	if err != nil {
+		t.Fatalf("Get() = %v, want nil", err)
+	}
+
+	if found.GetRole() != pb.Role_READ || found.GetVersion() != created.GetVersion() {
+		t.Errorf("CreateIfAbsent() of an existing permission changed it to role %v version %d, want %v version %d",
+			found.GetRole(), found.GetVersion(), pb.Role_READ, created.GetVersion())
+	}
+
+	expired := &memory.Permission{
+		FileID:    "file",
+		UserID:    "expired",
+		Role:      pb.Role_READ,
+		Creator:   "creator",
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}
+
+	if _, err := store.Create(context.Background(), expired); err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	expired.ExpiresAt = time.Time{}
+	if _, err := store.CreateIfAbsent(context.Background(), expired); err != nil {
+		t.Errorf("CreateIfAbsent() over an expired permission = %v, want nil", err)
+	}
+}
+
+func testCreateMany(t *testing.T, store service.Store) {
+	permissions := []service.Permission{
+		&memory.Permission{FileID: "file", UserID: "first", Role: pb.Role_READ, Creator: "creator"},
+		&memory.Permission{FileID: "file", UserID: "second", Role: pb.Role_WRITE, Creator: "creator"},
+	}
+
+	created, err := store.CreateMany(context.Background(), permissions)
+	if err != nil {
+		t.Fatalf("CreateMany() = %v, want nil", err)
+	}
+
+	if got, want := keys(created), []string{"file/first", "file/second"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("CreateMany() = %v, want %v", got, want)
+	}
+
+	invalid := []service.Permission{
+		&memory.Permission{FileID: "file", UserID: "third", Role: pb.Role_READ, Creator: "creator"},
+		&memory.Permission{FileID: "file", UserID: "fourth", Role: pb.Role_NONE, Creator: "creator"},
+	}
+
+	if _, err := store.CreateMany(context.Background(), invalid); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("CreateMany() with an invalid permission = %v, want code %v", err, codes.InvalidArgument)
+	}
+
+	if exists, err := store.Exists(context.Background(), "file", "third"); err != nil || exists {
+		t.Errorf("Exists() of a permission of an invalid batch = %v, %v, want false, nil", exists, err)
+	}
+}
+
+func testGetNotFound(t *testing.T, store service.Store) {
+	_, err := store.Get(context.Background(), service.And(service.ByFile("file"), service.ByUser("user")))
+	if err != service.ErrPermissionNotFound {
+		t.Errorf("Get() of a missing permission = %v, want %v", err, service.ErrPermissionNotFound)
+	}
+
+	if _, err := store.Delete(context.Background(), service.ByFile("file")); err != service.ErrPermissionNotFound {
+		t.Errorf("Delete() of a missing permission = %v, want %v", err, service.ErrPermissionNotFound)
+	}
+
+	_, err = store.UpdateRole(context.Background(), "file", "user", pb.Role_WRITE)
+	if err != service.ErrPermissionNotFound {
+		t.Errorf("UpdateRole() of a missing permission = %v, want %v", err, service.ErrPermissionNotFound)
+	}
+}
+
+func testGetAllFilters(t *testing.T, store service.Store) {
+	create(t, store, "file", "reader", pb.Role_READ)
+	create(t, store, "file", "writer", pb.Role_WRITE)
+	create(t, store, "other", "reader", pb.Role_OWNER)
+
+	tests := []struct {
+		name   string
+		filter service.Filter
+		want   []string
+	}{
+		{"all", service.Filter{}, []string{"file/reader", "file/writer", "other/reader"}},
+		{"file", service.ByFile("file"), []string{"file/reader", "file/writer"}},
+		{"user", service.ByUser("reader"), []string{"file/reader", "other/reader"}},
+		{"roles", service.ByRoles(pb.Role_WRITE, pb.Role_OWNER), []string{"file/writer", "other/reader"}},
+		{"file and user", service.And(service.ByFile("other"), service.ByUser("writer")), []string{}},
+	}
+
+	for _, tt := range tests {
+		permissions, err := store.GetAll(context.Background(), tt.filter)
+		if err != nil {
+			t.Fatalf("%s: GetAll() = %v, want nil", tt.name, err)
+		}
+
+		if got := keys(permissions); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: GetAll() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	permission, err := store.Get(context.Background(), service.And(service.ByFile("file"), service.ByUser("writer")))
+	if err != nil || permission.GetRole() != pb.Role_WRITE {
+		t.Errorf("Get() = %v, %v, want the WRITE permission of writer", permission, err)
+	}
+}
+
+func testGetAllPaged(t *testing.T, store service.Store) {
+	for _, userID := range []string{"a", "b", "c"} {
+		create(t, store, "file", userID, pb.Role_READ)
+	}
+
+	var pages [][]string
+	pageToken := ""
+	for {
+		permissions, nextPageToken, err := store.GetAllPaged(
+			context.Background(), service.ByFile("file"), 2, pageToken)
+		if err != nil {
+			t.Fatalf("GetAllPaged() = %v, want nil", err)
+		}
+
+		pages = append(pages, keys(permissions))
+		if nextPageToken == "" {
+			break
+		}
+
+		pageToken = nextPageToken
+	}
+
+	if want := [][]string{{"file/a", "file/b"}, {"file/c"}}; !reflect.DeepEqual(pages, want) {
+		t.Errorf("GetAllPaged() pages = %v, want %v", pages, want)
+	}
+
+	_, _, err := store.GetAllPaged(context.Background(), service.ByFile("file"), 2, "invalid")
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("GetAllPaged() with an invalid page token = %v, want code %v", err, codes.InvalidArgument)
+	}
+}
+
+func testGetAllCursor(t *testing.T, store service.Store) {
+	create(t, store, "file", "first", pb.Role_READ)
+	create(t, store, "file", "second", pb.Role_READ)
+
+	iterator, err := store.GetAllCursor(context.Background(), service.ByFile("file"))
+	if err != nil {
+		t.Fatalf("GetAllCursor() = %v, want nil", err)
+	}
+	defer iterator.Close(context.Background())
+
+	var permissions []service.Permission
+	for iterator.Next(context.Background()) {
+		permissions = append(permissions, iterator.Permission())
+	}
+
+	if err := iterator.Err(); err != nil {
+		t.Fatalf("Err() = %v, want nil", err)
+	}
+
+	if got, want := keys(permissions), []string{"file/first", "file/second"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetAllCursor() = %v, want %v", got, want)
+	}
+}
+
+func testGetAllSorted(t *testing.T, store service.Store) {
+	create(t, store, "file", "b", pb.Role_OWNER)
+	create(t, store, "file", "c", pb.Role_READ)
+	create(t, store, "file", "a", pb.Role_WRITE)
+
+	tests := []struct {
+		sortBy service.SortBy
+		want   []string
+	}{
+		{service.SortByID, []string{"file/b", "file/c", "file/a"}},
+		{service.SortByCreationTimeAscending, []string{"file/b", "file/c", "file/a"}},
+		{service.SortByCreationTimeDescending, []string{"file/a", "file/c", "file/b"}},
+		{service.SortByUserIDAscending, []string{"file/a", "file/b", "file/c"}},
+		{service.SortByUserIDDescending, []string{"file/c", "file/b", "file/a"}},
+		{service.SortByRoleAscending, []string{"file/c", "file/a", "file/b"}},
+		{service.SortByRoleDescending, []string{"file/b", "file/a", "file/c"}},
+	}
+
+	for _, tt := range tests {
+		permissions, err := store.GetAllSorted(context.Background(), service.ByFile("file"), tt.sortBy)
+		if err != nil {
+			t.Fatalf("GetAllSorted(%d) = %v, want nil", tt.sortBy, err)
+		}
+
+		if got := keys(permissions); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("GetAllSorted(%d) = %v, want %v", tt.sortBy, got, tt.want)
+		}
+	}
+
+	_, err := store.GetAllSorted(context.Background(), service.ByFile("file"), service.SortBy(-1))
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("GetAllSorted() of an unknown order = %v, want code %v", err, codes.InvalidArgument)
+	}
+}
+
+func testExpiredPermissions(t *testing.T, store service.Store) {
+	expired := &memory.Permission{
+		FileID:    "file",
+		UserID:    "user",
+		Role:      pb.Role_READ,
+		Creator:   "creator",
+		ExpiresAt: time.Now().Add(-time.Minute),
+	}
+
+	if _, err := store.Create(context.Background(), expired); err != nil {
+		t.Fatalf("Create() = %v, want nil", err)
+	}
+
+	_, err := store.Get(context.Background(), service.And(service.ByFile("file"), service.ByUser("user")))
+	if err != service.ErrPermissionNotFound {
+		t.Errorf("Get() of an expired permission = %v, want %v", err, service.ErrPermissionNotFound)
+	}
+
+	if count, err := store.Count(context.Background(), service.ByFile("file")); err != nil || count != 0 {
+		t.Errorf("Count() of an expired permission = %d, %v, want 0, nil", count, err)
+	}
+
+	if deleted, err := store.DeleteAllByFileID(context.Background(), "file"); err != nil || deleted != 1 {
+		t.Errorf("DeleteAllByFileID() of an expired permission = %d, %v, want 1, nil", deleted, err)
+	}
+}
+
+func testExists(t *testing.T, store service.Store) {
+	create(t, store, "file", "user", pb.Role_READ)
+
+	if exists, err := store.Exists(context.Background(), "file", "user"); err != nil || !exists {
+		t.Errorf("Exists() = %v, %v, want true, nil", exists, err)
+	}
+
+	if exists, err := store.Exists(context.Background(), "file", "other"); err != nil || exists {
+		t.Errorf("Exists() of a missing permission = %v, %v, want false, nil", exists, err)
+	}
+}
+
+func testExistsMatching(t *testing.T, store service.Store) {
+	create(t, store, "file", "reader", pb.Role_READ)
+	denied := &memory.Permission{
+		FileID: "file", UserID: "denied", Role: pb.Role_WRITE, Creator: "creator", Deny: true,
+	}
+	if _, err := store.Create(context.Background(), denied); err != nil {
+		t.Fatalf("Create() of a denying permission = %v, want nil", err)
+	}
+
+	tests := []struct {
+		name   string
+		filter service.Filter
+		want   bool
+	}{
+		{"file", service.ByFile("file"), true},
+		{"missing file", service.ByFile("other"), false},
+		{"role", service.And(service.ByFile("file"), service.ByRole(pb.Role_OWNER)), false},
+		{"granting", service.And(service.ByUser("reader"), service.ByDeny(false)), true},
+		{"denied", service.And(service.ByUser("denied"), service.ByDeny(false)), false},
+		{"denying", service.And(service.ByUser("denied"), service.ByDeny(true)), true},
+	}
+
+	for _, tt := range tests {
+		if exists, err := store.ExistsMatching(context.Background(), tt.filter); err != nil || exists != tt.want {
+			t.Errorf("%s: ExistsMatching() = %v, %v, want %v, nil", tt.name, exists, err, tt.want)
+		}
+	}
+}
+
+func testDelete(t *testing.T, store service.Store) {
+	create(t, store, "file", "first", pb.Role_READ)
+	create(t, store, "file", "second", pb.Role_READ)
+
+	deleted, err := store.Delete(context.Background(), service.ByFile("file"))
+	if err != nil {
+		t.Fatalf("Delete() = %v, want nil", err)
+	}
+
+	if deleted.GetUserID() != "first" {
+		t.Errorf("Delete() deleted the permission of %s, want first", deleted.GetUserID())
+	}
+
+	if exists, err := store.Exists(context.Background(), "file", "first"); err != nil || exists {
+		t.Errorf("Exists() of a deleted permission = %v, %v, want false, nil", exists, err)
+	}
+}
+
+func testDeleteMany(t *testing.T, store service.Store) {
+	create(t, store, "file", "reader", pb.Role_READ)
+	create(t, store, "file", "writer", pb.Role_WRITE)
+	create(t, store, "other", "reader", pb.Role_READ)
+
+	_, err := store.DeleteMany(context.Background(), service.Filter{})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("DeleteMany() with an empty filter = %v, want code %v", err, codes.InvalidArgument)
+	}
+
+	filter := service.And(service.ByFile("file"), service.ByRole(pb.Role_READ))
+	dryRun := service.WithDryRun(context.Background())
+	if deleted, err := store.DeleteMany(dryRun, filter); err != nil || deleted != 1 {
+		t.Errorf("DeleteMany() dry run = %d, %v, want 1, nil", deleted, err)
+	}
+
+	if deleted, err := store.DeleteMany(context.Background(), filter); err != nil || deleted != 1 {
+		t.Errorf("DeleteMany() = %d, %v, want 1, nil", deleted, err)
+	}
+
+	permissions, err := store.GetAll(context.Background(), service.Filter{})
+	if err != nil {
+		t.Fatalf("GetAll() = %v, want nil", err)
+	}
+
+	if got, want := keys(permissions), []string{"file/writer", "other/reader"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("GetAll() after DeleteMany() = %v, want %v", got, want)
+	}
+}
+
+func testDeleteAllByFileAndUser(t *testing.T, store service.Store) {
+	create(t, store, "file", "reader", pb.Role_READ)
+	create(t, store, "file", "writer", pb.Role_WRITE)
+	create(t, store, "other", "reader", pb.Role_READ)
+
+	if deleted, err := store.DeleteAllByFileID(context.Background(), "file"); err != nil || deleted != 2 {
+		t.Errorf("DeleteAllByFileID() = %d, %v, want 2, nil", deleted, err)
+	}
+
+	if deleted, err := store.DeleteAllByUserID(context.Background(), "reader"); err != nil || deleted != 1 {
+		t.Errorf("DeleteAllByUserID() = %d, %v, want 1, nil", deleted, err)
+	}
+
+	if _, err := store.DeleteAllByFileID(context.Background(), ""); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("DeleteAllByFileID() without a fileID = %v, want code %v", err, codes.InvalidArgument)
+	}
+}
+
+func testUpdateRoleIfVersion(t *testing.T, store service.Store) {
+	created := create(t, store, "file", "user", pb.Role_READ)
+
+	updated, err := store.UpdateRoleIfVersion(
+		context.Background(), "file", "user", pb.Role_WRITE, created.GetVersion())
+	if err != nil {
+		t.Fatalf("UpdateRoleIfVersion() = %v, want nil", err)
+	}
+
+	if updated.GetRole() != pb.Role_WRITE || updated.GetVersion() != created.GetVersion()+1 {
+		t.Errorf("UpdateRoleIfVersion() = role %v version %d, want %v and %d",
+			updated.GetRole(), updated.GetVersion(), pb.Role_WRITE, created.GetVersion()+1)
+	}
+
+	_, err = store.UpdateRoleIfVersion(context.Background(), "file", "user", pb.Role_OWNER, created.GetVersion())
+	if status.Code(err) != codes.Aborted {
+		t.Errorf("UpdateRoleIfVersion() of a stale version = %v, want code %v", err, codes.Aborted)
+	}
+}
+
+func testTransferOwnership(t *testing.T, store service.Store) {
+	create(t, store, "file", "owner", pb.Role_OWNER)
+
+	err := store.TransferOwnership(context.Background(), "file", "other", "owner")
+	if status.Code(err) != codes.FailedPrecondition {
+		t.Errorf("TransferOwnership() from a non owner = %v, want code %v", err, codes.FailedPrecondition)
+	}
+
+	if err := store.TransferOwnership(context.Background(), "file", "owner", "heir"); err != nil {
+		t.Fatalf("TransferOwnership() = %v, want nil", err)
+	}
+
+	permissions, err := store.GetAll(context.Background(), service.ByFile("file"))
+	if err != nil {
+		t.Fatalf("GetAll() = %v, want nil", err)
+	}
+
+	roles := make(map[string]pb.Role, len(permissions))
+	for _, permission := range permissions {
+		roles[permission.GetUserID()] = permission.GetRole()
+	}
+
+	if want := map[string]pb.Role{"owner": pb.Role_WRITE, "heir": pb.Role_OWNER}; !reflect.DeepEqual(roles, want) {
+		t.Errorf("roles after TransferOwnership() = %v, want %v", roles, want)
+	}
+}
@@ -0,0 +1,14 @@
+package storetest
+
+import (
+	"testing"
+
+	"github.com/meateam/permission-service/service"
+	"github.com/meateam/permission-service/service/memory"
+)
+
+func TestMemoryStore(t *testing.T) {
+	Run(t, func(t *testing.T) (service.Store, func()) {
+		return memory.NewMemoryStore(), func() {}
+	})
+}
@@ -0,0 +1,32 @@
+package service
+
+const (
+	// SubjectTypeUser is the subject type of a permission given to a single user,
+	// it's the subject type of permissions that don't have one.
+	SubjectTypeUser = "USER"
+
+	// SubjectTypeGroup is the subject type of a permission given to a group, such as a team,
+	// whose members all have the permission.
+	SubjectTypeGroup = "GROUP"
+)
+
+// ValidateSubjectType returns an InvalidArgumentError if subjectType is not empty, SubjectTypeUser
+// or SubjectTypeGroup, otherwise returns nil.
+func ValidateSubjectType(subjectType string) error {
+	if description := subjectTypeViolation(subjectType); description != "" {
+		return InvalidArgumentError("subjectType", description)
+	}
+
+	return nil
+}
+
+// subjectTypeViolation returns the description of why subjectType is invalid,
+// or an empty string if it's valid.
+func subjectTypeViolation(subjectType string) string {
+	switch subjectType {
+	case "", SubjectTypeUser, SubjectTypeGroup:
+		return ""
+	default:
+		return "must be " + SubjectTypeUser + " or " + SubjectTypeGroup
+	}
+}
@@ -0,0 +1,18 @@
+package service
+
+import "context"
+
+// tenantIDKey is the context key of the ID of the tenant that made a request.
+type tenantIDKey struct{}
+
+// WithTenantID returns a copy of ctx that carries tenantID as the ID of the tenant that made the request,
+// a multi-tenant store scopes the operations made with it to the tenant's permissions.
+func WithTenantID(ctx context.Context, tenantID string) context.Context {
+	return context.WithValue(ctx, tenantIDKey{}, tenantID)
+}
+
+// TenantIDFromContext returns the tenant ID that ctx carries, or an empty string if it carries none.
+func TenantIDFromContext(ctx context.Context) string {
+	tenantID, _ := ctx.Value(tenantIDKey{}).(string)
+	return tenantID
+}
@@ -0,0 +1,245 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	pb "github.com/meateam/permission-service/proto"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// DefaultStoreTimeout is the default timeout of each StoreWithTimeout operation.
+const DefaultStoreTimeout = 10 * time.Second
+
+// StoreWithTimeout is a Store that bounds each operation of the Store it wraps with a timeout.
+type StoreWithTimeout struct {
+	inner   Store
+	timeout time.Duration
+}
+
+// NewStoreWithTimeout returns a StoreWithTimeout that bounds each operation of inner with timeout d,
+// if d is not positive then DefaultStoreTimeout is used.
+func NewStoreWithTimeout(inner Store, d time.Duration) StoreWithTimeout {
+	if d <= 0 {
+		d = DefaultStoreTimeout
+	}
+
+	return StoreWithTimeout{inner: inner, timeout: d}
+}
+
+// Create runs inner's Create with the configured timeout.
+func (s StoreWithTimeout) Create(ctx context.Context, permission Permission) (Permission, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	created, err := s.inner.Create(timeoutCtx, permission)
+	return created, s.deadlineError(timeoutCtx, err)
+}
+
+// CreateIfAbsent runs inner's CreateIfAbsent with the configured timeout.
+func (s StoreWithTimeout) CreateIfAbsent(ctx context.Context, permission Permission) (Permission, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	created, err := s.inner.CreateIfAbsent(timeoutCtx, permission)
+	return created, s.deadlineError(timeoutCtx, err)
+}
+
+// CreateMany runs inner's CreateMany with the configured timeout.
+func (s StoreWithTimeout) CreateMany(ctx context.Context, permissions []Permission) ([]Permission, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	created, err := s.inner.CreateMany(timeoutCtx, permissions)
+	return created, s.deadlineError(timeoutCtx, err)
+}
+
+// Get runs inner's Get with the configured timeout.
+func (s StoreWithTimeout) Get(ctx context.Context, filter interface{}) (Permission, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	permission, err := s.inner.Get(timeoutCtx, filter)
+	return permission, s.deadlineError(timeoutCtx, err)
+}
+
+// GetAll runs inner's GetAll with the configured timeout.
+func (s StoreWithTimeout) GetAll(ctx context.Context, filter interface{}) ([]Permission, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	permissions, err := s.inner.GetAll(timeoutCtx, filter)
+	return permissions, s.deadlineError(timeoutCtx, err)
+}
+
+// GetAllSorted runs inner's GetAllSorted with the configured timeout.
+func (s StoreWithTimeout) GetAllSorted(
+	ctx context.Context,
+	filter interface{},
+	sortBy SortBy,
+) ([]Permission, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	permissions, err := s.inner.GetAllSorted(timeoutCtx, filter, sortBy)
+	return permissions, s.deadlineError(timeoutCtx, err)
+}
+
+// GetAllPaged runs inner's GetAllPaged with the configured timeout.
+func (s StoreWithTimeout) GetAllPaged(
+	ctx context.Context,
+	filter interface{},
+	pageSize int64,
+	pageToken string,
+) ([]Permission, string, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	permissions, nextPageToken, err := s.inner.GetAllPaged(timeoutCtx, filter, pageSize, pageToken)
+	return permissions, nextPageToken, s.deadlineError(timeoutCtx, err)
+}
+
+// Delete runs inner's Delete with the configured timeout.
+func (s StoreWithTimeout) Delete(ctx context.Context, filter interface{}) (Permission, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	permission, err := s.inner.Delete(timeoutCtx, filter)
+	return permission, s.deadlineError(timeoutCtx, err)
+}
+
+// UpdateRole runs inner's UpdateRole with the configured timeout.
+func (s StoreWithTimeout) UpdateRole(
+	ctx context.Context,
+	fileID string,
+	userID string,
+	role pb.Role,
+) (Permission, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	permission, err := s.inner.UpdateRole(timeoutCtx, fileID, userID, role)
+	return permission, s.deadlineError(timeoutCtx, err)
+}
+
+// UpdateRoleIfVersion runs inner's UpdateRoleIfVersion with the configured timeout.
+func (s StoreWithTimeout) UpdateRoleIfVersion(
+	ctx context.Context,
+	fileID string,
+	userID string,
+	role pb.Role,
+	expectedVersion int64,
+) (Permission, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	permission, err := s.inner.UpdateRoleIfVersion(timeoutCtx, fileID, userID, role, expectedVersion)
+	return permission, s.deadlineError(timeoutCtx, err)
+}
+
+// Count runs inner's Count with the configured timeout.
+func (s StoreWithTimeout) Count(ctx context.Context, filter interface{}) (int64, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	count, err := s.inner.Count(timeoutCtx, filter)
+	return count, s.deadlineError(timeoutCtx, err)
+}
+
+// Exists runs inner's Exists with the configured timeout.
+func (s StoreWithTimeout) Exists(ctx context.Context, fileID string, userID string) (bool, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	exists, err := s.inner.Exists(timeoutCtx, fileID, userID)
+	return exists, s.deadlineError(timeoutCtx, err)
+}
+
+// ExistsMatching runs inner's ExistsMatching with the configured timeout.
+func (s StoreWithTimeout) ExistsMatching(ctx context.Context, filter interface{}) (bool, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	exists, err := s.inner.ExistsMatching(timeoutCtx, filter)
+	return exists, s.deadlineError(timeoutCtx, err)
+}
+
+// DeleteMany runs inner's DeleteMany with the configured timeout.
+func (s StoreWithTimeout) DeleteMany(ctx context.Context, filter interface{}) (int64, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	deleted, err := s.inner.DeleteMany(timeoutCtx, filter)
+	return deleted, s.deadlineError(timeoutCtx, err)
+}
+
+// DeleteAllByFileID runs inner's DeleteAllByFileID with the configured timeout.
+func (s StoreWithTimeout) DeleteAllByFileID(ctx context.Context, fileID string) (int64, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	deleted, err := s.inner.DeleteAllByFileID(timeoutCtx, fileID)
+	return deleted, s.deadlineError(timeoutCtx, err)
+}
+
+// DeleteAllByUserID runs inner's DeleteAllByUserID with the configured timeout.
+func (s StoreWithTimeout) DeleteAllByUserID(ctx context.Context, userID string) (int64, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	deleted, err := s.inner.DeleteAllByUserID(timeoutCtx, userID)
+	return deleted, s.deadlineError(timeoutCtx, err)
+}
+
+// TransferOwnership runs inner's TransferOwnership with the configured timeout.
+func (s StoreWithTimeout) TransferOwnership(
+	ctx context.Context,
+	fileID string,
+	fromUserID string,
+	toUserID string,
+) error {
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	err := s.inner.TransferOwnership(timeoutCtx, fileID, fromUserID, toUserID)
+	return s.deadlineError(timeoutCtx, err)
+}
+
+// HealthCheck runs inner's HealthCheck with the configured timeout.
+func (s StoreWithTimeout) HealthCheck(ctx context.Context) (HealthResult, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	result, err := s.inner.HealthCheck(timeoutCtx)
+	return result, s.deadlineError(timeoutCtx, err)
+}
+
+// GetAllCursor runs inner's GetAllCursor with the configured timeout, which only applies to opening
+// the iterator since iterating is bound to the context passed to its Next.
+func (s StoreWithTimeout) GetAllCursor(ctx context.Context, filter interface{}) (PermissionIterator, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	permissions, err := s.inner.GetAllCursor(timeoutCtx, filter)
+	return permissions, s.deadlineError(timeoutCtx, err)
+}
+
+// HealthStatus runs inner's HealthStatus with the configured timeout.
+func (s StoreWithTimeout) HealthStatus(ctx context.Context) (HealthStatus, error) {
+	timeoutCtx, cancel := context.WithTimeout(ctx, s.timeout)
+	defer cancel()
+
+	health, err := s.inner.HealthStatus(timeoutCtx)
+	return health, s.deadlineError(timeoutCtx, err)
+}
+
+// deadlineError returns a DeadlineExceeded status error if err occurred because
+// timeoutCtx's deadline passed, otherwise returns err as is.
+func (s StoreWithTimeout) deadlineError(timeoutCtx context.Context, err error) error {
+	if err != nil && timeoutCtx.Err() == context.DeadlineExceeded {
+		return status.Errorf(codes.DeadlineExceeded, "store operation timed out after %v: %v", s.timeout, err)
+	}
+
+	return err
+}
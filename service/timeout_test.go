@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// sleepingStore is a Store whose Get and Exists sleep for delay unless their context is done first.
+type sleepingStore struct {
+	Store
+	delay time.Duration
+}
+
+// sleep waits for s.delay and returns nil, or returns ctx's error if it's done first.
+func (s sleepingStore) sleep(ctx context.Context) error {
+	select {
+	case <-time.After(s.delay):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Get sleeps and returns ErrPermissionNotFound.
+func (s sleepingStore) Get(ctx context.Context, filter interface{}) (Permission, error) {
+	if err := s.sleep(ctx); err != nil {
+		return nil, err
+	}
+
+	return nil, ErrPermissionNotFound
+}
+
+// Exists sleeps and returns true.
+func (s sleepingStore) Exists(ctx context.Context, fileID string, userID string) (bool, error) {
+	if err := s.sleep(ctx); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+func TestStoreWithTimeoutCancelsSlowOperation(t *testing.T) {
+	store := NewStoreWithTimeout(sleepingStore{delay: time.Minute}, 20*time.Millisecond)
+
+	start := time.Now()
+	_, err := store.Get(context.Background(), ByFile("file"))
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("Get() = %v, want code %v", err, codes.DeadlineExceeded)
+	}
+
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Get() returned after %v, want it cancelled after the timeout", elapsed)
+	}
+
+	exists, err := store.Exists(context.Background(), "file", "user")
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Errorf("Exists() = %v, %v, want code %v", exists, err, codes.DeadlineExceeded)
+	}
+}
+
+func TestStoreWithTimeoutWithinTimeout(t *testing.T) {
+	store := NewStoreWithTimeout(sleepingStore{delay: time.Millisecond}, time.Second)
+
+	if _, err := store.Get(context.Background(), ByFile("file")); err != ErrPermissionNotFound {
+		t.Errorf("Get() = %v, want %v", err, ErrPermissionNotFound)
+	}
+
+	if exists, err := store.Exists(context.Background(), "file", "user"); err != nil || !exists {
+		t.Errorf("Exists() = %v, %v, want true, nil", exists, err)
+	}
+}
+
+func TestStoreWithTimeoutKeepsCallerCancellation(t *testing.T) {
+	store := NewStoreWithTimeout(sleepingStore{delay: time.Minute}, time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := store.Get(ctx, ByFile("file")); err != context.Canceled {
+		t.Errorf("Get() of a cancelled context = %v, want %v", err, context.Canceled)
+	}
+}
+
+func TestNewStoreWithTimeoutDefault(t *testing.T) {
+	if store := NewStoreWithTimeout(sleepingStore{}, 0); store.timeout != DefaultStoreTimeout {
+		t.Errorf("NewStoreWithTimeout() timeout = %v, want %v", store.timeout, DefaultStoreTimeout)
+	}
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	pb "github.com/meateam/permission-service/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// InvalidArgumentError returns an InvalidArgument status error, with a BadRequest detail
+// describing why field is invalid, so clients can tell which field was rejected.
+func InvalidArgumentError(field string, description string) error {
+	st := status.New(codes.InvalidArgument, fmt.Sprintf("%s %s", field, description))
+	detailed, err := st.WithDetails(&errdetails.BadRequest{
+		FieldViolations: []*errdetails.BadRequest_FieldViolation{
+			{
+				Field:       field,
+				Description: description,
+			},
+		},
+	})
+	if err != nil {
+		return st.Err()
+	}
+
+	return detailed.Err()
+}
+
+// DefaultMaxIDLength is the maximum length of an ID by DefaultValidator.
+const DefaultMaxIDLength = 128
+
+// MaxMetadataSize is the maximum total size in bytes of the keys and values of a permission's metadata.
+const MaxMetadataSize = 4096
+
+// MaxReasonLength is the maximum length in characters of the reason a permission was given.
+const MaxReasonLength = 512
+
+// Validator is an interface for validating the format of fileIDs and userIDs, so deployments whose IDs
+// are ObjectIDs, UUIDs or any other format can reject malformed IDs before they're stored.
+type Validator interface {
+	// ValidateID returns an InvalidArgumentError if id is not a valid ID for field,
+	// such as fileID or userID, otherwise returns nil.
+	ValidateID(field string, id string) error
+}
+
+// DefaultValidator is the Validator used when none is configured, it accepts non-empty IDs of at most
+// MaxLength characters, DefaultMaxIDLength if it's not positive, without whitespace or control characters.
+type DefaultValidator struct {
+	MaxLength int
+}
+
+// ValidateID returns an InvalidArgumentError if id is empty, too long, or has whitespace or
+// control characters, otherwise returns nil.
+func (v DefaultValidator) ValidateID(field string, id string) error {
+	if id == "" {
+		return InvalidArgumentError(field, "is required")
+	}
+
+	maxLength := v.MaxLength
+	if maxLength <= 0 {
+		maxLength = DefaultMaxIDLength
+	}
+
+	if length := utf8.RuneCountInString(id); length > maxLength {
+		return InvalidArgumentError(field, fmt.Sprintf("must be at most %d characters, got %d", maxLength, length))
+	}
+
+	if !utf8.ValidString(id) {
+		return InvalidArgumentError(field, "must be valid UTF-8")
+	}
+
+	for _, r := range id {
+		if unicode.IsSpace(r) || unicode.IsControl(r) {
+			return InvalidArgumentError(field, fmt.Sprintf("must not contain whitespace or control character %q", r))
+		}
+	}
+
+	return nil
+}
+
+// ValidateID validates id as field by validator, or by DefaultValidator if validator is nil.
+func ValidateID(validator Validator, field string, id string) error {
+	if validator == nil {
+		validator = DefaultValidator{}
+	}
+
+	return validator.ValidateID(field, id)
+}
+
+// permissionFields is the part of a permission that is validated, it's implemented
+// by Permission and by the permission creation requests.
+type permissionFields interface {
+	GetFileID() string
+	GetUserID() string
+	GetRole() pb.Role
+	GetCreator() string
+	GetMetadata() map[string]string
+	GetReason() string
+	GetSubjectType() string
+}
+
+// ValidatePermission returns an InvalidArgumentError of the first invalid field of permission,
+// or nil if permission is valid. Its IDs are validated by validator, or by DefaultValidator if it's nil.
+func ValidatePermission(validator Validator, permission Permission) error {
+	return validatePermissionFields(validator, "", permission)
+}
+
+// ValidatePermissions returns an InvalidArgumentError of the first invalid field of permissions,
+// naming the field by its index such as permissions[2].userID, or nil if all of permissions are valid.
+// Their IDs are validated by validator, or by DefaultValidator if it's nil.
+func ValidatePermissions(validator Validator, permissions []Permission) error {
+	for i, permission := range permissions {
+		if err := validatePermissionFields(validator, fmt.Sprintf("permissions[%d].", i), permission); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validatePermissionFields returns an InvalidArgumentError of the first invalid field of permission,
+// the field name is prefixed with prefix, or nil if permission is valid.
+func validatePermissionFields(validator Validator, prefix string, permission permissionFields) error {
+	if err := ValidateID(validator, prefix+"fileID", permission.GetFileID()); err != nil {
+		return err
+	}
+
+	if err := ValidateID(validator, prefix+"userID", permission.GetUserID()); err != nil {
+		return err
+	}
+
+	if description := roleViolation(permission.GetRole()); description != "" {
+		return InvalidArgumentError(prefix+"role", description)
+	}
+
+	if permission.GetCreator() == "" {
+		return InvalidArgumentError(prefix+"creator", "is required")
+	}
+
+	if description := metadataViolation(permission.GetMetadata()); description != "" {
+		return InvalidArgumentError(prefix+"metadata", description)
+	}
+
+	if description := reasonViolation(permission.GetReason()); description != "" {
+		return InvalidArgumentError(prefix+"reason", description)
+	}
+
+	if description := subjectTypeViolation(permission.GetSubjectType()); description != "" {
+		return InvalidArgumentError(prefix+"subjectType", description)
+	}
+
+	return nil
+}
+
+// ValidateMetadata returns an InvalidArgumentError if metadata is larger than MaxMetadataSize
+// or has a key that can't be stored as a field name, otherwise returns nil.
+func ValidateMetadata(metadata map[string]string) error {
+	if description := metadataViolation(metadata); description != "" {
+		return InvalidArgumentError("metadata", description)
+	}
+
+	return nil
+}
+
+// metadataViolation returns the description of why metadata is invalid, or an empty string if it's valid.
+func metadataViolation(metadata map[string]string) string {
+	size := 0
+	for key, value := range metadata {
+		if key == "" || strings.HasPrefix(key, "$") || strings.Contains(key, ".") {
+			return fmt.Sprintf("key %q must be non-empty, must not start with $ and must not contain .", key)
+		}
+
+		size += len(key) + len(value)
+	}
+
+	if size > MaxMetadataSize {
+		return fmt.Sprintf("must be at most %d bytes, got %d", MaxMetadataSize, size)
+	}
+
+	return ""
+}
+
+// ValidateReason returns an InvalidArgumentError if reason is longer than MaxReasonLength characters,
+// otherwise returns nil.
+func ValidateReason(reason string) error {
+	if description := reasonViolation(reason); description != "" {
+		return InvalidArgumentError("reason", description)
+	}
+
+	return nil
+}
+
+// reasonViolation returns the description of why reason is invalid, or an empty string if it's valid.
+func reasonViolation(reason string) string {
+	if length := utf8.RuneCountInString(reason); length > MaxReasonLength {
+		return fmt.Sprintf("must be at most %d characters, got %d", MaxReasonLength, length)
+	}
+
+	return ""
+}
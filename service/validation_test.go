@@ -0,0 +1,264 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	pb "github.com/meateam/permission-service/proto"
+	"google.golang.org/genproto/googleapis/rpc/errdetails"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// fieldViolations returns the field violations of the BadRequest details of err.
+func fieldViolations(t *testing.T, err error) []*errdetails.BadRequest_FieldViolation {
+	t.Helper()
+
+	st, ok := status.FromError(err)
+	if !ok {
+		t.Fatalf("error %v is not a status error", err)
+	}
+
+	if st.Code() != codes.InvalidArgument {
+		t.Fatalf("error code = %v, want %v", st.Code(), codes.InvalidArgument)
+	}
+
+	for _, detail := range st.Details() {
+		if badRequest, ok := detail.(*errdetails.BadRequest); ok {
+			return badRequest.GetFieldViolations()
+		}
+	}
+
+	t.Fatalf("error %v has no BadRequest details", err)
+	return nil
+}
+
+func TestValidateCreatePermissionRequest(t *testing.T) {
+	valid := pb.CreatePermissionRequest{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"}
+
+	tests := []struct {
+		name   string
+		modify func(req *pb.CreatePermissionRequest)
+		field  string
+	}{
+		{name: "missing fileID", modify: func(req *pb.CreatePermissionRequest) { req.FileID = "" }, field: "fileID"},
+		{name: "missing userID", modify: func(req *pb.CreatePermissionRequest) { req.UserID = "" }, field: "userID"},
+		{name: "unknown role", modify: func(req *pb.CreatePermissionRequest) { req.Role = pb.Role(42) }, field: "role"},
+		{name: "NONE role", modify: func(req *pb.CreatePermissionRequest) { req.Role = pb.Role_NONE }, field: "role"},
+		{name: "missing creator", modify: func(req *pb.CreatePermissionRequest) { req.Creator = "" }, field: "creator"},
+		{
+			name:   "negative expiresAt",
+			modify: func(req *pb.CreatePermissionRequest) { req.ExpiresAt = -1 },
+			field:  "expiresAt",
+		},
+		{
+			name: "oversized metadata",
+			modify: func(req *pb.CreatePermissionRequest) {
+				req.Metadata = map[string]string{"note": strings.Repeat("x", MaxMetadataSize)}
+			},
+			field: "metadata",
+		},
+		{
+			name:   "operator metadata key",
+			modify: func(req *pb.CreatePermissionRequest) { req.Metadata = map[string]string{"$set": "x"} },
+			field:  "metadata",
+		},
+		{
+			name:   "too long reason",
+			modify: func(req *pb.CreatePermissionRequest) { req.Reason = strings.Repeat("x", MaxReasonLength+1) },
+			field:  "reason",
+		},
+		{
+			name:   "unknown subjectType",
+			modify: func(req *pb.CreatePermissionRequest) { req.SubjectType = "TEAM" },
+			field:  "subjectType",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.modify(&req)
+
+			_, err := Service{}.CreatePermission(context.Background(), &req)
+			violations := fieldViolations(t, err)
+			if len(violations) != 1 || violations[0].GetField() != tt.field {
+				t.Errorf("field violations = %v, want a single violation of %s", violations, tt.field)
+			}
+		})
+	}
+}
+
+func TestValidateCreatePermissionsRequest(t *testing.T) {
+	req := &pb.CreatePermissionsRequest{
+		Permissions: []*pb.CreatePermissionRequest{
+			{FileID: "file", UserID: "user", Role: pb.Role_READ, Creator: "creator"},
+			{FileID: "file", Role: pb.Role_READ, Creator: "creator"},
+		},
+	}
+
+	_, err := Service{}.CreatePermissions(context.Background(), req)
+	violations := fieldViolations(t, err)
+	if len(violations) != 1 || violations[0].GetField() != "permissions[1].userID" {
+		t.Errorf("field violations = %v, want a single violation of permissions[1].userID", violations)
+	}
+}
+
+func TestValidateRole(t *testing.T) {
+	for _, role := range []pb.Role{pb.Role_READ, pb.Role_WRITE, pb.Role_OWNER} {
+		if err := ValidateRole(role); err != nil {
+			t.Errorf("ValidateRole(%v) = %v, want nil", role, err)
+		}
+	}
+
+	for _, role := range []pb.Role{pb.Role_NONE, pb.Role(-1), pb.Role(42)} {
+		violations := fieldViolations(t, ValidateRole(role))
+		if len(violations) != 1 || violations[0].GetField() != "role" {
+			t.Errorf("ValidateRole(%v) field violations = %v, want a single violation of role", role, violations)
+		}
+	}
+}
+
+func TestValidateRoleListsValidRoles(t *testing.T) {
+	violations := fieldViolations(t, ValidateRole(pb.Role(42)))
+	if len(violations) != 1 {
+		t.Fatalf("ValidateRole(42) field violations = %v, want a single violation", violations)
+	}
+
+	want := "42 does not exist, must be one of READ, WRITE, OWNER"
+	if description := violations[0].GetDescription(); description != want {
+		t.Errorf("ValidateRole(42) description = %q, want %q", description, want)
+	}
+}
+
+func TestGetFilePermissionsInvalidRoles(t *testing.T) {
+	req := &pb.GetFilePermissionsRequest{FileID: "file", Roles: []pb.Role{pb.Role_OWNER, pb.Role_NONE}}
+
+	_, err := NewService(nil, nil).GetFilePermissions(context.Background(), req)
+	violations := fieldViolations(t, err)
+	if len(violations) != 1 || violations[0].GetField() != "roles[1]" {
+		t.Errorf("GetFilePermissions() field violations = %v, want a single violation of roles[1]", violations)
+	}
+}
+
+func TestIsPermittedInvalidRole(t *testing.T) {
+	for _, role := range []pb.Role{pb.Role(42), pb.Role_NONE} {
+		req := &pb.IsPermittedRequest{FileID: "file", UserID: "user", Role: role}
+
+		_, err := NewService(nil, nil).IsPermitted(context.Background(), req)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("IsPermitted() of role %d = %v, want code %v", role, err, codes.InvalidArgument)
+		}
+
+		violations := fieldViolations(t, err)
+		if len(violations) != 1 || violations[0].GetField() != "role" {
+			t.Errorf("IsPermitted() of role %d field violations = %v, want a single violation of role", role, violations)
+		}
+	}
+}
+
+func TestGetFilePermissionsInvalidSort(t *testing.T) {
+	tests := []struct {
+		name  string
+		req   *pb.GetFilePermissionsRequest
+		field string
+	}{
+		{
+			name:  "unknown field",
+			req:   &pb.GetFilePermissionsRequest{FileID: "file", SortBy: "creator"},
+			field: "sortBy",
+		},
+		{
+			name:  "descending without a field",
+			req:   &pb.GetFilePermissionsRequest{FileID: "file", SortDescending: true},
+			field: "sortDescending",
+		},
+		{
+			name:  "paged",
+			req:   &pb.GetFilePermissionsRequest{FileID: "file", SortBy: SortFieldRole, PageSize: 10},
+			field: "sortBy",
+		},
+	}
+
+	for _, tt := range tests {
+		_, err := NewService(nil, nil).GetFilePermissions(context.Background(), tt.req)
+		violations := fieldViolations(t, err)
+		if len(violations) != 1 || violations[0].GetField() != tt.field {
+			t.Errorf("%s: GetFilePermissions() field violations = %v, want a single violation of %s",
+				tt.name, violations, tt.field)
+		}
+	}
+}
+
+func TestDefaultValidator(t *testing.T) {
+	tests := []struct {
+		name    string
+		id      string
+		invalid bool
+	}{
+		{name: "hex ObjectID", id: "5d8a4e1c9f1b2c3d4e5f6a7b"},
+		{name: "UUID", id: "123e4567-e89b-12d3-a456-426614174000"},
+		{name: "non-ASCII", id: "קובץ"},
+		{name: "max length", id: strings.Repeat("a", DefaultMaxIDLength)},
+		{name: "empty", id: "", invalid: true},
+		{name: "too long", id: strings.Repeat("a", DefaultMaxIDLength+1), invalid: true},
+		{name: "space", id: "file id", invalid: true},
+		{name: "tab", id: "file\tid", invalid: true},
+		{name: "newline", id: "file\n", invalid: true},
+		{name: "control character", id: "file\x00id", invalid: true},
+		{name: "invalid UTF-8", id: "file\xff", invalid: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := DefaultValidator{}.ValidateID("fileID", tt.id)
+			if !tt.invalid {
+				if err != nil {
+					t.Errorf("ValidateID(%q) = %v, want nil", tt.id, err)
+				}
+
+				return
+			}
+
+			violations := fieldViolations(t, err)
+			if len(violations) != 1 || violations[0].GetField() != "fileID" {
+				t.Errorf("ValidateID(%q) field violations = %v, want a single violation of fileID", tt.id, violations)
+			}
+		})
+	}
+}
+
+func TestDefaultValidatorMaxLength(t *testing.T) {
+	validator := DefaultValidator{MaxLength: 4}
+	if err := validator.ValidateID("userID", "user"); err != nil {
+		t.Errorf("ValidateID() of 4 characters = %v, want nil", err)
+	}
+
+	if err := validator.ValidateID("userID", "users"); status.Code(err) != codes.InvalidArgument {
+		t.Errorf("ValidateID() of 5 characters = %v, want %v", err, codes.InvalidArgument)
+	}
+}
+
+// prefixValidator is a Validator that accepts only the IDs with its prefix.
+type prefixValidator string
+
+// ValidateID returns an InvalidArgumentError if id doesn't start with v.
+func (v prefixValidator) ValidateID(field string, id string) error {
+	if !strings.HasPrefix(id, string(v)) {
+		return InvalidArgumentError(field, fmt.Sprintf("must start with %s", string(v)))
+	}
+
+	return nil
+}
+
+func TestServiceWithValidator(t *testing.T) {
+	req := &pb.CreatePermissionRequest{FileID: "id-file", UserID: "user", Role: pb.Role_READ, Creator: "creator"}
+
+	_, err := Service{}.WithValidator(prefixValidator("id-")).CreatePermission(context.Background(), req)
+	violations := fieldViolations(t, err)
+	if len(violations) != 1 || violations[0].GetField() != "userID" {
+		t.Errorf("field violations = %v, want a single violation of userID", violations)
+	}
+}
@@ -0,0 +1,67 @@
+package service
+
+import (
+	"context"
+
+	pb "github.com/meateam/permission-service/proto"
+)
+
+// PermissionEventType is the kind of change of a permission that a PermissionEvent reports.
+type PermissionEventType string
+
+const (
+	// PermissionEventCreated reports a permission that was created, upserted or restored.
+	PermissionEventCreated PermissionEventType = "created"
+
+	// PermissionEventUpdated reports a permission whose role or other fields were updated.
+	PermissionEventUpdated PermissionEventType = "updated"
+
+	// PermissionEventDeleted reports a permission that was deleted or soft deleted.
+	PermissionEventDeleted PermissionEventType = "deleted"
+)
+
+// PermissionEvent is a change of a permission reported by a PermissionWatcher.
+type PermissionEvent struct {
+	// Type is the kind of the change.
+	Type PermissionEventType
+
+	// ID is the unique ID of the changed permission.
+	ID string
+
+	// FileID and UserID are the file and user of the changed permission, they may be empty for a
+	// deleted permission if the store doesn't keep the permissions it deletes.
+	FileID string
+	UserID string
+
+	// Role is the role the permission grants after the change, it's NONE for a deleted permission.
+	Role pb.Role
+
+	// ResumeToken resumes watching right after this event when it's given to WithResumeToken.
+	ResumeToken []byte
+
+	// Err is the error that stopped the watching, if it's set then it's the last event and the
+	// rest of the fields are empty.
+	Err error
+}
+
+// PermissionWatcher reports the changes of permissions as they happen.
+type PermissionWatcher interface {
+	// Watch returns a channel of the changes of permissions made after ctx's resume token, or from
+	// now if it has none. The channel is closed once ctx is done or after an event with Err set.
+	Watch(ctx context.Context) (<-chan PermissionEvent, error)
+}
+
+// resumeTokenKey is the context key of the resume token a watch starts after.
+type resumeTokenKey struct{}
+
+// WithResumeToken returns a copy of ctx that makes a PermissionWatcher resume watching right after the
+// event whose ResumeToken is token, so the events a disconnected client missed are not lost.
+func WithResumeToken(ctx context.Context, token []byte) context.Context {
+	return context.WithValue(ctx, resumeTokenKey{}, token)
+}
+
+// ResumeToken returns the resume token of ctx set by WithResumeToken, or nil if it has none.
+func ResumeToken(ctx context.Context) []byte {
+	token, _ := ctx.Value(resumeTokenKey{}).([]byte)
+	return token
+}